@@ -6,7 +6,6 @@ import (
 	"strconv"
 
 	"github.com/KashyretsIvanna/voice-balance/config"
-	"github.com/KashyretsIvanna/voice-balance/internals/model"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 )
@@ -37,10 +36,9 @@ func ConnectDB() {
 	fmt.Println("Connection Opened to Database")
 
 	// Migrate the database
-	DB.AutoMigrate(&model.User{})
-	DB.AutoMigrate(&model.Category{})
-	DB.AutoMigrate(&model.Reminder{})
-	DB.AutoMigrate(&model.Transaction{})
+	if err := Migrate(DB); err != nil {
+		panic(fmt.Sprintf("failed to migrate database: %v", err))
+	}
 
 	fmt.Println("Database Migrated")
 }
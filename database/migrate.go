@@ -0,0 +1,140 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+
+	"github.com/KashyretsIvanna/voice-balance/internals/model"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Migrate brings the schema of the database up to date with the models
+func Migrate(db *gorm.DB) error {
+	if err := migrateMoneyColumns(db); err != nil {
+		return fmt.Errorf("migrating amounts: %w", err)
+	}
+	err := db.AutoMigrate(
+		&model.User{},
+		&model.Category{},
+		&model.Reminder{},
+		&model.Transaction{},
+		&model.VoiceJob{},
+		&model.Budget{},
+		&model.RecurringTransaction{},
+		&model.ReminderDelivery{},
+		&model.Notification{},
+		&model.ExchangeRate{},
+		&model.CategoryAlias{},
+		&model.CategoryRule{},
+	)
+	if err != nil {
+		return err
+	}
+	if err := migrateCategoryNames(db); err != nil {
+		return fmt.Errorf("migrating category names: %w", err)
+	}
+	if err := migrateBudgetPeriods(db); err != nil {
+		return fmt.Errorf("migrating budget periods: %w", err)
+	}
+	return nil
+}
+
+// moneyColumns hold amounts, they used to be floats in units of the currency
+// and are integers in minor units now
+var moneyColumns = []struct{ table, column string }{
+	{"transactions", "amount"},
+	{"reminders", "amount"},
+	{"budgets", "limit_amount"},
+	{"recurring_transactions", "amount"},
+}
+
+// migrateMoneyColumns converts the float amount columns of an existing database into
+// integer minor units, 12.5 becomes 1250. It has to run before AutoMigrate, which would
+// change the type of the columns without converting the values. Converted columns are
+// skipped, so it can run on every start.
+func migrateMoneyColumns(db *gorm.DB) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		for _, c := range moneyColumns {
+			var dataType string
+			err := tx.Raw("SELECT data_type FROM information_schema.columns "+
+				"WHERE table_schema = current_schema() AND table_name = ? AND column_name = ?", c.table, c.column).
+				Scan(&dataType).Error
+			if err != nil {
+				return err
+			}
+			if dataType != "double precision" && dataType != "real" && dataType != "numeric" {
+				continue
+			}
+
+			err = tx.Exec(fmt.Sprintf("ALTER TABLE %[1]s ALTER COLUMN %[2]s TYPE bigint USING ROUND(%[2]s * 100)", c.table, c.column)).Error
+			if err != nil {
+				return fmt.Errorf("converting %s.%s into minor units: %w", c.table, c.column, err)
+			}
+			log.Printf("converted %s.%s into minor units", c.table, c.column)
+		}
+		return nil
+	})
+}
+
+// migrateCategoryNames replaces the global uniqueness of category names by one per user and
+// type that ignores case and deleted categories. Categories of a user that differ only in the
+// case of their name are merged into the oldest one first. It has to run after AutoMigrate.
+func migrateCategoryNames(db *gorm.DB) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("ALTER TABLE categories DROP CONSTRAINT IF EXISTS categories_name_key").Error; err != nil {
+			return err
+		}
+
+		var duplicates []struct{ ID, KeepID uuid.UUID }
+		err := tx.Raw("SELECT id, keep_id FROM (" +
+			"SELECT id, first_value(id) OVER (PARTITION BY user_id, LOWER(name), type ORDER BY created_at, id) AS keep_id " +
+			"FROM categories WHERE deleted_at IS NULL) AS named WHERE id <> keep_id").
+			Scan(&duplicates).Error
+		if err != nil {
+			return err
+		}
+		for _, d := range duplicates {
+			statements := []string{
+				"UPDATE transactions SET category_id = @keep WHERE category_id = @id",
+				"UPDATE recurring_transactions SET category_id = @keep WHERE category_id = @id",
+				// A category keeps one budget per period
+				"UPDATE budgets SET category_id = @keep WHERE category_id = @id AND deleted_at IS NULL AND NOT EXISTS " +
+					"(SELECT 1 FROM budgets AS kept WHERE kept.category_id = @keep AND kept.period = budgets.period AND kept.deleted_at IS NULL)",
+				"UPDATE budgets SET deleted_at = NOW() WHERE category_id = @id AND deleted_at IS NULL",
+				"UPDATE categories SET deleted_at = NOW() WHERE id = @id",
+			}
+			for _, statement := range statements {
+				if err := tx.Exec(statement, sql.Named("id", d.ID), sql.Named("keep", d.KeepID)).Error; err != nil {
+					return fmt.Errorf("merging category %s into %s: %w", d.ID, d.KeepID, err)
+				}
+			}
+			log.Printf("merged category %s into %s", d.ID, d.KeepID)
+		}
+
+		return tx.Exec("CREATE UNIQUE INDEX IF NOT EXISTS idx_categories_user_name_type " +
+			"ON categories (user_id, LOWER(name), type) WHERE deleted_at IS NULL").Error
+	})
+}
+
+// migrateBudgetPeriods makes the database keep one not deleted budget per category and period,
+// checking it in the application let concurrent requests create two. Of existing duplicates the
+// oldest budget is kept and the others are deleted. It has to run after migrateCategoryNames.
+func migrateBudgetPeriods(db *gorm.DB) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Exec("UPDATE budgets SET deleted_at = NOW() WHERE id IN (" +
+			"SELECT id FROM (" +
+			"SELECT id, first_value(id) OVER (PARTITION BY user_id, category_id, period ORDER BY created_at, id) AS keep_id " +
+			"FROM budgets WHERE deleted_at IS NULL) AS periods WHERE id <> keep_id)")
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected > 0 {
+			log.Printf("deleted %d budgets repeating the period of another budget of their category", result.RowsAffected)
+		}
+
+		return tx.Exec("CREATE UNIQUE INDEX IF NOT EXISTS idx_budgets_user_category_period " +
+			"ON budgets (user_id, category_id, period) WHERE deleted_at IS NULL").Error
+	})
+}
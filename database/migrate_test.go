@@ -0,0 +1,341 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/KashyretsIvanna/voice-balance/internals/model"
+	"github.com/google/uuid"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// fakeSchema is a database/sql driver that knows the types of columns, answers the
+// information_schema query of migrateMoneyColumns and records the statements run
+type fakeSchema struct {
+	mu       sync.Mutex
+	types    map[string]string // "table.column" to data_type, missing columns are absent
+	failOn   string            // statements containing it fail
+	executed []string
+	rollback bool
+}
+
+var alterColumnRegex = regexp.MustCompile(`^ALTER TABLE (\w+) ALTER COLUMN (\w+) TYPE (\w+)`)
+
+func (s *fakeSchema) open(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: sql.OpenDB(s)}), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func (s *fakeSchema) altered() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var statements []string
+	for _, statement := range s.executed {
+		if strings.HasPrefix(statement, "ALTER TABLE") {
+			statements = append(statements, statement)
+		}
+	}
+	return statements
+}
+
+func (s *fakeSchema) Connect(context.Context) (driver.Conn, error) { return &fakeConn{s}, nil }
+func (s *fakeSchema) Driver() driver.Driver                        { return nil }
+
+type fakeConn struct{ schema *fakeSchema }
+
+func (c *fakeConn) Prepare(query string) (driver.Stmt, error) {
+	return &fakeStmt{schema: c.schema, query: query}, nil
+}
+func (c *fakeConn) Close() error              { return nil }
+func (c *fakeConn) Begin() (driver.Tx, error) { return c, nil }
+func (c *fakeConn) Commit() error             { return nil }
+func (c *fakeConn) Rollback() error {
+	c.schema.mu.Lock()
+	defer c.schema.mu.Unlock()
+	c.schema.rollback = true
+	return nil
+}
+
+type fakeStmt struct {
+	schema *fakeSchema
+	query  string
+}
+
+func (s *fakeStmt) Close() error  { return nil }
+func (s *fakeStmt) NumInput() int { return -1 }
+
+func (s *fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
+	s.schema.mu.Lock()
+	defer s.schema.mu.Unlock()
+	if s.schema.failOn != "" && strings.Contains(s.query, s.schema.failOn) {
+		return nil, errors.New("canceling statement due to lock timeout")
+	}
+	s.schema.executed = append(s.schema.executed, s.query)
+	if match := alterColumnRegex.FindStringSubmatch(s.query); match != nil {
+		s.schema.types[match[1]+"."+match[2]] = match[3]
+	}
+	return driver.RowsAffected(0), nil
+}
+
+func (s *fakeStmt) Query(args []driver.Value) (driver.Rows, error) {
+	s.schema.mu.Lock()
+	defer s.schema.mu.Unlock()
+	if !strings.Contains(s.query, "information_schema.columns") || len(args) != 2 {
+		return nil, fmt.Errorf("unexpected query %q", s.query)
+	}
+	rows := &fakeRows{}
+	if dataType, ok := s.schema.types[fmt.Sprint(args[0])+"."+fmt.Sprint(args[1])]; ok {
+		rows.values = []string{dataType}
+	}
+	return rows, nil
+}
+
+type fakeRows struct{ values []string }
+
+func (r *fakeRows) Columns() []string { return []string{"data_type"} }
+func (r *fakeRows) Close() error      { return nil }
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.values) == 0 {
+		return io.EOF
+	}
+	dest[0], r.values = r.values[0], r.values[1:]
+	return nil
+}
+
+func TestMigrateMoneyColumnsConvertsFloatColumns(t *testing.T) {
+	schema := &fakeSchema{types: map[string]string{
+		"transactions.amount":  "double precision",
+		"reminders.amount":     "bigint",
+		"budgets.limit_amount": "numeric",
+		// recurring_transactions is created later by AutoMigrate
+	}}
+	db := schema.open(t)
+
+	if err := migrateMoneyColumns(db); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"ALTER TABLE transactions ALTER COLUMN amount TYPE bigint USING ROUND(amount * 100)",
+		"ALTER TABLE budgets ALTER COLUMN limit_amount TYPE bigint USING ROUND(limit_amount * 100)",
+	}
+	if got := schema.altered(); !reflect.DeepEqual(got, want) {
+		t.Errorf("statements %q, want %q", got, want)
+	}
+
+	// On the next start the columns are integers and nothing is converted twice
+	if err := migrateMoneyColumns(db); err != nil {
+		t.Fatal(err)
+	}
+	if got := schema.altered(); len(got) != len(want) {
+		t.Errorf("the second run altered %q", got[len(want):])
+	}
+}
+
+func TestMigrateMoneyColumnsRollsBackOnError(t *testing.T) {
+	schema := &fakeSchema{
+		types: map[string]string{
+			"transactions.amount":  "double precision",
+			"budgets.limit_amount": "real",
+		},
+		failOn: "ALTER TABLE budgets",
+	}
+
+	err := migrateMoneyColumns(schema.open(t))
+	if err == nil || !strings.Contains(err.Error(), "budgets.limit_amount") {
+		t.Fatalf("error = %v, want one naming budgets.limit_amount", err)
+	}
+	if !schema.rollback {
+		t.Errorf("the conversion of transactions.amount was not rolled back")
+	}
+}
+
+// openTestSchema makes an empty schema in the database in TEST_DATABASE_DSN that is dropped
+// when the test ends, the test is skipped when TEST_DATABASE_DSN is not set
+func openTestSchema(t *testing.T) *gorm.DB {
+	t.Helper()
+	dsn := os.Getenv("TEST_DATABASE_DSN")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_DSN is not set")
+	}
+	admin, err := gorm.Open(postgres.Open(dsn), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	schemaName := fmt.Sprintf("migrate_test_%d", time.Now().UnixNano())
+	if err := admin.Exec("CREATE SCHEMA " + schemaName).Error; err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { admin.Exec("DROP SCHEMA " + schemaName + " CASCADE") })
+
+	db, err := gorm.Open(postgres.Open(dsn+" search_path="+schemaName), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+// TestMigrateMoneyColumnsOnPostgres checks the conversion of the values themselves, it runs
+// against the database in TEST_DATABASE_DSN in a schema of its own
+func TestMigrateMoneyColumnsOnPostgres(t *testing.T) {
+	db := openTestSchema(t)
+	if err := db.Exec("CREATE TABLE transactions (amount double precision)").Error; err != nil {
+		t.Fatal(err)
+	}
+	// 0.07 * 100 and 1999.99 * 100 are not whole as floats
+	if err := db.Exec("INSERT INTO transactions VALUES (12.5), (0.07), (1999.99), (-3.1), (0)").Error; err != nil {
+		t.Fatal(err)
+	}
+
+	if err := migrateMoneyColumns(db); err != nil {
+		t.Fatal(err)
+	}
+	var amounts []int64
+	if err := db.Raw("SELECT amount FROM transactions ORDER BY amount").Scan(&amounts).Error; err != nil {
+		t.Fatal(err)
+	}
+	if want := []int64{-310, 0, 7, 1250, 199999}; !reflect.DeepEqual(amounts, want) {
+		t.Errorf("amounts %v, want %v", amounts, want)
+	}
+}
+
+func TestMigrateBudgetPeriodsDeletesDuplicatesBeforeIndexing(t *testing.T) {
+	schema := &fakeSchema{types: map[string]string{}}
+
+	if err := migrateBudgetPeriods(schema.open(t)); err != nil {
+		t.Fatal(err)
+	}
+	if len(schema.executed) != 2 {
+		t.Fatalf("statements %q, want an update and an index", schema.executed)
+	}
+	update, index := schema.executed[0], schema.executed[1]
+	if !strings.HasPrefix(update, "UPDATE budgets SET deleted_at") || !strings.Contains(update, "PARTITION BY user_id, category_id, period ORDER BY created_at, id") {
+		t.Errorf("duplicates are not deleted keeping the oldest budget: %q", update)
+	}
+	if index != "CREATE UNIQUE INDEX IF NOT EXISTS idx_budgets_user_category_period ON budgets (user_id, category_id, period) WHERE deleted_at IS NULL" {
+		t.Errorf("index %q", index)
+	}
+
+	schema = &fakeSchema{types: map[string]string{}, failOn: "CREATE UNIQUE INDEX"}
+	if err := migrateBudgetPeriods(schema.open(t)); err == nil || !schema.rollback {
+		t.Errorf("a failed index did not roll the deletions back: %v", err)
+	}
+}
+
+// TestMigrateCategoryNamesOnPostgres runs the migration on categories made before names were
+// unique per user and type regardless of case
+func TestMigrateCategoryNamesOnPostgres(t *testing.T) {
+	db := openTestSchema(t)
+	if err := db.AutoMigrate(&model.User{}, &model.Category{}, &model.Transaction{}, &model.RecurringTransaction{}, &model.Budget{}); err != nil {
+		t.Fatal(err)
+	}
+
+	user := &model.User{Email: "user@example.com", Password: "-"}
+	other := &model.User{Email: "other@example.com", Password: "-"}
+	if err := db.Create([]*model.User{user, other}).Error; err != nil {
+		t.Fatal(err)
+	}
+	start := time.Date(2024, time.May, 1, 12, 0, 0, 0, time.UTC)
+	deletedAt := start
+	category := func(userID uuid.UUID, name, categoryType string, minutes int) *model.Category {
+		t.Helper()
+		category := &model.Category{Name: name, Type: categoryType, UserID: userID, CreatedAt: start.Add(time.Duration(minutes) * time.Minute)}
+		if err := db.Create(category).Error; err != nil {
+			t.Fatal(err)
+		}
+		return category
+	}
+	kept := category(user.ID, "Їжа", model.CategoryTypeExpense, 0)
+	duplicate := category(user.ID, "їжа", model.CategoryTypeExpense, 1)
+	income := category(user.ID, "ЇЖА", model.CategoryTypeIncome, 2)
+	othersFood := category(other.ID, "їжа", model.CategoryTypeExpense, 3)
+	deleted := &model.Category{Name: "ЇЖА", Type: model.CategoryTypeExpense, UserID: user.ID, DeletedAt: &deletedAt}
+	if err := db.Create(deleted).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	transactions := []*model.Transaction{
+		{Amount: 100, Currency: "UAH", Date: start, UserID: user.ID, CategoryID: kept.ID},
+		{Amount: 200, Currency: "UAH", Date: start, UserID: user.ID, CategoryID: duplicate.ID},
+		{Amount: 300, Currency: "UAH", Date: start, UserID: user.ID, CategoryID: duplicate.ID, DeletedAt: &deletedAt},
+		{Amount: 400, Currency: "UAH", Date: start, UserID: user.ID, CategoryID: income.ID},
+		{Amount: 500, Currency: "UAH", Date: start, UserID: other.ID, CategoryID: othersFood.ID},
+	}
+	for _, transaction := range transactions {
+		if err := db.Create(transaction).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	// The budget of the duplicate repeats the period of the kept category and is deleted
+	keptBudget := &model.Budget{UserID: user.ID, CategoryID: kept.ID, Period: "monthly", Limit: 1000}
+	repeatedBudget := &model.Budget{UserID: user.ID, CategoryID: duplicate.ID, Period: "monthly", Limit: 2000}
+	movedBudget := &model.Budget{UserID: user.ID, CategoryID: duplicate.ID, Period: "weekly", Limit: 300}
+	for _, budget := range []*model.Budget{keptBudget, repeatedBudget, movedBudget} {
+		if err := db.Create(budget).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// The migration runs on every start
+	for run := 0; run < 2; run++ {
+		if err := migrateCategoryNames(db); err != nil {
+			t.Fatalf("run %d: %v", run+1, err)
+		}
+	}
+
+	var live []uuid.UUID
+	if err := db.Model(&model.Category{}).Where("deleted_at IS NULL").Order("created_at").Pluck("id", &live).Error; err != nil {
+		t.Fatal(err)
+	}
+	if want := []uuid.UUID{kept.ID, income.ID, othersFood.ID}; !reflect.DeepEqual(live, want) {
+		t.Errorf("not deleted categories %v, want %v", live, want)
+	}
+
+	categoryOf := map[model.Money]uuid.UUID{}
+	var moved []model.Transaction
+	if err := db.Find(&moved).Error; err != nil {
+		t.Fatal(err)
+	}
+	for _, transaction := range moved {
+		categoryOf[transaction.Amount] = transaction.CategoryID
+	}
+	want := map[model.Money]uuid.UUID{100: kept.ID, 200: kept.ID, 300: kept.ID, 400: income.ID, 500: othersFood.ID}
+	if !reflect.DeepEqual(categoryOf, want) {
+		t.Errorf("categories of the transactions by amount %v, want %v", categoryOf, want)
+	}
+
+	var budgets []model.Budget
+	if err := db.Where("deleted_at IS NULL").Order("limit_amount").Find(&budgets).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(budgets) != 2 || budgets[0].ID != movedBudget.ID || budgets[0].CategoryID != kept.ID || budgets[1].ID != keptBudget.ID {
+		t.Errorf("not deleted budgets %+v, want the kept one and the weekly one moved to %s", budgets, kept.ID)
+	}
+
+	// The index ignores case and deleted categories
+	if err := db.Create(&model.Category{Name: "ЇЖа", Type: model.CategoryTypeExpense, UserID: user.ID}).Error; err == nil {
+		t.Errorf("a name differing only in case was saved")
+	}
+	if err := db.Create(&model.Category{Name: "ЇЖа", Type: model.CategoryTypeExpense, UserID: other.ID, DeletedAt: &deletedAt}).Error; err != nil {
+		t.Errorf("a deleted category repeating a name was not saved: %v", err)
+	}
+	if err := db.Create(&model.Category{Name: "Їжа", Type: model.CategoryTypeIncome, UserID: other.ID}).Error; err != nil {
+		t.Errorf("the name of an expense category was not saved for an income category: %v", err)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"log"
 	"net/http"
 	"strings"
 	"time"
@@ -11,6 +12,7 @@ import (
 	"github.com/KashyretsIvanna/voice-balance/config"
 	"github.com/KashyretsIvanna/voice-balance/internals/model"
 	"github.com/KashyretsIvanna/voice-balance/internals/repositories"
+	services "github.com/KashyretsIvanna/voice-balance/internals/services"
 	"github.com/dgrijalva/jwt-go"
 	"github.com/gofiber/fiber/v2"
 	"golang.org/x/crypto/bcrypt"
@@ -82,11 +84,13 @@ func isValidEmail(email string) bool {
 type RegisterRequest struct {
 	Email    string `json:"email"`
 	Password string `json:"password"`
+	// Language of voice commands and of the default categories, uk when empty
+	Language string `json:"language" example:"uk"`
 }
 
 // Register handles user registration
 // @Summary      Register a new user
-// @Description  Registers a new user with email and password
+// @Description  Registers a new user with email and password. The user starts with the default categories of the language.
 // @Tags         auth
 // @Accept       json
 // @Produce      json
@@ -109,6 +113,14 @@ func Register(c *fiber.Ctx) error {
 		return c.Status(http.StatusBadRequest).SendString("Invalid email format")
 	}
 
+	language := services.DefaultLanguage()
+	if regReq.Language != "" {
+		var err error
+		if language, err = services.LookupLanguage(regReq.Language); err != nil {
+			return c.Status(http.StatusBadRequest).SendString("Unsupported language")
+		}
+	}
+
 	// Check if the user already exists
 	existingUser, err := repositories.GetUserByEmail(regReq.Email)
 	fmt.Print(existingUser)
@@ -126,17 +138,27 @@ func Register(c *fiber.Ctx) error {
 	user := &model.User{
 		Email:    regReq.Email,
 		Password: string(hashedPassword),
+		Language: language.Code,
 	}
 
 	// Save the new user to the database
 	if err := repositories.AddUser(user); err != nil {
 		return c.Status(http.StatusInternalServerError).SendString("Could not create user")
 	}
+	seedDefaultCategories(user)
 
 	// Return success response
 	return c.Status(http.StatusCreated).SendString("User created successfully")
 }
 
+// seedDefaultCategories gives a new user the default categories of the user's language.
+// The account is usable without them, a failure is logged and the user can reset the categories later.
+func seedDefaultCategories(user *model.User) {
+	if err := services.SeedDefaultCategories(user.ID, user.Language); err != nil {
+		log.Printf("seeding default categories of user %s failed: %v", user.ID, err)
+	}
+}
+
 // EmailPasswordLogin handles login with email/password
 // @Summary      Login with Email and Password
 // @Description  Authenticates a user with email and password, returns access and refresh tokens
@@ -270,7 +292,10 @@ func GoogleCallback(c *fiber.Ctx) error {
 
 	if _, err := repositories.GetUserByEmail(googleUser.Email); err != nil {
 		// Register new user
-		repositories.AddUser(&model.User{Email: googleUser.Email})
+		user := &model.User{Email: googleUser.Email}
+		if err := repositories.AddUser(user); err == nil {
+			seedDefaultCategories(user)
+		}
 	}
 
 	accessToken, err := generateToken(googleUser.Email, jwtAccessKey, 15*time.Hour)
@@ -0,0 +1,261 @@
+package handlers
+
+import (
+	"errors"
+
+	models "github.com/KashyretsIvanna/voice-balance/internals/model"
+	services "github.com/KashyretsIvanna/voice-balance/internals/services"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// budgetErrorResponse maps service errors to HTTP responses
+func budgetErrorResponse(c *fiber.Ctx, err error) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Budget not found"})
+	}
+	if errors.Is(err, services.ErrInvalidBudget) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	if errors.Is(err, services.ErrMissingExchangeRates) {
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+}
+
+// AddBudget godoc
+// @Summary      Add a new budget
+// @Description  Limits the spending in an expense category per week, month or year. threshold is the percent
+// @Description  of the limit expenses are warned about at, 100 by default. With rollover the money left unspent
+// @Description  in the previous period is added to the limit.
+// @Param Authorization header string true "Insert your access token" default(Bearer <Add access token here>)
+// @Tags         budgets
+// @Accept       json
+// @Produce      json
+// @Param        budget  body      models.Budget  true  "Budget Data"
+// @Success      201     {object}  models.Budget
+// @Failure      400     {object}  map[string]string
+// @Failure      500     {object}  map[string]string
+// @Router       /api/budgets [post]
+func AddBudget(c *fiber.Ctx) error {
+	userID, ok := c.Locals("ID").(uuid.UUID)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User ID not found in context",
+		})
+	}
+
+	budget := new(models.Budget)
+	if err := c.BodyParser(budget); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	// The owner always comes from the server
+	budget.ID = uuid.Nil
+	budget.UserID = userID
+	budget.DeletedAt = nil
+
+	if err := services.CreateBudget(budget); err != nil {
+		return budgetErrorResponse(c, err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(budget)
+}
+
+// GetBudgets godoc
+// @Summary      List budgets
+// @Description  Returns the budgets of the authenticated user
+// @Param Authorization header string true "Insert your access token" default(Bearer <Add access token here>)
+// @Tags         budgets
+// @Produce      json
+// @Success      200  {array}   models.Budget
+// @Failure      500  {object}  map[string]string
+// @Router       /api/budgets [get]
+func GetBudgets(c *fiber.Ctx) error {
+	userID, ok := c.Locals("ID").(uuid.UUID)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User ID not found in context",
+		})
+	}
+
+	budgets, err := services.GetBudgets(userID)
+	if err != nil {
+		return budgetErrorResponse(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"status":  "success",
+		"message": "Budgets retrieved",
+		"data":    budgets,
+	})
+}
+
+// GetBudgetStatuses godoc
+// @Summary      Get the status of all budgets
+// @Description  Returns the spending of every budget of the authenticated user in its current period:
+// @Description  spent against the limit and the spending projected for the end of the period at the current pace.
+// @Description  Periods are calendar weeks, months and years in the time zone of the user.
+// @Param Authorization header string true "Insert your access token" default(Bearer <Add access token here>)
+// @Tags         budgets
+// @Produce      json
+// @Success      200  {array}   services.BudgetStatus
+// @Failure      422  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Router       /api/budgets/status [get]
+func GetBudgetStatuses(c *fiber.Ctx) error {
+	userID, ok := c.Locals("ID").(uuid.UUID)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User ID not found in context",
+		})
+	}
+
+	statuses, err := services.GetBudgetStatuses(userID)
+	if err != nil {
+		return budgetErrorResponse(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"status":  "success",
+		"message": "Budget statuses retrieved",
+		"data":    statuses,
+	})
+}
+
+// GetBudget godoc
+// @Summary      Get a budget
+// @Description  Returns one budget of the authenticated user
+// @Param Authorization header string true "Insert your access token" default(Bearer <Add access token here>)
+// @Tags         budgets
+// @Produce      json
+// @Param        id   path      string  true  "Budget ID"
+// @Success      200  {object}  models.Budget
+// @Failure      400  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Router       /api/budgets/{id} [get]
+func GetBudget(c *fiber.Ctx) error {
+	userID, ok := c.Locals("ID").(uuid.UUID)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User ID not found in context",
+		})
+	}
+
+	budgetID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid budget ID"})
+	}
+
+	budget, err := services.GetBudget(userID, budgetID)
+	if err != nil {
+		return budgetErrorResponse(c, err)
+	}
+
+	return c.JSON(budget)
+}
+
+// GetBudgetStatus godoc
+// @Summary      Get the status of a budget
+// @Description  Returns the spending of a budget in its current period against its limit,
+// @Description  with the spending projected for the end of the period
+// @Param Authorization header string true "Insert your access token" default(Bearer <Add access token here>)
+// @Tags         budgets
+// @Produce      json
+// @Param        id   path      string  true  "Budget ID"
+// @Success      200  {object}  services.BudgetStatus
+// @Failure      400  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Failure      422  {object}  map[string]string
+// @Router       /api/budgets/{id}/status [get]
+func GetBudgetStatus(c *fiber.Ctx) error {
+	userID, ok := c.Locals("ID").(uuid.UUID)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User ID not found in context",
+		})
+	}
+
+	budgetID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid budget ID"})
+	}
+
+	status, err := services.GetBudgetStatus(userID, budgetID)
+	if err != nil {
+		return budgetErrorResponse(c, err)
+	}
+
+	return c.JSON(status)
+}
+
+// UpdateBudget godoc
+// @Summary      Update a budget
+// @Description  Changes the period, limit, threshold or rollover of a budget
+// @Param Authorization header string true "Insert your access token" default(Bearer <Add access token here>)
+// @Tags         budgets
+// @Accept       json
+// @Produce      json
+// @Param        id      path      string                 true  "Budget ID"
+// @Param        budget  body      services.BudgetUpdate  true  "Fields to update"
+// @Success      200     {object}  models.Budget
+// @Failure      400     {object}  map[string]string
+// @Failure      404     {object}  map[string]string
+// @Router       /api/budgets/{id} [patch]
+func UpdateBudget(c *fiber.Ctx) error {
+	userID, ok := c.Locals("ID").(uuid.UUID)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User ID not found in context",
+		})
+	}
+
+	budgetID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid budget ID"})
+	}
+
+	var update services.BudgetUpdate
+	if err := c.BodyParser(&update); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	budget, err := services.UpdateBudget(userID, budgetID, update)
+	if err != nil {
+		return budgetErrorResponse(c, err)
+	}
+
+	return c.JSON(budget)
+}
+
+// DeleteBudget godoc
+// @Summary      Delete a budget
+// @Description  Soft deletes a budget of the authenticated user
+// @Param Authorization header string true "Insert your access token" default(Bearer <Add access token here>)
+// @Tags         budgets
+// @Produce      json
+// @Param        id   path      string  true  "Budget ID"
+// @Success      200  {object}  map[string]string
+// @Failure      400  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Router       /api/budgets/{id} [delete]
+func DeleteBudget(c *fiber.Ctx) error {
+	userID, ok := c.Locals("ID").(uuid.UUID)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User ID not found in context",
+		})
+	}
+
+	budgetID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid budget ID"})
+	}
+
+	if err := services.DeleteBudget(userID, budgetID); err != nil {
+		return budgetErrorResponse(c, err)
+	}
+
+	return c.JSON(fiber.Map{"status": "success", "message": "Budget deleted"})
+}
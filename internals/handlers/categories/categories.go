@@ -1,17 +1,34 @@
 package handlers
 
 import (
-	"github.com/KashyretsIvanna/voice-balance/database"
+	"errors"
+
 	"github.com/KashyretsIvanna/voice-balance/internals/model"
-	"github.com/KashyretsIvanna/voice-balance/internals/repositories"
+	services "github.com/KashyretsIvanna/voice-balance/internals/services"
 	"github.com/google/uuid"
 
 	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
 )
 
+// categoryErrorResponse maps service errors to HTTP responses
+func categoryErrorResponse(c *fiber.Ctx, err error) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Category not found"})
+	}
+	if errors.Is(err, services.ErrInvalidCategory) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	if errors.Is(err, services.ErrCategoryExists) || errors.Is(err, services.ErrCategoryInUse) {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+}
+
 // AddCategoryHandler godoc
 // @Summary Add a new category
-// @Description Add a new category to the database
+// @Description Add a new category of the authenticated user. Names are unique per user and type, case is ignored.
+// @Description With parent_id the category is a subcategory of another category of the same type.
 // @Tags categories
 // @Accept json
 // @Produce json
@@ -19,11 +36,10 @@ import (
 // @Param category body model.Category true "Category to add"
 // @Success 201 {object} model.Category
 // @Failure 400 {object} map[string]string "Invalid input"
+// @Failure 409 {object} map[string]string "Category already exists"
 // @Failure 500 {object} map[string]string "Failed to add category"
 // @Router /api/categories [post]
 func AddCategoryHandler(c *fiber.Ctx) error {
-	db := database.DB
-
 	// Retrieve user ID from context and handle potential errors
 	userID, ok := c.Locals("ID").(uuid.UUID)
 	if !ok {
@@ -43,11 +59,9 @@ func AddCategoryHandler(c *fiber.Ctx) error {
 	// Set the UserID for the category
 	category.UserID = userID
 
-	// Save the category using the repository function
-	if err := repositories.AddCategory(db, category); err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Failed to add category",
-		})
+	// Save the category using the service function
+	if err := services.CreateCategory(category); err != nil {
+		return categoryErrorResponse(c, err)
 	}
 
 	// Return the created category
@@ -55,15 +69,15 @@ func AddCategoryHandler(c *fiber.Ctx) error {
 }
 
 // GetCategoriesByUserID retrieves categories for the authenticated user
-// @Description Get categories for the authenticated user
+// @Description Get categories for the authenticated user, an empty list when there are none
 // @Param Authorization header string true "Insert your access token" default(Bearer <Add access token here>)
 // @Tags categories
 // @Accept json
 // @Produce json
 // @Success 200 {array} model.Category
+// @Failure 500 {object} map[string]string
 // @router /api/categories [get]
 func GetCategoriesByUserID(c *fiber.Ctx) error {
-	db := database.DB
 	userID, ok := c.Locals("ID").(uuid.UUID)
 	if !ok {
 		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
@@ -71,28 +85,323 @@ func GetCategoriesByUserID(c *fiber.Ctx) error {
 		})
 	}
 
-	var categories []model.Category
-
 	// Retrieve categories by UserID
-	if err := db.Where("user_id = ?", userID).Find(&categories).Error; err != nil {
+	categories, err := services.GetCategories(userID)
+	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "Failed to retrieve categories",
 		})
 	}
 
-	// If no categories are found, you can return an empty array or a 404 status
-	if len(categories) == 0 {
-		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-			"status":  "error",
-			"message": "No categories found for this user",
-			"data":    nil,
+	// Return the categories
+	return c.JSON(fiber.Map{
+		"status":  "success",
+		"message": "Categories found",
+		"data":    categories,
+	})
+}
+
+// ResetCategoriesHandler godoc
+// @Summary Reset categories to defaults
+// @Description Adds the missing default categories of the user's language again and deletes the other
+// @Description categories, except the ones still used by transactions, recurring transactions, budgets or category rules
+// @Param Authorization header string true "Insert your access token" default(Bearer <Add access token here>)
+// @Tags categories
+// @Produce json
+// @Success 200 {array} model.Category
+// @Failure 500 {object} map[string]string
+// @Router /api/categories/reset [post]
+func ResetCategoriesHandler(c *fiber.Ctx) error {
+	userID, ok := c.Locals("ID").(uuid.UUID)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User ID not found in context",
 		})
 	}
 
-	// Return the categories
+	categories, err := services.ResetCategories(userID)
+	if err != nil {
+		return categoryErrorResponse(c, err)
+	}
+
 	return c.JSON(fiber.Map{
 		"status":  "success",
-		"message": "Categories found",
+		"message": "Categories reset to defaults",
 		"data":    categories,
 	})
 }
+
+// UpdateCategoryHandler godoc
+// @Summary Update a category
+// @Description Renames a category, changes its type or moves it under another category of the same type.
+// @Description clear_parent makes it a top-level category.
+// @Tags categories
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Insert your access token" default(Bearer <Add access token here>)
+// @Param id path string true "Category ID"
+// @Param category body services.CategoryUpdate true "Fields to update"
+// @Success 200 {object} model.Category
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string "Category already exists"
+// @Router /api/categories/{id} [patch]
+func UpdateCategoryHandler(c *fiber.Ctx) error {
+	userID, ok := c.Locals("ID").(uuid.UUID)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User ID not found in context",
+		})
+	}
+
+	categoryID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid category ID"})
+	}
+
+	var update services.CategoryUpdate
+	if err := c.BodyParser(&update); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	category, err := services.UpdateCategory(userID, categoryID, update)
+	if err != nil {
+		return categoryErrorResponse(c, err)
+	}
+
+	return c.JSON(category)
+}
+
+// DeleteCategoryHandler godoc
+// @Summary Delete a category
+// @Description Deletes a category no transaction, recurring transaction, budget or category rule uses, its subcategories
+// @Description move to its parent. Categories in use can be merged into another category instead.
+// @Tags categories
+// @Produce json
+// @Param Authorization header string true "Insert your access token" default(Bearer <Add access token here>)
+// @Param id path string true "Category ID"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string "Category is in use"
+// @Router /api/categories/{id} [delete]
+func DeleteCategoryHandler(c *fiber.Ctx) error {
+	userID, ok := c.Locals("ID").(uuid.UUID)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User ID not found in context",
+		})
+	}
+
+	categoryID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid category ID"})
+	}
+
+	if err := services.DeleteCategory(userID, categoryID); err != nil {
+		return categoryErrorResponse(c, err)
+	}
+
+	return c.JSON(fiber.Map{"status": "success", "message": "Category deleted"})
+}
+
+// MergeCategoryHandler godoc
+// @Summary Merge a category into another one
+// @Description Moves the transactions, recurring transactions, budgets and subcategories of a category into
+// @Description another category of the same type and deletes it, all at once. Budgets of a period the target
+// @Description category already has a budget for are deleted.
+// @Tags categories
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Insert your access token" default(Bearer <Add access token here>)
+// @Param id path string true "ID of the category to merge"
+// @Param merge body services.CategoryMerge true "Category to merge into"
+// @Success 200 {object} services.MergedCategory
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /api/categories/{id}/merge [post]
+func MergeCategoryHandler(c *fiber.Ctx) error {
+	userID, ok := c.Locals("ID").(uuid.UUID)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User ID not found in context",
+		})
+	}
+
+	categoryID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid category ID"})
+	}
+
+	var merge services.CategoryMerge
+	if err := c.BodyParser(&merge); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	merged, err := services.MergeCategory(userID, categoryID, merge)
+	if err != nil {
+		return categoryErrorResponse(c, err)
+	}
+
+	return c.JSON(merged)
+}
+
+// ResolveCategoryHandler godoc
+// @Summary Match a spoken category
+// @Description Matches a spoken category like "продуктах" or "на їжу" with the names and aliases of the categories
+// @Description of the user, without creating anything. decision is "match" for a confident match, "ask" when
+// @Description the user should pick one of the candidates and "create" when no category is close.
+// @Tags categories
+// @Produce json
+// @Param Authorization header string true "Insert your access token" default(Bearer <Add access token here>)
+// @Param phrase query string true "Spoken category"
+// @Param type query string false "Category type: income or expense" default(expense)
+// @Param language query string false "Language of the phrase, the one from the user settings by default"
+// @Success 200 {object} services.CategoryResolution
+// @Failure 400 {object} map[string]string
+// @Router /api/categories/resolve [get]
+func ResolveCategoryHandler(c *fiber.Ctx) error {
+	userID, ok := c.Locals("ID").(uuid.UUID)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User ID not found in context",
+		})
+	}
+
+	categoryType := c.Query("type", model.CategoryTypeExpense)
+	if categoryType != model.CategoryTypeIncome && categoryType != model.CategoryTypeExpense {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "type must be income or expense"})
+	}
+
+	language, err := services.ResolveLanguage(userID, c.Query("language"))
+	if errors.Is(err, services.ErrUnsupportedLanguage) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	if err != nil {
+		return categoryErrorResponse(c, err)
+	}
+
+	resolution, err := services.MatchCategory(userID, c.Query("phrase"), categoryType, language)
+	if err != nil {
+		return categoryErrorResponse(c, err)
+	}
+
+	return c.JSON(resolution)
+}
+
+// CategoryAliasRequest is the body of AddCategoryAliasHandler
+type CategoryAliasRequest struct {
+	Alias string `json:"alias" example:"їжа"`
+}
+
+// GetCategoryAliasesHandler godoc
+// @Summary List the aliases of a category
+// @Description Returns the other names spoken categories are matched with for a category
+// @Tags categories
+// @Produce json
+// @Param Authorization header string true "Insert your access token" default(Bearer <Add access token here>)
+// @Param id path string true "Category ID"
+// @Success 200 {array} model.CategoryAlias
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /api/categories/{id}/aliases [get]
+func GetCategoryAliasesHandler(c *fiber.Ctx) error {
+	userID, ok := c.Locals("ID").(uuid.UUID)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User ID not found in context",
+		})
+	}
+
+	categoryID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid category ID"})
+	}
+
+	aliases, err := services.CategoryAliases(userID, categoryID)
+	if err != nil {
+		return categoryErrorResponse(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"status":  "success",
+		"message": "Aliases found",
+		"data":    aliases,
+	})
+}
+
+// AddCategoryAliasHandler godoc
+// @Summary Add an alias to a category
+// @Description Adds another name a spoken category is matched with, e.g. "їжа" for "продукти".
+// @Description An alias cannot be the name or an alias of another category of the same type.
+// @Tags categories
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Insert your access token" default(Bearer <Add access token here>)
+// @Param id path string true "Category ID"
+// @Param alias body CategoryAliasRequest true "Alias"
+// @Success 201 {object} model.CategoryAlias
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string "Alias already used"
+// @Router /api/categories/{id}/aliases [post]
+func AddCategoryAliasHandler(c *fiber.Ctx) error {
+	userID, ok := c.Locals("ID").(uuid.UUID)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User ID not found in context",
+		})
+	}
+
+	categoryID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid category ID"})
+	}
+
+	var request CategoryAliasRequest
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	alias, err := services.AddCategoryAlias(userID, categoryID, request.Alias)
+	if err != nil {
+		return categoryErrorResponse(c, err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(alias)
+}
+
+// DeleteCategoryAliasHandler godoc
+// @Summary Delete an alias of a category
+// @Tags categories
+// @Produce json
+// @Param Authorization header string true "Insert your access token" default(Bearer <Add access token here>)
+// @Param id path string true "Category ID"
+// @Param aliasId path string true "Alias ID"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /api/categories/{id}/aliases/{aliasId} [delete]
+func DeleteCategoryAliasHandler(c *fiber.Ctx) error {
+	userID, ok := c.Locals("ID").(uuid.UUID)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User ID not found in context",
+		})
+	}
+
+	categoryID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid category ID"})
+	}
+	aliasID, err := uuid.Parse(c.Params("aliasId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid alias ID"})
+	}
+
+	if err := services.DeleteCategoryAlias(userID, categoryID, aliasID); err != nil {
+		return categoryErrorResponse(c, err)
+	}
+
+	return c.JSON(fiber.Map{"status": "success", "message": "Alias deleted"})
+}
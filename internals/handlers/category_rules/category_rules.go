@@ -0,0 +1,236 @@
+package handlers
+
+import (
+	"errors"
+
+	models "github.com/KashyretsIvanna/voice-balance/internals/model"
+	services "github.com/KashyretsIvanna/voice-balance/internals/services"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// categoryRuleErrorResponse maps service errors to HTTP responses
+func categoryRuleErrorResponse(c *fiber.Ctx, err error) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Category rule not found"})
+	}
+	if errors.Is(err, services.ErrInvalidCategoryRule) || errors.Is(err, services.ErrInvalidDateRange) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+}
+
+// AddCategoryRule godoc
+// @Summary      Add a category rule
+// @Description  Adds a rule that assigns its category to transactions added without a category_id and to voice
+// @Description  commands that name no category. A rule matches when the description contains one of the keywords,
+// @Description  matches the pattern (a regular expression), the amount is between min_amount and max_amount and
+// @Description  the date is on one of the weekdays, leaving out the conditions that are not set.
+// @Description  Rules are tried from the lowest priority up and the first one that matches wins.
+// @Param Authorization header string true "Insert your access token" default(Bearer <Add access token here>)
+// @Tags         category-rules
+// @Accept       json
+// @Produce      json
+// @Param        rule  body      models.CategoryRule  true  "Category Rule Data"
+// @Success      201   {object}  models.CategoryRule
+// @Failure      400   {object}  map[string]string
+// @Failure      500   {object}  map[string]string
+// @Router       /api/category-rules [post]
+func AddCategoryRule(c *fiber.Ctx) error {
+	userID, ok := c.Locals("ID").(uuid.UUID)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User ID not found in context",
+		})
+	}
+
+	rule := new(models.CategoryRule)
+	if err := c.BodyParser(rule); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	// The owner always comes from the server
+	rule.ID = uuid.Nil
+	rule.UserID = userID
+	rule.DeletedAt = nil
+
+	if err := services.CreateCategoryRule(rule); err != nil {
+		return categoryRuleErrorResponse(c, err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(rule)
+}
+
+// GetCategoryRules godoc
+// @Summary      List category rules
+// @Description  Returns the category rules of the authenticated user in the order they are tried
+// @Param Authorization header string true "Insert your access token" default(Bearer <Add access token here>)
+// @Tags         category-rules
+// @Produce      json
+// @Success      200  {array}   models.CategoryRule
+// @Failure      500  {object}  map[string]string
+// @Router       /api/category-rules [get]
+func GetCategoryRules(c *fiber.Ctx) error {
+	userID, ok := c.Locals("ID").(uuid.UUID)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User ID not found in context",
+		})
+	}
+
+	rules, err := services.GetCategoryRules(userID)
+	if err != nil {
+		return categoryRuleErrorResponse(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"status":  "success",
+		"message": "Category rules retrieved",
+		"data":    rules,
+	})
+}
+
+// GetCategoryRule godoc
+// @Summary      Get a category rule
+// @Description  Returns one category rule of the authenticated user
+// @Param Authorization header string true "Insert your access token" default(Bearer <Add access token here>)
+// @Tags         category-rules
+// @Produce      json
+// @Param        id   path      string  true  "Category Rule ID"
+// @Success      200  {object}  models.CategoryRule
+// @Failure      400  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Router       /api/category-rules/{id} [get]
+func GetCategoryRule(c *fiber.Ctx) error {
+	userID, ok := c.Locals("ID").(uuid.UUID)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User ID not found in context",
+		})
+	}
+
+	ruleID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid category rule ID"})
+	}
+
+	rule, err := services.GetCategoryRule(userID, ruleID)
+	if err != nil {
+		return categoryRuleErrorResponse(c, err)
+	}
+
+	return c.JSON(rule)
+}
+
+// UpdateCategoryRule godoc
+// @Summary      Update a category rule
+// @Description  Changes the fields of a category rule that are set in the body.
+// @Description  clear_min_amount and clear_max_amount remove a bound of the amount.
+// @Param Authorization header string true "Insert your access token" default(Bearer <Add access token here>)
+// @Tags         category-rules
+// @Accept       json
+// @Produce      json
+// @Param        id      path      string                       true  "Category Rule ID"
+// @Param        update  body      services.CategoryRuleUpdate  true  "Fields to change"
+// @Success      200     {object}  models.CategoryRule
+// @Failure      400     {object}  map[string]string
+// @Failure      404     {object}  map[string]string
+// @Router       /api/category-rules/{id} [patch]
+func UpdateCategoryRule(c *fiber.Ctx) error {
+	userID, ok := c.Locals("ID").(uuid.UUID)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User ID not found in context",
+		})
+	}
+
+	ruleID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid category rule ID"})
+	}
+
+	var update services.CategoryRuleUpdate
+	if err := c.BodyParser(&update); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	rule, err := services.UpdateCategoryRule(userID, ruleID, update)
+	if err != nil {
+		return categoryRuleErrorResponse(c, err)
+	}
+
+	return c.JSON(rule)
+}
+
+// DeleteCategoryRule godoc
+// @Summary      Delete a category rule
+// @Description  Deletes a category rule, the categories it already assigned stay
+// @Param Authorization header string true "Insert your access token" default(Bearer <Add access token here>)
+// @Tags         category-rules
+// @Produce      json
+// @Param        id   path      string  true  "Category Rule ID"
+// @Success      200  {object}  map[string]string
+// @Failure      400  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Router       /api/category-rules/{id} [delete]
+func DeleteCategoryRule(c *fiber.Ctx) error {
+	userID, ok := c.Locals("ID").(uuid.UUID)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User ID not found in context",
+		})
+	}
+
+	ruleID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid category rule ID"})
+	}
+
+	if err := services.DeleteCategoryRule(userID, ruleID); err != nil {
+		return categoryRuleErrorResponse(c, err)
+	}
+
+	return c.JSON(fiber.Map{"status": "success", "message": "Category rule deleted"})
+}
+
+// ApplyCategoryRules godoc
+// @Summary      Apply the category rules to existing transactions
+// @Description  Tries the category rules on the transactions of the authenticated user between start_date and
+// @Description  end_date, optionally only on the ones of category_ids, and moves each transaction into the category
+// @Description  of the first rule it matches. Only rules of the type of the current category of a transaction are
+// @Description  tried. With dry_run the changes are listed without saving them. Transactions are saved in batches,
+// @Description  changed counts all changes while changes lists at most 1000 of them and truncated tells there are more.
+// @Param Authorization header string true "Insert your access token" default(Bearer <Add access token here>)
+// @Tags         category-rules
+// @Accept       json
+// @Produce      json
+// @Param        apply  body      services.CategoryRuleApply  true  "Transactions to apply the rules to"
+// @Success      200    {object}  services.CategoryRuleApplication
+// @Failure      400    {object}  map[string]string
+// @Failure      404    {object}  map[string]string "A transaction was deleted meanwhile, earlier batches are saved"
+// @Failure      500    {object}  map[string]string
+// @Router       /api/category-rules/apply [post]
+func ApplyCategoryRules(c *fiber.Ctx) error {
+	userID, ok := c.Locals("ID").(uuid.UUID)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User ID not found in context",
+		})
+	}
+
+	var apply services.CategoryRuleApply
+	if err := c.BodyParser(&apply); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	application, err := services.ApplyCategoryRules(userID, apply)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+	}
+	if err != nil {
+		return categoryRuleErrorResponse(c, err)
+	}
+
+	return c.JSON(application)
+}
@@ -0,0 +1,139 @@
+package handlers
+
+import (
+	"crypto/subtle"
+	"errors"
+	"time"
+
+	"github.com/KashyretsIvanna/voice-balance/config"
+	services "github.com/KashyretsIvanna/voice-balance/internals/services"
+	"github.com/gofiber/fiber/v2"
+)
+
+// canWriteRates tells whether the request carries EXCHANGE_RATES_KEY in X-Rates-Key. Rates are
+// shared by every user, without the key set they cannot be written through the API at all.
+func canWriteRates(c *fiber.Ctx) bool {
+	key := config.Config("EXCHANGE_RATES_KEY")
+	return key != "" && subtle.ConstantTimeCompare([]byte(c.Get("X-Rates-Key")), []byte(key)) == 1
+}
+
+func exchangeRateErrorResponse(c *fiber.Ctx, err error) error {
+	if errors.Is(err, services.ErrInvalidExchangeRate) || errors.Is(err, services.ErrInvalidCurrency) ||
+		errors.Is(err, services.ErrInvalidDateRange) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+}
+
+// GetExchangeRates godoc
+// @Summary      Get exchange rates
+// @Description  Returns the stored prices of currencies in UAH by date. The range defaults to the current month.
+// @Param Authorization header string true "Insert your access token" default(Bearer <Add access token here>)
+// @Tags         exchange-rates
+// @Produce      json
+// @Param        currency     query     string false  "ISO 4217 code, every currency when empty"
+// @Param        start_date   query     string false  "Start Date (YYYY-MM-DD)"
+// @Param        end_date     query     string false  "End Date (YYYY-MM-DD)"
+// @Success      200          {array}   model.ExchangeRate
+// @Failure      400          {object}  map[string]string
+// @Failure      500          {object}  map[string]string
+// @Router       /api/exchange-rates [get]
+func GetExchangeRates(c *fiber.Ctx) error {
+	start, end, err := services.ParseDateRange(c.Query("start_date"), c.Query("end_date"), time.UTC, time.Now())
+	if err != nil {
+		return exchangeRateErrorResponse(c, err)
+	}
+
+	rates, err := services.GetExchangeRates(c.Query("currency"), start, end)
+	if err != nil {
+		return exchangeRateErrorResponse(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"status":  "success",
+		"message": "Exchange rates retrieved",
+		"data":    rates,
+	})
+}
+
+// ImportExchangeRates godoc
+// @Summary      Import exchange rates
+// @Description  Saves prices of currencies in UAH, a rate already stored for the same currency and date is replaced.
+// @Description  Needs the key set in EXCHANGE_RATES_KEY in the X-Rates-Key header.
+// @Param Authorization header string true "Insert your access token" default(Bearer <Add access token here>)
+// @Tags         exchange-rates
+// @Accept       json
+// @Produce      json
+// @Param        X-Rates-Key  header    string                         true  "Exchange rates key"
+// @Param        rates        body      []services.ExchangeRateInput   true  "Rates"
+// @Success      201          {array}   model.ExchangeRate
+// @Failure      400          {object}  map[string]string
+// @Failure      403          {object}  map[string]string
+// @Failure      500          {object}  map[string]string
+// @Router       /api/exchange-rates [post]
+func ImportExchangeRates(c *fiber.Ctx) error {
+	if !canWriteRates(c) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Invalid exchange rates key"})
+	}
+
+	var inputs []services.ExchangeRateInput
+	if err := c.BodyParser(&inputs); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	rates, err := services.ImportExchangeRates(inputs)
+	if err != nil {
+		return exchangeRateErrorResponse(c, err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(rates)
+}
+
+// SyncExchangeRates godoc
+// @Summary      Sync exchange rates from the provider
+// @Description  Fetches the rates of every day of the range from the provider set in EXCHANGE_RATE_PROVIDER,
+// @Description  at most a year at once. The range defaults to the current month until today.
+// @Description  Needs the key set in EXCHANGE_RATES_KEY in the X-Rates-Key header.
+// @Param Authorization header string true "Insert your access token" default(Bearer <Add access token here>)
+// @Tags         exchange-rates
+// @Produce      json
+// @Param        X-Rates-Key  header    string true   "Exchange rates key"
+// @Param        start_date   query     string false  "Start Date (YYYY-MM-DD)"
+// @Param        end_date     query     string false  "End Date (YYYY-MM-DD)"
+// @Success      200          {object}  map[string]interface{}
+// @Failure      400          {object}  map[string]string
+// @Failure      403          {object}  map[string]string
+// @Failure      502          {object}  map[string]string
+// @Router       /api/exchange-rates/sync [post]
+func SyncExchangeRates(c *fiber.Ctx) error {
+	if !canWriteRates(c) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Invalid exchange rates key"})
+	}
+
+	start, end, err := services.ParseDateRange(c.Query("start_date"), c.Query("end_date"), time.UTC, time.Now())
+	if err != nil {
+		return exchangeRateErrorResponse(c, err)
+	}
+
+	provider, err := services.NewExchangeRateProvider()
+	if err != nil {
+		return exchangeRateErrorResponse(c, err)
+	}
+	if provider == nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "No exchange rate provider is configured"})
+	}
+
+	saved, err := services.SyncExchangeRates(c.UserContext(), provider, start, end)
+	if errors.Is(err, services.ErrInvalidDateRange) {
+		return exchangeRateErrorResponse(c, err)
+	}
+	if err != nil {
+		return c.Status(fiber.StatusBadGateway).JSON(fiber.Map{"error": err.Error(), "saved": saved})
+	}
+
+	return c.JSON(fiber.Map{
+		"status":  "success",
+		"message": "Exchange rates synced",
+		"data":    fiber.Map{"provider": provider.Name(), "saved": saved},
+	})
+}
@@ -0,0 +1,85 @@
+package handlers
+
+import (
+	"errors"
+	"strconv"
+
+	services "github.com/KashyretsIvanna/voice-balance/internals/services"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// GetNotifications godoc
+// @Summary      Get the notification inbox
+// @Description  Returns the in-app notifications of the authenticated user, newest first
+// @Param Authorization header string true "Insert your access token" default(Bearer <Add access token here>)
+// @Tags         notifications
+// @Produce      json
+// @Param        unread  query     bool  false  "Only unread notifications"
+// @Success      200     {array}   model.Notification
+// @Failure      400     {object}  map[string]string
+// @Failure      500     {object}  map[string]string
+// @Router       /api/notifications [get]
+func GetNotifications(c *fiber.Ctx) error {
+	userID, ok := c.Locals("ID").(uuid.UUID)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User ID not found in context",
+		})
+	}
+
+	unreadOnly := false
+	if unread := c.Query("unread"); unread != "" {
+		var err error
+		if unreadOnly, err = strconv.ParseBool(unread); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "unread must be true or false"})
+		}
+	}
+
+	notifications, err := services.GetNotifications(userID, unreadOnly)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.JSON(fiber.Map{
+		"status":  "success",
+		"message": "Notifications retrieved",
+		"data":    notifications,
+	})
+}
+
+// MarkNotificationRead godoc
+// @Summary      Mark a notification as read
+// @Description  Sets the read time of a notification in the inbox of the authenticated user
+// @Param Authorization header string true "Insert your access token" default(Bearer <Add access token here>)
+// @Tags         notifications
+// @Produce      json
+// @Param        id   path      string  true  "Notification ID"
+// @Success      200  {object}  model.Notification
+// @Failure      400  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Router       /api/notifications/{id}/read [post]
+func MarkNotificationRead(c *fiber.Ctx) error {
+	userID, ok := c.Locals("ID").(uuid.UUID)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User ID not found in context",
+		})
+	}
+
+	notificationID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid notification ID"})
+	}
+
+	notification, err := services.MarkNotificationRead(userID, notificationID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Notification not found"})
+	}
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.JSON(notification)
+}
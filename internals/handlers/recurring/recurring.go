@@ -0,0 +1,240 @@
+package handlers
+
+import (
+	"errors"
+
+	models "github.com/KashyretsIvanna/voice-balance/internals/model"
+	services "github.com/KashyretsIvanna/voice-balance/internals/services"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// recurringErrorResponse maps service errors to HTTP responses
+func recurringErrorResponse(c *fiber.Ctx, err error) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Recurring transaction not found"})
+	}
+	if errors.Is(err, services.ErrInvalidRecurringTransaction) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	if errors.Is(err, services.ErrRecurringTransactionBusy) {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+}
+
+// AddRecurringTransaction godoc
+// @Summary      Add a recurring transaction
+// @Description  Posts a transaction every interval days, weeks, months or years from start_date until end_date.
+// @Description  Monthly and yearly occurrences keep the day of month of start_date, or the last day of shorter months.
+// @Description  Occurrences between a start_date in the past and now are posted on the next run of the scheduler.
+// @Param Authorization header string true "Insert your access token" default(Bearer <Add access token here>)
+// @Tags         recurring
+// @Accept       json
+// @Produce      json
+// @Param        recurring  body      models.RecurringTransaction  true  "Recurring Transaction Data"
+// @Success      201        {object}  models.RecurringTransaction
+// @Failure      400        {object}  map[string]string
+// @Failure      500        {object}  map[string]string
+// @Router       /api/recurring [post]
+func AddRecurringTransaction(c *fiber.Ctx) error {
+	userID, ok := c.Locals("ID").(uuid.UUID)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User ID not found in context",
+		})
+	}
+
+	recurring := new(models.RecurringTransaction)
+	if err := c.BodyParser(recurring); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	// The owner and the schedule state always come from the server
+	recurring.ID = uuid.Nil
+	recurring.UserID = userID
+	recurring.NextDate = nil
+	recurring.DeletedAt = nil
+
+	if err := services.CreateRecurringTransaction(recurring); err != nil {
+		return recurringErrorResponse(c, err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(recurring)
+}
+
+// GetRecurringTransactions godoc
+// @Summary      List recurring transactions
+// @Description  Returns the recurring transactions of the authenticated user ordered by the next occurrence
+// @Param Authorization header string true "Insert your access token" default(Bearer <Add access token here>)
+// @Tags         recurring
+// @Produce      json
+// @Success      200  {array}   models.RecurringTransaction
+// @Failure      500  {object}  map[string]string
+// @Router       /api/recurring [get]
+func GetRecurringTransactions(c *fiber.Ctx) error {
+	userID, ok := c.Locals("ID").(uuid.UUID)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User ID not found in context",
+		})
+	}
+
+	recurring, err := services.GetRecurringTransactions(userID)
+	if err != nil {
+		return recurringErrorResponse(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"status":  "success",
+		"message": "Recurring transactions retrieved",
+		"data":    recurring,
+	})
+}
+
+// GetRecurringTransaction godoc
+// @Summary      Get a recurring transaction
+// @Description  Returns one recurring transaction of the authenticated user
+// @Param Authorization header string true "Insert your access token" default(Bearer <Add access token here>)
+// @Tags         recurring
+// @Produce      json
+// @Param        id   path      string  true  "Recurring Transaction ID"
+// @Success      200  {object}  models.RecurringTransaction
+// @Failure      400  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Router       /api/recurring/{id} [get]
+func GetRecurringTransaction(c *fiber.Ctx) error {
+	userID, ok := c.Locals("ID").(uuid.UUID)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User ID not found in context",
+		})
+	}
+
+	recurringID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid recurring transaction ID"})
+	}
+
+	recurring, err := services.GetRecurringTransaction(userID, recurringID)
+	if err != nil {
+		return recurringErrorResponse(c, err)
+	}
+
+	return c.JSON(recurring)
+}
+
+// PreviewRecurringTransaction godoc
+// @Summary      Preview upcoming occurrences
+// @Description  Returns the dates of the next occurrences of a recurring transaction that are not posted yet
+// @Param Authorization header string true "Insert your access token" default(Bearer <Add access token here>)
+// @Tags         recurring
+// @Produce      json
+// @Param        id     path      string  true   "Recurring Transaction ID"
+// @Param        count  query     int     false  "Number of occurrences, at most 100" default(10)
+// @Success      200    {array}   string
+// @Failure      400    {object}  map[string]string
+// @Failure      404    {object}  map[string]string
+// @Router       /api/recurring/{id}/preview [get]
+func PreviewRecurringTransaction(c *fiber.Ctx) error {
+	userID, ok := c.Locals("ID").(uuid.UUID)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User ID not found in context",
+		})
+	}
+
+	recurringID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid recurring transaction ID"})
+	}
+
+	count := c.QueryInt("count", 10)
+	if count <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "count must be greater than zero"})
+	}
+
+	occurrences, err := services.PreviewRecurringTransaction(userID, recurringID, count)
+	if err != nil {
+		return recurringErrorResponse(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"status":  "success",
+		"message": "Upcoming occurrences",
+		"data":    occurrences,
+	})
+}
+
+// UpdateRecurringTransaction godoc
+// @Summary      Update a recurring transaction
+// @Description  Changes the amount, category, description or schedule of a recurring transaction.
+// @Description  A changed schedule continues after the last posted occurrence.
+// @Param Authorization header string true "Insert your access token" default(Bearer <Add access token here>)
+// @Tags         recurring
+// @Accept       json
+// @Produce      json
+// @Param        id         path      string                               true  "Recurring Transaction ID"
+// @Param        recurring  body      services.RecurringTransactionUpdate  true  "Fields to update"
+// @Success      200        {object}  models.RecurringTransaction
+// @Failure      400        {object}  map[string]string
+// @Failure      404        {object}  map[string]string
+// @Failure      409        {object}  map[string]string
+// @Router       /api/recurring/{id} [patch]
+func UpdateRecurringTransaction(c *fiber.Ctx) error {
+	userID, ok := c.Locals("ID").(uuid.UUID)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User ID not found in context",
+		})
+	}
+
+	recurringID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid recurring transaction ID"})
+	}
+
+	var update services.RecurringTransactionUpdate
+	if err := c.BodyParser(&update); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	recurring, err := services.UpdateRecurringTransaction(userID, recurringID, update)
+	if err != nil {
+		return recurringErrorResponse(c, err)
+	}
+
+	return c.JSON(recurring)
+}
+
+// DeleteRecurringTransaction godoc
+// @Summary      Delete a recurring transaction
+// @Description  Soft deletes a recurring transaction, transactions it already posted are kept
+// @Param Authorization header string true "Insert your access token" default(Bearer <Add access token here>)
+// @Tags         recurring
+// @Produce      json
+// @Param        id   path      string  true  "Recurring Transaction ID"
+// @Success      200  {object}  map[string]string
+// @Failure      400  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Router       /api/recurring/{id} [delete]
+func DeleteRecurringTransaction(c *fiber.Ctx) error {
+	userID, ok := c.Locals("ID").(uuid.UUID)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User ID not found in context",
+		})
+	}
+
+	recurringID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid recurring transaction ID"})
+	}
+
+	if err := services.DeleteRecurringTransaction(userID, recurringID); err != nil {
+		return recurringErrorResponse(c, err)
+	}
+
+	return c.JSON(fiber.Map{"status": "success", "message": "Recurring transaction deleted"})
+}
@@ -0,0 +1,357 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	models "github.com/KashyretsIvanna/voice-balance/internals/model"
+	"github.com/KashyretsIvanna/voice-balance/internals/repositories"
+	services "github.com/KashyretsIvanna/voice-balance/internals/services"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// parseDateParam accepts either a plain date (YYYY-MM-DD) or a RFC3339 timestamp.
+// Plain dates used as the upper bound of a range cover the whole day.
+func parseDateParam(value string, endOfDay bool) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+
+	t, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if endOfDay {
+		t = t.Add(24*time.Hour - time.Nanosecond)
+	}
+	return t, nil
+}
+
+// reminderErrorResponse maps service errors to HTTP responses
+func reminderErrorResponse(c *fiber.Ctx, err error) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Reminder not found"})
+	}
+	if errors.Is(err, services.ErrInvalidReminder) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+}
+
+// AddReminder godoc
+// @Summary      Add a new reminder
+// @Description  Creates a reminder for the authenticated user
+// @Param Authorization header string true "Insert your access token" default(Bearer <Add access token here>)
+// @Tags         reminders
+// @Accept       json
+// @Produce      json
+// @Param        reminder  body      models.Reminder  true  "Reminder Data"
+// @Success      201       {object}  models.Reminder
+// @Failure      400       {object}  map[string]string
+// @Failure      500       {object}  map[string]string
+// @Router       /api/reminders [post]
+func AddReminder(c *fiber.Ctx) error {
+	userID, ok := c.Locals("ID").(uuid.UUID)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User ID not found in context",
+		})
+	}
+
+	reminder := new(models.Reminder)
+	if err := c.BodyParser(reminder); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	// The owner and the state always come from the server
+	reminder.ID = uuid.Nil
+	reminder.UserID = userID
+	reminder.IsCompleted = false
+	reminder.DeletedAt = nil
+	reminder.SnoozedUntil = nil
+	reminder.NotifiedAt = nil
+
+	if err := services.CreateReminder(reminder); err != nil {
+		return reminderErrorResponse(c, err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(reminder)
+}
+
+// GetReminders godoc
+// @Summary      List reminders
+// @Description  Returns reminders of the authenticated user filtered by due date range and completion state
+// @Param Authorization header string true "Insert your access token" default(Bearer <Add access token here>)
+// @Tags         reminders
+// @Produce      json
+// @Param        due_from      query     string  false  "Due from (YYYY-MM-DD or RFC3339)"
+// @Param        due_to        query     string  false  "Due to (YYYY-MM-DD or RFC3339)"
+// @Param        is_completed  query     bool    false  "Filter by completion state"
+// @Success      200           {array}   models.Reminder
+// @Failure      400           {object}  map[string]string
+// @Failure      500           {object}  map[string]string
+// @Router       /api/reminders [get]
+func GetReminders(c *fiber.Ctx) error {
+	userID, ok := c.Locals("ID").(uuid.UUID)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User ID not found in context",
+		})
+	}
+
+	var filter repositories.ReminderFilter
+
+	if dueFrom := c.Query("due_from"); dueFrom != "" {
+		from, err := parseDateParam(dueFrom, false)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": fmt.Sprintf("Invalid due_from format: %s", err.Error()),
+			})
+		}
+		filter.DueFrom = &from
+	}
+
+	if dueTo := c.Query("due_to"); dueTo != "" {
+		to, err := parseDateParam(dueTo, true)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": fmt.Sprintf("Invalid due_to format: %s", err.Error()),
+			})
+		}
+		filter.DueTo = &to
+	}
+
+	if isCompleted := c.Query("is_completed"); isCompleted != "" {
+		completed, err := strconv.ParseBool(isCompleted)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "is_completed must be true or false",
+			})
+		}
+		filter.IsCompleted = &completed
+	}
+
+	reminders, err := services.GetReminders(userID, filter)
+	if err != nil {
+		return reminderErrorResponse(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"status":  "success",
+		"message": "Reminders retrieved",
+		"data":    reminders,
+	})
+}
+
+// GetReminder godoc
+// @Summary      Get a reminder
+// @Description  Returns one reminder of the authenticated user
+// @Param Authorization header string true "Insert your access token" default(Bearer <Add access token here>)
+// @Tags         reminders
+// @Produce      json
+// @Param        id   path      string  true  "Reminder ID"
+// @Success      200  {object}  models.Reminder
+// @Failure      400  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Router       /api/reminders/{id} [get]
+func GetReminder(c *fiber.Ctx) error {
+	userID, ok := c.Locals("ID").(uuid.UUID)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User ID not found in context",
+		})
+	}
+
+	reminderID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid reminder ID"})
+	}
+
+	reminder, err := services.GetReminder(userID, reminderID)
+	if err != nil {
+		return reminderErrorResponse(c, err)
+	}
+
+	return c.JSON(reminder)
+}
+
+// UpdateReminder godoc
+// @Summary      Update a reminder
+// @Description  Changes the title, amount, due date or completion state of a reminder
+// @Param Authorization header string true "Insert your access token" default(Bearer <Add access token here>)
+// @Tags         reminders
+// @Accept       json
+// @Produce      json
+// @Param        id        path      string                   true  "Reminder ID"
+// @Param        reminder  body      services.ReminderUpdate  true  "Fields to update"
+// @Success      200       {object}  models.Reminder
+// @Failure      400       {object}  map[string]string
+// @Failure      404       {object}  map[string]string
+// @Router       /api/reminders/{id} [patch]
+func UpdateReminder(c *fiber.Ctx) error {
+	userID, ok := c.Locals("ID").(uuid.UUID)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User ID not found in context",
+		})
+	}
+
+	reminderID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid reminder ID"})
+	}
+
+	var update services.ReminderUpdate
+	if err := c.BodyParser(&update); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	reminder, err := services.UpdateReminder(userID, reminderID, update)
+	if err != nil {
+		return reminderErrorResponse(c, err)
+	}
+
+	return c.JSON(reminder)
+}
+
+// CompleteReminder godoc
+// @Summary      Complete a reminder
+// @Description  Marks a reminder as completed
+// @Param Authorization header string true "Insert your access token" default(Bearer <Add access token here>)
+// @Tags         reminders
+// @Produce      json
+// @Param        id   path      string  true  "Reminder ID"
+// @Success      200  {object}  models.Reminder
+// @Failure      400  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Router       /api/reminders/{id}/complete [post]
+func CompleteReminder(c *fiber.Ctx) error {
+	userID, ok := c.Locals("ID").(uuid.UUID)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User ID not found in context",
+		})
+	}
+
+	reminderID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid reminder ID"})
+	}
+
+	reminder, err := services.CompleteReminder(userID, reminderID)
+	if err != nil {
+		return reminderErrorResponse(c, err)
+	}
+
+	return c.JSON(reminder)
+}
+
+// SnoozeReminder godoc
+// @Summary      Snooze a reminder
+// @Description  Notifies about a reminder again after a number of minutes or at a given time, the due date stays as it is
+// @Param Authorization header string true "Insert your access token" default(Bearer <Add access token here>)
+// @Tags         reminders
+// @Accept       json
+// @Produce      json
+// @Param        id      path      string                   true  "Reminder ID"
+// @Param        snooze  body      services.ReminderSnooze  true  "Snooze for minutes or until a time"
+// @Success      200     {object}  models.Reminder
+// @Failure      400     {object}  map[string]string
+// @Failure      404     {object}  map[string]string
+// @Router       /api/reminders/{id}/snooze [post]
+func SnoozeReminder(c *fiber.Ctx) error {
+	userID, ok := c.Locals("ID").(uuid.UUID)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User ID not found in context",
+		})
+	}
+
+	reminderID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid reminder ID"})
+	}
+
+	var snooze services.ReminderSnooze
+	if err := c.BodyParser(&snooze); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	reminder, err := services.SnoozeReminder(userID, reminderID, snooze)
+	if err != nil {
+		return reminderErrorResponse(c, err)
+	}
+
+	return c.JSON(reminder)
+}
+
+// GetReminderDeliveries godoc
+// @Summary      Get the delivery log of a reminder
+// @Description  Returns every attempt to deliver the notifications of a reminder, newest first
+// @Param Authorization header string true "Insert your access token" default(Bearer <Add access token here>)
+// @Tags         reminders
+// @Produce      json
+// @Param        id   path      string  true  "Reminder ID"
+// @Success      200  {array}   models.ReminderDelivery
+// @Failure      400  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Router       /api/reminders/{id}/deliveries [get]
+func GetReminderDeliveries(c *fiber.Ctx) error {
+	userID, ok := c.Locals("ID").(uuid.UUID)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User ID not found in context",
+		})
+	}
+
+	reminderID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid reminder ID"})
+	}
+
+	deliveries, err := services.GetReminderDeliveries(userID, reminderID)
+	if err != nil {
+		return reminderErrorResponse(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"status":  "success",
+		"message": "Deliveries retrieved",
+		"data":    deliveries,
+	})
+}
+
+// DeleteReminder godoc
+// @Summary      Delete a reminder
+// @Description  Soft deletes a reminder of the authenticated user
+// @Param Authorization header string true "Insert your access token" default(Bearer <Add access token here>)
+// @Tags         reminders
+// @Produce      json
+// @Param        id   path      string  true  "Reminder ID"
+// @Success      200  {object}  map[string]string
+// @Failure      400  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Router       /api/reminders/{id} [delete]
+func DeleteReminder(c *fiber.Ctx) error {
+	userID, ok := c.Locals("ID").(uuid.UUID)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User ID not found in context",
+		})
+	}
+
+	reminderID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid reminder ID"})
+	}
+
+	if err := services.DeleteReminder(userID, reminderID); err != nil {
+		return reminderErrorResponse(c, err)
+	}
+
+	return c.JSON(fiber.Map{"status": "success", "message": "Reminder deleted"})
+}
@@ -1,25 +1,75 @@
 package handlers
 
 import (
-	"fmt"
+	"errors"
+	"strconv"
+	"time"
 
+	models "github.com/KashyretsIvanna/voice-balance/internals/model"
+	repositories "github.com/KashyretsIvanna/voice-balance/internals/repositories"
 	services "github.com/KashyretsIvanna/voice-balance/internals/services"
 	"github.com/google/uuid"
 
 	"github.com/gofiber/fiber/v2"
 )
 
+// statisticsErrorResponse maps service errors to HTTP responses
+func statisticsErrorResponse(c *fiber.Ctx, err error) error {
+	if errors.Is(err, services.ErrInvalidDateRange) || errors.Is(err, services.ErrInvalidCurrency) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	if errors.Is(err, services.ErrMissingExchangeRates) {
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+}
+
+// parseStatisticsFilter reads the type, category_id, currency and rollup query parameters
+func parseStatisticsFilter(c *fiber.Ctx) (repositories.CategoryTotalsFilter, error) {
+	filter := repositories.CategoryTotalsFilter{CategoryType: c.Query("type")}
+	if filter.CategoryType != "" && filter.CategoryType != models.CategoryTypeIncome && filter.CategoryType != models.CategoryTypeExpense {
+		return filter, errors.New("type must be income or expense")
+	}
+	if value := c.Query("category_id"); value != "" {
+		categoryID, err := uuid.Parse(value)
+		if err != nil {
+			return filter, errors.New("Invalid category ID")
+		}
+		filter.CategoryID = &categoryID
+	}
+	currency, err := services.NormalizeCurrency(c.Query("currency"))
+	if err != nil {
+		return filter, err
+	}
+	filter.Currency = currency
+	if value := c.Query("rollup"); value != "" {
+		rollup, err := strconv.ParseBool(value)
+		if err != nil {
+			return filter, errors.New("rollup must be true or false")
+		}
+		filter.Rollup = rollup
+	}
+	return filter, nil
+}
+
 // GetStatisticsByCategory godoc
 // @Summary      Get statistics by category
-// @Description  Returns income and expense statistics by category and date range
+// @Description  Returns the total, the number of transactions, the average and the share of every category
+// @Description  in the date range, split into incomes and expenses, with the overall income, expense and net balance.
+// @Description  Plain dates are taken in the time zone of the user. The range defaults to the current month.
 // @Tags         statistics
 // @Produce      json
 // @Param Authorization header string true "Insert your access token" default(Bearer <Add access token here>)
-// @Param        start_date   query     string false  "Start Date (YYYY-MM-DD)"
-// @Param        end_date     query     string false  "End Date (YYYY-MM-DD)"
-// @Success      200          {array}   model.Transaction
-// @Failure      400          {object}  interface{} // This tells Swagger to expect any object as a response
-// @Failure      500          {object}  interface{}
+// @Param        start_date   query     string false  "Start Date (YYYY-MM-DD or RFC3339)"
+// @Param        end_date     query     string false  "End Date (YYYY-MM-DD or RFC3339)"
+// @Param        type         query     string false  "Category type: income or expense"
+// @Param        currency     query     string false  "Currency of the amounts, the currency of the user by default"
+// @Param        category_id  query     string false  "Only this category"
+// @Param        rollup       query     bool   false  "Include subcategories in their parents"
+// @Success      200          {object}  services.CategoryStatisticsReport
+// @Failure      400          {object}  map[string]string
+// @Failure      422          {object}  map[string]string
+// @Failure      500          {object}  map[string]string
 // @Router       /api/statistics/category [get]
 func GetStatisticsByCategory(c *fiber.Ctx) error {
 	userID, ok := c.Locals("ID").(uuid.UUID)
@@ -28,14 +78,205 @@ func GetStatisticsByCategory(c *fiber.Ctx) error {
 			"error": "User ID not found in context",
 		})
 	}
-	startDate := c.Query("start_date")
-	endDate := c.Query("end_date")
 
-	fmt.Print(endDate)
-	stats, err := services.GetStatistics(userID, startDate, endDate)
+	location, err := services.UserLocation(userID)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+		return statisticsErrorResponse(c, err)
+	}
+
+	start, end, err := services.ParseDateRange(c.Query("start_date"), c.Query("end_date"), location, time.Now())
+	if err != nil {
+		return statisticsErrorResponse(c, err)
+	}
+
+	filter, err := parseStatisticsFilter(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	stats, err := services.GetCategoryStatistics(userID, start, end, filter)
+	if err != nil {
+		return statisticsErrorResponse(c, err)
 	}
 
 	return c.Status(fiber.StatusOK).JSON(stats)
 }
+
+// GetStatisticsTimeSeries godoc
+// @Summary      Get statistics over time
+// @Description  Groups income and expense totals into calendar buckets of the user's time zone.
+// @Description  Weeks start on the first day of week from the user settings. Buckets without
+// @Description  transactions are returned with zero totals. The range defaults to the current month.
+// @Tags         statistics
+// @Produce      json
+// @Param Authorization header string true "Insert your access token" default(Bearer <Add access token here>)
+// @Param        bucket       query     string false  "Bucket size: day, week, month or year" default(day)
+// @Param        start_date   query     string false  "Start Date (YYYY-MM-DD or RFC3339)"
+// @Param        end_date     query     string false  "End Date (YYYY-MM-DD or RFC3339)"
+// @Param        type         query     string false  "Category type: income or expense"
+// @Param        currency     query     string false  "Currency of the amounts, the currency of the user by default"
+// @Param        category_id  query     string false  "Only this category"
+// @Param        rollup       query     bool   false  "Include subcategories in their parents"
+// @Success      200          {object}  services.TimeSeries
+// @Failure      400          {object}  map[string]string
+// @Failure      422          {object}  map[string]string
+// @Failure      500          {object}  map[string]string
+// @Router       /api/statistics/timeseries [get]
+func GetStatisticsTimeSeries(c *fiber.Ctx) error {
+	userID, ok := c.Locals("ID").(uuid.UUID)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User ID not found in context",
+		})
+	}
+
+	location, err := services.UserLocation(userID)
+	if err != nil {
+		return statisticsErrorResponse(c, err)
+	}
+
+	start, end, err := services.ParseDateRange(c.Query("start_date"), c.Query("end_date"), location, time.Now())
+	if err != nil {
+		return statisticsErrorResponse(c, err)
+	}
+
+	filter, err := parseStatisticsFilter(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	bucket := services.StatisticsRange(c.Query("bucket", string(services.RangeDay)))
+	series, err := services.GetStatisticsTimeSeries(userID, bucket, start, end, filter)
+	if err != nil {
+		return statisticsErrorResponse(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(series)
+}
+
+// CompareStatistics godoc
+// @Summary      Compare two periods
+// @Description  Compares the category totals of the range from start_date to end_date with the period of the
+// @Description  same length right before it, or with compare_start_date to compare_end_date when both are given.
+// @Description  Returns the change of every category, new and disappeared categories and the largest movers.
+// @Description  Plain dates are taken in the time zone of the user. The range defaults to the current month.
+// @Tags         statistics
+// @Produce      json
+// @Param Authorization header string true "Insert your access token" default(Bearer <Add access token here>)
+// @Param        start_date          query     string false  "Start Date (YYYY-MM-DD or RFC3339)"
+// @Param        end_date            query     string false  "End Date (YYYY-MM-DD or RFC3339)"
+// @Param        compare_start_date  query     string false  "Start Date of the period to compare with"
+// @Param        compare_end_date    query     string false  "End Date of the period to compare with"
+// @Param        type                query     string false  "Category type: income or expense"
+// @Param        currency            query     string false  "Currency of the amounts, the currency of the user by default"
+// @Param        category_id         query     string false  "Only this category"
+// @Param        rollup              query     bool   false  "Include subcategories in their parents"
+// @Param        limit               query     int    false  "Number of the largest movers" default(5)
+// @Success      200                 {object}  services.ComparisonReport
+// @Failure      400                 {object}  map[string]string
+// @Failure      422                 {object}  map[string]string
+// @Failure      500                 {object}  map[string]string
+// @Router       /api/statistics/compare [get]
+func CompareStatistics(c *fiber.Ctx) error {
+	userID, ok := c.Locals("ID").(uuid.UUID)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User ID not found in context",
+		})
+	}
+
+	location, err := services.UserLocation(userID)
+	if err != nil {
+		return statisticsErrorResponse(c, err)
+	}
+
+	now := time.Now()
+	start, end, err := services.ParseDateRange(c.Query("start_date"), c.Query("end_date"), location, now)
+	if err != nil {
+		return statisticsErrorResponse(c, err)
+	}
+	current := services.ComparisonPeriod{StartDate: start, EndDate: end}
+
+	var previous services.ComparisonPeriod
+	compareStart, compareEnd := c.Query("compare_start_date"), c.Query("compare_end_date")
+	switch {
+	case compareStart != "" && compareEnd != "":
+		previous.StartDate, previous.EndDate, err = services.ParseDateRange(compareStart, compareEnd, location, now)
+		if err != nil {
+			return statisticsErrorResponse(c, err)
+		}
+	case compareStart != "" || compareEnd != "":
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "compare_start_date and compare_end_date must be given together",
+		})
+	default:
+		previous.StartDate, previous.EndDate = services.PreviousPeriod(start, end)
+	}
+
+	filter, err := parseStatisticsFilter(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	limit := c.QueryInt("limit", 5)
+	if limit < 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "limit must not be negative"})
+	}
+
+	report, err := services.CompareStatistics(userID, current, previous, filter, limit)
+	if err != nil {
+		return statisticsErrorResponse(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(report)
+}
+
+// GetBalance godoc
+// @Summary      Get balance
+// @Description  Returns the current balance of the user, the opening balance right before start_date,
+// @Description  the closing balance at end_date and the running balance at the end of every bucket in between.
+// @Description  Incomes add to the balance and expenses subtract from it. Plain dates are taken in the time
+// @Description  zone of the user. The range defaults to the current month.
+// @Tags         statistics
+// @Produce      json
+// @Param Authorization header string true "Insert your access token" default(Bearer <Add access token here>)
+// @Param        bucket       query     string false  "Bucket size: day, week, month or year" default(day)
+// @Param        start_date   query     string false  "Start Date (YYYY-MM-DD or RFC3339)"
+// @Param        end_date     query     string false  "End Date (YYYY-MM-DD or RFC3339)"
+// @Param        currency     query     string false  "Currency of the amounts, the currency of the user by default"
+// @Success      200          {object}  services.BalanceReport
+// @Failure      400          {object}  map[string]string
+// @Failure      422          {object}  map[string]string
+// @Failure      500          {object}  map[string]string
+// @Router       /api/statistics/balance [get]
+func GetBalance(c *fiber.Ctx) error {
+	userID, ok := c.Locals("ID").(uuid.UUID)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User ID not found in context",
+		})
+	}
+
+	location, err := services.UserLocation(userID)
+	if err != nil {
+		return statisticsErrorResponse(c, err)
+	}
+
+	start, end, err := services.ParseDateRange(c.Query("start_date"), c.Query("end_date"), location, time.Now())
+	if err != nil {
+		return statisticsErrorResponse(c, err)
+	}
+
+	currency, err := services.NormalizeCurrency(c.Query("currency"))
+	if err != nil {
+		return statisticsErrorResponse(c, err)
+	}
+
+	bucket := services.StatisticsRange(c.Query("bucket", string(services.RangeDay)))
+	report, err := services.GetBalanceReport(userID, bucket, start, end, currency)
+	if err != nil {
+		return statisticsErrorResponse(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(report)
+}
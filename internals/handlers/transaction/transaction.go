@@ -1,30 +1,46 @@
 package handlers
 
 import (
+	"errors"
 	"fmt"
-	"time"
+	"strconv"
+	"strings"
 
-	"github.com/KashyretsIvanna/voice-balance/database"
 	models "github.com/KashyretsIvanna/voice-balance/internals/model"
 	services "github.com/KashyretsIvanna/voice-balance/internals/services"
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
+	"gorm.io/gorm"
 )
 
+// transactionErrorResponse maps service errors to HTTP responses
+func transactionErrorResponse(c *fiber.Ctx, err error) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Transaction not found"})
+	}
+	if errors.Is(err, services.ErrInvalidTransaction) || errors.Is(err, services.ErrInvalidCurrency) ||
+		errors.Is(err, services.ErrInvalidAmount) || errors.Is(err, services.ErrInvalidDateRange) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+}
+
 // AddTransaction godoc
 // @Summary      Add a new transaction
-// @Description  Adds an income or expense transaction by category
+// @Description  Adds an income or expense transaction by category. budget_warning is set when the
+// @Description  transaction pushes a budget of its category over its threshold or over its limit.
+// @Description  Without a category_id the category of the first category rule the transaction matches is taken,
+// @Description  category_rule_id names that rule.
 // @Param Authorization header string true "Insert your access token" default(Bearer <Add access token here>)
 // @Tags         transactions
 // @Accept       json
 // @Produce      json
 // @Param        transaction  body      models.Transaction  true  "Transaction Data"
-// @Success      200          {object}  models.Transaction
+// @Success      200          {object}  services.CreatedTransaction
 // @Failure      400          {object}  interface{} // This tells Swagger to expect any object as a response
 // @Failure      500          {object}  interface{}
 // @Router       /api/transaction [post]
 func AddTransaction(c *fiber.Ctx) error {
-	transaction := new(models.Transaction)
 	userID, ok := c.Locals("ID").(uuid.UUID)
 	if !ok {
 		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
@@ -32,104 +48,360 @@ func AddTransaction(c *fiber.Ctx) error {
 		})
 	}
 
-	// userIDStr := "74c508d6-3b65-4583-a962-95a06ff2eb5b" // Example UUID as string
-	// userID, err := uuid.Parse(userIDStr)
-	// if err != nil {
-	// 	return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-	// 		"error": "Invalid user ID",
-	// 	})
-	// }
-	transaction.UserID = userID
-
+	transaction := new(models.Transaction)
 	if err := c.BodyParser(transaction); err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
 	}
 
-	if err := services.CreateTransaction(transaction); err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	// The owner and the bookkeeping fields always come from the server
+	transaction.ID = uuid.Nil
+	transaction.UserID = userID
+	transaction.Category = models.Category{}
+	transaction.RecurringID = nil
+	transaction.OccurrenceDate = nil
+	transaction.DeletedAt = nil
+
+	created, err := services.CreateTransaction(transaction)
+	if err != nil {
+		return transactionErrorResponse(c, err)
 	}
 
-	return c.Status(fiber.StatusOK).JSON(transaction)
+	return c.Status(fiber.StatusOK).JSON(created)
 }
 
 // GetTransactions godoc
-// @Summary      Get transactions grouped by category
-// @Description  Retrieve transactions by category and date range
+// @Summary      List transactions
+// @Description  Returns a page of the transactions of the authenticated user, newest first by default.
+// @Description  The next page is requested with the next_cursor of the previous one and the same sort and order.
+// @Description  Plain dates are taken in the time zone of the user, amounts are compared in the currency of each transaction.
 // @Param Authorization header string true "Insert your access token" default(Bearer <Add access token here>)
 // @Tags         transactions
 // @Accept       json
 // @Produce      json
-// @Param        categoryId query      string  false "Category ID"
-// @Param        startDate  query      string  false "Start Date in YYYY-MM-DD format"
-// @Param        endDate    query      string  false "End Date in YYYY-MM-DD format"
-// @Success      200        {array}   models.TransactionGroupedByCategory
-// @Failure      400        {object}  interface{} // This tells Swagger to expect any object as a response
-// @Failure      500        {object}  interface{}
+// @Param        categoryId query      []string false "Category IDs, repeated or separated by commas" collectionFormat(multi)
+// @Param        type       query      string  false "Category type: income or expense"
+// @Param        startDate  query      string  false "Start Date (YYYY-MM-DD or RFC3339)"
+// @Param        endDate    query      string  false "End Date (YYYY-MM-DD or RFC3339)"
+// @Param        minAmount  query      number  false "Smallest amount"
+// @Param        maxAmount  query      number  false "Largest amount"
+// @Param        search     query      string  false "Text the description contains"
+// @Param        sort       query      string  false "Sort by date or amount" Enums(date, amount) default(date)
+// @Param        order      query      string  false "Sort order" Enums(asc, desc) default(desc)
+// @Param        limit      query      int     false "Page size, at most 200" default(50)
+// @Param        cursor     query      string  false "next_cursor of the previous page"
+// @Param        groupBy    query      string  false "Group the page by category or day" Enums(category, day)
+// @Param        deleted    query      bool    false "List the deleted transactions instead, to restore them"
+// @Success      200        {object}  services.TransactionPage
+// @Failure      400        {object}  map[string]string
+// @Failure      500        {object}  map[string]string
 // @Router       /api/transaction [get]
 func GetTransactions(c *fiber.Ctx) error {
-	db := database.DB
 	userID, ok := c.Locals("ID").(uuid.UUID)
 	if !ok {
 		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
 			"error": "User ID not found in context",
 		})
 	}
-	// userIDStr := "74c508d6-3b65-4583-a962-95a06ff2eb5b" // Example UUID as string
-	// userID, err := uuid.Parse(userIDStr)
-	// if err != nil {
-	// 	return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-	// 		"error": "Invalid user ID",
-	// 	})
-	// }
 
-	categoryID := c.Query("categoryId") // Get the category ID from the query params
-	startDate := c.Query("startDate")   // Get the start date from the query params
-	endDate := c.Query("endDate")       // Get the end date from the query params
+	query, err := parseTransactionQuery(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
 
-	var transactions []models.Transaction
+	page, err := services.ListTransactions(userID, query)
+	if err != nil {
+		return transactionErrorResponse(c, err)
+	}
 
-	// Build the query
-	query := db.Where("user_id = ?", userID)
+	return c.JSON(fiber.Map{
+		"status":  "success",
+		"message": "Transactions retrieved",
+		"data":    page,
+	})
+}
 
-	if categoryID != "" {
-		query = query.Where("category_id = ?", categoryID)
+// parseTransactionQuery reads the filters, the sort order and the page of GetTransactions
+func parseTransactionQuery(c *fiber.Ctx) (services.TransactionQuery, error) {
+	query := services.TransactionQuery{
+		CategoryType: c.Query("type"),
+		StartDate:    c.Query("startDate"),
+		EndDate:      c.Query("endDate"),
+		Search:       c.Query("search"),
+		Sort:         c.Query("sort"),
+		Cursor:       c.Query("cursor"),
+		GroupBy:      c.Query("groupBy"),
 	}
 
-	if startDate != "" {
-		start, err := time.Parse("2006-01-02", startDate)
-		if err != nil {
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-				"error": fmt.Sprintf("Invalid start date format: %s", err.Error()),
-			})
+	for _, value := range c.Context().QueryArgs().PeekMulti("categoryId") {
+		for _, part := range strings.Split(string(value), ",") {
+			if part = strings.TrimSpace(part); part == "" {
+				continue
+			}
+			categoryID, err := uuid.Parse(part)
+			if err != nil {
+				return query, fmt.Errorf("Invalid category ID %q", part)
+			}
+			query.CategoryIDs = append(query.CategoryIDs, categoryID)
+		}
+	}
+
+	bounds := []struct {
+		name   string
+		amount **models.Money
+	}{{"minAmount", &query.MinAmount}, {"maxAmount", &query.MaxAmount}}
+	for _, bound := range bounds {
+		if value := c.Query(bound.name); value != "" {
+			parsed, err := models.ParseMoney(value)
+			if err != nil {
+				return query, fmt.Errorf("%s: %w", bound.name, err)
+			}
+			*bound.amount = &parsed
+		}
+	}
+
+	switch c.Query("order") {
+	case "", "desc":
+	case "asc":
+		query.Ascending = true
+	default:
+		return query, errors.New("order must be asc or desc")
+	}
+
+	if value := c.Query("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit < 1 {
+			return query, fmt.Errorf("limit must be a number between 1 and %d", services.MaxTransactionPageSize)
 		}
-		query = query.Where("date >= ?", start)
+		query.Limit = limit
 	}
 
-	if endDate != "" {
-		end, err := time.Parse("2006-01-02", endDate)
+	if value := c.Query("deleted"); value != "" {
+		deleted, err := strconv.ParseBool(value)
 		if err != nil {
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-				"error": fmt.Sprintf("Invalid end date format: %s", err.Error()),
-			})
+			return query, errors.New("deleted must be true or false")
 		}
-		query = query.Where("date <= ?", end)
+		query.Deleted = deleted
+	}
+	return query, nil
+}
+
+// GetTransaction godoc
+// @Summary      Get a transaction
+// @Description  Returns a transaction of the authenticated user with its category
+// @Param Authorization header string true "Insert your access token" default(Bearer <Add access token here>)
+// @Tags         transactions
+// @Produce      json
+// @Param        id   path      string  true  "Transaction ID"
+// @Success      200  {object}  model.Transaction
+// @Failure      400  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Router       /api/transaction/{id} [get]
+func GetTransaction(c *fiber.Ctx) error {
+	userID, ok := c.Locals("ID").(uuid.UUID)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User ID not found in context",
+		})
 	}
 
-	// Execute the query
-	if err := query.Preload("Category").Find(&transactions).Error; err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	transactionID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid transaction ID"})
+	}
+
+	transaction, err := services.GetTransaction(userID, transactionID)
+	if err != nil {
+		return transactionErrorResponse(c, err)
+	}
+
+	return c.JSON(transaction)
+}
+
+// UpdateTransaction godoc
+// @Summary      Update a transaction
+// @Description  Changes the category, amount, currency, description or date of a transaction,
+// @Description  for example to fix a misheard voice command
+// @Param Authorization header string true "Insert your access token" default(Bearer <Add access token here>)
+// @Tags         transactions
+// @Accept       json
+// @Produce      json
+// @Param        id           path      string                      true  "Transaction ID"
+// @Param        transaction  body      services.TransactionUpdate  true  "Fields to update"
+// @Success      200          {object}  model.Transaction
+// @Failure      400          {object}  map[string]string
+// @Failure      404          {object}  map[string]string
+// @Router       /api/transaction/{id} [patch]
+func UpdateTransaction(c *fiber.Ctx) error {
+	userID, ok := c.Locals("ID").(uuid.UUID)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User ID not found in context",
+		})
+	}
+
+	transactionID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid transaction ID"})
+	}
+
+	var update services.TransactionUpdate
+	if err := c.BodyParser(&update); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	transaction, err := services.UpdateTransaction(userID, transactionID, update)
+	if err != nil {
+		return transactionErrorResponse(c, err)
+	}
+
+	return c.JSON(transaction)
+}
+
+// DeleteTransaction godoc
+// @Summary      Delete a transaction
+// @Description  Soft deletes a transaction of the authenticated user, it can be restored
+// @Param Authorization header string true "Insert your access token" default(Bearer <Add access token here>)
+// @Tags         transactions
+// @Produce      json
+// @Param        id   path      string  true  "Transaction ID"
+// @Success      200  {object}  map[string]string
+// @Failure      400  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Router       /api/transaction/{id} [delete]
+func DeleteTransaction(c *fiber.Ctx) error {
+	userID, ok := c.Locals("ID").(uuid.UUID)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User ID not found in context",
+		})
+	}
+
+	transactionID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid transaction ID"})
+	}
+
+	if err := services.DeleteTransaction(userID, transactionID); err != nil {
+		return transactionErrorResponse(c, err)
+	}
+
+	return c.JSON(fiber.Map{"status": "success", "message": "Transaction deleted"})
+}
+
+// RestoreTransaction godoc
+// @Summary      Restore a transaction
+// @Description  Brings back a deleted transaction of the authenticated user. When its category was deleted
+// @Description  meanwhile, category_id names the category it is restored into.
+// @Param Authorization header string true "Insert your access token" default(Bearer <Add access token here>)
+// @Tags         transactions
+// @Accept       json
+// @Produce      json
+// @Param        id       path      string                       true   "Transaction ID"
+// @Param        restore  body      services.TransactionRestore  false  "Category to restore the transaction into"
+// @Success      200      {object}  model.Transaction
+// @Failure      400      {object}  map[string]string
+// @Failure      404      {object}  map[string]string
+// @Router       /api/transaction/{id}/restore [post]
+func RestoreTransaction(c *fiber.Ctx) error {
+	userID, ok := c.Locals("ID").(uuid.UUID)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User ID not found in context",
+		})
+	}
+
+	transactionID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid transaction ID"})
+	}
+
+	var restore services.TransactionRestore
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&restore); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+	}
+
+	transaction, err := services.RestoreTransaction(userID, transactionID, restore)
+	if err != nil {
+		return transactionErrorResponse(c, err)
+	}
+
+	return c.JSON(transaction)
+}
+
+// BulkDeleteTransactions godoc
+// @Summary      Delete many transactions
+// @Description  Soft deletes up to 500 transactions of the authenticated user at once. Nothing is deleted
+// @Description  when one of them is not found.
+// @Param Authorization header string true "Insert your access token" default(Bearer <Add access token here>)
+// @Tags         transactions
+// @Accept       json
+// @Produce      json
+// @Param        bulk  body      services.TransactionBulkDelete  true  "Transactions to delete"
+// @Success      200   {object}  map[string]interface{}
+// @Failure      400   {object}  map[string]string
+// @Failure      404   {object}  map[string]string
+// @Router       /api/transaction/bulk/delete [post]
+func BulkDeleteTransactions(c *fiber.Ctx) error {
+	userID, ok := c.Locals("ID").(uuid.UUID)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User ID not found in context",
+		})
+	}
+
+	var bulk services.TransactionBulkDelete
+	if err := c.BodyParser(&bulk); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	deleted, err := services.BulkDeleteTransactions(userID, bulk)
+	if err != nil {
+		return transactionErrorResponse(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"status":  "success",
+		"message": "Transactions deleted",
+		"data":    fiber.Map{"count": deleted},
+	})
+}
+
+// BulkRecategorizeTransactions godoc
+// @Summary      Move many transactions into a category
+// @Description  Changes the category of up to 500 transactions of the authenticated user at once. Nothing is
+// @Description  changed when one of them is not found.
+// @Param Authorization header string true "Insert your access token" default(Bearer <Add access token here>)
+// @Tags         transactions
+// @Accept       json
+// @Produce      json
+// @Param        bulk  body      services.TransactionBulkRecategorize  true  "Transactions and their new category"
+// @Success      200   {object}  map[string]interface{}
+// @Failure      400   {object}  map[string]string
+// @Failure      404   {object}  map[string]string
+// @Router       /api/transaction/bulk/recategorize [post]
+func BulkRecategorizeTransactions(c *fiber.Ctx) error {
+	userID, ok := c.Locals("ID").(uuid.UUID)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User ID not found in context",
+		})
+	}
+
+	var bulk services.TransactionBulkRecategorize
+	if err := c.BodyParser(&bulk); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
 	}
 
-	// Group transactions by category
-	groupedTransactions := make(map[uuid.UUID][]models.Transaction)
-	for _, transaction := range transactions {
-		groupedTransactions[transaction.CategoryID] = append(groupedTransactions[transaction.CategoryID], transaction)
+	updated, err := services.BulkRecategorizeTransactions(userID, bulk)
+	if err != nil {
+		return transactionErrorResponse(c, err)
 	}
 
-	// Return the grouped transactions
 	return c.JSON(fiber.Map{
-		"status":      "success",
-		"message":     "Transactions retrieved",
-		"groupedData": groupedTransactions,
+		"status":  "success",
+		"message": "Transactions recategorized",
+		"data":    fiber.Map{"count": updated},
 	})
 }
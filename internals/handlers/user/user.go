@@ -1,8 +1,11 @@
 package userHandler
 
 import (
+	"errors"
+
 	"github.com/KashyretsIvanna/voice-balance/database"
 	"github.com/KashyretsIvanna/voice-balance/internals/model"
+	"github.com/KashyretsIvanna/voice-balance/internals/services"
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
 	"gorm.io/gorm"
@@ -166,3 +169,72 @@ func DeleteUser(c *fiber.Ctx) error {
 	// Return success message
 	return c.JSON(fiber.Map{"status": "success", "message": "User Deleted"})
 }
+
+// GetSettings returns the settings of the authenticated user
+// @Description Get the settings of the authenticated user, e.g. the language of voice commands
+// @Param Authorization header string true "Insert your access token" default(Bearer <Add access token here>)
+// @Tags user
+// @Produce json
+// @Success 200 {object} services.UserSettings
+// @Failure 404 {object} map[string]string
+// @Router /api/user/me/settings [get]
+func GetSettings(c *fiber.Ctx) error {
+	userID, ok := c.Locals("ID").(uuid.UUID)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User ID not found in context",
+		})
+	}
+
+	settings, err := services.GetUserSettings(userID)
+	if err != nil {
+		return settingsErrorResponse(c, err)
+	}
+
+	return c.JSON(fiber.Map{"status": "success", "message": "Settings found", "data": settings})
+}
+
+// UpdateSettings changes the settings of the authenticated user
+// @Description Update the settings of the authenticated user. Supported languages are uk, en and ru.
+// @Description The currency is the ISO 4217 code new transactions default to and statistics are reported in.
+// @Param Authorization header string true "Insert your access token" default(Bearer <Add access token here>)
+// @Tags user
+// @Accept json
+// @Produce json
+// @Param settings body services.UserSettingsUpdate true "Settings to change"
+// @Success 200 {object} services.UserSettings
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /api/user/me/settings [patch]
+func UpdateSettings(c *fiber.Ctx) error {
+	userID, ok := c.Locals("ID").(uuid.UUID)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User ID not found in context",
+		})
+	}
+
+	var update services.UserSettingsUpdate
+	if err := c.BodyParser(&update); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid input"})
+	}
+
+	settings, err := services.UpdateUserSettings(userID, update)
+	if err != nil {
+		return settingsErrorResponse(c, err)
+	}
+
+	return c.JSON(fiber.Map{"status": "success", "message": "Settings updated", "data": settings})
+}
+
+// settingsErrorResponse maps errors of the settings service to HTTP responses
+func settingsErrorResponse(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "User not found"})
+	case errors.Is(err, services.ErrInvalidSettings):
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	default:
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+}
@@ -0,0 +1,341 @@
+package handlers
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+
+	models "github.com/KashyretsIvanna/voice-balance/internals/model"
+	"github.com/KashyretsIvanna/voice-balance/internals/services"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// voiceStreamFromParams returns the stream addressed by the :id parameter
+func voiceStreamFromParams(c *fiber.Ctx) (*services.VoiceStream, error) {
+	userID, ok := c.Locals("ID").(uuid.UUID)
+	if !ok {
+		return nil, c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User ID not found in context",
+		})
+	}
+
+	streamID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return nil, c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid stream ID"})
+	}
+
+	stream, err := services.GetVoiceStream(userID, streamID)
+	if err != nil {
+		return nil, c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+	}
+	return stream, nil
+}
+
+// StartVoiceStream godoc
+// @Summary      Start a streaming recognition
+// @Description  Opens a stream the client uploads audio to in chunks while it is recorded.
+// @Description  Without codec the format is detected from the header of the first chunk.
+// @Param Authorization header string true "Insert your access token" default(Bearer <Add access token here>)
+// @Tags         transcription
+// @Produce      json
+// @Param        codec        query     string  false  "Audio codec: pcm_s16le, mulaw, flac, opus, amr, amr_wb"
+// @Param        container    query     string  false  "Container of Opus audio: ogg or webm"
+// @Param        sample_rate  query     int     false  "Sample rate in Hz"
+// @Param        channels     query     int     false  "Number of channels"
+// @Param        lang         query     string  false  "Language of the command: uk, en or ru. Defaults to the user settings"
+// @Success      201          {object}  services.VoiceStreamState
+// @Failure      400          {object}  map[string]string
+// @Failure      415          {object}  map[string]string
+// @Router       /api/voice/stream [post]
+func StartVoiceStream(c *fiber.Ctx) error {
+	userID, ok := c.Locals("ID").(uuid.UUID)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User ID not found in context",
+		})
+	}
+
+	language, err := resolveLanguageParam(c, userID)
+	if err != nil {
+		return languageErrorResponse(c, err)
+	}
+
+	var format *services.AudioFormat
+	if codec := c.Query("codec"); codec != "" {
+		sampleRate, err := strconv.Atoi(c.Query("sample_rate", "16000"))
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "sample_rate must be a number"})
+		}
+		channels, err := strconv.Atoi(c.Query("channels", "1"))
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "channels must be a number"})
+		}
+		format = &services.AudioFormat{
+			Container:       c.Query("container"),
+			Codec:           codec,
+			SampleRateHertz: int32(sampleRate),
+			Channels:        int32(channels),
+		}
+	}
+
+	stream, err := services.StartVoiceStream(userID, format, language)
+	if err != nil {
+		return transcriptionErrorResponse(c, err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(stream.State())
+}
+
+// SendVoiceStreamChunk godoc
+// @Summary      Upload a chunk of a streamed recording
+// @Description  Appends the raw request body to the stream and returns the transcript recognized so far
+// @Param Authorization header string true "Insert your access token" default(Bearer <Add access token here>)
+// @Tags         transcription
+// @Accept       application/octet-stream
+// @Produce      json
+// @Param        id   path      string  true  "Stream ID"
+// @Success      200  {object}  services.VoiceStreamState
+// @Failure      404  {object}  map[string]string
+// @Failure      415  {object}  map[string]string
+// @Router       /api/voice/stream/{id}/chunks [post]
+func SendVoiceStreamChunk(c *fiber.Ctx) error {
+	stream, err := voiceStreamFromParams(c)
+	if stream == nil {
+		return err
+	}
+
+	if len(c.Body()) == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "The chunk is empty"})
+	}
+
+	// The request body buffer is reused by fiber, the recognizer gets a copy
+	chunk := append([]byte(nil), c.Body()...)
+	if err := stream.Send(chunk); err != nil {
+		return transcriptionErrorResponse(c, err)
+	}
+
+	return c.JSON(stream.State())
+}
+
+// GetVoiceStream godoc
+// @Summary      Get the transcript of a stream
+// @Description  Returns the final and the interim transcript recognized so far
+// @Param Authorization header string true "Insert your access token" default(Bearer <Add access token here>)
+// @Tags         transcription
+// @Produce      json
+// @Param        id   path      string  true  "Stream ID"
+// @Success      200  {object}  services.VoiceStreamState
+// @Failure      404  {object}  map[string]string
+// @Router       /api/voice/stream/{id} [get]
+func GetVoiceStream(c *fiber.Ctx) error {
+	stream, err := voiceStreamFromParams(c)
+	if stream == nil {
+		return err
+	}
+
+	return c.JSON(stream.State())
+}
+
+// VoiceStreamEvents godoc
+// @Summary      Receive transcripts of a stream
+// @Description  Server-sent events with a services.TranscriptResult per recognized piece of speech.
+// @Description  A "done" event is sent when the recognition is over.
+// @Param Authorization header string true "Insert your access token" default(Bearer <Add access token here>)
+// @Tags         transcription
+// @Produce      text/event-stream
+// @Param        id   path      string  true  "Stream ID"
+// @Success      200  {object}  services.TranscriptResult
+// @Failure      404  {object}  map[string]string
+// @Router       /api/voice/stream/{id}/events [get]
+func VoiceStreamEvents(c *fiber.Ctx) error {
+	stream, err := voiceStreamFromParams(c)
+	if stream == nil {
+		return err
+	}
+
+	results, unsubscribe := stream.Subscribe()
+
+	c.Set("Content-Type", "text/event-stream")
+	c.Set("Cache-Control", "no-cache")
+	c.Set("Connection", "keep-alive")
+
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		defer unsubscribe()
+
+		for result := range results {
+			data, err := json.Marshal(result)
+			if err != nil {
+				return
+			}
+			fmt.Fprintf(w, "data: %s\n\n", data)
+			if err := w.Flush(); err != nil {
+				// The client went away
+				return
+			}
+		}
+
+		fmt.Fprint(w, "event: done\ndata: {}\n\n")
+		w.Flush()
+	})
+	return nil
+}
+
+// FinishVoiceStream godoc
+// @Summary      Finish a streamed recording
+// @Description  Closes the stream, waits for the final transcript and interprets it like /api/voice
+// @Param Authorization header string true "Insert your access token" default(Bearer <Add access token here>)
+// @Tags         transcription
+// @Produce      json
+// @Param        id       path      string  true   "Stream ID"
+// @Param        execute  query     bool    false  "Execute the interpreted action"
+// @Success      200      {object}  map[string]interface{}
+// @Failure      404      {object}  map[string]string
+// @Failure      422      {object}  services.VoiceActionValidationError
+// @Failure      500      {object}  map[string]string
+// @Router       /api/voice/stream/{id}/finish [post]
+func FinishVoiceStream(c *fiber.Ctx) error {
+	stream, err := voiceStreamFromParams(c)
+	if stream == nil {
+		return err
+	}
+
+	execute, err := parseExecuteParam(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "execute must be true or false",
+		})
+	}
+
+	transcription, err := stream.Finish(c.Context())
+	if err != nil {
+		if errors.Is(err, services.ErrIncompleteVoiceAction) {
+			return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{"error": err.Error()})
+		}
+		return transcriptionErrorResponse(c, err)
+	}
+
+	return voiceCommandResponse(c, transcription, stream.Language(), execute)
+}
+
+// voiceJobResponse adds the interpreted action and the execution result to a job
+func voiceJobResponse(job *models.VoiceJob) fiber.Map {
+	response := fiber.Map{
+		"id":         job.ID,
+		"status":     job.Status,
+		"execute":    job.Execute,
+		"language":   job.Language,
+		"transcript": job.Transcript,
+		"created_at": job.CreatedAt,
+		"updated_at": job.UpdatedAt,
+	}
+	if job.Action != "" {
+		response["action"] = json.RawMessage(job.Action)
+	}
+	if job.Result != "" {
+		response["result"] = json.RawMessage(job.Result)
+	}
+	if job.Error != "" {
+		response["error"] = job.Error
+	}
+	return response
+}
+
+// CreateVoiceJob godoc
+// @Summary      Transcribe a long recording in the background
+// @Description  Accepts recordings longer than a minute and returns a job to poll with GET /api/voice/jobs/{id}
+// @Param Authorization header string true "Insert your access token" default(Bearer <Add access token here>)
+// @Tags         transcription
+// @Accept       multipart/form-data
+// @Produce      json
+// @Param        file     formData  file    true   "Audio file to transcribe"
+// @Param        execute  query     bool    false  "Execute the interpreted action"
+// @Param        lang     query     string  false  "Language of the command: uk, en or ru. Defaults to the user settings"
+// @Success      202      {object}  models.VoiceJob
+// @Failure      400      {object}  map[string]string
+// @Failure      500      {object}  map[string]string
+// @Router       /api/voice/jobs [post]
+func CreateVoiceJob(c *fiber.Ctx) error {
+	userID, ok := c.Locals("ID").(uuid.UUID)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User ID not found in context",
+		})
+	}
+
+	file, err := c.FormFile("file")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Failed to get file: Please upload a valid file.",
+		})
+	}
+
+	execute, err := parseExecuteParam(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "execute must be true or false",
+		})
+	}
+
+	language, err := resolveLanguageParam(c, userID)
+	if err != nil {
+		return languageErrorResponse(c, err)
+	}
+
+	src, err := file.Open()
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	defer src.Close()
+
+	audio, err := io.ReadAll(src)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	job, err := services.CreateVoiceJob(userID, audio, language, execute)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.Status(fiber.StatusAccepted).JSON(voiceJobResponse(job))
+}
+
+// GetVoiceJob godoc
+// @Summary      Get a background transcription job
+// @Description  Returns the status of the job and, once it is done, the transcript, the action and the result
+// @Param Authorization header string true "Insert your access token" default(Bearer <Add access token here>)
+// @Tags         transcription
+// @Produce      json
+// @Param        id   path      string  true  "Job ID"
+// @Success      200  {object}  models.VoiceJob
+// @Failure      400  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Router       /api/voice/jobs/{id} [get]
+func GetVoiceJob(c *fiber.Ctx) error {
+	userID, ok := c.Locals("ID").(uuid.UUID)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User ID not found in context",
+		})
+	}
+
+	jobID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid job ID"})
+	}
+
+	job, err := services.GetVoiceJob(userID, jobID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Job not found"})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.JSON(voiceJobResponse(job))
+}
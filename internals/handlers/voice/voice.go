@@ -1,26 +1,43 @@
 package handlers
 
 import (
+	"errors"
 	"fmt"
-	"strings"
+	"strconv"
 
 	"github.com/KashyretsIvanna/voice-balance/internals/services"
 	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
 )
 
 // TranscribeAudio godoc
 // @Summary      Transcribe audio to text
-// @Description  Receives an audio file and transcribes it to text using Google Cloud Speech-to-Text
+// @Description  Receives an audio file and transcribes it to text using Google Cloud Speech-to-Text.
+// @Description  The audio format and sample rate are detected from the file; formats the recognizer
+// @Description  cannot decode are transcoded with ffmpeg or rejected with 415.
+// @Description  With execute=true the interpreted action is also performed: expenses and incomes are
+// @Description  saved as transactions, reminders are created and statistics are computed.
 // @Param Authorization header string true "Insert your access token" default(Bearer <Add access token here>)
 // @Tags         transcription
-// @Accept       audio/wav
+// @Accept       multipart/form-data
 // @Produce      json
-// @Param        file  formData  file  true  "Audio file to transcribe"
-// @Success      200   {object}  map[string]string
+// @Param        file     formData  file    true   "Audio file to transcribe"
+// @Param        execute  query     bool    false  "Execute the interpreted action"
+// @Param        lang     query     string  false  "Language of the command: uk, en or ru. Defaults to the user settings"
+// @Success      200   {object}  map[string]interface{} "action is one of services.ExpenseAction, services.IncomeAction, services.ReminderAction, services.StatisticsAction, services.UnknownAction"
 // @Failure      400   {object}  map[string]string
+// @Failure      415   {object}  map[string]string
+// @Failure      422   {object}  services.VoiceActionValidationError
 // @Failure      500   {object}  map[string]string
 // @Router       /api/voice [post]
 func TranscribeAudio(c *fiber.Ctx) error {
+	userID, ok := c.Locals("ID").(uuid.UUID)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User ID not found in context",
+		})
+	}
+
 	// Parse the uploaded file
 	file, err := c.FormFile("file")
 	if err != nil {
@@ -29,28 +46,125 @@ func TranscribeAudio(c *fiber.Ctx) error {
 		})
 	}
 
+	execute, err := parseExecuteParam(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "execute must be true or false",
+		})
+	}
+
+	language, err := resolveLanguageParam(c, userID)
+	if err != nil {
+		return languageErrorResponse(c, err)
+	}
+
 	// Attempt to parse the file and transcribe the audio
-	transcription, err := services.ParseText(file)
+	transcription, err := services.ParseText(file, language)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": fmt.Sprintf("Failed to transcribe the uploaded file: %v", err),
+		return transcriptionErrorResponse(c, err)
+	}
+
+	return voiceCommandResponse(c, transcription, language, execute)
+}
+
+// parseExecuteParam reads the execute flag from the query or the form
+func parseExecuteParam(c *fiber.Ctx) (bool, error) {
+	value := c.Query("execute", c.FormValue("execute"))
+	if value == "" {
+		return false, nil
+	}
+	return strconv.ParseBool(value)
+}
+
+// resolveLanguageParam returns the language named by the lang query or form parameter,
+// falling back to the language from the user settings
+func resolveLanguageParam(c *fiber.Ctx, userID uuid.UUID) (*services.Language, error) {
+	return services.ResolveLanguage(userID, c.Query("lang", c.FormValue("lang")))
+}
+
+// languageErrorResponse maps errors of resolving the language to HTTP responses
+func languageErrorResponse(c *fiber.Ctx, err error) error {
+	if errors.Is(err, services.ErrUnsupportedLanguage) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": fmt.Sprintf("%v, supported languages are %v", err, services.SupportedLanguageCodes()),
+		})
+	}
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+}
+
+// transcriptionErrorResponse maps errors of speech recognition to HTTP responses
+func transcriptionErrorResponse(c *fiber.Ctx, err error) error {
+	if errors.Is(err, services.ErrUnsupportedAudioFormat) {
+		return c.Status(fiber.StatusUnsupportedMediaType).JSON(fiber.Map{
+			"error": fmt.Sprintf("Unsupported audio: %v. Upload WAV, FLAC, OGG/Opus, WebM/Opus, MP3, M4A or AMR audio.", err),
 		})
 	}
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+		"error": fmt.Sprintf("Failed to transcribe the uploaded file: %v", err),
+	})
+}
 
-	// Convert the transcribed text to lowercase
-	textCommand := strings.ToLower(transcription)
+// voiceCommandResponse interprets the transcribed command, executes it if asked to
+// and writes the outcome
+func voiceCommandResponse(c *fiber.Ctx, transcription string, language *services.Language, execute bool) error {
+	userID, ok := c.Locals("ID").(uuid.UUID)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User ID not found in context",
+		})
+	}
 
-	// Use the AskAi service to interpret the text command
-	err, res := services.AskAi(textCommand)
+	action, result, err := services.ProcessVoiceCommand(userID, transcription, language, execute)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": fmt.Sprintf("AI processing error: %v", err),
+		var validationErr *services.VoiceActionValidationError
+		var confirmationErr *services.CategoryConfirmationError
+		switch {
+		case errors.As(err, &validationErr):
+			return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
+				"error":      "The command is missing required information",
+				"transcript": transcription,
+				"type":       validationErr.Type,
+				"fields":     validationErr.Fields,
+			})
+		case errors.As(err, &confirmationErr):
+			return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
+				"error":      "The category needs to be confirmed",
+				"transcript": transcription,
+				"action":     action,
+				"phrase":     confirmationErr.Phrase,
+				"candidates": confirmationErr.Candidates,
+			})
+		case action == nil:
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": fmt.Sprintf("AI processing error: %v", err),
+			})
+		}
+
+		status := fiber.StatusInternalServerError
+		if errors.Is(err, services.ErrIncompleteVoiceAction) || errors.Is(err, services.ErrInvalidReminder) ||
+			errors.Is(err, services.ErrInvalidAmount) || errors.Is(err, services.ErrInvalidTransaction) {
+			status = fiber.StatusUnprocessableEntity
+		}
+		return c.Status(status).JSON(fiber.Map{
+			"error":  fmt.Sprintf("Failed to execute the voice command: %v", err),
+			"action": action,
+		})
+	}
+
+	if result == nil {
+		// Return the successfully processed action
+		return c.JSON(fiber.Map{
+			"status":     "success",
+			"transcript": transcription,
+			"action":     action,
 		})
 	}
 
-	// Return the successfully processed action
+	// Return the interpreted action together with what was done for it
 	return c.JSON(fiber.Map{
-		"status": "success",
-		"action": res,
+		"status":     "success",
+		"transcript": transcription,
+		"action":     action,
+		"result":     result,
 	})
-}
\ No newline at end of file
+}
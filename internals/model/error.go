@@ -5,12 +5,3 @@ package model
 type ErrorResponse struct {
 	Error string `json:"error"`
 }
-
-
-
-type TransactionGroupedByCategory struct {
-	CategoryName string         `json:"category_name"`
-	CategoryType string         `json:"category_type"`
-	Transactions []Transaction  `json:"transactions"`
-}
-
@@ -18,31 +18,44 @@ type User struct {
 	LastName     string     `json:"last_name"`
 	Password     string     `gorm:"not null"` // Only for email/password login
 	RefreshToken string     `gorm:""`         // To store the refresh token
+	Language     string     `json:"language" gorm:"size:8;not null;default:uk"` // Language of voice commands
+	TimeZone     string     `json:"time_zone" gorm:"size:64;not null;default:Europe/Kyiv"` // IANA time zone dates are given in
+	WeekStart    string     `json:"week_start" gorm:"size:10;not null;default:monday"`     // First day of week of statistics
+	Currency     string     `json:"currency" gorm:"size:3;not null;default:UAH"`           // Currency of new transactions and reports
 }
 
 type Reminder struct {
 	// Adds some metadata fields to the table
-	ID          uuid.UUID  `gorm:"type:uuid;primary_key"` // Use PostgreSQL's uuid_generate_v4 function
+	ID          uuid.UUID  `json:"id" gorm:"type:uuid;primary_key"` // Use PostgreSQL's uuid_generate_v4 function
 	CreatedAt   time.Time  `json:"created_at"`
 	UpdatedAt   time.Time  `json:"updated_at"`
 	DeletedAt   *time.Time `json:"deleted_at,omitempty" gorm:"index"` // Soft delete
-	Title       string     `gorm:"size:100;not null"`
-	Amount      float64    `gorm:"not null"`
-	DueDate     time.Time  `gorm:"not null"`
-	IsCompleted bool       `gorm:"default:false"`
-	UserID      uuid.UUID  `gorm:"not null"` // Foreign key to User
+	Title       string     `json:"title" gorm:"size:100;not null"`
+	Amount      Money      `json:"amount" gorm:"not null" swaggertype:"number"`
+	DueDate     time.Time  `json:"due_date" gorm:"not null"`
+	IsCompleted bool       `json:"is_completed" gorm:"default:false"`
+	UserID      uuid.UUID  `json:"user_id" gorm:"not null"` // Foreign key to User
+	// SnoozedUntil moves the notification of a reminder without changing its due date
+	SnoozedUntil *time.Time `json:"snoozed_until,omitempty"`
+	NotifiedAt   *time.Time `json:"notified_at,omitempty"` // When the notification was delivered or given up
+	Attempts     int        `json:"-" gorm:"not null;default:0"` // Failed delivery attempts of the current notification
+	RetryAt      *time.Time `json:"-" gorm:"index"`              // No delivery is attempted before it
 
 }
 
 type Transaction struct {
 	// Adds some metadata fields to the table
 	ID          uuid.UUID  `gorm:"type:uuid;primary_key"` // Use PostgreSQL's uuid_generate_v4 function
-	Amount      float64    `gorm:"not null"`
+	Amount      Money      `gorm:"not null" swaggertype:"number"`
+	Currency    string     `json:"currency" gorm:"size:3;not null;default:UAH"` // ISO 4217 code
 	Description string     `gorm:"size:255"`
 	Date        time.Time  `gorm:"not null"`
 	UserID      uuid.UUID  `gorm:"not null"` // Foreign key to User
 	Category   	Category   `gorm:"foreignKey:CategoryID"`
 	CategoryID  uuid.UUID  `gorm:"not null"` // Foreign key to Category
+	// Set on transactions posted by a recurring transaction, an occurrence is posted only once
+	RecurringID    *uuid.UUID `json:"recurring_id,omitempty" gorm:"type:uuid;uniqueIndex:idx_transactions_occurrence"`
+	OccurrenceDate *time.Time `json:"occurrence_date,omitempty" gorm:"uniqueIndex:idx_transactions_occurrence"`
 	CreatedAt   time.Time  `json:"created_at"`
 	UpdatedAt   time.Time  `json:"updated_at"`
 	DeletedAt   *time.Time `json:"deleted_at,omitempty" gorm:"index"` // Soft delete
@@ -54,9 +67,178 @@ type Category struct {
 	CreatedAt time.Time  `json:"created_at"`
 	UpdatedAt time.Time  `json:"updated_at"`
 	DeletedAt *time.Time `json:"deleted_at,omitempty" gorm:"index"` // Soft delete
-	Name      string     `gorm:"size:100;not null;unique"`
+	Name      string     `gorm:"size:100;not null"` // Unique per user and type, case is ignored, see database/migrate.go
 	Type      string     `gorm:"size:20;not null"` // 'income' or 'expense'
 	UserID    uuid.UUID  `gorm:"not null"`         // Foreign key to User
+	// ParentID makes the category a subcategory of another category of the same type
+	ParentID *uuid.UUID `json:"parent_id,omitempty" gorm:"type:uuid;index"`
+}
+
+// CategoryAlias is another name of a category, spoken categories are matched with it like with the name
+type CategoryAlias struct {
+	ID         uuid.UUID `json:"id" gorm:"type:uuid;primary_key"`
+	CreatedAt  time.Time `json:"created_at"`
+	UserID     uuid.UUID `json:"user_id" gorm:"not null;index"`
+	CategoryID uuid.UUID `json:"category_id" gorm:"type:uuid;not null;uniqueIndex:idx_category_aliases_category_alias"`
+	Alias      string    `json:"alias" gorm:"size:100;not null;uniqueIndex:idx_category_aliases_category_alias"` // Lower case
+}
+
+// CategoryRule assigns its category to transactions that come without one, like voice commands
+// without a category. A rule matches when all of its conditions that are set match.
+type CategoryRule struct {
+	ID         uuid.UUID  `json:"id" gorm:"type:uuid;primary_key"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+	DeletedAt  *time.Time `json:"deleted_at,omitempty" gorm:"index"` // Soft delete
+	UserID     uuid.UUID  `json:"user_id" gorm:"not null;index"`
+	CategoryID uuid.UUID  `json:"category_id" gorm:"type:uuid;not null"`
+	Name       string     `json:"name" gorm:"size:100;not null"`
+	// Rules are tried from the lowest priority up, the first one that matches wins
+	Priority int `json:"priority" gorm:"not null;default:0"`
+	// The description contains one of the keywords, case is ignored
+	Keywords StringList `json:"keywords" swaggertype:"array,string"`
+	// The description matches the regular expression, case is ignored
+	Pattern string `json:"pattern" gorm:"size:255"`
+	// The amount in the currency of the transaction is within the range, both ends are included
+	MinAmount *Money `json:"min_amount,omitempty" swaggertype:"number"`
+	MaxAmount *Money `json:"max_amount,omitempty" swaggertype:"number"`
+	// The transaction is on one of the weekdays, like "monday", in the time zone of the user
+	Weekdays StringList `json:"weekdays" swaggertype:"array,string"`
+}
+
+// Values of Category.Type
+const (
+	CategoryTypeIncome  = "income"
+	CategoryTypeExpense = "expense"
+)
+
+// DefaultCurrency is the currency of amounts given without one. Exchange rates are prices in it.
+const DefaultCurrency = "UAH"
+
+// ExchangeRate is the price of one unit of a currency in DefaultCurrency on a date
+type ExchangeRate struct {
+	ID        uuid.UUID `json:"id" gorm:"type:uuid;primary_key"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	Currency  string    `json:"currency" gorm:"size:3;not null;uniqueIndex:idx_exchange_rates_currency_date"`
+	Date      time.Time `json:"date" gorm:"type:date;not null;uniqueIndex:idx_exchange_rates_currency_date"`
+	Rate      float64   `json:"rate" gorm:"not null"`
+	Source    string    `json:"source" gorm:"size:20"` // "import" or the name of the provider
+}
+
+// Values of Budget.Period
+const (
+	BudgetPeriodWeekly  = "weekly"
+	BudgetPeriodMonthly = "monthly"
+	BudgetPeriodYearly  = "yearly"
+)
+
+// Budget limits the spending of a user in an expense category per calendar period
+type Budget struct {
+	ID         uuid.UUID  `json:"id" gorm:"type:uuid;primary_key"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+	DeletedAt  *time.Time `json:"deleted_at,omitempty" gorm:"index"` // Soft delete
+	UserID     uuid.UUID  `json:"user_id" gorm:"not null;index"`
+	CategoryID uuid.UUID  `json:"category_id" gorm:"type:uuid;not null;index"`
+	Period     string     `json:"period" gorm:"size:10;not null"`
+	Limit      Money      `json:"limit" gorm:"column:limit_amount;not null" swaggertype:"number"`
+	Threshold  float64    `json:"threshold" gorm:"not null;default:100"` // Percent of the limit a warning is given at
+	Rollover   bool       `json:"rollover" gorm:"default:false"`         // Unspent money of the previous period adds to the limit
+}
+
+// Values of RecurringTransaction.Frequency
+const (
+	FrequencyDaily   = "daily"
+	FrequencyWeekly  = "weekly"
+	FrequencyMonthly = "monthly"
+	FrequencyYearly  = "yearly"
+)
+
+// RecurringTransaction posts a transaction on every occurrence of its schedule: every Interval
+// days, weeks, months or years from StartDate until EndDate
+type RecurringTransaction struct {
+	ID          uuid.UUID  `json:"id" gorm:"type:uuid;primary_key"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+	DeletedAt   *time.Time `json:"deleted_at,omitempty" gorm:"index"` // Soft delete
+	UserID      uuid.UUID  `json:"user_id" gorm:"not null;index"`
+	CategoryID  uuid.UUID  `json:"category_id" gorm:"type:uuid;not null"`
+	Amount      Money      `json:"amount" gorm:"not null" swaggertype:"number"`
+	Currency    string     `json:"currency" gorm:"size:3;not null;default:UAH"` // ISO 4217 code
+	Description string     `json:"description" gorm:"size:255"`
+	Frequency   string     `json:"frequency" gorm:"size:10;not null"`
+	Interval    int        `json:"interval" gorm:"not null;default:1"`
+	StartDate   time.Time  `json:"start_date" gorm:"not null"`
+	EndDate     *time.Time `json:"end_date,omitempty"`
+	NextDate    *time.Time `json:"next_date,omitempty" gorm:"index"` // Next occurrence to post, nil when the schedule has ended
+}
+
+// Values of ReminderDelivery.Status
+const (
+	DeliverySent   = "sent"
+	DeliveryFailed = "failed"
+)
+
+// ReminderDelivery logs one attempt to deliver a reminder through one channel
+type ReminderDelivery struct {
+	ID         uuid.UUID `json:"id" gorm:"type:uuid;primary_key"`
+	CreatedAt  time.Time `json:"created_at"`
+	ReminderID uuid.UUID `json:"reminder_id" gorm:"type:uuid;not null;index"`
+	UserID     uuid.UUID `json:"user_id" gorm:"not null"`
+	Channel    string    `json:"channel" gorm:"size:20;not null"`
+	DueAt      time.Time `json:"due_at" gorm:"not null"` // Due date or end of snooze the notification was for
+	Attempt    int       `json:"attempt" gorm:"not null"`
+	Status     string    `json:"status" gorm:"size:10;not null"`
+	Error      string    `json:"error,omitempty" gorm:"type:text"`
+}
+
+// Notification is a message in the in-app inbox of a user
+type Notification struct {
+	ID         uuid.UUID  `json:"id" gorm:"type:uuid;primary_key"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UserID     uuid.UUID  `json:"user_id" gorm:"not null;index"`
+	ReminderID *uuid.UUID `json:"reminder_id,omitempty" gorm:"type:uuid"`
+	Title      string     `json:"title" gorm:"size:100;not null"`
+	Body       string     `json:"body" gorm:"type:text"`
+	ReadAt     *time.Time `json:"read_at,omitempty"`
+}
+
+// Values of VoiceJob.Status
+const (
+	VoiceJobPending    = "pending"
+	VoiceJobProcessing = "processing"
+	VoiceJobDone       = "done"
+	VoiceJobFailed     = "failed"
+)
+
+// VoiceJob is a long recording transcribed in the background
+type VoiceJob struct {
+	ID         uuid.UUID `json:"id" gorm:"type:uuid;primary_key"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+	UserID     uuid.UUID `json:"user_id" gorm:"not null;index"`
+	Status     string    `json:"status" gorm:"size:20;not null"`
+	Execute    bool      `json:"execute"`
+	Language   string    `json:"language" gorm:"size:8"`
+	Transcript string    `json:"transcript"`
+	Action     string    `json:"-" gorm:"type:text"` // Interpreted action as JSON
+	Result     string    `json:"-" gorm:"type:text"` // Execution result as JSON
+	Error      string    `json:"error,omitempty"`
+}
+
+func (alias *CategoryAlias) BeforeCreate(tx *gorm.DB) (err error) {
+	if alias.ID == uuid.Nil {
+		alias.ID = uuid.New() // Generate a new UUID
+	}
+	return
+}
+
+func (rule *CategoryRule) BeforeCreate(tx *gorm.DB) (err error) {
+	if rule.ID == uuid.Nil {
+		rule.ID = uuid.New() // Generate a new UUID
+	}
+	return
 }
 
 func (category *Category) BeforeCreate(tx *gorm.DB) (err error) {
@@ -86,3 +268,45 @@ func (reminder *Reminder) BeforeCreate(tx *gorm.DB) (err error) {
 	}
 	return
 }
+
+func (budget *Budget) BeforeCreate(tx *gorm.DB) (err error) {
+	if budget.ID == uuid.Nil {
+		budget.ID = uuid.New() // Generate a new UUID
+	}
+	return
+}
+
+func (recurring *RecurringTransaction) BeforeCreate(tx *gorm.DB) (err error) {
+	if recurring.ID == uuid.Nil {
+		recurring.ID = uuid.New() // Generate a new UUID
+	}
+	return
+}
+
+func (delivery *ReminderDelivery) BeforeCreate(tx *gorm.DB) (err error) {
+	if delivery.ID == uuid.Nil {
+		delivery.ID = uuid.New() // Generate a new UUID
+	}
+	return
+}
+
+func (notification *Notification) BeforeCreate(tx *gorm.DB) (err error) {
+	if notification.ID == uuid.Nil {
+		notification.ID = uuid.New() // Generate a new UUID
+	}
+	return
+}
+
+func (rate *ExchangeRate) BeforeCreate(tx *gorm.DB) (err error) {
+	if rate.ID == uuid.Nil {
+		rate.ID = uuid.New() // Generate a new UUID
+	}
+	return
+}
+
+func (job *VoiceJob) BeforeCreate(tx *gorm.DB) (err error) {
+	if job.ID == uuid.Nil {
+		job.ID = uuid.New() // Generate a new UUID
+	}
+	return
+}
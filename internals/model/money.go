@@ -0,0 +1,120 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// MoneyDecimals is the number of decimals Money keeps
+const MoneyDecimals = 2
+
+var (
+	// ErrInvalidMoney is returned for amounts that are not decimal numbers with at most MoneyDecimals decimals
+	ErrInvalidMoney = errors.New("invalid amount")
+	// ErrMoneyPrecision is returned for numbers with more than MoneyDecimals decimals, it is an ErrInvalidMoney
+	ErrMoneyPrecision = fmt.Errorf("%w: too many decimals", ErrInvalidMoney)
+)
+
+// Money is an exact amount in minor units of its currency, hundredths like kopiyky or cents.
+// It is stored as an integer and written to JSON as a decimal number, 1250 is 12.50.
+type Money int64
+
+// ParseMoney reads a decimal number like "12.5", "-3" or "1 250,75" without rounding,
+// numbers with more than MoneyDecimals decimals are rejected
+func ParseMoney(text string) (Money, error) {
+	value := strings.NewReplacer(" ", "", " ", "", "_", "").Replace(strings.TrimSpace(text))
+	value = strings.Replace(value, ",", ".", 1)
+
+	negative := strings.HasPrefix(value, "-")
+	value = strings.TrimPrefix(strings.TrimPrefix(value, "-"), "+")
+
+	units, fraction := value, ""
+	if i := strings.IndexByte(value, '.'); i >= 0 {
+		units, fraction = value[:i], value[i+1:]
+	}
+	if units+fraction == "" || !isDigits(units) || !isDigits(fraction) {
+		return 0, fmt.Errorf("%w: %q is not a number", ErrInvalidMoney, text)
+	}
+	// Trailing zeros do not add precision, 12.500 is 12.50
+	fraction = strings.TrimRight(fraction, "0")
+	if len(fraction) > MoneyDecimals {
+		return 0, fmt.Errorf("%w: %q, at most %d are allowed", ErrMoneyPrecision, text, MoneyDecimals)
+	}
+
+	fraction += strings.Repeat("0", MoneyDecimals-len(fraction))
+	digits := strings.TrimLeft(units+fraction, "0")
+	if digits == "" {
+		return 0, nil
+	}
+	minor, err := strconv.ParseInt(digits, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %q is too large", ErrInvalidMoney, text)
+	}
+	if negative {
+		minor = -minor
+	}
+	return Money(minor), nil
+}
+
+// MoneyFromFloat converts a float without rounding it: the shortest decimal form
+// of the float must fit into MoneyDecimals decimals
+func MoneyFromFloat(value float64) (Money, error) {
+	if math.IsNaN(value) || math.IsInf(value, 0) {
+		return 0, fmt.Errorf("%w: not a number", ErrInvalidMoney)
+	}
+	return ParseMoney(strconv.FormatFloat(value, 'f', -1, 64))
+}
+
+func isDigits(text string) bool {
+	for _, r := range text {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// Float64 returns the amount in units of the currency, for ratios and display only
+func (m Money) Float64() float64 {
+	return float64(m) / 100
+}
+
+// Scale multiplies the amount by factor and rounds the result to whole minor units,
+// half away from zero
+func (m Money) Scale(factor float64) Money {
+	return Money(math.Round(float64(m) * factor))
+}
+
+// String formats the amount with two decimals, like 12.50 or -0.05
+func (m Money) String() string {
+	sign, minor := "", int64(m)
+	if minor < 0 {
+		sign, minor = "-", -minor
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, minor/100, minor%100)
+}
+
+// MarshalJSON writes the amount as a decimal number
+func (m Money) MarshalJSON() ([]byte, error) {
+	return []byte(m.String()), nil
+}
+
+// UnmarshalJSON reads the amount from a number or a string without going through a float
+func (m *Money) UnmarshalJSON(data []byte) error {
+	text := string(data)
+	if text == "null" {
+		return nil
+	}
+	if unquoted, err := strconv.Unquote(text); err == nil {
+		text = unquoted
+	}
+	parsed, err := ParseMoney(text)
+	if err != nil {
+		return err
+	}
+	*m = parsed
+	return nil
+}
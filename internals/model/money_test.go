@@ -0,0 +1,131 @@
+package model
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestParseMoney(t *testing.T) {
+	tests := []struct {
+		text string
+		want Money
+	}{
+		{"12.5", 1250},
+		{"12,50", 1250},
+		{"0.07", 7},
+		{"-3", -300},
+		{"+3.1", 310},
+		{"1 250,75", 125075},
+		{"1_000", 100000},
+		{" 42 ", 4200},
+		{".5", 50},
+		{"5.", 500},
+		{"12.500", 1250},
+		{"0", 0},
+		{"00.00", 0},
+		{"92233720368547758.07", 9223372036854775807},
+	}
+	for _, test := range tests {
+		got, err := ParseMoney(test.text)
+		if err != nil || got != test.want {
+			t.Errorf("ParseMoney(%q) = %d, %v, want %d", test.text, got, err, test.want)
+		}
+	}
+}
+
+func TestParseMoneyDoesNotRound(t *testing.T) {
+	for _, text := range []string{"12.345", "0.001", "1999.999", "-0.005"} {
+		if _, err := ParseMoney(text); !errors.Is(err, ErrMoneyPrecision) || !errors.Is(err, ErrInvalidMoney) {
+			t.Errorf("ParseMoney(%q) error = %v, want ErrMoneyPrecision", text, err)
+		}
+	}
+	for _, text := range []string{"", "-", ".", "12.5.1", "1e3", "abc", "12,5,0", "92233720368547758.08"} {
+		if _, err := ParseMoney(text); !errors.Is(err, ErrInvalidMoney) || errors.Is(err, ErrMoneyPrecision) {
+			t.Errorf("ParseMoney(%q) error = %v, want ErrInvalidMoney", text, err)
+		}
+	}
+}
+
+func TestMoneyFromFloat(t *testing.T) {
+	tests := []struct {
+		value float64
+		want  Money
+	}{
+		{12.5, 1250},
+		// As floats these are not whole when multiplied by 100
+		{0.07, 7},
+		{1999.99, 199999},
+		{-3.1, -310},
+	}
+	for _, test := range tests {
+		got, err := MoneyFromFloat(test.value)
+		if err != nil || got != test.want {
+			t.Errorf("MoneyFromFloat(%v) = %d, %v, want %d", test.value, got, err, test.want)
+		}
+	}
+	if _, err := MoneyFromFloat(12.345); !errors.Is(err, ErrMoneyPrecision) {
+		t.Errorf("MoneyFromFloat(12.345) error = %v, want ErrMoneyPrecision", err)
+	}
+}
+
+func TestMoneyScaleRoundsHalfAwayFromZero(t *testing.T) {
+	tests := []struct {
+		money  Money
+		factor float64
+		want   Money
+	}{
+		{1000, 0.8, 800},
+		{5, 0.5, 3},
+		{-5, 0.5, -3},
+		{1, 0.49, 0},
+		{333, 1.0 / 3, 111},
+	}
+	for _, test := range tests {
+		if got := test.money.Scale(test.factor); got != test.want {
+			t.Errorf("Money(%d).Scale(%v) = %d, want %d", test.money, test.factor, got, test.want)
+		}
+	}
+}
+
+func TestMoneyJSONRoundTrip(t *testing.T) {
+	for _, money := range []Money{0, 5, 1250, -5, -125075, 9223372036854775807} {
+		data, err := json.Marshal(money)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var parsed Money
+		if err := json.Unmarshal(data, &parsed); err != nil || parsed != money {
+			t.Errorf("%d written as %s was read back as %d, %v", money, data, parsed, err)
+		}
+	}
+
+	data, _ := json.Marshal(struct {
+		Amount Money `json:"amount"`
+	}{-5})
+	if string(data) != `{"amount":-0.05}` {
+		t.Errorf("JSON %s, want a decimal number", data)
+	}
+}
+
+func TestMoneyUnmarshalJSON(t *testing.T) {
+	var body struct {
+		Amount Money `json:"amount"`
+		Limit  Money `json:"limit"`
+		Other  Money `json:"other"`
+	}
+	body.Other = 100
+	if err := json.Unmarshal([]byte(`{"amount": 12.5, "limit": "1 250,75", "other": null}`), &body); err != nil {
+		t.Fatal(err)
+	}
+	if body.Amount != 1250 || body.Limit != 125075 || body.Other != 100 {
+		t.Errorf("unexpected amounts %+v", body)
+	}
+
+	for _, data := range []string{`12.345`, `"12.345"`, `1e2`, `true`, `"abc"`} {
+		var money Money
+		if err := json.Unmarshal([]byte(data), &money); !errors.Is(err, ErrInvalidMoney) {
+			t.Errorf("reading %s gave %d, %v, want ErrInvalidMoney", data, money, err)
+		}
+	}
+}
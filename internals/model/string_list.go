@@ -0,0 +1,44 @@
+package model
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+)
+
+// StringList is a list of texts stored in one text column as a JSON array
+type StringList []string
+
+// Value writes the list as a JSON array, an empty list as []
+func (l StringList) Value() (driver.Value, error) {
+	if l == nil {
+		return "[]", nil
+	}
+	data, err := json.Marshal([]string(l))
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+// Scan reads the list from a JSON array
+func (l *StringList) Scan(value interface{}) error {
+	var data []byte
+	switch value := value.(type) {
+	case nil:
+		*l = nil
+		return nil
+	case string:
+		data = []byte(value)
+	case []byte:
+		data = value
+	default:
+		return fmt.Errorf("cannot scan %T into a StringList", value)
+	}
+	return json.Unmarshal(data, (*[]string)(l))
+}
+
+// GormDataType is the column type of the list
+func (StringList) GormDataType() string {
+	return "text"
+}
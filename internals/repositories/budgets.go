@@ -0,0 +1,101 @@
+package repositories
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/KashyretsIvanna/voice-balance/database"
+	models "github.com/KashyretsIvanna/voice-balance/internals/model"
+	"github.com/google/uuid"
+)
+
+// ErrBudgetExists is returned when a budget is saved for a category and period
+// that already have a not deleted budget
+var ErrBudgetExists = errors.New("budget exists")
+
+// budgetPeriodIndex is the unique index on the category and the period of not deleted budgets
+const budgetPeriodIndex = "idx_budgets_user_category_period"
+
+// budgetError turns the violation of budgetPeriodIndex into ErrBudgetExists
+func budgetError(err error) error {
+	if isUniqueViolation(err, budgetPeriodIndex) {
+		return ErrBudgetExists
+	}
+	return err
+}
+
+// isUniqueViolation tells whether Postgres rejected a row because of the unique index
+func isUniqueViolation(err error, index string) bool {
+	var pgErr interface{ SQLState() string }
+	return errors.As(err, &pgErr) && pgErr.SQLState() == "23505" && strings.Contains(err.Error(), index)
+}
+
+// SaveBudget creates a new budget in the database.
+// Returns ErrBudgetExists if the category already has a budget for the period.
+func SaveBudget(budget *models.Budget) error {
+	db := database.DB
+
+	return budgetError(db.Create(budget).Error)
+}
+
+// FindBudgets returns the not deleted budgets of a user, oldest first
+func FindBudgets(userID uuid.UUID) ([]models.Budget, error) {
+	db := database.DB
+
+	var budgets []models.Budget
+	err := db.Where("user_id = ? AND deleted_at IS NULL", userID).
+		Order("created_at ASC").Find(&budgets).Error
+	return budgets, err
+}
+
+// FindBudgetsByCategory returns the not deleted budgets of a user for one category
+func FindBudgetsByCategory(userID, categoryID uuid.UUID) ([]models.Budget, error) {
+	db := database.DB
+
+	var budgets []models.Budget
+	err := db.Where("user_id = ? AND category_id = ? AND deleted_at IS NULL", userID, categoryID).
+		Order("created_at ASC").Find(&budgets).Error
+	return budgets, err
+}
+
+// FindBudgetsByCategories returns the not deleted budgets of a user for any of the categories
+func FindBudgetsByCategories(userID uuid.UUID, categoryIDs []uuid.UUID) ([]models.Budget, error) {
+	db := database.DB
+
+	var budgets []models.Budget
+	err := db.Where("user_id = ? AND category_id IN ? AND deleted_at IS NULL", userID, categoryIDs).
+		Order("created_at ASC").Find(&budgets).Error
+	return budgets, err
+}
+
+// FindBudgetByID returns a not deleted budget owned by the user.
+// Returns gorm.ErrRecordNotFound if there is no such budget.
+func FindBudgetByID(userID, budgetID uuid.UUID) (*models.Budget, error) {
+	db := database.DB
+
+	budget := &models.Budget{}
+	err := db.Where("id = ? AND user_id = ? AND deleted_at IS NULL", budgetID, userID).
+		First(budget).Error
+	if err != nil {
+		return nil, err
+	}
+	return budget, nil
+}
+
+// UpdateBudget saves all fields of an existing budget.
+// Returns ErrBudgetExists if the category already has another budget for the period.
+func UpdateBudget(budget *models.Budget) error {
+	db := database.DB
+
+	return budgetError(db.Save(budget).Error)
+}
+
+// SoftDeleteBudget marks the budget as deleted without removing the row
+func SoftDeleteBudget(budget *models.Budget) error {
+	db := database.DB
+
+	now := time.Now()
+	budget.DeletedAt = &now
+	return db.Model(budget).Update("deleted_at", now).Error
+}
@@ -0,0 +1,33 @@
+package repositories
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+// pgError stands for the errors of the Postgres driver
+type pgError struct{ code, message string }
+
+func (e *pgError) Error() string    { return e.message }
+func (e *pgError) SQLState() string { return e.code }
+
+func TestBudgetErrorDetectsDuplicatePeriod(t *testing.T) {
+	duplicate := &pgError{"23505", `duplicate key value violates unique constraint "idx_budgets_user_category_period"`}
+	if err := budgetError(fmt.Errorf("saving: %w", duplicate)); !errors.Is(err, ErrBudgetExists) {
+		t.Errorf("error = %v, want ErrBudgetExists", err)
+	}
+
+	for _, err := range []error{
+		&pgError{"23505", `duplicate key value violates unique constraint "budgets_pkey"`},
+		&pgError{"23503", `insert or update on table "budgets" violates foreign key constraint`},
+		errors.New(`duplicate key value violates unique constraint "idx_budgets_user_category_period"`),
+	} {
+		if got := budgetError(err); got != err {
+			t.Errorf("budgetError(%v) = %v, want it unchanged", err, got)
+		}
+	}
+	if err := budgetError(nil); err != nil {
+		t.Errorf("budgetError(nil) = %v", err)
+	}
+}
@@ -1,7 +1,11 @@
 package repositories
 
 import (
+	"database/sql"
+	"time"
+
 	"github.com/KashyretsIvanna/voice-balance/internals/model"
+	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
 
@@ -9,3 +13,220 @@ import (
 func AddCategory(db *gorm.DB, category *model.Category) error {
 	return db.Create(category).Error
 }
+
+// FindCategoryByName looks up a not deleted category of the user by its name and type.
+// Returns gorm.ErrRecordNotFound if the user has no such category.
+func FindCategoryByName(db *gorm.DB, userID uuid.UUID, name, categoryType string) (*model.Category, error) {
+	category := &model.Category{}
+	err := db.Where("user_id = ? AND LOWER(name) = LOWER(?) AND type = ? AND deleted_at IS NULL", userID, name, categoryType).
+		First(category).Error
+	if err != nil {
+		return nil, err
+	}
+	return category, nil
+}
+
+// FindCategoryByID returns a not deleted category owned by the user.
+// Returns gorm.ErrRecordNotFound if there is no such category.
+func FindCategoryByID(db *gorm.DB, userID, categoryID uuid.UUID) (*model.Category, error) {
+	category := &model.Category{}
+	err := db.Where("id = ? AND user_id = ? AND deleted_at IS NULL", categoryID, userID).
+		First(category).Error
+	if err != nil {
+		return nil, err
+	}
+	return category, nil
+}
+
+// FindCategoriesByUser returns the not deleted categories of a user ordered by type and name
+func FindCategoriesByUser(db *gorm.DB, userID uuid.UUID) ([]model.Category, error) {
+	var categories []model.Category
+	err := db.Where("user_id = ? AND deleted_at IS NULL", userID).
+		Order("type, LOWER(name)").
+		Find(&categories).Error
+	return categories, err
+}
+
+// SoftDeleteUnusedCategories marks the categories of a user as deleted, except the kept ones
+// and the ones still used by a transaction, a recurring transaction, a budget or a category rule.
+// Deleted transactions keep their categories as well, they may be restored.
+// Remaining subcategories of deleted categories become top-level categories.
+func SoftDeleteUnusedCategories(db *gorm.DB, userID uuid.UUID, keepIDs []uuid.UUID) (int64, error) {
+	query := db.Model(&model.Category{}).
+		Where("user_id = ? AND deleted_at IS NULL", userID).
+		Where("NOT EXISTS (SELECT 1 FROM transactions WHERE transactions.category_id = categories.id)").
+		Where("NOT EXISTS (SELECT 1 FROM recurring_transactions WHERE recurring_transactions.category_id = categories.id AND recurring_transactions.deleted_at IS NULL)").
+		Where("NOT EXISTS (SELECT 1 FROM budgets WHERE budgets.category_id = categories.id AND budgets.deleted_at IS NULL)").
+		Where("NOT EXISTS (SELECT 1 FROM category_rules WHERE category_rules.category_id = categories.id AND category_rules.deleted_at IS NULL)")
+	if len(keepIDs) > 0 {
+		query = query.Where("id NOT IN ?", keepIDs)
+	}
+
+	result := query.Update("deleted_at", time.Now())
+	if result.Error != nil {
+		return 0, result.Error
+	}
+
+	err := db.Model(&model.Category{}).
+		Where("user_id = ? AND deleted_at IS NULL", userID).
+		Where("parent_id IN (SELECT id FROM categories WHERE deleted_at IS NOT NULL)").
+		Update("parent_id", nil).Error
+	return result.RowsAffected, err
+}
+
+// categoryTreeSQL selects the ID of a category and of its not deleted subcategories at any depth
+const categoryTreeSQL = "WITH RECURSIVE tree AS (SELECT id FROM categories WHERE id = ? " +
+	"UNION SELECT categories.id FROM categories JOIN tree ON categories.parent_id = tree.id WHERE categories.deleted_at IS NULL) " +
+	"SELECT id FROM tree"
+
+// UpdateCategory saves all fields of an existing category
+func UpdateCategory(db *gorm.DB, category *model.Category) error {
+	return db.Save(category).Error
+}
+
+// CountCategoryUses counts the transactions of a category, deleted ones included since they may be restored,
+// and its not deleted recurring transactions, budgets and category rules
+func CountCategoryUses(db *gorm.DB, categoryID uuid.UUID) (int64, error) {
+	var uses int64
+	err := db.Raw("SELECT "+
+		"(SELECT COUNT(*) FROM transactions WHERE category_id = @id) + "+
+		"(SELECT COUNT(*) FROM recurring_transactions WHERE category_id = @id AND deleted_at IS NULL) + "+
+		"(SELECT COUNT(*) FROM budgets WHERE category_id = @id AND deleted_at IS NULL) + "+
+		"(SELECT COUNT(*) FROM category_rules WHERE category_id = @id AND deleted_at IS NULL)", sql.Named("id", categoryID)).
+		Scan(&uses).Error
+	return uses, err
+}
+
+// SoftDeleteCategory marks the category as deleted, its subcategories move to its parent
+func SoftDeleteCategory(db *gorm.DB, category *model.Category) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&model.Category{}).
+			Where("parent_id = ? AND deleted_at IS NULL", category.ID).
+			Update("parent_id", category.ParentID).Error
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		category.DeletedAt = &now
+		return tx.Model(category).Update("deleted_at", now).Error
+	})
+}
+
+// CategoryMergeCounts counts what MergeCategories moved into the target category
+type CategoryMergeCounts struct {
+	Transactions          int64 `json:"transactions"`
+	RecurringTransactions int64 `json:"recurring_transactions"`
+	Budgets               int64 `json:"budgets"`
+	Subcategories         int64 `json:"subcategories"`
+	CategoryRules         int64 `json:"category_rules"`
+}
+
+// MergeCategories moves the transactions, recurring transactions, budgets, aliases, category rules and subcategories of
+// source into target and deletes source, all in one database transaction. A budget of source
+// is deleted instead when target already has a budget of its period.
+func MergeCategories(db *gorm.DB, source, target *model.Category) (CategoryMergeCounts, error) {
+	var counts CategoryMergeCounts
+	err := db.Transaction(func(tx *gorm.DB) error {
+		// Deleted transactions move too, they may be restored later
+		result := tx.Model(&model.Transaction{}).
+			Where("category_id = ?", source.ID).
+			Updates(map[string]interface{}{"category_id": target.ID, "updated_at": time.Now()})
+		if result.Error != nil {
+			return result.Error
+		}
+		counts.Transactions = result.RowsAffected
+
+		result = tx.Model(&model.RecurringTransaction{}).
+			Where("category_id = ?", source.ID).
+			Update("category_id", target.ID)
+		if result.Error != nil {
+			return result.Error
+		}
+		counts.RecurringTransactions = result.RowsAffected
+
+		result = tx.Model(&model.Budget{}).
+			Where("category_id = ? AND deleted_at IS NULL", source.ID).
+			Where("NOT EXISTS (SELECT 1 FROM budgets AS kept WHERE kept.category_id = ? AND kept.period = budgets.period AND kept.deleted_at IS NULL)", target.ID).
+			Update("category_id", target.ID)
+		if result.Error != nil {
+			return result.Error
+		}
+		counts.Budgets = result.RowsAffected
+
+		err := tx.Model(&model.Budget{}).
+			Where("category_id = ? AND deleted_at IS NULL", source.ID).
+			Update("deleted_at", time.Now()).Error
+		if err != nil {
+			return err
+		}
+
+		// Aliases target already has are dropped
+		err = tx.Model(&model.CategoryAlias{}).
+			Where("category_id = ?", source.ID).
+			Where("alias NOT IN (SELECT alias FROM category_aliases WHERE category_id = ?)", target.ID).
+			Update("category_id", target.ID).Error
+		if err != nil {
+			return err
+		}
+		if err := tx.Where("category_id = ?", source.ID).Delete(&model.CategoryAlias{}).Error; err != nil {
+			return err
+		}
+
+		result = tx.Model(&model.CategoryRule{}).
+			Where("category_id = ? AND deleted_at IS NULL", source.ID).
+			Updates(map[string]interface{}{"category_id": target.ID, "updated_at": time.Now()})
+		if result.Error != nil {
+			return result.Error
+		}
+		counts.CategoryRules = result.RowsAffected
+
+		result = tx.Model(&model.Category{}).
+			Where("parent_id = ? AND deleted_at IS NULL", source.ID).
+			Update("parent_id", target.ID)
+		if result.Error != nil {
+			return result.Error
+		}
+		counts.Subcategories = result.RowsAffected
+
+		now := time.Now()
+		source.DeletedAt = &now
+		return tx.Model(source).Update("deleted_at", now).Error
+	})
+	return counts, err
+}
+
+// FindCategoryAliases returns the aliases of a category ordered by alias
+func FindCategoryAliases(db *gorm.DB, categoryID uuid.UUID) ([]model.CategoryAlias, error) {
+	var aliases []model.CategoryAlias
+	err := db.Where("category_id = ?", categoryID).Order("alias").Find(&aliases).Error
+	return aliases, err
+}
+
+// FindAliasesByUser returns the aliases of the not deleted categories of a user with the given type
+func FindAliasesByUser(db *gorm.DB, userID uuid.UUID, categoryType string) ([]model.CategoryAlias, error) {
+	var aliases []model.CategoryAlias
+	err := db.Joins("JOIN categories ON categories.id = category_aliases.category_id").
+		Where("category_aliases.user_id = ? AND categories.type = ? AND categories.deleted_at IS NULL", userID, categoryType).
+		Find(&aliases).Error
+	return aliases, err
+}
+
+// AddCategoryAlias saves a new alias of a category
+func AddCategoryAlias(db *gorm.DB, alias *model.CategoryAlias) error {
+	return db.Create(alias).Error
+}
+
+// DeleteCategoryAlias removes an alias of a category of the user.
+// Returns gorm.ErrRecordNotFound if there is no such alias.
+func DeleteCategoryAlias(db *gorm.DB, userID, categoryID, aliasID uuid.UUID) error {
+	result := db.Where("id = ? AND category_id = ? AND user_id = ?", aliasID, categoryID, userID).
+		Delete(&model.CategoryAlias{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
@@ -0,0 +1,52 @@
+package repositories
+
+import (
+	"testing"
+
+	"github.com/KashyretsIvanna/voice-balance/database/dbtest"
+	models "github.com/KashyretsIvanna/voice-balance/internals/model"
+	"github.com/google/uuid"
+)
+
+func TestCategoriesUsedByRulesAreKept(t *testing.T) {
+	db := dbtest.Use(t)
+	userID := uuid.New()
+	ruled := &models.Category{Name: "Кава", Type: models.CategoryTypeExpense, UserID: userID}
+	unused := &models.Category{Name: "Хобі", Type: models.CategoryTypeExpense, UserID: userID}
+	for _, category := range []*models.Category{ruled, unused} {
+		if err := db.Create(category).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	rule := &models.CategoryRule{UserID: userID, CategoryID: ruled.ID, Name: "кав'ярні", Keywords: models.StringList{"кава"}}
+	if err := db.Create(rule).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	if uses, err := CountCategoryUses(db, ruled.ID); err != nil || uses != 1 {
+		t.Errorf("uses of the category of a rule = %d, %v, want 1", uses, err)
+	}
+
+	deleted, err := SoftDeleteUnusedCategories(db, userID, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if deleted != 1 {
+		t.Errorf("%d categories deleted, want only the unused one", deleted)
+	}
+	rules, err := FindCategoryRules(userID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rules) != 1 || rules[0].ID != rule.ID {
+		t.Errorf("rules %v, want the rule kept with its category", rules)
+	}
+
+	// A deleted rule no longer keeps its category
+	if err := SoftDeleteCategoryRule(rule); err != nil {
+		t.Fatal(err)
+	}
+	if uses, err := CountCategoryUses(db, ruled.ID); err != nil || uses != 0 {
+		t.Errorf("uses after deleting the rule = %d, %v, want 0", uses, err)
+	}
+}
@@ -0,0 +1,85 @@
+package repositories
+
+import (
+	"time"
+
+	"github.com/KashyretsIvanna/voice-balance/database"
+	models "github.com/KashyretsIvanna/voice-balance/internals/model"
+	"github.com/google/uuid"
+	"gorm.io/gorm/clause"
+)
+
+// SaveCategoryRule creates a new category rule in the database
+func SaveCategoryRule(rule *models.CategoryRule) error {
+	db := database.DB
+
+	return db.Create(rule).Error
+}
+
+// FindCategoryRules returns the not deleted category rules of a user in the order they are tried,
+// rules of deleted categories are left out
+func FindCategoryRules(userID uuid.UUID) ([]models.CategoryRule, error) {
+	db := database.DB
+
+	var rules []models.CategoryRule
+	err := db.Joins("JOIN categories ON categories.id = category_rules.category_id AND categories.deleted_at IS NULL").
+		Where("category_rules.user_id = ? AND category_rules.deleted_at IS NULL", userID).
+		Order("category_rules.priority ASC, category_rules.created_at ASC").
+		Find(&rules).Error
+	return rules, err
+}
+
+// FindCategoryRuleByID returns a not deleted category rule owned by the user.
+// Returns gorm.ErrRecordNotFound if there is no such rule.
+func FindCategoryRuleByID(userID, ruleID uuid.UUID) (*models.CategoryRule, error) {
+	db := database.DB
+
+	rule := &models.CategoryRule{}
+	err := db.Where("id = ? AND user_id = ? AND deleted_at IS NULL", ruleID, userID).First(rule).Error
+	if err != nil {
+		return nil, err
+	}
+	return rule, nil
+}
+
+// UpdateCategoryRule saves all fields of an existing category rule
+func UpdateCategoryRule(rule *models.CategoryRule) error {
+	db := database.DB
+
+	return db.Omit(clause.Associations).Save(rule).Error
+}
+
+// SoftDeleteCategoryRule marks the category rule as deleted without removing the row
+func SoftDeleteCategoryRule(rule *models.CategoryRule) error {
+	db := database.DB
+
+	now := time.Now()
+	rule.DeletedAt = &now
+	return db.Model(rule).Update("deleted_at", now).Error
+}
+
+// FindTransactionsPage returns up to limit not deleted transactions of a user with their categories
+// ordered by date and ID, starting after the transaction after or from the first one when it is nil.
+// start and end are included and nil leaves that end open. With categoryIDs only transactions of
+// those categories are returned.
+func FindTransactionsPage(userID uuid.UUID, start, end *time.Time, categoryIDs []uuid.UUID, after *models.Transaction, limit int) ([]models.Transaction, error) {
+	db := database.DB
+
+	query := db.Preload("Category").Where("user_id = ? AND deleted_at IS NULL", userID)
+	if start != nil {
+		query = query.Where("date >= ?", *start)
+	}
+	if end != nil {
+		query = query.Where("date <= ?", *end)
+	}
+	if len(categoryIDs) > 0 {
+		query = query.Where("category_id IN ?", categoryIDs)
+	}
+	if after != nil {
+		query = query.Where("(date, id) > (?, ?)", after.Date, after.ID)
+	}
+
+	var transactions []models.Transaction
+	err := query.Order("date ASC, id ASC").Limit(limit).Find(&transactions).Error
+	return transactions, err
+}
@@ -0,0 +1,82 @@
+package repositories
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/KashyretsIvanna/voice-balance/database"
+	models "github.com/KashyretsIvanna/voice-balance/internals/model"
+	"github.com/google/uuid"
+	"gorm.io/gorm/clause"
+)
+
+// SaveExchangeRates inserts the rates, a rate of a currency on a date that is already stored is replaced
+func SaveExchangeRates(rates []models.ExchangeRate) error {
+	db := database.DB
+
+	if len(rates) == 0 {
+		return nil
+	}
+	return db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "currency"}, {Name: "date"}},
+		DoUpdates: clause.AssignmentColumns([]string{"rate", "source", "updated_at"}),
+	}).Create(&rates).Error
+}
+
+// FindExchangeRates returns the rates between start and end ordered by date,
+// only of one currency when it is not empty
+func FindExchangeRates(currency string, start, end time.Time) ([]models.ExchangeRate, error) {
+	db := database.DB
+
+	query := db.Where("date BETWEEN ? AND ?", start, end)
+	if currency != "" {
+		query = query.Where("currency = ?", currency)
+	}
+
+	var rates []models.ExchangeRate
+	err := query.Order("date ASC, currency ASC").Find(&rates).Error
+	return rates, err
+}
+
+// FindCurrenciesWithoutRates returns the currencies of the not deleted transactions of a user
+// that cannot be converted into currency because there is no exchange rate for them
+func FindCurrenciesWithoutRates(userID uuid.UUID, currency string) ([]string, error) {
+	db := database.DB
+
+	// The report currency needs rates as well when there is anything to convert,
+	// unless it is the one rates are given in
+	var missing []string
+	err := db.Raw("WITH foreign_currencies AS ("+
+		"SELECT DISTINCT currency FROM transactions "+
+		"WHERE user_id = @user AND deleted_at IS NULL AND currency <> @currency) "+
+		"SELECT code FROM ("+
+		"SELECT currency AS code FROM foreign_currencies "+
+		"UNION SELECT CAST(@currency AS text) FROM foreign_currencies) codes "+
+		"WHERE code <> @base AND NOT EXISTS (SELECT 1 FROM exchange_rates WHERE exchange_rates.currency = codes.code) "+
+		"ORDER BY code",
+		sql.Named("user", userID), sql.Named("currency", currency), sql.Named("base", models.DefaultCurrency)).
+		Scan(&missing).Error
+	return missing, err
+}
+
+// exchangeRateSQL selects the rate of a currency on the date of a transaction: the latest one
+// on or before it, otherwise the earliest one after it
+func exchangeRateSQL(currency string) string {
+	return fmt.Sprintf(`CASE WHEN %[1]s = '%[2]s' THEN 1 ELSE COALESCE(
+		(SELECT r.rate FROM exchange_rates r WHERE r.currency = %[1]s AND r.date <= transactions.date ORDER BY r.date DESC LIMIT 1),
+		(SELECT r.rate FROM exchange_rates r WHERE r.currency = %[1]s ORDER BY r.date ASC LIMIT 1)) END`, currency, models.DefaultCurrency)
+}
+
+// amountSQL is the amount of a transaction in the currency of the filter with its arguments.
+// Converted amounts are rounded to whole minor units, so their sum is exact.
+// Without a currency amounts are taken as they are stored.
+func amountSQL(filter CategoryTotalsFilter) (string, []interface{}) {
+	if filter.Currency == "" {
+		return "transactions.amount", nil
+	}
+
+	expression := "ROUND(transactions.amount * CASE WHEN transactions.currency = ? THEN 1 ELSE " +
+		exchangeRateSQL("transactions.currency") + " / " + exchangeRateSQL("?") + " END)"
+	return expression, []interface{}{filter.Currency, filter.Currency, filter.Currency, filter.Currency}
+}
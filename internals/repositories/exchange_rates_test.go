@@ -0,0 +1,148 @@
+package repositories
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/KashyretsIvanna/voice-balance/database/dbtest"
+	models "github.com/KashyretsIvanna/voice-balance/internals/model"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+func testDay(month time.Month, dayOfMonth int) time.Time {
+	return time.Date(2024, month, dayOfMonth, 12, 0, 0, 0, time.UTC)
+}
+
+// saveTestTransactions saves the transactions for a new user and returns its ID
+func saveTestTransactions(t *testing.T, db *gorm.DB, transactions ...*models.Transaction) uuid.UUID {
+	t.Helper()
+	userID := uuid.New()
+	category := &models.Category{Name: "Подорожі", Type: models.CategoryTypeExpense, UserID: userID}
+	if err := db.Create(category).Error; err != nil {
+		t.Fatal(err)
+	}
+	for _, transaction := range transactions {
+		transaction.UserID = userID
+		transaction.CategoryID = category.ID
+		if err := db.Create(transaction).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	return userID
+}
+
+func TestFindCurrenciesWithoutRates(t *testing.T) {
+	db := dbtest.Use(t)
+	if err := SaveExchangeRates([]models.ExchangeRate{{Currency: "USD", Date: testDay(time.March, 1), Rate: 40.12}}); err != nil {
+		t.Fatal(err)
+	}
+	deleted := testDay(time.April, 2)
+	userID := saveTestTransactions(t, db,
+		&models.Transaction{Amount: 10000, Currency: "UAH", Date: testDay(time.April, 1)},
+		&models.Transaction{Amount: 2500, Currency: "USD", Date: testDay(time.April, 1)},
+		&models.Transaction{Amount: 700, Currency: "EUR", Date: testDay(time.April, 1)},
+		&models.Transaction{Amount: 300, Currency: "EUR", Date: testDay(time.April, 3)},
+		&models.Transaction{Amount: 900, Currency: "PLN", Date: testDay(time.April, 2), DeletedAt: &deleted},
+	)
+	onlyHryvnias := saveTestTransactions(t, db, &models.Transaction{Amount: 10000, Currency: "UAH", Date: testDay(time.April, 1)})
+
+	tests := []struct {
+		userID   uuid.UUID
+		currency string
+		want     []string
+	}{
+		{userID, "UAH", []string{"EUR"}},
+		{userID, "USD", []string{"EUR"}},
+		{userID, "EUR", nil},
+		// The report currency needs rates of its own
+		{userID, "GBP", []string{"EUR", "GBP"}},
+		// Nothing is converted
+		{onlyHryvnias, "UAH", nil},
+		{onlyHryvnias, "GBP", []string{"GBP"}},
+	}
+	for _, test := range tests {
+		missing, err := FindCurrenciesWithoutRates(test.userID, test.currency)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if strings.Join(missing, ",") != strings.Join(test.want, ",") {
+			t.Errorf("in %s: got %v, want %v", test.currency, missing, test.want)
+		}
+	}
+}
+
+// convertedAmount is amountSQL of one transaction, nil when it cannot be converted
+func convertedAmount(t *testing.T, db *gorm.DB, transaction *models.Transaction, currency string) *int64 {
+	t.Helper()
+	amount, args := amountSQL(CategoryTotalsFilter{Currency: currency})
+	var converted []*int64
+	err := db.Model(&models.Transaction{}).Select("CAST("+amount+" AS bigint)", args...).
+		Where("id = ?", transaction.ID).Scan(&converted).Error
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(converted) != 1 {
+		t.Fatalf("got %d rows for one transaction", len(converted))
+	}
+	return converted[0]
+}
+
+func TestAmountSQL(t *testing.T) {
+	db := dbtest.Use(t)
+	err := SaveExchangeRates([]models.ExchangeRate{
+		{Currency: "USD", Date: testDay(time.March, 1), Rate: 40.12},
+		{Currency: "USD", Date: testDay(time.April, 1), Rate: 41.53},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	inDollars := func(amount models.Money, date time.Time) *models.Transaction {
+		return &models.Transaction{Amount: amount, Currency: "USD", Date: date}
+	}
+	inHryvnias := &models.Transaction{Amount: 10000, Currency: "UAH", Date: testDay(time.April, 10)}
+	inEuros := &models.Transaction{Amount: 700, Currency: "EUR", Date: testDay(time.April, 10)}
+	tests := []struct {
+		name        string
+		transaction *models.Transaction
+		currency    string
+		want        *int64
+	}{
+		// 1001 * 41.53 = 41571.53
+		{"rounded to kopecks", inDollars(1001, testDay(time.April, 10)), "UAH", amountOf(41572)},
+		// 999 * 40.12 = 40079.88 with the rate before the transaction
+		{"latest rate before", inDollars(999, testDay(time.March, 31)), "UAH", amountOf(40080)},
+		// 250 * 40.12 with the earliest rate
+		{"before any rate", inDollars(250, testDay(time.February, 1)), "UAH", amountOf(10030)},
+		// 10000 / 41.53 = 240.79
+		{"rounded to cents", inHryvnias, "USD", amountOf(241)},
+		{"same currency", inDollars(1234, testDay(time.April, 10)), "USD", amountOf(1234)},
+		// There are no rates for euros at all
+		{"same currency without rates", inEuros, "EUR", amountOf(700)},
+		{"missing rate", inEuros, "UAH", nil},
+		{"missing rate of the report currency", inHryvnias, "EUR", nil},
+		{"without a currency", inEuros, "", amountOf(700)},
+	}
+	for _, test := range tests {
+		if test.transaction.ID == uuid.Nil {
+			saveTestTransactions(t, db, test.transaction)
+		}
+		got := convertedAmount(t, db, test.transaction, test.currency)
+		if (got == nil) != (test.want == nil) || got != nil && *got != *test.want {
+			t.Errorf("%s: got %v, want %v", test.name, valueOf(got), valueOf(test.want))
+		}
+	}
+}
+
+func amountOf(amount int64) *int64 {
+	return &amount
+}
+
+func valueOf(amount *int64) interface{} {
+	if amount == nil {
+		return "NULL"
+	}
+	return *amount
+}
@@ -0,0 +1,50 @@
+package repositories
+
+import (
+	"time"
+
+	"github.com/KashyretsIvanna/voice-balance/database"
+	models "github.com/KashyretsIvanna/voice-balance/internals/model"
+	"github.com/google/uuid"
+)
+
+// SaveNotification adds a notification to the inbox of its user
+func SaveNotification(notification *models.Notification) error {
+	db := database.DB
+
+	return db.Create(notification).Error
+}
+
+// FindNotifications returns the inbox of a user, newest first
+func FindNotifications(userID uuid.UUID, unreadOnly bool) ([]models.Notification, error) {
+	db := database.DB
+
+	query := db.Where("user_id = ?", userID)
+	if unreadOnly {
+		query = query.Where("read_at IS NULL")
+	}
+
+	var notifications []models.Notification
+	err := query.Order("created_at DESC").Find(&notifications).Error
+	return notifications, err
+}
+
+// MarkNotificationRead sets the read time of a notification of the user.
+// Returns gorm.ErrRecordNotFound if there is no such notification.
+func MarkNotificationRead(userID, notificationID uuid.UUID, readAt time.Time) (*models.Notification, error) {
+	db := database.DB
+
+	notification := &models.Notification{}
+	if err := db.Where("id = ? AND user_id = ?", notificationID, userID).First(notification).Error; err != nil {
+		return nil, err
+	}
+	if notification.ReadAt != nil {
+		return notification, nil
+	}
+
+	notification.ReadAt = &readAt
+	if err := db.Model(notification).Update("read_at", readAt).Error; err != nil {
+		return nil, err
+	}
+	return notification, nil
+}
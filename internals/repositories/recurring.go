@@ -0,0 +1,125 @@
+package repositories
+
+import (
+	"time"
+
+	"github.com/KashyretsIvanna/voice-balance/database"
+	models "github.com/KashyretsIvanna/voice-balance/internals/model"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// SaveRecurringTransaction creates a new recurring transaction in the database
+func SaveRecurringTransaction(recurring *models.RecurringTransaction) error {
+	db := database.DB
+
+	return db.Create(recurring).Error
+}
+
+// FindRecurringTransactions returns the not deleted recurring transactions of a user ordered by the next occurrence
+func FindRecurringTransactions(userID uuid.UUID) ([]models.RecurringTransaction, error) {
+	db := database.DB
+
+	var recurring []models.RecurringTransaction
+	err := db.Where("user_id = ? AND deleted_at IS NULL", userID).
+		Order("next_date ASC NULLS LAST").Find(&recurring).Error
+	return recurring, err
+}
+
+// FindRecurringTransactionByID returns a not deleted recurring transaction owned by the user.
+// Returns gorm.ErrRecordNotFound if there is no such recurring transaction.
+func FindRecurringTransactionByID(userID, recurringID uuid.UUID) (*models.RecurringTransaction, error) {
+	db := database.DB
+
+	recurring := &models.RecurringTransaction{}
+	err := db.Where("id = ? AND user_id = ? AND deleted_at IS NULL", recurringID, userID).
+		First(recurring).Error
+	if err != nil {
+		return nil, err
+	}
+	return recurring, nil
+}
+
+// FindDueRecurringTransactions returns up to limit not deleted recurring transactions
+// of all users with an occurrence due at or before now
+func FindDueRecurringTransactions(now time.Time, limit int) ([]models.RecurringTransaction, error) {
+	db := database.DB
+
+	var recurring []models.RecurringTransaction
+	err := db.Where("deleted_at IS NULL AND next_date IS NOT NULL AND next_date <= ?", now).
+		Order("next_date ASC").Limit(limit).Find(&recurring).Error
+	return recurring, err
+}
+
+// LastRecurringOccurrence returns the date of the latest occurrence posted for a recurring transaction,
+// or nil if none was posted
+func LastRecurringOccurrence(recurringID uuid.UUID) (*time.Time, error) {
+	db := database.DB
+
+	var last *time.Time
+	err := db.Model(&models.Transaction{}).
+		Select("MAX(occurrence_date)").
+		Where("recurring_id = ?", recurringID).
+		Scan(&last).Error
+	return last, err
+}
+
+// UpdateRecurringTransaction saves the given columns of an existing recurring transaction. When
+// next_date is among them, it is only saved while next_date is still loadedNextDate, false is
+// returned without saving anything when the scheduler moved it in the meantime.
+func UpdateRecurringTransaction(recurring *models.RecurringTransaction, columns map[string]interface{}, loadedNextDate *time.Time) (bool, error) {
+	db := database.DB
+
+	query := db.Model(recurring)
+	if _, ok := columns["next_date"]; ok {
+		if loadedNextDate == nil {
+			query = query.Where("next_date IS NULL")
+		} else {
+			query = query.Where("next_date = ?", *loadedNextDate)
+		}
+	}
+	result := query.Updates(columns)
+	return result.RowsAffected > 0, result.Error
+}
+
+// SoftDeleteRecurringTransaction marks the recurring transaction as deleted without removing the row
+func SoftDeleteRecurringTransaction(recurring *models.RecurringTransaction) error {
+	db := database.DB
+
+	now := time.Now()
+	recurring.DeletedAt = &now
+	return db.Model(recurring).Update("deleted_at", now).Error
+}
+
+// PostRecurringOccurrence saves the transaction of the occurrence at recurring.NextDate and moves
+// NextDate to next in one database transaction. It returns false without saving anything when
+// NextDate was moved in the meantime, e.g. by another replica. A transaction for an occurrence
+// that was posted before is skipped by the unique index on the occurrence, NextDate is still
+// moved past it but false is returned as nothing was posted.
+func PostRecurringOccurrence(recurring *models.RecurringTransaction, transaction *models.Transaction, next *time.Time) (bool, error) {
+	db := database.DB
+
+	moved, claimed := false, false
+	err := db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.RecurringTransaction{}).
+			Where("id = ? AND next_date = ? AND deleted_at IS NULL", recurring.ID, recurring.NextDate).
+			Update("next_date", next)
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		moved = true
+
+		result = tx.Clauses(clause.OnConflict{DoNothing: true}).Create(transaction)
+		claimed = result.RowsAffected > 0
+		return result.Error
+	})
+	if err != nil {
+		return false, err
+	}
+
+	if moved {
+		recurring.NextDate = next
+	}
+	return claimed, nil
+}
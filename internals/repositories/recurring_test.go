@@ -0,0 +1,202 @@
+package repositories
+
+import (
+	"testing"
+	"time"
+
+	"github.com/KashyretsIvanna/voice-balance/database/dbtest"
+	models "github.com/KashyretsIvanna/voice-balance/internals/model"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// saveTestRecurring saves a monthly recurring transaction with an occurrence due on the first of May
+func saveTestRecurring(t *testing.T, db *gorm.DB) *models.RecurringTransaction {
+	t.Helper()
+	userID := uuid.New()
+	category := &models.Category{Name: "Оренда", Type: models.CategoryTypeExpense, UserID: userID}
+	if err := db.Create(category).Error; err != nil {
+		t.Fatal(err)
+	}
+	due := time.Date(2024, time.May, 1, 9, 0, 0, 0, time.UTC)
+	recurring := &models.RecurringTransaction{
+		UserID:     userID,
+		CategoryID: category.ID,
+		Amount:     50000,
+		Currency:   "UAH",
+		Frequency:  models.FrequencyMonthly,
+		Interval:   1,
+		StartDate:  due,
+		NextDate:   &due,
+	}
+	if err := db.Create(recurring).Error; err != nil {
+		t.Fatal(err)
+	}
+	return recurring
+}
+
+func occurrenceOf(recurring *models.RecurringTransaction) (*models.Transaction, *time.Time) {
+	due := *recurring.NextDate
+	next := due.AddDate(0, 1, 0)
+	return &models.Transaction{
+		Amount:         recurring.Amount,
+		Currency:       recurring.Currency,
+		Date:           due,
+		UserID:         recurring.UserID,
+		CategoryID:     recurring.CategoryID,
+		RecurringID:    &recurring.ID,
+		OccurrenceDate: &due,
+	}, &next
+}
+
+func storedNextDate(t *testing.T, db *gorm.DB, recurringID uuid.UUID) *time.Time {
+	t.Helper()
+	stored := &models.RecurringTransaction{}
+	if err := db.First(stored, "id = ?", recurringID).Error; err != nil {
+		t.Fatal(err)
+	}
+	return stored.NextDate
+}
+
+func postedOccurrences(t *testing.T, db *gorm.DB, recurringID uuid.UUID) int64 {
+	t.Helper()
+	var count int64
+	if err := db.Model(&models.Transaction{}).Where("recurring_id = ?", recurringID).Count(&count).Error; err != nil {
+		t.Fatal(err)
+	}
+	return count
+}
+
+func TestPostRecurringOccurrence(t *testing.T) {
+	db := dbtest.Use(t)
+	recurring := saveTestRecurring(t, db)
+	due := *recurring.NextDate
+	// Another replica loaded the recurring transaction at the same time
+	stale := *recurring
+
+	transaction, next := occurrenceOf(recurring)
+	claimed, err := PostRecurringOccurrence(recurring, transaction, next)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !claimed || !recurring.NextDate.Equal(*next) {
+		t.Fatalf("claimed %v with NextDate %v, want the occurrence posted and NextDate %v", claimed, recurring.NextDate, next)
+	}
+	if stored := storedNextDate(t, db, recurring.ID); stored == nil || !stored.Equal(*next) {
+		t.Errorf("stored next_date %v, want %v", stored, next)
+	}
+
+	transaction, next = occurrenceOf(&stale)
+	claimed, err = PostRecurringOccurrence(&stale, transaction, next)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if claimed || !stale.NextDate.Equal(due) {
+		t.Errorf("the stale copy claimed %v with NextDate %v, want nothing claimed", claimed, stale.NextDate)
+	}
+	if count := postedOccurrences(t, db, recurring.ID); count != 1 {
+		t.Errorf("%d transactions posted, want 1", count)
+	}
+}
+
+func TestPostRecurringOccurrenceSkipsPostedOccurrence(t *testing.T) {
+	db := dbtest.Use(t)
+	recurring := saveTestRecurring(t, db)
+	due := *recurring.NextDate
+
+	transaction, next := occurrenceOf(recurring)
+	if _, err := PostRecurringOccurrence(recurring, transaction, next); err != nil {
+		t.Fatal(err)
+	}
+	// A schedule change put next_date back on the posted occurrence
+	if err := db.Model(recurring).Update("next_date", due).Error; err != nil {
+		t.Fatal(err)
+	}
+	recurring.NextDate = &due
+
+	transaction, next = occurrenceOf(recurring)
+	claimed, err := PostRecurringOccurrence(recurring, transaction, next)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if claimed {
+		t.Errorf("the posted occurrence was claimed again")
+	}
+	if !recurring.NextDate.Equal(*next) {
+		t.Errorf("NextDate %v, want it moved past the posted occurrence to %v", recurring.NextDate, next)
+	}
+	if count := postedOccurrences(t, db, recurring.ID); count != 1 {
+		t.Errorf("%d transactions posted, want 1", count)
+	}
+}
+
+func TestPostRecurringOccurrenceSkipsDeleted(t *testing.T) {
+	db := dbtest.Use(t)
+	recurring := saveTestRecurring(t, db)
+	if err := SoftDeleteRecurringTransaction(recurring); err != nil {
+		t.Fatal(err)
+	}
+
+	transaction, next := occurrenceOf(recurring)
+	claimed, err := PostRecurringOccurrence(recurring, transaction, next)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if claimed || postedOccurrences(t, db, recurring.ID) != 0 {
+		t.Errorf("an occurrence of a deleted recurring transaction was posted")
+	}
+}
+
+func TestUpdateRecurringTransactionKeepsNextDateMovedByScheduler(t *testing.T) {
+	db := dbtest.Use(t)
+	recurring := saveTestRecurring(t, db)
+	loaded := *recurring
+	loadedNextDate := loaded.NextDate
+
+	// The scheduler posts the occurrence after the edit loaded the recurring transaction
+	transaction, next := occurrenceOf(recurring)
+	if _, err := PostRecurringOccurrence(recurring, transaction, next); err != nil {
+		t.Fatal(err)
+	}
+
+	// A new schedule computed from the loaded row is not saved
+	weekly := loadedNextDate.AddDate(0, 0, 7)
+	updated, err := UpdateRecurringTransaction(&loaded, map[string]interface{}{
+		"frequency": models.FrequencyWeekly,
+		"next_date": weekly,
+	}, loadedNextDate)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if updated {
+		t.Errorf("a schedule computed before the occurrence was posted was saved")
+	}
+	if stored := storedNextDate(t, db, recurring.ID); stored == nil || !stored.Equal(*next) {
+		t.Errorf("stored next_date %v, want %v left by the scheduler", stored, next)
+	}
+
+	// Other columns are saved without touching next_date
+	updated, err = UpdateRecurringTransaction(&loaded, map[string]interface{}{"description": "квартира"}, loadedNextDate)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !updated {
+		t.Errorf("the description was not saved")
+	}
+	stored := &models.RecurringTransaction{}
+	if err := db.First(stored, "id = ?", recurring.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if stored.Description != "квартира" || stored.NextDate == nil || !stored.NextDate.Equal(*next) {
+		t.Errorf("stored description %q and next_date %v, want %q and %v", stored.Description, stored.NextDate, "квартира", next)
+	}
+
+	// A schedule computed from the current next_date is saved
+	updated, err = UpdateRecurringTransaction(&loaded, map[string]interface{}{"next_date": weekly}, next)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored := storedNextDate(t, db, recurring.ID); !updated || stored == nil || !stored.Equal(weekly) {
+		t.Errorf("updated %v with next_date %v, want %v", updated, stored, weekly)
+	}
+}
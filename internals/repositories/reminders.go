@@ -0,0 +1,156 @@
+package repositories
+
+import (
+	"time"
+
+	"github.com/KashyretsIvanna/voice-balance/database"
+	models "github.com/KashyretsIvanna/voice-balance/internals/model"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ReminderFilter narrows down the reminders returned by FindReminders.
+// Nil fields are not applied.
+type ReminderFilter struct {
+	DueFrom     *time.Time
+	DueTo       *time.Time
+	IsCompleted *bool
+}
+
+// SaveReminder creates a new reminder in the database
+func SaveReminder(reminder *models.Reminder) error {
+	db := database.DB
+
+	return db.Create(reminder).Error
+}
+
+// FindReminders returns the not deleted reminders of a user ordered by due date
+func FindReminders(userID uuid.UUID, filter ReminderFilter) ([]models.Reminder, error) {
+	db := database.DB
+
+	query := db.Where("user_id = ? AND deleted_at IS NULL", userID)
+
+	if filter.DueFrom != nil {
+		query = query.Where("due_date >= ?", *filter.DueFrom)
+	}
+
+	if filter.DueTo != nil {
+		query = query.Where("due_date <= ?", *filter.DueTo)
+	}
+
+	if filter.IsCompleted != nil {
+		query = query.Where("is_completed = ?", *filter.IsCompleted)
+	}
+
+	var reminders []models.Reminder
+	err := query.Order("due_date ASC").Find(&reminders).Error
+	return reminders, err
+}
+
+// FindReminderByID returns a not deleted reminder owned by the user.
+// Returns gorm.ErrRecordNotFound if there is no such reminder.
+func FindReminderByID(userID, reminderID uuid.UUID) (*models.Reminder, error) {
+	db := database.DB
+
+	reminder := &models.Reminder{}
+	err := db.Where("id = ? AND user_id = ? AND deleted_at IS NULL", reminderID, userID).
+		First(reminder).Error
+	if err != nil {
+		return nil, err
+	}
+	return reminder, nil
+}
+
+// UpdateReminder saves the given columns of an existing reminder. The other columns, like the
+// delivery lease the dispatcher may hold meanwhile, are left as they are in the database.
+func UpdateReminder(reminder *models.Reminder, columns map[string]interface{}) error {
+	db := database.DB
+
+	return db.Model(reminder).Updates(columns).Error
+}
+
+// SoftDeleteReminder marks the reminder as deleted without removing the row
+func SoftDeleteReminder(reminder *models.Reminder) error {
+	db := database.DB
+
+	now := time.Now()
+	reminder.DeletedAt = &now
+	return db.Model(reminder).Update("deleted_at", now).Error
+}
+
+// FindReminderDeliveries returns the delivery log of a reminder of the user, newest first
+func FindReminderDeliveries(userID, reminderID uuid.UUID) ([]models.ReminderDelivery, error) {
+	db := database.DB
+
+	var deliveries []models.ReminderDelivery
+	err := db.Where("reminder_id = ? AND user_id = ?", reminderID, userID).
+		Order("created_at DESC").Find(&deliveries).Error
+	return deliveries, err
+}
+
+// ReminderRepository runs the queries of the reminder dispatcher on DB
+type ReminderRepository struct {
+	DB *gorm.DB
+}
+
+// NewReminderRepository returns a reminder repository working on db
+func NewReminderRepository(db *gorm.DB) *ReminderRepository {
+	return &ReminderRepository{DB: db}
+}
+
+// FindDueReminders returns up to limit reminders of all users whose notification is due at or before now:
+// not completed, not notified yet, past the due date or the end of the snooze and not waiting for a retry
+func (r *ReminderRepository) FindDueReminders(now time.Time, limit int) ([]models.Reminder, error) {
+	var reminders []models.Reminder
+	err := r.DB.Where("deleted_at IS NULL AND is_completed = ? AND notified_at IS NULL", false).
+		Where("COALESCE(snoozed_until, due_date) <= ?", now).
+		Where("retry_at IS NULL OR retry_at <= ?", now).
+		Order("due_date ASC").Limit(limit).Find(&reminders).Error
+	return reminders, err
+}
+
+// ClaimReminder reserves the delivery of a due reminder until leaseUntil, so that other
+// replicas skip it. It returns false when the reminder was claimed or changed in the meantime.
+func (r *ReminderRepository) ClaimReminder(reminder *models.Reminder, now, leaseUntil time.Time) (bool, error) {
+	result := r.DB.Model(&models.Reminder{}).
+		Where("id = ? AND attempts = ? AND notified_at IS NULL AND (retry_at IS NULL OR retry_at <= ?)", reminder.ID, reminder.Attempts, now).
+		Updates(map[string]interface{}{"retry_at": leaseUntil, "attempts": reminder.Attempts + 1})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return false, nil
+	}
+
+	reminder.RetryAt = &leaseUntil
+	reminder.Attempts++
+	return true, nil
+}
+
+// FindUser returns the not deleted user a reminder belongs to
+func (r *ReminderRepository) FindUser(userID uuid.UUID) (*models.User, error) {
+	user := &models.User{}
+	if err := r.DB.Where("id = ? AND deleted_at IS NULL", userID).First(user).Error; err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// UpdateReminderColumns changes only the given columns of a reminder
+func (r *ReminderRepository) UpdateReminderColumns(reminderID uuid.UUID, columns map[string]interface{}) error {
+	return r.DB.Model(&models.Reminder{}).Where("id = ?", reminderID).Updates(columns).Error
+}
+
+// SaveReminderDelivery adds an entry to the delivery log
+func (r *ReminderRepository) SaveReminderDelivery(delivery *models.ReminderDelivery) error {
+	return r.DB.Create(delivery).Error
+}
+
+// FindDeliveredChannels returns the channels a notification of the reminder due at dueAt was sent through
+func (r *ReminderRepository) FindDeliveredChannels(reminderID uuid.UUID, dueAt time.Time) ([]string, error) {
+	var channels []string
+	err := r.DB.Model(&models.ReminderDelivery{}).
+		Where("reminder_id = ? AND due_at = ? AND status = ?", reminderID, dueAt, models.DeliverySent).
+		Distinct().Pluck("channel", &channels).Error
+	return channels, err
+}
@@ -1,17 +1,115 @@
 package repositories
 
 import (
+	"time"
 
 	"github.com/KashyretsIvanna/voice-balance/database"
 	models "github.com/KashyretsIvanna/voice-balance/internals/model"
 	"github.com/google/uuid"
+	"gorm.io/gorm"
 )
 
-func FindTransactionsByCategoryAndDate(userID uuid.UUID, startDate, endDate string) ([]models.Transaction, error) {
+// CategoryTotal is the sum and the number of the transactions of one category
+type CategoryTotal struct {
+	CategoryID   uuid.UUID
+	CategoryName string
+	CategoryType string
+	Total        models.Money
+	Count        int64
+}
+
+// CategoryTotalsFilter narrows down the transactions summed by SumTransactionsByCategory
+type CategoryTotalsFilter struct {
+	CategoryType string
+	CategoryID   *uuid.UUID
+	// Currency the amounts are converted into with the rates of the transaction dates,
+	// without it amounts are summed as they are stored
+	Currency string
+	// ExcludeTransaction leaves one transaction out of the sums
+	ExcludeTransaction *uuid.UUID
+	// Rollup adds the subcategories of CategoryID at any depth to the sums. The services
+	// also nest the totals of subcategories into the ones of their parents with it.
+	Rollup bool
+}
+
+// whereCategory narrows a query joined with categories down to the category of the filter
+func whereCategory(query *gorm.DB, filter CategoryTotalsFilter) *gorm.DB {
+	if filter.CategoryID == nil {
+		return query
+	}
+	if filter.Rollup {
+		return query.Where("categories.id IN ("+categoryTreeSQL+")", *filter.CategoryID)
+	}
+	return query.Where("categories.id = ?", *filter.CategoryID)
+}
+
+// SumTransactionsByCategory groups the transactions of a user between start and end
+// by category and sums them up in the database. The largest totals come first.
+func SumTransactionsByCategory(userID uuid.UUID, start, end time.Time, filter CategoryTotalsFilter) ([]CategoryTotal, error) {
+	db := database.DB
+
+	amount, args := amountSQL(filter)
+	query := db.Model(&models.Transaction{}).
+		Select("categories.id AS category_id, categories.name AS category_name, categories.type AS category_type, "+
+			"CAST(SUM("+amount+") AS bigint) AS total, COUNT(*) AS count", args...).
+		Joins("JOIN categories ON categories.id = transactions.category_id").
+		Where("transactions.user_id = ? AND transactions.deleted_at IS NULL", userID).
+		Where("transactions.date BETWEEN ? AND ?", start, end)
+
+	if filter.CategoryType != "" {
+		query = query.Where("categories.type = ?", filter.CategoryType)
+	}
+	query = whereCategory(query, filter)
+	if filter.ExcludeTransaction != nil {
+		query = query.Where("transactions.id <> ?", *filter.ExcludeTransaction)
+	}
+
+	var totals []CategoryTotal
+	err := query.
+		Group("categories.id, categories.name, categories.type").
+		Order("total DESC").
+		Scan(&totals).Error
+	return totals, err
+}
+
+// PeriodTotal is the sum and the number of the transactions of one category type in one period
+type PeriodTotal struct {
+	// Period is the start of the period as a wall clock time of the time zone, its location is UTC
+	Period       time.Time
+	CategoryType string
+	Total        models.Money
+	Count        int64
+}
+
+// SumTransactionsByPeriod groups the transactions of a user between start and end by
+// calendar period and category type and sums them up in the database. unit is a
+// date_trunc unit (day, week, month or year), periods are computed in timeZone and
+// weeks begin weekOffset days before Monday. weekOffset must be 0 for other units.
+func SumTransactionsByPeriod(userID uuid.UUID, start, end time.Time, unit, timeZone string, weekOffset int, filter CategoryTotalsFilter) ([]PeriodTotal, error) {
 	db := database.DB
 
-	var transactions []models.Transaction
-	err := db.Where("user_id = ? AND date BETWEEN ? AND ?", userID, startDate, endDate).
-		Find(&transactions).Error
-	return transactions, err
+	period := "date_trunc(?, (transactions.date AT TIME ZONE ?) + ? * interval '1 day') - ? * interval '1 day'"
+	amount, amountArgs := amountSQL(filter)
+	args := append([]interface{}{unit, timeZone, weekOffset, weekOffset}, amountArgs...)
+	query := db.Model(&models.Transaction{}).
+		Select("("+period+") AS period, categories.type AS category_type, "+
+			"CAST(SUM("+amount+") AS bigint) AS total, COUNT(*) AS count", args...).
+		Joins("JOIN categories ON categories.id = transactions.category_id").
+		Where("transactions.user_id = ? AND transactions.deleted_at IS NULL", userID).
+		Where("transactions.date BETWEEN ? AND ?", start, end)
+
+	if filter.CategoryType != "" {
+		query = query.Where("categories.type = ?", filter.CategoryType)
+	}
+	query = whereCategory(query, filter)
+	if filter.ExcludeTransaction != nil {
+		query = query.Where("transactions.id <> ?", *filter.ExcludeTransaction)
+	}
+
+	var totals []PeriodTotal
+	err := query.
+		Group("period, categories.type").
+		Order("period").
+		Scan(&totals).Error
+	return totals, err
 }
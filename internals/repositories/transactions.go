@@ -1,8 +1,15 @@
 package repositories
 
 import (
+	"fmt"
+	"strings"
+	"time"
+
 	"github.com/KashyretsIvanna/voice-balance/database"
 	models "github.com/KashyretsIvanna/voice-balance/internals/model"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 
@@ -11,3 +18,235 @@ func SaveTransaction(transaction *models.Transaction) error {
 
 	return db.Create(transaction).Error
 }
+
+// FindTransactionByID returns a transaction owned by the user with its category, a deleted one
+// only when deleted is true. Returns gorm.ErrRecordNotFound if there is no such transaction.
+func FindTransactionByID(userID, transactionID uuid.UUID, deleted bool) (*models.Transaction, error) {
+	db := database.DB
+
+	query := db.Preload("Category").Where("id = ? AND user_id = ?", transactionID, userID)
+	if deleted {
+		query = query.Where("deleted_at IS NOT NULL")
+	} else {
+		query = query.Where("deleted_at IS NULL")
+	}
+
+	transaction := &models.Transaction{}
+	if err := query.First(transaction).Error; err != nil {
+		return nil, err
+	}
+	return transaction, nil
+}
+
+// UpdateTransaction saves all fields of an existing transaction, its category is not saved
+func UpdateTransaction(transaction *models.Transaction) error {
+	db := database.DB
+
+	return db.Omit(clause.Associations).Save(transaction).Error
+}
+
+// SoftDeleteTransaction marks the transaction as deleted without removing the row
+func SoftDeleteTransaction(transaction *models.Transaction) error {
+	db := database.DB
+
+	now := time.Now()
+	transaction.DeletedAt = &now
+	return db.Model(transaction).Update("deleted_at", now).Error
+}
+
+// RestoreTransaction clears the deletion mark of a soft deleted transaction and saves its category
+func RestoreTransaction(transaction *models.Transaction) error {
+	db := database.DB
+
+	transaction.DeletedAt = nil
+	return db.Model(transaction).
+		Updates(map[string]interface{}{"deleted_at": nil, "category_id": transaction.CategoryID, "updated_at": time.Now()}).Error
+}
+
+// RecategorizeTransactions moves not deleted transactions of a user into a category.
+// Either all of them are moved or, when one of them is not found, none.
+func RecategorizeTransactions(userID uuid.UUID, transactionIDs []uuid.UUID, categoryID uuid.UUID) (int64, error) {
+	db := database.DB
+
+	var updated int64
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := lockTransactions(tx, userID, transactionIDs); err != nil {
+			return err
+		}
+		result := tx.Model(&models.Transaction{}).
+			Where("id IN ? AND user_id = ?", transactionIDs, userID).
+			Updates(map[string]interface{}{"category_id": categoryID, "updated_at": time.Now()})
+		updated = result.RowsAffected
+		return result.Error
+	})
+	return updated, err
+}
+
+// CategoryMove is a change of the category of transactions from one category into another
+type CategoryMove struct {
+	From uuid.UUID
+	To   uuid.UUID
+}
+
+// RecategorizeTransactionGroups moves not deleted transactions of a user into other categories,
+// groups maps a move to the transactions it applies to. A transaction is moved only while it
+// is still in the category the move starts from, the ones deleted or moved elsewhere since
+// they were read are skipped. Returns the number of transactions moved.
+func RecategorizeTransactionGroups(userID uuid.UUID, groups map[CategoryMove][]uuid.UUID) (int64, error) {
+	db := database.DB
+
+	var updated int64
+	err := db.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		for move, ids := range groups {
+			result := tx.Model(&models.Transaction{}).
+				Where("id IN ? AND user_id = ? AND category_id = ? AND deleted_at IS NULL", ids, userID, move.From).
+				Updates(map[string]interface{}{"category_id": move.To, "updated_at": now})
+			if result.Error != nil {
+				return result.Error
+			}
+			updated += result.RowsAffected
+		}
+		return nil
+	})
+	return updated, err
+}
+
+// SoftDeleteTransactions marks not deleted transactions of a user as deleted.
+// Either all of them are deleted or, when one of them is not found, none.
+func SoftDeleteTransactions(userID uuid.UUID, transactionIDs []uuid.UUID) (int64, error) {
+	db := database.DB
+
+	var deleted int64
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := lockTransactions(tx, userID, transactionIDs); err != nil {
+			return err
+		}
+		result := tx.Model(&models.Transaction{}).
+			Where("id IN ? AND user_id = ?", transactionIDs, userID).
+			Update("deleted_at", time.Now())
+		deleted = result.RowsAffected
+		return result.Error
+	})
+	return deleted, err
+}
+
+// lockTransactions locks the not deleted transactions of a user until the end of tx and
+// returns gorm.ErrRecordNotFound naming the ones that are not found
+func lockTransactions(tx *gorm.DB, userID uuid.UUID, transactionIDs []uuid.UUID) error {
+	var found []uuid.UUID
+	err := tx.Model(&models.Transaction{}).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id IN ? AND user_id = ? AND deleted_at IS NULL", transactionIDs, userID).
+		Pluck("id", &found).Error
+	if err != nil {
+		return err
+	}
+	if len(found) == len(transactionIDs) {
+		return nil
+	}
+
+	foundSet := make(map[uuid.UUID]bool, len(found))
+	for _, id := range found {
+		foundSet[id] = true
+	}
+	var missing []uuid.UUID
+	for _, id := range transactionIDs {
+		if !foundSet[id] {
+			missing = append(missing, id)
+		}
+	}
+	return fmt.Errorf("%w: transactions %v", gorm.ErrRecordNotFound, missing)
+}
+
+// transactionSortColumns are the columns transactions can be listed in the order of
+var transactionSortColumns = map[string]string{
+	"date":   "transactions.date",
+	"amount": "transactions.amount",
+}
+
+// TransactionListFilter narrows down, orders and pages the transactions listed by ListTransactions
+type TransactionListFilter struct {
+	// Deleted lists the deleted transactions instead of the others
+	Deleted      bool
+	CategoryIDs  []uuid.UUID
+	CategoryType string
+	Start, End   *time.Time
+	// Amounts are compared as they are stored, in the currency of each transaction
+	MinAmount, MaxAmount *models.Money
+	// Search is a text the description has to contain, case is ignored
+	Search string
+	// SortColumn is "date" or "amount", transactions with the same value are ordered by ID
+	SortColumn string
+	Ascending  bool
+	// AfterValue and AfterID continue a listing after the transaction with this sort value and ID
+	AfterValue interface{}
+	AfterID    *uuid.UUID
+	Limit      int
+}
+
+// ListTransactions returns a page of the transactions of a user with their categories,
+// ordered by the sort column and the ID so pages never overlap or skip a transaction
+func ListTransactions(userID uuid.UUID, filter TransactionListFilter) ([]models.Transaction, error) {
+	db := database.DB
+
+	column, ok := transactionSortColumns[filter.SortColumn]
+	if !ok {
+		return nil, fmt.Errorf("unknown sort column %q", filter.SortColumn)
+	}
+
+	query := db.Model(&models.Transaction{}).
+		Select("transactions.*").
+		Where("transactions.user_id = ?", userID)
+	if filter.Deleted {
+		query = query.Where("transactions.deleted_at IS NOT NULL")
+	} else {
+		query = query.Where("transactions.deleted_at IS NULL")
+	}
+
+	if len(filter.CategoryIDs) > 0 {
+		query = query.Where("transactions.category_id IN ?", filter.CategoryIDs)
+	}
+	if filter.CategoryType != "" {
+		query = query.Joins("JOIN categories ON categories.id = transactions.category_id").
+			Where("categories.type = ?", filter.CategoryType)
+	}
+	if filter.Start != nil {
+		query = query.Where("transactions.date >= ?", *filter.Start)
+	}
+	if filter.End != nil {
+		query = query.Where("transactions.date <= ?", *filter.End)
+	}
+	if filter.MinAmount != nil {
+		query = query.Where("transactions.amount >= ?", *filter.MinAmount)
+	}
+	if filter.MaxAmount != nil {
+		query = query.Where("transactions.amount <= ?", *filter.MaxAmount)
+	}
+	if filter.Search != "" {
+		query = query.Where("transactions.description ILIKE ?", "%"+likeEscaper.Replace(filter.Search)+"%")
+	}
+
+	direction := "DESC"
+	if filter.Ascending {
+		direction = "ASC"
+	}
+	if filter.AfterID != nil {
+		comparison := "<"
+		if filter.Ascending {
+			comparison = ">"
+		}
+		query = query.Where(fmt.Sprintf("(%s, transactions.id) %s (?, ?)", column, comparison), filter.AfterValue, *filter.AfterID)
+	}
+
+	var transactions []models.Transaction
+	err := query.
+		Preload("Category").
+		Order(fmt.Sprintf("%[1]s %[2]s, transactions.id %[2]s", column, direction)).
+		Limit(filter.Limit).
+		Find(&transactions).Error
+	return transactions, err
+}
+
+// likeEscaper makes the wildcards of a text match themselves in a LIKE pattern
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
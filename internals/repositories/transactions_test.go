@@ -0,0 +1,63 @@
+package repositories
+
+import (
+	"testing"
+	"time"
+
+	"github.com/KashyretsIvanna/voice-balance/database/dbtest"
+	models "github.com/KashyretsIvanna/voice-balance/internals/model"
+	"github.com/google/uuid"
+)
+
+func TestRecategorizeTransactionGroupsSkipsChangedTransactions(t *testing.T) {
+	db := dbtest.Use(t)
+	moved := &models.Transaction{Amount: 100, Currency: "UAH", Date: testDay(time.May, 1)}
+	movedElsewhere := &models.Transaction{Amount: 200, Currency: "UAH", Date: testDay(time.May, 2)}
+	deleted := &models.Transaction{Amount: 300, Currency: "UAH", Date: testDay(time.May, 3)}
+	notInFrom := &models.Transaction{Amount: 400, Currency: "UAH", Date: testDay(time.May, 4)}
+	userID := saveTestTransactions(t, db, moved, movedElsewhere, deleted, notInFrom)
+	travel := moved.CategoryID
+	taxi := &models.Category{Name: "Таксі", Type: models.CategoryTypeExpense, UserID: userID}
+	coffee := &models.Category{Name: "Кава", Type: models.CategoryTypeExpense, UserID: userID}
+	if err := db.Create([]*models.Category{taxi, coffee}).Error; err != nil {
+		t.Fatal(err)
+	}
+	othersTransaction := &models.Transaction{Amount: 500, Currency: "UAH", Date: testDay(time.May, 5)}
+	saveTestTransactions(t, db, othersTransaction)
+
+	// Changed by the user after the rules read them
+	if err := db.Model(movedElsewhere).Update("category_id", taxi.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Model(deleted).Update("deleted_at", time.Now()).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	updated, err := RecategorizeTransactionGroups(userID, map[CategoryMove][]uuid.UUID{
+		{From: travel, To: coffee.ID}:  {moved.ID, movedElsewhere.ID, deleted.ID, othersTransaction.ID},
+		{From: taxi.ID, To: coffee.ID}: {notInFrom.ID},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if updated != 1 {
+		t.Errorf("%d transactions counted as moved, want 1", updated)
+	}
+
+	want := map[uuid.UUID]uuid.UUID{
+		moved.ID:             coffee.ID,
+		movedElsewhere.ID:    taxi.ID,
+		deleted.ID:           travel,
+		notInFrom.ID:         travel,
+		othersTransaction.ID: othersTransaction.CategoryID,
+	}
+	for id, categoryID := range want {
+		var stored models.Transaction
+		if err := db.First(&stored, "id = ?", id).Error; err != nil {
+			t.Fatal(err)
+		}
+		if stored.CategoryID != categoryID {
+			t.Errorf("transaction of %v is in category %s, want %s", stored.Amount, stored.CategoryID, categoryID)
+		}
+	}
+}
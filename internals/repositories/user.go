@@ -31,3 +31,17 @@ func GetUserByEmail(email string) (*model.User, error) {
 	return user, nil
 }
 
+
+// GetUserByID finds a user by ID
+func GetUserByID(userID uuid.UUID) (*model.User, error) {
+	user := &model.User{}
+	if err := database.DB.Where("id = ? AND deleted_at IS NULL", userID).First(user).Error; err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// UpdateUserColumns saves the given columns of a user
+func UpdateUserColumns(userID uuid.UUID, columns map[string]interface{}) error {
+	return database.DB.Model(&model.User{}).Where("id = ?", userID).Updates(columns).Error
+}
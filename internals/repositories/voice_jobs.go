@@ -0,0 +1,53 @@
+package repositories
+
+import (
+	"time"
+
+	"github.com/KashyretsIvanna/voice-balance/database"
+	models "github.com/KashyretsIvanna/voice-balance/internals/model"
+	"github.com/google/uuid"
+)
+
+// SaveVoiceJob creates a new voice job in the database
+func SaveVoiceJob(job *models.VoiceJob) error {
+	db := database.DB
+
+	return db.Create(job).Error
+}
+
+// UpdateVoiceJob saves all fields of an existing voice job
+func UpdateVoiceJob(job *models.VoiceJob) error {
+	db := database.DB
+
+	return db.Save(job).Error
+}
+
+// FinishVoiceJob saves the status and the outcome of a pending or processing voice job.
+// It returns false and saves nothing when the job is already finished.
+func FinishVoiceJob(job *models.VoiceJob) (bool, error) {
+	db := database.DB
+
+	result := db.Model(job).
+		Where("status IN ?", []string{models.VoiceJobPending, models.VoiceJobProcessing}).
+		Updates(map[string]interface{}{
+			"status":     job.Status,
+			"transcript": job.Transcript,
+			"action":     job.Action,
+			"result":     job.Result,
+			"error":      job.Error,
+			"updated_at": time.Now(),
+		})
+	return result.RowsAffected > 0, result.Error
+}
+
+// FindVoiceJobByID returns a voice job owned by the user.
+// Returns gorm.ErrRecordNotFound if there is no such job.
+func FindVoiceJobByID(userID, jobID uuid.UUID) (*models.VoiceJob, error) {
+	db := database.DB
+
+	job := &models.VoiceJob{}
+	if err := db.Where("id = ? AND user_id = ?", jobID, userID).First(job).Error; err != nil {
+		return nil, err
+	}
+	return job, nil
+}
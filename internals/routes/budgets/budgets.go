@@ -0,0 +1,20 @@
+package noteRoutes
+
+import (
+	authHandler "github.com/KashyretsIvanna/voice-balance/internals/handlers/auth"
+	handlers "github.com/KashyretsIvanna/voice-balance/internals/handlers/budgets"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func SetupBudgetRoutes(router fiber.Router) {
+	budgets := router.Group("/budgets")
+
+	budgets.Post("", authHandler.AuthMiddleware, handlers.AddBudget)
+	budgets.Get("", authHandler.AuthMiddleware, handlers.GetBudgets)
+	budgets.Get("/status", authHandler.AuthMiddleware, handlers.GetBudgetStatuses)
+	budgets.Get("/:id", authHandler.AuthMiddleware, handlers.GetBudget)
+	budgets.Get("/:id/status", authHandler.AuthMiddleware, handlers.GetBudgetStatus)
+	budgets.Patch("/:id", authHandler.AuthMiddleware, handlers.UpdateBudget)
+	budgets.Delete("/:id", authHandler.AuthMiddleware, handlers.DeleteBudget)
+}
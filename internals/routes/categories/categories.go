@@ -11,5 +11,13 @@ func SetupCategoriesRoutes(router fiber.Router) {
 	categories := router.Group("/categories")
 	categories.Post("", authHandler.AuthMiddleware, handlers.AddCategoryHandler)
 	categories.Get("", authHandler.AuthMiddleware, handlers.GetCategoriesByUserID)
+	categories.Post("/reset", authHandler.AuthMiddleware, handlers.ResetCategoriesHandler)
+	categories.Get("/resolve", authHandler.AuthMiddleware, handlers.ResolveCategoryHandler)
+	categories.Patch("/:id", authHandler.AuthMiddleware, handlers.UpdateCategoryHandler)
+	categories.Delete("/:id", authHandler.AuthMiddleware, handlers.DeleteCategoryHandler)
+	categories.Post("/:id/merge", authHandler.AuthMiddleware, handlers.MergeCategoryHandler)
+	categories.Get("/:id/aliases", authHandler.AuthMiddleware, handlers.GetCategoryAliasesHandler)
+	categories.Post("/:id/aliases", authHandler.AuthMiddleware, handlers.AddCategoryAliasHandler)
+	categories.Delete("/:id/aliases/:aliasId", authHandler.AuthMiddleware, handlers.DeleteCategoryAliasHandler)
 
 }
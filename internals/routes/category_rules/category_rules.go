@@ -0,0 +1,19 @@
+package noteRoutes
+
+import (
+	authHandler "github.com/KashyretsIvanna/voice-balance/internals/handlers/auth"
+	handlers "github.com/KashyretsIvanna/voice-balance/internals/handlers/category_rules"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func SetupCategoryRuleRoutes(router fiber.Router) {
+	rules := router.Group("/category-rules")
+
+	rules.Get("", authHandler.AuthMiddleware, handlers.GetCategoryRules)
+	rules.Post("", authHandler.AuthMiddleware, handlers.AddCategoryRule)
+	rules.Post("/apply", authHandler.AuthMiddleware, handlers.ApplyCategoryRules)
+	rules.Get("/:id", authHandler.AuthMiddleware, handlers.GetCategoryRule)
+	rules.Patch("/:id", authHandler.AuthMiddleware, handlers.UpdateCategoryRule)
+	rules.Delete("/:id", authHandler.AuthMiddleware, handlers.DeleteCategoryRule)
+}
@@ -0,0 +1,16 @@
+package noteRoutes
+
+import (
+	authHandler "github.com/KashyretsIvanna/voice-balance/internals/handlers/auth"
+	handlers "github.com/KashyretsIvanna/voice-balance/internals/handlers/exchange_rates"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func SetupExchangeRateRoutes(router fiber.Router) {
+	rates := router.Group("/exchange-rates")
+
+	rates.Get("", authHandler.AuthMiddleware, handlers.GetExchangeRates)
+	rates.Post("", authHandler.AuthMiddleware, handlers.ImportExchangeRates)
+	rates.Post("/sync", authHandler.AuthMiddleware, handlers.SyncExchangeRates)
+}
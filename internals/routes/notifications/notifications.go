@@ -0,0 +1,15 @@
+package noteRoutes
+
+import (
+	authHandler "github.com/KashyretsIvanna/voice-balance/internals/handlers/auth"
+	handlers "github.com/KashyretsIvanna/voice-balance/internals/handlers/notifications"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func SetupNotificationRoutes(router fiber.Router) {
+	notifications := router.Group("/notifications")
+
+	notifications.Get("", authHandler.AuthMiddleware, handlers.GetNotifications)
+	notifications.Post("/:id/read", authHandler.AuthMiddleware, handlers.MarkNotificationRead)
+}
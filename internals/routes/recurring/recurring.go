@@ -0,0 +1,19 @@
+package noteRoutes
+
+import (
+	authHandler "github.com/KashyretsIvanna/voice-balance/internals/handlers/auth"
+	handlers "github.com/KashyretsIvanna/voice-balance/internals/handlers/recurring"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func SetupRecurringRoutes(router fiber.Router) {
+	recurring := router.Group("/recurring")
+
+	recurring.Post("", authHandler.AuthMiddleware, handlers.AddRecurringTransaction)
+	recurring.Get("", authHandler.AuthMiddleware, handlers.GetRecurringTransactions)
+	recurring.Get("/:id", authHandler.AuthMiddleware, handlers.GetRecurringTransaction)
+	recurring.Get("/:id/preview", authHandler.AuthMiddleware, handlers.PreviewRecurringTransaction)
+	recurring.Patch("/:id", authHandler.AuthMiddleware, handlers.UpdateRecurringTransaction)
+	recurring.Delete("/:id", authHandler.AuthMiddleware, handlers.DeleteRecurringTransaction)
+}
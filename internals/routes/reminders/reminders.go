@@ -0,0 +1,21 @@
+package noteRoutes
+
+import (
+	authHandler "github.com/KashyretsIvanna/voice-balance/internals/handlers/auth"
+	handlers "github.com/KashyretsIvanna/voice-balance/internals/handlers/reminders"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func SetupReminderRoutes(router fiber.Router) {
+	reminders := router.Group("/reminders")
+
+	reminders.Post("", authHandler.AuthMiddleware, handlers.AddReminder)
+	reminders.Get("", authHandler.AuthMiddleware, handlers.GetReminders)
+	reminders.Get("/:id", authHandler.AuthMiddleware, handlers.GetReminder)
+	reminders.Patch("/:id", authHandler.AuthMiddleware, handlers.UpdateReminder)
+	reminders.Post("/:id/complete", authHandler.AuthMiddleware, handlers.CompleteReminder)
+	reminders.Post("/:id/snooze", authHandler.AuthMiddleware, handlers.SnoozeReminder)
+	reminders.Get("/:id/deliveries", authHandler.AuthMiddleware, handlers.GetReminderDeliveries)
+	reminders.Delete("/:id", authHandler.AuthMiddleware, handlers.DeleteReminder)
+}
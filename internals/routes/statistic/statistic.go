@@ -10,5 +10,8 @@ import (
 func SetupStatisticsRoutes(router fiber.Router) {
 	statistics := router.Group("/statistics")
 	statistics.Get("/category",authHandler.AuthMiddleware, handlers.GetStatisticsByCategory)
+	statistics.Get("/timeseries", authHandler.AuthMiddleware, handlers.GetStatisticsTimeSeries)
+	statistics.Get("/compare", authHandler.AuthMiddleware, handlers.CompareStatistics)
+	statistics.Get("/balance", authHandler.AuthMiddleware, handlers.GetBalance)
 
 }
@@ -13,6 +13,12 @@ func SetupTransactionRoutes(router fiber.Router) {
 	// Create a Note
 	transaction.Post("/",authHandler.AuthMiddleware, handlers.AddTransaction)
 	transaction.Get("/",authHandler.AuthMiddleware,  handlers.GetTransactions)
+	transaction.Post("/bulk/delete", authHandler.AuthMiddleware, handlers.BulkDeleteTransactions)
+	transaction.Post("/bulk/recategorize", authHandler.AuthMiddleware, handlers.BulkRecategorizeTransactions)
+	transaction.Get("/:id", authHandler.AuthMiddleware, handlers.GetTransaction)
+	transaction.Patch("/:id", authHandler.AuthMiddleware, handlers.UpdateTransaction)
+	transaction.Delete("/:id", authHandler.AuthMiddleware, handlers.DeleteTransaction)
+	transaction.Post("/:id/restore", authHandler.AuthMiddleware, handlers.RestoreTransaction)
 
 
 }
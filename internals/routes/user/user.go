@@ -12,6 +12,8 @@ func SetupUserRoutes(router fiber.Router) {
 	// Read all Users
 	user.Get("/", authHandler.AuthMiddleware, userHandler.GetUsers)
 	user.Get("/me", authHandler.AuthMiddleware, userHandler.GetMe)
+	user.Get("/me/settings", authHandler.AuthMiddleware, userHandler.GetSettings)
+	user.Patch("/me/settings", authHandler.AuthMiddleware, userHandler.UpdateSettings)
 
 	// // Read one User
 	user.Get("/:userId", authHandler.AuthMiddleware, userHandler.GetUser)
@@ -13,4 +13,15 @@ func SetupVoiceRoutes(router fiber.Router) {
 	// Create a Note
 	transaction.Post("/", authHandler.AuthMiddleware, voiceHandler.TranscribeAudio)
 
+	// Streaming recognition of recordings uploaded in chunks
+	transaction.Post("/stream", authHandler.AuthMiddleware, voiceHandler.StartVoiceStream)
+	transaction.Get("/stream/:id", authHandler.AuthMiddleware, voiceHandler.GetVoiceStream)
+	transaction.Post("/stream/:id/chunks", authHandler.AuthMiddleware, voiceHandler.SendVoiceStreamChunk)
+	transaction.Get("/stream/:id/events", authHandler.AuthMiddleware, voiceHandler.VoiceStreamEvents)
+	transaction.Post("/stream/:id/finish", authHandler.AuthMiddleware, voiceHandler.FinishVoiceStream)
+
+	// Background recognition of long recordings
+	transaction.Post("/jobs", authHandler.AuthMiddleware, voiceHandler.CreateVoiceJob)
+	transaction.Get("/jobs/:id", authHandler.AuthMiddleware, voiceHandler.GetVoiceJob)
+
 }
@@ -0,0 +1,288 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"github.com/KashyretsIvanna/voice-balance/config"
+)
+
+// ErrUnsupportedAudioFormat is returned for uploads that can neither be recognized nor transcoded
+var ErrUnsupportedAudioFormat = errors.New("unsupported audio format")
+
+// Codecs recognized by DetectAudioFormat
+const (
+	CodecPCM16  = "pcm_s16le"
+	CodecPCM    = "pcm" // PCM with another sample size or float samples
+	CodecMulaw  = "mulaw"
+	CodecFLAC   = "flac"
+	CodecOpus   = "opus"
+	CodecVorbis = "vorbis"
+	CodecMP3    = "mp3"
+	CodecAAC    = "aac"
+	CodecAMR    = "amr"
+	CodecAMRWB  = "amr_wb"
+)
+
+// canonicalSampleRate is the sample rate audio is transcoded to
+const canonicalSampleRate = 16000
+
+// AudioFormat describes the container and the encoding of an audio file
+type AudioFormat struct {
+	Container       string `json:"container"`
+	Codec           string `json:"codec"`
+	SampleRateHertz int32  `json:"sample_rate_hertz"`
+	Channels        int32  `json:"channels"`
+}
+
+// recognizableCodecs can be passed to the recognizer without transcoding
+var recognizableCodecs = map[string]bool{
+	CodecPCM16: true,
+	CodecMulaw: true,
+	CodecFLAC:  true,
+	CodecOpus:  true,
+	CodecAMR:   true,
+	CodecAMRWB: true,
+}
+
+// DetectAudioFormat sniffs the container and codec of audio data from its header.
+// The sample rate is read from the header where the container stores it.
+func DetectAudioFormat(data []byte) (AudioFormat, error) {
+	switch {
+	case len(data) >= 12 && string(data[0:4]) == "RIFF" && string(data[8:12]) == "WAVE":
+		return parseWAVHeader(data)
+	case bytes.HasPrefix(data, []byte("fLaC")):
+		return parseFLACHeader(data)
+	case bytes.HasPrefix(data, []byte("OggS")):
+		return parseOggHeader(data)
+	case bytes.HasPrefix(data, []byte{0x1A, 0x45, 0xDF, 0xA3}):
+		return parseWebMHeader(data)
+	case bytes.HasPrefix(data, []byte("#!AMR-WB\n")):
+		return AudioFormat{Container: "amr", Codec: CodecAMRWB, SampleRateHertz: 16000, Channels: 1}, nil
+	case bytes.HasPrefix(data, []byte("#!AMR\n")):
+		return AudioFormat{Container: "amr", Codec: CodecAMR, SampleRateHertz: 8000, Channels: 1}, nil
+	case len(data) >= 8 && string(data[4:8]) == "ftyp":
+		// MP4/M4A/3GP files recorded by phones almost always carry AAC
+		return AudioFormat{Container: "mp4", Codec: CodecAAC}, nil
+	case bytes.HasPrefix(data, []byte("ID3")) || isMPEGFrame(data):
+		return parseMP3Header(data)
+	default:
+		return AudioFormat{}, fmt.Errorf("%w: the file is not WAV, FLAC, OGG, WebM, MP3, MP4 or AMR audio", ErrUnsupportedAudioFormat)
+	}
+}
+
+func parseWAVHeader(data []byte) (AudioFormat, error) {
+	format := AudioFormat{Container: "wav"}
+
+	// Walk the RIFF chunks until the "fmt " chunk
+	for offset := 12; offset+8 <= len(data); {
+		chunkID := string(data[offset : offset+4])
+		chunkSize := int(binary.LittleEndian.Uint32(data[offset+4 : offset+8]))
+		body := data[offset+8:]
+
+		if chunkID == "fmt " {
+			if chunkSize < 16 || len(body) < chunkSize {
+				break
+			}
+			audioFormat := binary.LittleEndian.Uint16(body[0:2])
+			format.Channels = int32(binary.LittleEndian.Uint16(body[2:4]))
+			format.SampleRateHertz = int32(binary.LittleEndian.Uint32(body[4:8]))
+			bitsPerSample := binary.LittleEndian.Uint16(body[14:16])
+
+			// WAVE_FORMAT_EXTENSIBLE stores the real format in the sub format GUID
+			if audioFormat == 0xFFFE {
+				if chunkSize < 26 {
+					break
+				}
+				audioFormat = binary.LittleEndian.Uint16(body[24:26])
+			}
+
+			switch {
+			case audioFormat == 1 && bitsPerSample == 16:
+				format.Codec = CodecPCM16
+			case audioFormat == 1 || audioFormat == 3:
+				format.Codec = CodecPCM
+			case audioFormat == 7:
+				format.Codec = CodecMulaw
+			default:
+				return format, fmt.Errorf("%w: WAV audio format %d", ErrUnsupportedAudioFormat, audioFormat)
+			}
+			return format, nil
+		}
+
+		// Chunks are padded to an even size
+		offset += 8 + chunkSize + chunkSize%2
+	}
+
+	return format, fmt.Errorf("%w: WAV file without a complete fmt chunk", ErrUnsupportedAudioFormat)
+}
+
+func parseFLACHeader(data []byte) (AudioFormat, error) {
+	format := AudioFormat{Container: "flac", Codec: CodecFLAC}
+
+	// "fLaC", the metadata block header and the 34 bytes of STREAMINFO, which comes first
+	// and holds the sample rate in 20 bits and the channel count in 3 bits after the first 10 bytes
+	if len(data) < 8+34 || data[4]&0x7F != 0 {
+		return format, fmt.Errorf("%w: truncated FLAC header", ErrUnsupportedAudioFormat)
+	}
+	info := data[8:]
+	format.SampleRateHertz = int32(info[10])<<12 | int32(info[11])<<4 | int32(info[12])>>4
+	format.Channels = int32((info[12]>>1)&0x07) + 1
+	return format, nil
+}
+
+func parseOggHeader(data []byte) (AudioFormat, error) {
+	format := AudioFormat{Container: "ogg"}
+
+	// The first page holds the identification header of the codec
+	if len(data) < 27 {
+		return format, fmt.Errorf("%w: truncated OGG page", ErrUnsupportedAudioFormat)
+	}
+	payloadStart := 27 + int(data[26])
+	if payloadStart > len(data) {
+		return format, fmt.Errorf("%w: truncated OGG page", ErrUnsupportedAudioFormat)
+	}
+	payload := data[payloadStart:]
+
+	switch {
+	case bytes.HasPrefix(payload, []byte("OpusHead")) && len(payload) >= 19:
+		format.Codec = CodecOpus
+		format.Channels = int32(payload[9])
+		format.SampleRateHertz = opusSampleRate(int32(binary.LittleEndian.Uint32(payload[12:16])))
+	case bytes.HasPrefix(payload, []byte("\x01vorbis")) && len(payload) >= 30:
+		format.Codec = CodecVorbis
+		format.Channels = int32(payload[11])
+		format.SampleRateHertz = int32(binary.LittleEndian.Uint32(payload[12:16]))
+	case bytes.HasPrefix(payload, []byte("\x7fFLAC")):
+		format.Codec = CodecFLAC
+	default:
+		return format, fmt.Errorf("%w: unknown OGG codec", ErrUnsupportedAudioFormat)
+	}
+	return format, nil
+}
+
+func parseWebMHeader(data []byte) (AudioFormat, error) {
+	format := AudioFormat{Container: "webm"}
+
+	// Browsers record WebM with Opus, the codec ID is stored as a plain string
+	header := data
+	if len(header) > 4096 {
+		header = header[:4096]
+	}
+	switch {
+	case bytes.Contains(header, []byte("A_OPUS")):
+		format.Codec = CodecOpus
+		format.SampleRateHertz = 48000
+	case bytes.Contains(header, []byte("A_VORBIS")):
+		format.Codec = CodecVorbis
+	default:
+		return format, fmt.Errorf("%w: unknown WebM codec", ErrUnsupportedAudioFormat)
+	}
+	return format, nil
+}
+
+// isMPEGFrame checks for the sync word of an MPEG audio frame
+func isMPEGFrame(data []byte) bool {
+	return len(data) >= 4 && data[0] == 0xFF && data[1]&0xE0 == 0xE0
+}
+
+func parseMP3Header(data []byte) (AudioFormat, error) {
+	format := AudioFormat{Container: "mp3", Codec: CodecMP3}
+
+	// Skip the ID3v2 tag, its size is stored as a syncsafe integer
+	offset := 0
+	if bytes.HasPrefix(data, []byte("ID3")) {
+		if len(data) < 10 {
+			return format, fmt.Errorf("%w: truncated ID3 tag", ErrUnsupportedAudioFormat)
+		}
+		offset = 10 + (int(data[6]&0x7F)<<21 | int(data[7]&0x7F)<<14 | int(data[8]&0x7F)<<7 | int(data[9]&0x7F))
+	}
+	if offset > len(data) || !isMPEGFrame(data[offset:]) {
+		return format, fmt.Errorf("%w: no MPEG audio frame after the ID3 tag", ErrUnsupportedAudioFormat)
+	}
+
+	rates := map[byte][3]int32{
+		3: {44100, 48000, 32000}, // MPEG 1
+		2: {22050, 24000, 16000}, // MPEG 2
+		0: {11025, 12000, 8000},  // MPEG 2.5
+	}
+	version := (data[offset+1] >> 3) & 0x03
+	rateIndex := (data[offset+2] >> 2) & 0x03
+	if versionRates, ok := rates[version]; ok && rateIndex < 3 {
+		format.SampleRateHertz = versionRates[rateIndex]
+	}
+	if (data[offset+3]>>6)&0x03 == 3 {
+		format.Channels = 1
+	} else {
+		format.Channels = 2
+	}
+	return format, nil
+}
+
+// opusSampleRate picks the Opus decoding rate closest to the original input rate
+func opusSampleRate(inputRate int32) int32 {
+	for _, rate := range []int32{8000, 12000, 16000, 24000} {
+		if inputRate > 0 && inputRate <= rate {
+			return rate
+		}
+	}
+	return 48000
+}
+
+// PrepareAudio detects the format of uploaded audio and transcodes it to 16 kHz mono
+// LINEAR16 WAV with ffmpeg when the recognizer cannot decode it, or always when
+// AUDIO_TRANSCODE is "always" (useful for local recognizers that only read WAV).
+// ErrUnsupportedAudioFormat is returned when the audio can neither be recognized nor transcoded.
+func PrepareAudio(ctx context.Context, data []byte) ([]byte, AudioFormat, error) {
+	format, detectErr := DetectAudioFormat(data)
+
+	forceTranscode := strings.EqualFold(config.Config("AUDIO_TRANSCODE"), "always")
+	if detectErr == nil && recognizableCodecs[format.Codec] && !forceTranscode {
+		return data, format, nil
+	}
+
+	ffmpeg := config.Config("FFMPEG_PATH")
+	if ffmpeg == "" {
+		ffmpeg = "ffmpeg"
+	}
+	if _, err := exec.LookPath(ffmpeg); err != nil {
+		if detectErr != nil {
+			return nil, format, detectErr
+		}
+		if recognizableCodecs[format.Codec] {
+			return data, format, nil
+		}
+		return nil, format, fmt.Errorf("%w: %s audio in %s needs transcoding, but ffmpeg is not available",
+			ErrUnsupportedAudioFormat, format.Codec, format.Container)
+	}
+
+	transcoded, err := transcodeAudio(ctx, ffmpeg, data)
+	if err != nil {
+		return nil, format, err
+	}
+	return transcoded, AudioFormat{Container: "wav", Codec: CodecPCM16, SampleRateHertz: canonicalSampleRate, Channels: 1}, nil
+}
+
+// transcodeAudio converts any audio ffmpeg can decode to 16 kHz mono LINEAR16 WAV
+func transcodeAudio(ctx context.Context, ffmpeg string, data []byte) ([]byte, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, ffmpeg,
+		"-hide_banner", "-loglevel", "error",
+		"-i", "pipe:0",
+		"-ac", "1", "-ar", fmt.Sprint(canonicalSampleRate), "-acodec", "pcm_s16le",
+		"-f", "wav", "pipe:1",
+	)
+	cmd.Stdin = bytes.NewReader(data)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("%w: ffmpeg could not decode the audio: %s", ErrUnsupportedAudioFormat, strings.TrimSpace(stderr.String()))
+	}
+	return stdout.Bytes(), nil
+}
@@ -0,0 +1,150 @@
+package services
+
+import (
+	"encoding/binary"
+	"errors"
+	"testing"
+)
+
+func le16(v uint16) []byte { return binary.LittleEndian.AppendUint16(nil, v) }
+func le32(v uint32) []byte { return binary.LittleEndian.AppendUint32(nil, v) }
+
+func concat(parts ...[]byte) []byte {
+	var data []byte
+	for _, part := range parts {
+		data = append(data, part...)
+	}
+	return data
+}
+
+// wavHeader builds a RIFF header up to the end of the fmt chunk, after the chunks before it
+func wavHeader(formatTag, channels uint16, rate uint32, bits uint16, before ...[]byte) []byte {
+	fmtBody := concat(le16(formatTag), le16(channels), le32(rate), le32(rate*uint32(channels*bits/8)), le16(channels*bits/8), le16(bits))
+	if formatTag == 0xFFFE {
+		// cbSize, valid bits, channel mask and the GUID starting with the real format
+		fmtBody = concat(fmtBody, le16(22), le16(bits), le32(0), le16(1), make([]byte, 14))
+	}
+	return concat([]byte("RIFF"), le32(0), []byte("WAVE"), concat(before...), []byte("fmt "), le32(uint32(len(fmtBody))), fmtBody)
+}
+
+func riffChunk(id string, body []byte) []byte {
+	chunk := concat([]byte(id), le32(uint32(len(body))), body)
+	if len(body)%2 == 1 {
+		chunk = append(chunk, 0)
+	}
+	return chunk
+}
+
+// flacHeader builds "fLaC" and a STREAMINFO block of 16 bit audio
+func flacHeader(rate uint32, channels byte) []byte {
+	info := make([]byte, 34)
+	info[10] = byte(rate >> 12)
+	info[11] = byte(rate >> 4)
+	info[12] = byte(rate<<4) | (channels-1)<<1
+	info[13] = 15 << 4
+	return concat([]byte("fLaC"), []byte{0x80, 0, 0, 34}, info)
+}
+
+// oggPage builds the first page of an OGG stream holding one packet
+func oggPage(packet []byte) []byte {
+	return concat([]byte("OggS"), []byte{0, 2}, make([]byte, 8+4+4+4), []byte{1, byte(len(packet))}, packet)
+}
+
+func opusHead(channels byte, inputRate uint32) []byte {
+	return concat([]byte("OpusHead"), []byte{1, channels}, le16(312), le32(inputRate), le16(0), []byte{0})
+}
+
+func vorbisIdentification(channels byte, rate uint32) []byte {
+	return concat([]byte("\x01vorbis"), le32(0), []byte{channels}, le32(rate), le32(0), le32(128000), le32(0), []byte{0xB8, 1})
+}
+
+// id3Tag builds an ID3v2 tag with size bytes after its header
+func id3Tag(size int) []byte {
+	return concat([]byte("ID3"), []byte{4, 0, 0, 0, byte(size >> 14 & 0x7F), byte(size >> 7 & 0x7F), byte(size & 0x7F)}, make([]byte, size))
+}
+
+func TestDetectAudioFormat(t *testing.T) {
+	// MPEG 1 layer III at 44.1 kHz in joint stereo, at 48 kHz in mono and MPEG 2 at 16 kHz
+	mp3Stereo := []byte{0xFF, 0xFB, 0x90, 0x64}
+	mp3Mono := []byte{0xFF, 0xFB, 0x94, 0xC4}
+	mp3MPEG2 := []byte{0xFF, 0xF3, 0x88, 0xC4}
+	webmOpus := concat([]byte{0x1A, 0x45, 0xDF, 0xA3, 0x9F, 0x42, 0x86, 0x81, 0x01}, []byte("webm"), make([]byte, 40), []byte{0x86, 0x86}, []byte("A_OPUS"))
+
+	tests := []struct {
+		name   string
+		header []byte
+		want   AudioFormat
+	}{
+		{"wav pcm", wavHeader(1, 1, 16000, 16), AudioFormat{"wav", CodecPCM16, 16000, 1}},
+		{"wav with chunks before fmt", wavHeader(1, 2, 44100, 16, riffChunk("JUNK", make([]byte, 27)), riffChunk("LIST", []byte("INFOISFT"))), AudioFormat{"wav", CodecPCM16, 44100, 2}},
+		{"wav 8 bit pcm", wavHeader(1, 1, 8000, 8), AudioFormat{"wav", CodecPCM, 8000, 1}},
+		{"wav float", wavHeader(3, 1, 48000, 32), AudioFormat{"wav", CodecPCM, 48000, 1}},
+		{"wav mu-law", wavHeader(7, 1, 8000, 8), AudioFormat{"wav", CodecMulaw, 8000, 1}},
+		{"wav extensible pcm", wavHeader(0xFFFE, 2, 48000, 16), AudioFormat{"wav", CodecPCM16, 48000, 2}},
+		{"flac", flacHeader(44100, 2), AudioFormat{"flac", CodecFLAC, 44100, 2}},
+		{"flac mono", flacHeader(16000, 1), AudioFormat{"flac", CodecFLAC, 16000, 1}},
+		{"ogg opus", oggPage(opusHead(1, 16000)), AudioFormat{"ogg", CodecOpus, 16000, 1}},
+		{"ogg opus of 44.1 kHz", oggPage(opusHead(2, 44100)), AudioFormat{"ogg", CodecOpus, 48000, 2}},
+		{"ogg vorbis", oggPage(vorbisIdentification(2, 44100)), AudioFormat{"ogg", CodecVorbis, 44100, 2}},
+		{"webm opus", webmOpus, AudioFormat{"webm", CodecOpus, 48000, 0}},
+		{"mp3", mp3Stereo, AudioFormat{"mp3", CodecMP3, 44100, 2}},
+		{"mp3 mono", mp3Mono, AudioFormat{"mp3", CodecMP3, 48000, 1}},
+		{"mp3 mpeg 2", mp3MPEG2, AudioFormat{"mp3", CodecMP3, 16000, 1}},
+		{"mp3 with an id3 tag", concat(id3Tag(300), mp3Stereo), AudioFormat{"mp3", CodecMP3, 44100, 2}},
+		{"amr", []byte("#!AMR\n"), AudioFormat{"amr", CodecAMR, 8000, 1}},
+		{"amr wideband", []byte("#!AMR-WB\n"), AudioFormat{"amr", CodecAMRWB, 16000, 1}},
+		{"mp4", concat(le32(0x18), []byte("ftypM4A ")), AudioFormat{"mp4", CodecAAC, 0, 0}},
+	}
+	for _, test := range tests {
+		got, err := DetectAudioFormat(test.header)
+		if err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+		if got != test.want {
+			t.Errorf("%s: got %+v, want %+v", test.name, got, test.want)
+		}
+
+		// Audio data follows the header
+		withAudio := concat(test.header, make([]byte, 256))
+		if got, err := DetectAudioFormat(withAudio); err != nil || got != test.want {
+			t.Errorf("%s followed by audio: got %+v, %v", test.name, got, err)
+		}
+
+		// Every header cut short is rejected
+		for length := 0; length < len(test.header); length++ {
+			if test.want.Codec == CodecAAC && length >= 8 || test.want.Codec == CodecAMR && length >= 6 {
+				continue
+			}
+			if _, err := DetectAudioFormat(test.header[:length]); !errors.Is(err, ErrUnsupportedAudioFormat) {
+				t.Errorf("%s cut to %d bytes: %v, want ErrUnsupportedAudioFormat", test.name, length, err)
+			}
+		}
+	}
+}
+
+func TestDetectAudioFormatRejectsUnknownAudio(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"empty", nil},
+		{"text", []byte("витрати 100 на каву")},
+		{"zeros", make([]byte, 64)},
+		{"wav without fmt", concat([]byte("RIFF"), le32(4), []byte("WAVE"), riffChunk("data", make([]byte, 32)))},
+		{"wav with a short fmt chunk", concat([]byte("RIFF"), le32(0), []byte("WAVE"), riffChunk("fmt ", make([]byte, 8)), make([]byte, 32))},
+		{"wav with a chunk size past the end", concat([]byte("RIFF"), le32(0), []byte("WAVE"), []byte("LIST"), le32(0xFFFFFFFF), make([]byte, 32))},
+		{"wav adpcm", wavHeader(2, 1, 8000, 4)},
+		{"flac starting with another block", concat([]byte("fLaC"), []byte{0x84, 0, 0, 34}, make([]byte, 34))},
+		{"ogg speex", oggPage(concat([]byte("Speex   "), make([]byte, 72)))},
+		{"ogg with a segment table past the end", concat([]byte("OggS"), make([]byte, 22), []byte{255}, make([]byte, 10))},
+		{"webm video", concat([]byte{0x1A, 0x45, 0xDF, 0xA3}, []byte("V_VP8"), make([]byte, 64))},
+		{"id3 tag longer than the file", concat(id3Tag(0)[:6], []byte{0x7F, 0x7F, 0x7F, 0x7F}, []byte{0xFF, 0xFB, 0x90, 0x64})},
+		{"id3 tag without audio", concat(id3Tag(16), []byte("not audio"))},
+	}
+	for _, test := range tests {
+		if format, err := DetectAudioFormat(test.data); !errors.Is(err, ErrUnsupportedAudioFormat) {
+			t.Errorf("%s: got %+v, %v, want ErrUnsupportedAudioFormat", test.name, format, err)
+		}
+	}
+}
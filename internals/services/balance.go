@@ -0,0 +1,122 @@
+package services
+
+import (
+	"time"
+
+	models "github.com/KashyretsIvanna/voice-balance/internals/model"
+	repositories "github.com/KashyretsIvanna/voice-balance/internals/repositories"
+	"github.com/google/uuid"
+)
+
+// SignedAmount returns how a transaction changes the balance. Amounts are stored positive,
+// the sign comes from the type of the category: incomes add to the balance, expenses subtract.
+// Every balance and net total is computed with it.
+func SignedAmount(categoryType string, amount models.Money) models.Money {
+	switch categoryType {
+	case models.CategoryTypeIncome:
+		return amount
+	case models.CategoryTypeExpense:
+		return -amount
+	default:
+		return 0
+	}
+}
+
+// BalancePoint is the balance at the end of one calendar bucket
+type BalancePoint struct {
+	Start   time.Time    `json:"start"`
+	End     time.Time    `json:"end"`
+	Income  models.Money `json:"income" swaggertype:"number"`
+	Expense models.Money `json:"expense" swaggertype:"number"`
+	Change  models.Money `json:"change" swaggertype:"number"`
+	Balance models.Money `json:"balance" swaggertype:"number"`
+}
+
+// BalanceReport holds the current balance of a user and the running balance over a range
+type BalanceReport struct {
+	Balance models.Money `json:"balance" swaggertype:"number" example:"15230.5"`
+	AsOf    time.Time    `json:"as_of"`
+	// Currency all amounts are converted into
+	Currency string `json:"currency" example:"UAH"`
+
+	StartDate time.Time `json:"start_date"`
+	EndDate   time.Time `json:"end_date"`
+	// OpeningBalance is the balance right before StartDate, ClosingBalance the one at EndDate
+	OpeningBalance models.Money    `json:"opening_balance" swaggertype:"number"`
+	ClosingBalance models.Money    `json:"closing_balance" swaggertype:"number"`
+	Bucket         StatisticsRange `json:"bucket" example:"day"`
+	Series         []BalancePoint  `json:"series"`
+}
+
+// balanceAt returns the balance of a user made of all transactions up to and including at
+// in the currency of the filter
+func balanceAt(userID uuid.UUID, at time.Time, filter repositories.CategoryTotalsFilter) (models.Money, error) {
+	totals, err := repositories.SumTransactionsByCategory(userID, time.Time{}, at, filter)
+	if err != nil {
+		return 0, err
+	}
+
+	var balance models.Money
+	for _, total := range totals {
+		balance += SignedAmount(total.CategoryType, total.Total)
+	}
+	return balance, nil
+}
+
+// GetBalanceReport returns the current balance of a user and the balance at the end of every
+// bucket between start and end, starting from the opening balance before start. Amounts are
+// converted into currency, the one of the user when it is empty.
+func GetBalanceReport(userID uuid.UUID, bucket StatisticsRange, start, end time.Time, currency string) (*BalanceReport, error) {
+	filter, err := reportingCurrency(userID, repositories.CategoryTotalsFilter{Currency: currency})
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	balance, err := balanceAt(userID, now, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	opening, err := balanceAt(userID, start.Add(-time.Nanosecond), filter)
+	if err != nil {
+		return nil, err
+	}
+
+	series, err := statisticsTimeSeries(userID, bucket, start, end, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &BalanceReport{
+		Balance:        balance,
+		AsOf:           now.In(start.Location()),
+		Currency:       filter.Currency,
+		StartDate:      series.StartDate,
+		EndDate:        series.EndDate,
+		OpeningBalance: opening,
+		Bucket:         bucket,
+	}
+	report.Series, report.ClosingBalance = runningBalance(opening, series)
+
+	return report, nil
+}
+
+// runningBalance returns the balance at the end of every bucket of the series starting from
+// opening, and the balance at the end of the last one
+func runningBalance(opening models.Money, series *TimeSeries) ([]BalancePoint, models.Money) {
+	points := make([]BalancePoint, 0, len(series.Points))
+	running := opening
+	for _, point := range series.Points {
+		running += point.Net
+		points = append(points, BalancePoint{
+			Start:   point.Start,
+			End:     point.End,
+			Income:  point.Income,
+			Expense: point.Expense,
+			Change:  point.Net,
+			Balance: running,
+		})
+	}
+	return points, running
+}
@@ -0,0 +1,82 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/KashyretsIvanna/voice-balance/database/dbtest"
+	models "github.com/KashyretsIvanna/voice-balance/internals/model"
+	repositories "github.com/KashyretsIvanna/voice-balance/internals/repositories"
+)
+
+func TestRunningBalanceAddsUpToClosingOnPostgres(t *testing.T) {
+	db := dbtest.Use(t)
+	kyiv := kyivLocation(t)
+	opening := testTransaction{time.Date(2023, time.November, 15, 12, 0, 0, 0, kyiv), models.CategoryTypeIncome, 1000000}
+	transactions := []testTransaction{
+		{time.Date(2023, time.December, 31, 23, 30, 0, 0, kyiv), models.CategoryTypeIncome, 2000000},
+		{time.Date(2024, time.January, 1, 0, 10, 0, 0, kyiv), models.CategoryTypeExpense, 12550},
+		{time.Date(2024, time.March, 8, 10, 0, 0, 0, kyiv), models.CategoryTypeExpense, 99999},
+		{time.Date(2024, time.March, 31, 23, 59, 0, 0, kyiv), models.CategoryTypeIncome, 1},
+		{time.Date(2024, time.June, 1, 0, 0, 0, 0, kyiv), models.CategoryTypeExpense, 500},
+	}
+
+	want := opening.amount
+	for _, transaction := range transactions {
+		want += SignedAmount(transaction.categoryType, transaction.amount)
+	}
+
+	start := time.Date(2023, time.December, 1, 0, 0, 0, 0, kyiv)
+	end := time.Date(2024, time.June, 30, 23, 59, 59, 0, kyiv)
+	for _, weekStart := range []string{"monday", "sunday", "saturday"} {
+		user := saveStatisticsTransactions(t, db, weekStart, append([]testTransaction{opening}, transactions...))
+
+		for _, bucket := range []StatisticsRange{RangeDay, RangeWeek, RangeMonth, RangeYear} {
+			report, err := GetBalanceReport(user.ID, bucket, start, end, "")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if report.OpeningBalance != opening.amount {
+				t.Errorf("%s buckets, week start %s: opening %v, want %v", bucket, weekStart, report.OpeningBalance, opening.amount)
+			}
+
+			sum := report.OpeningBalance
+			for _, point := range report.Series {
+				sum += point.Change
+			}
+			if report.ClosingBalance != sum || report.ClosingBalance != want {
+				t.Errorf("%s buckets, week start %s: closing %v, opening + bucket changes %v, want %v",
+					bucket, weekStart, report.ClosingBalance, sum, want)
+			}
+			if last := report.Series[len(report.Series)-1]; last.Balance != report.ClosingBalance {
+				t.Errorf("%s buckets, week start %s: the last point ends at %v, not at the closing balance", bucket, weekStart, last.Balance)
+			}
+
+			atEnd, err := balanceAt(user.ID, end, repositories.CategoryTotalsFilter{Currency: models.DefaultCurrency})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if report.ClosingBalance != atEnd {
+				t.Errorf("%s buckets, week start %s: closing %v, balance at the end %v", bucket, weekStart, report.ClosingBalance, atEnd)
+			}
+		}
+	}
+}
+
+func TestRunningBalance(t *testing.T) {
+	series := &TimeSeries{Points: []TimeSeriesPoint{{Net: 5000}, {Net: -7000}, {}, {Net: 150}}}
+
+	points, closing := runningBalance(1000, series)
+	want := []models.Money{6000, -1000, -1000, -850}
+	if len(points) != len(want) {
+		t.Fatalf("got %d points, want %d", len(points), len(want))
+	}
+	for i, balance := range want {
+		if points[i].Balance != balance || points[i].Change != series.Points[i].Net {
+			t.Errorf("point %d balance %v change %v, want %v and %v", i, points[i].Balance, points[i].Change, balance, series.Points[i].Net)
+		}
+	}
+	if closing != -850 {
+		t.Errorf("closing %v, want -850", closing)
+	}
+}
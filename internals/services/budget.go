@@ -0,0 +1,382 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/KashyretsIvanna/voice-balance/database"
+	models "github.com/KashyretsIvanna/voice-balance/internals/model"
+	repositories "github.com/KashyretsIvanna/voice-balance/internals/repositories"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ErrInvalidBudget is returned when a budget does not pass validation
+var ErrInvalidBudget = errors.New("invalid budget")
+
+// Threshold of budgets created without one: the warning is given when the limit is reached
+const defaultBudgetThreshold = 100
+
+// budgetPeriods maps the periods of budgets to the calendar ranges they cover
+var budgetPeriods = map[string]StatisticsRange{
+	models.BudgetPeriodWeekly:  RangeWeek,
+	models.BudgetPeriodMonthly: RangeMonth,
+	models.BudgetPeriodYearly:  RangeYear,
+}
+
+// BudgetUpdate holds the budget fields a user is allowed to change.
+// Nil fields are left untouched.
+type BudgetUpdate struct {
+	Period    *string       `json:"period" example:"monthly"`
+	Limit     *models.Money `json:"limit" swaggertype:"number" example:"5000"`
+	Threshold *float64      `json:"threshold" example:"80"`
+	Rollover  *bool         `json:"rollover"`
+}
+
+// BudgetStatus is the spending of the current period of a budget
+type BudgetStatus struct {
+	Budget      models.Budget `json:"budget"`
+	PeriodStart time.Time     `json:"period_start"`
+	PeriodEnd   time.Time     `json:"period_end"`
+	// Currency of the limit and the spending, the one of the user
+	Currency string `json:"currency" example:"UAH"`
+	// CarriedOver is the money left unspent in the previous period when rollover is on
+	CarriedOver models.Money `json:"carried_over" swaggertype:"number"`
+	// Limit of the period, the limit of the budget plus CarriedOver
+	Limit     models.Money `json:"limit" swaggertype:"number" example:"5000"`
+	Spent     models.Money `json:"spent" swaggertype:"number" example:"3200"`
+	Remaining models.Money `json:"remaining" swaggertype:"number" example:"1800"`
+	// Percent of the limit spent
+	Percent float64 `json:"percent" example:"64"`
+	// Projected is the spending at the end of the period if it goes on at the current pace
+	Projected        models.Money `json:"projected" swaggertype:"number" example:"4960"`
+	ThresholdReached bool         `json:"threshold_reached"`
+	Exceeded         bool         `json:"exceeded"`
+}
+
+// BudgetWarning tells that a transaction pushed a budget over its threshold or its limit
+type BudgetWarning struct {
+	BudgetID   uuid.UUID    `json:"budget_id"`
+	CategoryID uuid.UUID    `json:"category_id"`
+	Period     string       `json:"period" example:"monthly"`
+	Limit      models.Money `json:"limit" swaggertype:"number" example:"5000"`
+	Threshold  float64      `json:"threshold" example:"80"`
+	Spent      models.Money `json:"spent" swaggertype:"number" example:"4100"`
+	Percent    float64      `json:"percent" example:"82"`
+	Exceeded   bool         `json:"exceeded"`
+}
+
+func validateBudget(budget *models.Budget) error {
+	if _, ok := budgetPeriods[budget.Period]; !ok {
+		return fmt.Errorf("%w: period must be weekly, monthly or yearly", ErrInvalidBudget)
+	}
+	settings, err := GetUserSettings(budget.UserID)
+	if err != nil {
+		return err
+	}
+	// Limits are in the currency of the user
+	if err := checkAmount(budget.Limit, settings.Currency); err != nil {
+		return fmt.Errorf("%w: limit: %v", ErrInvalidBudget, err)
+	}
+	if budget.Threshold == 0 {
+		budget.Threshold = defaultBudgetThreshold
+	}
+	if budget.Threshold < 0 || math.IsInf(budget.Threshold, 0) || math.IsNaN(budget.Threshold) {
+		return fmt.Errorf("%w: threshold must be a percent of the limit greater than zero", ErrInvalidBudget)
+	}
+
+	category, err := repositories.FindCategoryByID(database.DB, budget.UserID, budget.CategoryID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return fmt.Errorf("%w: category %s not found", ErrInvalidBudget, budget.CategoryID)
+	}
+	if err != nil {
+		return err
+	}
+	if category.Type != models.CategoryTypeExpense {
+		return fmt.Errorf("%w: budgets can only be set for expense categories", ErrInvalidBudget)
+	}
+	return nil
+}
+
+// budgetSaveError reports a second budget of a category for the same period, the database
+// rejects it with a unique index so that concurrent requests cannot both create one
+func budgetSaveError(budget *models.Budget, err error) error {
+	if errors.Is(err, repositories.ErrBudgetExists) {
+		return fmt.Errorf("%w: the category already has a %s budget", ErrInvalidBudget, budget.Period)
+	}
+	return err
+}
+
+func CreateBudget(budget *models.Budget) error {
+	if err := validateBudget(budget); err != nil {
+		return err
+	}
+	return budgetSaveError(budget, repositories.SaveBudget(budget))
+}
+
+func GetBudgets(userID uuid.UUID) ([]models.Budget, error) {
+	return repositories.FindBudgets(userID)
+}
+
+func GetBudget(userID, budgetID uuid.UUID) (*models.Budget, error) {
+	return repositories.FindBudgetByID(userID, budgetID)
+}
+
+func UpdateBudget(userID, budgetID uuid.UUID, update BudgetUpdate) (*models.Budget, error) {
+	budget, err := repositories.FindBudgetByID(userID, budgetID)
+	if err != nil {
+		return nil, err
+	}
+
+	if update.Period != nil {
+		budget.Period = *update.Period
+	}
+	if update.Limit != nil {
+		budget.Limit = *update.Limit
+	}
+	if update.Threshold != nil {
+		budget.Threshold = *update.Threshold
+	}
+	if update.Rollover != nil {
+		budget.Rollover = *update.Rollover
+	}
+
+	if err := validateBudget(budget); err != nil {
+		return nil, err
+	}
+	if err := repositories.UpdateBudget(budget); err != nil {
+		return nil, budgetSaveError(budget, err)
+	}
+	return budget, nil
+}
+
+func DeleteBudget(userID, budgetID uuid.UUID) error {
+	budget, err := repositories.FindBudgetByID(userID, budgetID)
+	if err != nil {
+		return err
+	}
+	return repositories.SoftDeleteBudget(budget)
+}
+
+// GetBudgetStatuses returns the status of every budget of the user in its current period
+func GetBudgetStatuses(userID uuid.UUID) ([]BudgetStatus, error) {
+	budgets, err := repositories.FindBudgets(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	settings, err := GetUserSettings(userID)
+	if err != nil {
+		return nil, err
+	}
+	location, err := settings.Location()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := checkSpendingRates(userID, settings.Currency); err != nil {
+		return nil, err
+	}
+
+	now := time.Now().In(location)
+	statuses := make([]BudgetStatus, 0, len(budgets))
+	for _, budget := range budgets {
+		status, err := budgetStatus(budget, now, settings.FirstDayOfWeek(), settings.Currency)
+		if err != nil {
+			return nil, err
+		}
+		statuses = append(statuses, *status)
+	}
+	return statuses, nil
+}
+
+// GetBudgetStatus returns the status of one budget of the user in its current period
+func GetBudgetStatus(userID, budgetID uuid.UUID) (*BudgetStatus, error) {
+	budget, err := repositories.FindBudgetByID(userID, budgetID)
+	if err != nil {
+		return nil, err
+	}
+
+	settings, err := GetUserSettings(userID)
+	if err != nil {
+		return nil, err
+	}
+	location, err := settings.Location()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := checkSpendingRates(userID, settings.Currency); err != nil {
+		return nil, err
+	}
+	return budgetStatus(*budget, time.Now().In(location), settings.FirstDayOfWeek(), settings.Currency)
+}
+
+// checkSpendingRates returns ErrMissingExchangeRates when expenses of the user cannot be converted
+// into currency. Their converted amounts would be left out of the spending without it.
+func checkSpendingRates(userID uuid.UUID, currency string) error {
+	_, err := reportingCurrency(userID, repositories.CategoryTotalsFilter{Currency: currency})
+	return err
+}
+
+// budgetStatus computes the status of the budget in the period that contains now,
+// spending is converted into currency
+func budgetStatus(budget models.Budget, now time.Time, weekStart time.Weekday, currency string) (*BudgetStatus, error) {
+	start, end := StatisticsRangeBounds(budgetPeriods[budget.Period], now, weekStart)
+
+	spent, err := categorySpending(budget.UserID, budget.CategoryID, start, end, currency)
+	if err != nil {
+		return nil, err
+	}
+
+	status := &BudgetStatus{
+		Budget:      budget,
+		PeriodStart: start,
+		PeriodEnd:   end,
+		Currency:    currency,
+		Limit:       budget.Limit,
+		Spent:       spent,
+	}
+
+	// Only whole previous periods the budget existed in are carried over
+	if budget.Rollover && budget.CreatedAt.Before(start) {
+		previousStart, previousEnd := StatisticsRangeBounds(budgetPeriods[budget.Period], start.Add(-time.Nanosecond), weekStart)
+		previousSpent, err := categorySpending(budget.UserID, budget.CategoryID, previousStart, previousEnd, currency)
+		if err != nil {
+			return nil, err
+		}
+		if previousSpent < budget.Limit {
+			status.CarriedOver = budget.Limit - previousSpent
+		}
+		status.Limit = budget.Limit + status.CarriedOver
+	}
+
+	status.Remaining = status.Limit - spent
+	status.Percent = sharePercent(spent, status.Limit)
+	status.ThresholdReached = spent >= thresholdAmount(status.Limit, budget.Threshold)
+	status.Exceeded = spent > status.Limit
+
+	// The first day counts as a whole one, otherwise a morning purchase would be
+	// projected onto the period by the hour
+	elapsed, length := now.Sub(start), end.Sub(start)
+	if elapsed < 24*time.Hour {
+		elapsed = 24 * time.Hour
+	}
+	if elapsed > length {
+		elapsed = length
+	}
+	status.Projected = spent.Scale(float64(length) / float64(elapsed))
+
+	return status, nil
+}
+
+// checkBudgets returns a warning for every budget of the category of an expense or of one of
+// its parent categories that the expense pushed over its threshold or over its limit, in the
+// period of the expense date
+func checkBudgets(transaction *models.Transaction) ([]BudgetWarning, error) {
+	byID, err := categoryIndex(transaction.UserID)
+	if err != nil {
+		return nil, err
+	}
+	budgets, err := repositories.FindBudgetsByCategories(transaction.UserID, categoryWithAncestors(byID, transaction.CategoryID))
+	if err != nil || len(budgets) == 0 {
+		return nil, err
+	}
+
+	settings, err := GetUserSettings(transaction.UserID)
+	if err != nil {
+		return nil, err
+	}
+	location, err := settings.Location()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := checkSpendingRates(transaction.UserID, settings.Currency); err != nil {
+		return nil, err
+	}
+
+	var warnings []BudgetWarning
+	for _, budget := range budgets {
+		status, err := budgetStatus(budget, transaction.Date.In(location), settings.FirstDayOfWeek(), settings.Currency)
+		if err != nil {
+			return nil, err
+		}
+
+		threshold := thresholdAmount(status.Limit, budget.Threshold)
+		before := status.Spent - transaction.Amount
+		if transaction.Currency != settings.Currency {
+			// The converted amount is not known here, the spending without the expense is summed up
+			filter := repositories.CategoryTotalsFilter{
+				CategoryType:       models.CategoryTypeExpense,
+				CategoryID:         &budget.CategoryID,
+				Currency:           settings.Currency,
+				ExcludeTransaction: &transaction.ID,
+				Rollup:             true,
+			}
+			if before, err = sumSpending(budget.UserID, status.PeriodStart, status.PeriodEnd, filter); err != nil {
+				return nil, err
+			}
+		}
+		crossedThreshold := before < threshold && status.Spent >= threshold
+		crossedLimit := before <= status.Limit && status.Spent > status.Limit
+		if !crossedThreshold && !crossedLimit {
+			continue
+		}
+
+		warnings = append(warnings, BudgetWarning{
+			BudgetID:   budget.ID,
+			CategoryID: budget.CategoryID,
+			Period:     budget.Period,
+			Limit:      status.Limit,
+			Threshold:  budget.Threshold,
+			Spent:      status.Spent,
+			Percent:    status.Percent,
+			Exceeded:   status.Exceeded,
+		})
+	}
+	return warnings, nil
+}
+
+// categorySpending sums the expenses of one category and of its subcategories between start
+// and end in currency
+func categorySpending(userID, categoryID uuid.UUID, start, end time.Time, currency string) (models.Money, error) {
+	return sumSpending(userID, start, end, repositories.CategoryTotalsFilter{
+		CategoryType: models.CategoryTypeExpense,
+		CategoryID:   &categoryID,
+		Currency:     currency,
+		Rollup:       true,
+	})
+}
+
+// categoryWithAncestors returns the ID of the category followed by the IDs of its parent,
+// the parent of its parent and so on
+func categoryWithAncestors(byID map[uuid.UUID]*models.Category, categoryID uuid.UUID) []uuid.UUID {
+	ids := []uuid.UUID{categoryID}
+	category, ok := byID[categoryID]
+	for ok && category.ParentID != nil && !containsUUID(ids, *category.ParentID) {
+		ids = append(ids, *category.ParentID)
+		category, ok = byID[*category.ParentID]
+	}
+	return ids
+}
+
+// sumSpending sums the transactions matching the filter between start and end
+func sumSpending(userID uuid.UUID, start, end time.Time, filter repositories.CategoryTotalsFilter) (models.Money, error) {
+	totals, err := repositories.SumTransactionsByCategory(userID, start, end, filter)
+	if err != nil {
+		return 0, err
+	}
+
+	var spent models.Money
+	for _, total := range totals {
+		spent += total.Total
+	}
+	return spent, nil
+}
+
+func thresholdAmount(limit models.Money, threshold float64) models.Money {
+	return limit.Scale(threshold / 100)
+}
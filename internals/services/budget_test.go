@@ -0,0 +1,119 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/KashyretsIvanna/voice-balance/database/dbtest"
+	models "github.com/KashyretsIvanna/voice-balance/internals/model"
+	repositories "github.com/KashyretsIvanna/voice-balance/internals/repositories"
+	"github.com/google/uuid"
+)
+
+func TestCategoryWithAncestors(t *testing.T) {
+	food, groceries, fruit, other := uuid.New(), uuid.New(), uuid.New(), uuid.New()
+	byID := map[uuid.UUID]*models.Category{
+		food:      {ID: food},
+		groceries: {ID: groceries, ParentID: &food},
+		fruit:     {ID: fruit, ParentID: &groceries},
+		other:     {ID: other},
+	}
+
+	tests := []struct {
+		category uuid.UUID
+		want     []uuid.UUID
+	}{
+		{fruit, []uuid.UUID{fruit, groceries, food}},
+		{groceries, []uuid.UUID{groceries, food}},
+		{food, []uuid.UUID{food}},
+		{other, []uuid.UUID{other}},
+	}
+	for _, test := range tests {
+		if got := categoryWithAncestors(byID, test.category); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("categoryWithAncestors(%s) = %v, want %v", test.category, got, test.want)
+		}
+	}
+
+	// A deleted parent ends the chain, a cycle does not hang
+	deleted := uuid.New()
+	orphan := uuid.New()
+	byID[orphan] = &models.Category{ID: orphan, ParentID: &deleted}
+	if got := categoryWithAncestors(byID, orphan); !reflect.DeepEqual(got, []uuid.UUID{orphan, deleted}) {
+		t.Errorf("with a deleted parent: %v", got)
+	}
+	byID[food].ParentID = &fruit
+	if got := categoryWithAncestors(byID, fruit); !reflect.DeepEqual(got, []uuid.UUID{fruit, groceries, food}) {
+		t.Errorf("with a cycle: %v", got)
+	}
+}
+
+func TestBudgetSaveErrorReportsDuplicatePeriod(t *testing.T) {
+	budget := &models.Budget{Period: models.BudgetPeriodMonthly}
+
+	err := budgetSaveError(budget, repositories.ErrBudgetExists)
+	if !errors.Is(err, ErrInvalidBudget) {
+		t.Errorf("error = %v, want ErrInvalidBudget", err)
+	}
+
+	other := fmt.Errorf("connection reset")
+	if err := budgetSaveError(budget, other); err != other {
+		t.Errorf("error = %v, want it unchanged", err)
+	}
+	if err := budgetSaveError(budget, nil); err != nil {
+		t.Errorf("error = %v without an error", err)
+	}
+}
+
+func TestBudgetStatusNeedsExchangeRatesOnPostgres(t *testing.T) {
+	db := dbtest.Use(t)
+	user := saveTestUser(t, db)
+	food := saveTestCategory(t, db, user.ID, "Продукти", models.CategoryTypeExpense)
+	budget := &models.Budget{UserID: user.ID, CategoryID: food.ID, Period: models.BudgetPeriodMonthly, Limit: 50000, Threshold: 100}
+	if err := db.Create(budget).Error; err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	saveTestTransaction(t, db, &models.Transaction{UserID: user.ID, CategoryID: food.ID, Amount: 3000, Date: now})
+
+	status, err := GetBudgetStatus(user.ID, budget.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.Spent != 3000 {
+		t.Fatalf("spent %v, want 30.00", status.Spent)
+	}
+
+	// An expense in euros without a rate would drop out of the spending
+	euros := saveTestTransaction(t, db, &models.Transaction{UserID: user.ID, CategoryID: food.ID, Amount: 2000, Currency: "EUR", Date: now})
+	if _, err := GetBudgetStatus(user.ID, budget.ID); !errors.Is(err, ErrMissingExchangeRates) {
+		t.Errorf("status of one budget: %v, want ErrMissingExchangeRates", err)
+	}
+	if _, err := GetBudgetStatuses(user.ID); !errors.Is(err, ErrMissingExchangeRates) {
+		t.Errorf("statuses: %v, want ErrMissingExchangeRates", err)
+	}
+	if _, err := checkBudgets(euros); !errors.Is(err, ErrMissingExchangeRates) {
+		t.Errorf("budget warnings: %v, want ErrMissingExchangeRates", err)
+	}
+
+	// 20.00 EUR at 45.00 makes 900.00 UAH, over the limit
+	if err := repositories.SaveExchangeRates([]models.ExchangeRate{{Currency: "EUR", Date: now, Rate: 45}}); err != nil {
+		t.Fatal(err)
+	}
+	status, err = GetBudgetStatus(user.ID, budget.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.Spent != 93000 || !status.Exceeded {
+		t.Errorf("spent %v, exceeded %v, want 930.00 over the limit", status.Spent, status.Exceeded)
+	}
+	warnings, err := checkBudgets(euros)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(warnings) != 1 || !warnings[0].Exceeded {
+		t.Errorf("warnings %+v, want the budget exceeded", warnings)
+	}
+}
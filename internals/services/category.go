@@ -0,0 +1,325 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/KashyretsIvanna/voice-balance/database"
+	models "github.com/KashyretsIvanna/voice-balance/internals/model"
+	repositories "github.com/KashyretsIvanna/voice-balance/internals/repositories"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrInvalidCategory is returned when a category does not pass validation
+	ErrInvalidCategory = errors.New("invalid category")
+	// ErrCategoryExists is returned when the user already has a category of the same name and type
+	ErrCategoryExists = errors.New("category already exists")
+	// ErrCategoryInUse is returned for deleting a category transactions, recurring transactions, budgets or category rules still use
+	ErrCategoryInUse = errors.New("category is in use")
+)
+
+// CategoryUpdate holds the category fields a user is allowed to change.
+// Nil fields are left untouched, ClearParent makes the category a top-level one.
+type CategoryUpdate struct {
+	Name        *string    `json:"name" example:"таксі"`
+	Type        *string    `json:"type" example:"expense"`
+	ParentID    *uuid.UUID `json:"parent_id"`
+	ClearParent bool       `json:"clear_parent"`
+}
+
+// CategoryMerge names the category another category is merged into
+type CategoryMerge struct {
+	TargetID uuid.UUID `json:"target_id"`
+}
+
+// MergedCategory is the category a category was merged into with the counts of what moved
+type MergedCategory struct {
+	Category *models.Category                 `json:"category"`
+	Moved    repositories.CategoryMergeCounts `json:"moved"`
+}
+
+// validateCategory trims the name of a category and checks its fields
+func validateCategory(category *models.Category) error {
+	category.Name = strings.TrimSpace(category.Name)
+	if category.Name == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalidCategory)
+	}
+	if len([]rune(category.Name)) > 100 {
+		return fmt.Errorf("%w: name must be at most 100 characters", ErrInvalidCategory)
+	}
+	if category.Type != models.CategoryTypeIncome && category.Type != models.CategoryTypeExpense {
+		return fmt.Errorf("%w: type must be income or expense", ErrInvalidCategory)
+	}
+	return nil
+}
+
+// checkCategoryName fails with ErrCategoryExists when another category of the user has the name and type of the category
+func checkCategoryName(category *models.Category) error {
+	other, err := repositories.FindCategoryByName(database.DB, category.UserID, category.Name, category.Type)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if other.ID != category.ID {
+		return fmt.Errorf("%w: %s %q", ErrCategoryExists, category.Type, category.Name)
+	}
+	return nil
+}
+
+// categoryIndex returns the not deleted categories of a user by ID
+func categoryIndex(userID uuid.UUID) (map[uuid.UUID]*models.Category, error) {
+	categories, err := repositories.FindCategoriesByUser(database.DB, userID)
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[uuid.UUID]*models.Category, len(categories))
+	for i := range categories {
+		byID[categories[i].ID] = &categories[i]
+	}
+	return byID, nil
+}
+
+// hasAncestor tells whether ancestorID is the parent of the category, the parent of its parent and so on
+func hasAncestor(byID map[uuid.UUID]*models.Category, category *models.Category, ancestorID uuid.UUID) bool {
+	seen := map[uuid.UUID]bool{}
+	for category.ParentID != nil && !seen[category.ID] {
+		if *category.ParentID == ancestorID {
+			return true
+		}
+		seen[category.ID] = true
+		parent, ok := byID[*category.ParentID]
+		if !ok {
+			return false
+		}
+		category = parent
+	}
+	return false
+}
+
+// checkCategoryTree makes sure the parent of a category is another category of the user
+// with the same type that is not one of its subcategories, and that the subcategories
+// of the category have its type
+func checkCategoryTree(category *models.Category) error {
+	byID, err := categoryIndex(category.UserID)
+	if err != nil {
+		return err
+	}
+	return checkCategoryTreeOf(byID, category)
+}
+
+// checkCategoryTreeOf is checkCategoryTree within the categories of the user by ID
+func checkCategoryTreeOf(byID map[uuid.UUID]*models.Category, category *models.Category) error {
+	for _, other := range byID {
+		if other.ParentID != nil && *other.ParentID == category.ID && other.Type != category.Type {
+			return fmt.Errorf("%w: subcategory %q is an %s category", ErrInvalidCategory, other.Name, other.Type)
+		}
+	}
+
+	if category.ParentID == nil {
+		return nil
+	}
+	parent, ok := byID[*category.ParentID]
+	if !ok {
+		return fmt.Errorf("%w: parent category %s not found", ErrInvalidCategory, *category.ParentID)
+	}
+	if parent.Type != category.Type {
+		return fmt.Errorf("%w: parent category %q is an %s category", ErrInvalidCategory, parent.Name, parent.Type)
+	}
+	if parent.ID == category.ID || hasAncestor(byID, parent, category.ID) {
+		return fmt.Errorf("%w: a category cannot be a subcategory of itself or of its subcategories", ErrInvalidCategory)
+	}
+	return nil
+}
+
+// CreateCategory saves a new category of the user. Names are unique per user and type, case is ignored.
+// A category with a parent is a subcategory of it, both must have the same type.
+func CreateCategory(category *models.Category) error {
+	if err := validateCategory(category); err != nil {
+		return err
+	}
+	if err := checkCategoryName(category); err != nil {
+		return err
+	}
+	if err := checkCategoryTree(category); err != nil {
+		return err
+	}
+	return repositories.AddCategory(database.DB, category)
+}
+
+func GetCategory(userID, categoryID uuid.UUID) (*models.Category, error) {
+	return repositories.FindCategoryByID(database.DB, userID, categoryID)
+}
+
+func UpdateCategory(userID, categoryID uuid.UUID, update CategoryUpdate) (*models.Category, error) {
+	category, err := repositories.FindCategoryByID(database.DB, userID, categoryID)
+	if err != nil {
+		return nil, err
+	}
+
+	if update.Name != nil {
+		category.Name = *update.Name
+	}
+	if update.Type != nil && *update.Type != category.Type {
+		if *update.Type == models.CategoryTypeIncome {
+			budgets, err := repositories.FindBudgetsByCategory(userID, categoryID)
+			if err != nil {
+				return nil, err
+			}
+			if len(budgets) > 0 {
+				return nil, fmt.Errorf("%w: a category with budgets must stay an expense category", ErrInvalidCategory)
+			}
+		}
+		category.Type = *update.Type
+	}
+	if update.ParentID != nil {
+		category.ParentID = update.ParentID
+	}
+	if update.ClearParent {
+		category.ParentID = nil
+	}
+
+	if err := validateCategory(category); err != nil {
+		return nil, err
+	}
+	if err := checkCategoryName(category); err != nil {
+		return nil, err
+	}
+	if err := checkCategoryTree(category); err != nil {
+		return nil, err
+	}
+	if err := repositories.UpdateCategory(database.DB, category); err != nil {
+		return nil, err
+	}
+	return category, nil
+}
+
+// DeleteCategory deletes a category nothing uses anymore, its subcategories move to its parent.
+// Categories still in use can be merged into another one instead.
+func DeleteCategory(userID, categoryID uuid.UUID) error {
+	db := database.DB
+	category, err := repositories.FindCategoryByID(db, userID, categoryID)
+	if err != nil {
+		return err
+	}
+
+	uses, err := repositories.CountCategoryUses(db, categoryID)
+	if err != nil {
+		return err
+	}
+	if uses > 0 {
+		return fmt.Errorf("%w: %d transactions, deleted ones included, recurring transactions, budgets or category rules use it, merge it into another category instead", ErrCategoryInUse, uses)
+	}
+	return repositories.SoftDeleteCategory(db, category)
+}
+
+// MergeCategory moves everything of a category into another category of the same type and deletes it.
+// A category cannot be merged into one of its own subcategories.
+func MergeCategory(userID, categoryID uuid.UUID, merge CategoryMerge) (*MergedCategory, error) {
+	db := database.DB
+	if merge.TargetID == categoryID {
+		return nil, fmt.Errorf("%w: a category cannot be merged into itself", ErrInvalidCategory)
+	}
+
+	source, err := repositories.FindCategoryByID(db, userID, categoryID)
+	if err != nil {
+		return nil, err
+	}
+	target, err := repositories.FindCategoryByID(db, userID, merge.TargetID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("%w: target category %s not found", ErrInvalidCategory, merge.TargetID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if source.Type != target.Type {
+		return nil, fmt.Errorf("%w: an %s category cannot be merged into an %s category", ErrInvalidCategory, source.Type, target.Type)
+	}
+
+	// The subcategories of source move to target, target must not be one of them
+	byID, err := categoryIndex(userID)
+	if err != nil {
+		return nil, err
+	}
+	if hasAncestor(byID, target, source.ID) {
+		return nil, fmt.Errorf("%w: a category cannot be merged into one of its subcategories", ErrInvalidCategory)
+	}
+
+	counts, err := repositories.MergeCategories(db, source, target)
+	if err != nil {
+		return nil, err
+	}
+	return &MergedCategory{Category: target, Moved: counts}, nil
+}
+
+func GetCategories(userID uuid.UUID) ([]models.Category, error) {
+	return repositories.FindCategoriesByUser(database.DB, userID)
+}
+
+// SeedDefaultCategories gives the user the default categories of a language,
+// the ones the user already has are skipped
+func SeedDefaultCategories(userID uuid.UUID, languageCode string) error {
+	language, err := LookupLanguage(languageCode)
+	if err != nil {
+		language = DefaultLanguage()
+	}
+
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		_, err := addDefaultCategories(tx, userID, language)
+		return err
+	})
+}
+
+// ResetCategories brings the categories of the user back to the defaults of the user's language:
+// missing default categories are added again and the other categories are deleted, unless
+// a transaction, a recurring transaction, a budget or a category rule still uses them.
+func ResetCategories(userID uuid.UUID) ([]models.Category, error) {
+	settings, err := GetUserSettings(userID)
+	if err != nil {
+		return nil, err
+	}
+	language, err := LookupLanguage(settings.Language)
+	if err != nil {
+		language = DefaultLanguage()
+	}
+
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		defaults, err := addDefaultCategories(tx, userID, language)
+		if err != nil {
+			return err
+		}
+
+		keepIDs := make([]uuid.UUID, len(defaults))
+		for i, category := range defaults {
+			keepIDs[i] = category.ID
+		}
+		_, err = repositories.SoftDeleteUnusedCategories(tx, userID, keepIDs)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return GetCategories(userID)
+}
+
+// addDefaultCategories adds the default categories of the language the user does not have yet
+// and returns all of them, the added and the existing ones
+func addDefaultCategories(tx *gorm.DB, userID uuid.UUID, language *Language) ([]models.Category, error) {
+	categories := make([]models.Category, 0, len(language.DefaultCategories))
+	for _, defaultCategory := range language.DefaultCategories {
+		category, err := repositories.FindCategoryByName(tx, userID, defaultCategory.Name, defaultCategory.Type)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			category = &models.Category{Name: defaultCategory.Name, Type: defaultCategory.Type, UserID: userID}
+			err = repositories.AddCategory(tx, category)
+		}
+		if err != nil {
+			return nil, err
+		}
+		categories = append(categories, *category)
+	}
+	return categories, nil
+}
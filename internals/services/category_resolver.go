@@ -0,0 +1,392 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/KashyretsIvanna/voice-balance/database"
+	models "github.com/KashyretsIvanna/voice-balance/internals/model"
+	repositories "github.com/KashyretsIvanna/voice-balance/internals/repositories"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Confidences a spoken category is matched with
+const (
+	// categoryMatchConfidence is the confidence a match is used at without asking
+	categoryMatchConfidence = 0.75
+	// categoryAskConfidence is the confidence below which a new category is created instead of asking
+	categoryAskConfidence = 0.5
+	// maxCategoryCandidates limits the candidates offered when the user is asked
+	maxCategoryCandidates = 3
+	// minCategoryStem is the number of letters a word keeps at least when its ending is removed
+	minCategoryStem = 2
+)
+
+// Values of CategoryResolution.Decision
+const (
+	CategoryDecisionMatch  = "match"
+	CategoryDecisionAsk    = "ask"
+	CategoryDecisionCreate = "create"
+)
+
+// Values of CategoryMatch.MatchedBy
+const (
+	CategoryMatchedByName  = "name"
+	CategoryMatchedByAlias = "alias"
+)
+
+// CategoryWords is the vocabulary spoken categories are normalized with in one language
+type CategoryWords struct {
+	// StopWords like prepositions are left out of phrases, "на їжу" is matched as "їжу"
+	StopWords []string
+	// Endings of inflected forms, the longest one a word ends with is removed
+	Endings []string
+}
+
+// CategoryMatch is a category of the user a spoken phrase resembles
+type CategoryMatch struct {
+	Category *models.Category `json:"category"`
+	// Confidence is between 0 and 1, 1 is an exact match of the name or an alias
+	Confidence float64 `json:"confidence" example:"0.95"`
+	// MatchedBy tells whether the name or an alias matched, Text is the one that did
+	MatchedBy string `json:"matched_by" example:"name"`
+	Text      string `json:"text" example:"продукти"`
+}
+
+// CategoryResolution is the outcome of matching a spoken phrase with the categories of a user
+type CategoryResolution struct {
+	Phrase string `json:"phrase" example:"продуктах"`
+	// Decision is "match" when Match is confident enough to be used, "ask" when the user
+	// should choose one of the candidates and "create" when no category is close
+	Decision string         `json:"decision" example:"match"`
+	Match    *CategoryMatch `json:"match,omitempty"`
+	// Candidates are the closest categories, the best one first
+	Candidates []CategoryMatch `json:"candidates"`
+}
+
+// CategoryConfirmationError is returned when a spoken category resembles categories of the
+// user without being close enough to one of them. The user is asked to pick a candidate,
+// to add the phrase as an alias or to create a category.
+type CategoryConfirmationError struct {
+	Phrase     string          `json:"phrase"`
+	Candidates []CategoryMatch `json:"candidates"`
+}
+
+func (e *CategoryConfirmationError) Error() string {
+	names := make([]string, len(e.Candidates))
+	for i, candidate := range e.Candidates {
+		names[i] = fmt.Sprintf("%q", candidate.Category.Name)
+	}
+	return fmt.Sprintf("category %q is not certain, did you mean %s?", e.Phrase, strings.Join(names, ", "))
+}
+
+// MatchCategory compares a spoken category with the names and the aliases of the user's
+// categories of a type. Words are compared without stop words and inflection endings of
+// the language, the rest is compared by edit distance. Nothing is created.
+func MatchCategory(userID uuid.UUID, phrase, categoryType string, language *Language) (*CategoryResolution, error) {
+	db := database.DB
+	words := language.Grammar.CategoryWords
+
+	resolution := &CategoryResolution{Phrase: normalizeCategoryPhrase(phrase), Candidates: []CategoryMatch{}}
+	if resolution.Phrase == "" {
+		return nil, fmt.Errorf("%w: category is empty", ErrInvalidCategory)
+	}
+
+	categories, err := repositories.FindCategoriesByUser(db, userID)
+	if err != nil {
+		return nil, err
+	}
+	aliases, err := repositories.FindAliasesByUser(db, userID, categoryType)
+	if err != nil {
+		return nil, err
+	}
+
+	rankCategories(resolution, categories, aliases, categoryType, words)
+	return resolution, nil
+}
+
+// rankCategories fills in the candidates and the decision of a resolution from the categories
+// of the type and their aliases
+func rankCategories(resolution *CategoryResolution, categories []models.Category, aliases []models.CategoryAlias, categoryType string, words *CategoryWords) {
+	// The best match of every category, by its name or by one of its aliases
+	best := map[uuid.UUID]CategoryMatch{}
+	consider := func(category *models.Category, text, matchedBy string) {
+		confidence := categorySimilarity(resolution.Phrase, text, words)
+		if current, ok := best[category.ID]; !ok || confidence > current.Confidence {
+			best[category.ID] = CategoryMatch{Category: category, Confidence: confidence, MatchedBy: matchedBy, Text: text}
+		}
+	}
+	byID := map[uuid.UUID]*models.Category{}
+	for i := range categories {
+		if categories[i].Type != categoryType {
+			continue
+		}
+		byID[categories[i].ID] = &categories[i]
+		consider(&categories[i], categories[i].Name, CategoryMatchedByName)
+	}
+	for _, alias := range aliases {
+		if category, ok := byID[alias.CategoryID]; ok {
+			consider(category, alias.Alias, CategoryMatchedByAlias)
+		}
+	}
+
+	for _, match := range best {
+		if match.Confidence >= categoryAskConfidence {
+			resolution.Candidates = append(resolution.Candidates, match)
+		}
+	}
+	sort.SliceStable(resolution.Candidates, func(i, j int) bool {
+		a, b := resolution.Candidates[i], resolution.Candidates[j]
+		if a.Confidence != b.Confidence {
+			return a.Confidence > b.Confidence
+		}
+		return a.Category.Name < b.Category.Name
+	})
+	if len(resolution.Candidates) > maxCategoryCandidates {
+		resolution.Candidates = resolution.Candidates[:maxCategoryCandidates]
+	}
+
+	switch {
+	case len(resolution.Candidates) == 0:
+		resolution.Decision = CategoryDecisionCreate
+	case resolution.Candidates[0].Confidence >= categoryMatchConfidence:
+		resolution.Decision = CategoryDecisionMatch
+		resolution.Match = &resolution.Candidates[0]
+	default:
+		resolution.Decision = CategoryDecisionAsk
+	}
+}
+
+// ResolveCategoryByName returns the user's category a spoken category name of the type refers to.
+// A new category is created when none resembles it, a *CategoryConfirmationError is returned
+// when some do but none closely enough. The language of the user normalizes the phrase.
+func ResolveCategoryByName(userID uuid.UUID, name, categoryType string) (*models.Category, error) {
+	language, err := ResolveLanguage(userID, "")
+	if err != nil {
+		return nil, err
+	}
+
+	resolution, err := MatchCategory(userID, name, categoryType, language)
+	if err != nil {
+		return nil, err
+	}
+	switch resolution.Decision {
+	case CategoryDecisionMatch:
+		return resolution.Match.Category, nil
+	case CategoryDecisionAsk:
+		return nil, &CategoryConfirmationError{Phrase: resolution.Phrase, Candidates: resolution.Candidates}
+	}
+
+	category := &models.Category{
+		Name:   categoryNameOf(resolution.Phrase, language.Grammar.CategoryWords),
+		Type:   categoryType,
+		UserID: userID,
+	}
+	if err := CreateCategory(category); err != nil {
+		return nil, err
+	}
+	return category, nil
+}
+
+// CategoryAliases returns the aliases of a category of the user
+func CategoryAliases(userID, categoryID uuid.UUID) ([]models.CategoryAlias, error) {
+	db := database.DB
+	if _, err := repositories.FindCategoryByID(db, userID, categoryID); err != nil {
+		return nil, err
+	}
+	return repositories.FindCategoryAliases(db, categoryID)
+}
+
+// AddCategoryAlias adds another name to a category of the user. An alias must not be the name
+// or an alias of another category of the same type.
+func AddCategoryAlias(userID, categoryID uuid.UUID, text string) (*models.CategoryAlias, error) {
+	db := database.DB
+	category, err := repositories.FindCategoryByID(db, userID, categoryID)
+	if err != nil {
+		return nil, err
+	}
+
+	alias := &models.CategoryAlias{UserID: userID, CategoryID: categoryID, Alias: normalizeCategoryPhrase(text)}
+	if alias.Alias == "" {
+		return nil, fmt.Errorf("%w: alias is required", ErrInvalidCategory)
+	}
+	if utf8.RuneCountInString(alias.Alias) > 100 {
+		return nil, fmt.Errorf("%w: alias must be at most 100 characters", ErrInvalidCategory)
+	}
+
+	named, err := repositories.FindCategoryByName(db, userID, alias.Alias, category.Type)
+	if err == nil && named.ID != categoryID {
+		return nil, fmt.Errorf("%w: %q is the name of another category", ErrCategoryExists, alias.Alias)
+	}
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	aliases, err := repositories.FindAliasesByUser(db, userID, category.Type)
+	if err != nil {
+		return nil, err
+	}
+	for _, other := range aliases {
+		if other.Alias == alias.Alias {
+			return nil, fmt.Errorf("%w: %q is already an alias", ErrCategoryExists, alias.Alias)
+		}
+	}
+
+	if err := repositories.AddCategoryAlias(db, alias); err != nil {
+		return nil, err
+	}
+	return alias, nil
+}
+
+func DeleteCategoryAlias(userID, categoryID, aliasID uuid.UUID) error {
+	return repositories.DeleteCategoryAlias(database.DB, userID, categoryID, aliasID)
+}
+
+// normalizeCategoryPhrase lower cases a phrase, writes apostrophes the ASCII way and collapses spaces
+func normalizeCategoryPhrase(phrase string) string {
+	phrase = strings.NewReplacer("’", "'", "ʼ", "'", "`", "'", "ё", "е").Replace(strings.ToLower(phrase))
+	return strings.Join(strings.Fields(phrase), " ")
+}
+
+// categoryNameOf is the name a category created for a phrase gets: the phrase without leading stop words
+func categoryNameOf(phrase string, words *CategoryWords) string {
+	fields := strings.Fields(phrase)
+	for len(fields) > 1 && words != nil && containsString(words.StopWords, fields[0]) {
+		fields = fields[1:]
+	}
+	return strings.Join(fields, " ")
+}
+
+// categoryStems splits a normalized phrase into words without stop words and inflection endings
+func categoryStems(phrase string, words *CategoryWords) []string {
+	var stems []string
+	for _, word := range strings.FieldsFunc(phrase, func(r rune) bool {
+		return r == ' ' || r == ',' || r == '.' || r == '-' || r == '/'
+	}) {
+		if words == nil {
+			stems = append(stems, word)
+			continue
+		}
+		if containsString(words.StopWords, word) {
+			continue
+		}
+		stems = append(stems, stemCategoryWord(word, words.Endings))
+	}
+	if len(stems) == 0 && phrase != "" {
+		// A phrase of stop words only is compared as it is
+		stems = strings.Fields(phrase)
+	}
+	return stems
+}
+
+// stemCategoryWord removes the longest of the endings a word ends with, keeping minCategoryStem letters at least
+func stemCategoryWord(word string, endings []string) string {
+	longest := ""
+	for _, ending := range endings {
+		if len(ending) > len(longest) && strings.HasSuffix(word, ending) &&
+			utf8.RuneCountInString(word)-utf8.RuneCountInString(ending) >= minCategoryStem {
+			longest = ending
+		}
+	}
+	return strings.TrimSuffix(word, longest)
+}
+
+// categorySimilarity rates how close a spoken phrase is to the name or an alias of a category,
+// from 0 for nothing in common to 1 for the same text
+func categorySimilarity(phrase, text string, words *CategoryWords) float64 {
+	text = normalizeCategoryPhrase(text)
+	if phrase == text {
+		return 1
+	}
+
+	phraseStems, textStems := categoryStems(phrase, words), categoryStems(text, words)
+	joinedPhrase, joinedText := strings.Join(phraseStems, " "), strings.Join(textStems, " ")
+	if joinedPhrase == joinedText {
+		return 0.95
+	}
+
+	// The whole stemmed phrase or its words, whichever fits better
+	similarity := editSimilarity(joinedPhrase, joinedText)
+	if words := wordSimilarity(phraseStems, textStems); words > similarity {
+		similarity = words
+	}
+	return 0.9 * similarity
+}
+
+// wordSimilarity is the Dice coefficient of two lists of words, where words match when they are
+// nearly the same, each word is counted by how close it is to its best match
+func wordSimilarity(a, b []string) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+	var matched float64
+	for _, x := range a {
+		var best float64
+		for _, y := range b {
+			if similarity := editSimilarity(x, y); similarity > best {
+				best = similarity
+			}
+		}
+		// Words sharing less than most of their letters do not match at all
+		if best >= 0.75 {
+			matched += best
+		}
+	}
+	return 2 * matched / float64(len(a)+len(b))
+}
+
+// editSimilarity is 1 minus the Levenshtein distance of two texts relative to the longer one
+func editSimilarity(a, b string) float64 {
+	x, y := []rune(a), []rune(b)
+	longest := len(x)
+	if len(y) > longest {
+		longest = len(y)
+	}
+	if longest == 0 {
+		return 1
+	}
+	return 1 - float64(levenshtein(x, y))/float64(longest)
+}
+
+// levenshtein counts the insertions, deletions and substitutions of letters turning a into b
+func levenshtein(a, b []rune) int {
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = minInt(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(b)]
+}
+
+func minInt(values ...int) int {
+	min := values[0]
+	for _, value := range values[1:] {
+		if value < min {
+			min = value
+		}
+	}
+	return min
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
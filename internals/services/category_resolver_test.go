@@ -0,0 +1,163 @@
+package services
+
+import (
+	"math"
+	"testing"
+
+	models "github.com/KashyretsIvanna/voice-balance/internals/model"
+	"github.com/google/uuid"
+)
+
+func TestCategorySimilarity(t *testing.T) {
+	tests := []struct {
+		phrase, text string
+		want         float64
+	}{
+		{"продукти", "Продукти", 1},
+		// Inflected forms and stop words are left out
+		{"продуктах", "Продукти", 0.95},
+		{"продуктів", "Продукти", 0.95},
+		{"на їжу", "Їжа", 0.95},
+		// One letter mistyped in eight
+		{"продукьи", "Продукти", 0.9 * (1 - 1.0/7)},
+		// One word of two matches
+		{"кафе", "Кафе та ресторани", 0.6},
+		{"одяг", "Продукти", 0.9 * (1 - 5.0/7)},
+		{"ліки", "Здоров'я", 0},
+	}
+	for _, test := range tests {
+		got := categorySimilarity(normalizeCategoryPhrase(test.phrase), test.text, ukrainianCategoryWords)
+		if math.Abs(got-test.want) > 1e-9 {
+			t.Errorf("categorySimilarity(%q, %q) = %v, want %v", test.phrase, test.text, got, test.want)
+		}
+	}
+}
+
+func TestStemCategoryWord(t *testing.T) {
+	tests := []struct{ word, want string }{
+		{"продуктах", "продукт"},
+		{"продукти", "продукт"},
+		{"продуктів", "продукт"},
+		{"їжу", "їж"},
+		// The longest ending is removed
+		{"розвагами", "розваг"},
+		// Two letters are kept at least
+		{"має", "ма"},
+		{"ах", "ах"},
+	}
+	for _, test := range tests {
+		if got := stemCategoryWord(test.word, ukrainianCategoryWords.Endings); got != test.want {
+			t.Errorf("stemCategoryWord(%q) = %q, want %q", test.word, got, test.want)
+		}
+	}
+}
+
+func TestWordSimilarity(t *testing.T) {
+	tests := []struct {
+		a, b []string
+		want float64
+	}{
+		{[]string{"кафе"}, []string{"кафе", "ресторан"}, 2.0 / 3},
+		{[]string{"ресторан", "кафе"}, []string{"кафе", "ресторан"}, 1},
+		// Words sharing less than three quarters of their letters do not count
+		{[]string{"кава"}, []string{"кафе"}, 0},
+		{[]string{"транспотр"}, []string{"транспорт"}, 1 - 2.0/9},
+		{nil, []string{"кафе"}, 0},
+	}
+	for _, test := range tests {
+		if got := wordSimilarity(test.a, test.b); math.Abs(got-test.want) > 1e-9 {
+			t.Errorf("wordSimilarity(%q, %q) = %v, want %v", test.a, test.b, got, test.want)
+		}
+	}
+}
+
+func TestLevenshtein(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"", "", 0},
+		{"кава", "", 4},
+		{"кава", "кафе", 2},
+		{"транспотр", "транспорт", 2},
+		{"продукти", "продукьи", 1},
+		{"їжа", "їжу", 1},
+	}
+	for _, test := range tests {
+		if got := levenshtein([]rune(test.a), []rune(test.b)); got != test.want {
+			t.Errorf("levenshtein(%q, %q) = %d, want %d", test.a, test.b, got, test.want)
+		}
+	}
+}
+
+func TestRankCategories(t *testing.T) {
+	groceries := models.Category{ID: uuid.New(), Name: "Продукти", Type: models.CategoryTypeExpense}
+	food := models.Category{ID: uuid.New(), Name: "Їжа", Type: models.CategoryTypeExpense}
+	cafe := models.Category{ID: uuid.New(), Name: "Кафе та ресторани", Type: models.CategoryTypeExpense}
+	// An income category of the same name is not a candidate for expenses
+	salary := models.Category{ID: uuid.New(), Name: "Продукти", Type: models.CategoryTypeIncome}
+	categories := []models.Category{groceries, food, cafe, salary}
+	aliases := []models.CategoryAlias{{CategoryID: groceries.ID, Alias: "харчі"}}
+
+	tests := []struct {
+		phrase    string
+		decision  string
+		category  uuid.UUID
+		matchedBy string
+	}{
+		{"продуктах", CategoryDecisionMatch, groceries.ID, CategoryMatchedByName},
+		{"на їжу", CategoryDecisionMatch, food.ID, CategoryMatchedByName},
+		{"Продукьи", CategoryDecisionMatch, groceries.ID, CategoryMatchedByName},
+		{"харчі", CategoryDecisionMatch, groceries.ID, CategoryMatchedByAlias},
+		{"харчах", CategoryDecisionMatch, groceries.ID, CategoryMatchedByAlias},
+		{"кафе", CategoryDecisionAsk, cafe.ID, CategoryMatchedByName},
+		{"одяг", CategoryDecisionCreate, uuid.Nil, ""},
+	}
+	for _, test := range tests {
+		resolution := &CategoryResolution{Phrase: normalizeCategoryPhrase(test.phrase), Candidates: []CategoryMatch{}}
+		rankCategories(resolution, categories, aliases, models.CategoryTypeExpense, ukrainianCategoryWords)
+
+		if resolution.Decision != test.decision {
+			t.Errorf("%q: decision %q, want %q", test.phrase, resolution.Decision, test.decision)
+			continue
+		}
+		switch test.decision {
+		case CategoryDecisionMatch:
+			if resolution.Match == nil || resolution.Match.Category.ID != test.category || resolution.Match.MatchedBy != test.matchedBy {
+				t.Errorf("%q: match %+v, want %s by %s", test.phrase, resolution.Match, test.category, test.matchedBy)
+			}
+		case CategoryDecisionAsk:
+			if resolution.Match != nil || len(resolution.Candidates) == 0 || resolution.Candidates[0].Category.ID != test.category {
+				t.Errorf("%q: match %+v and candidates %+v, want %s offered first", test.phrase, resolution.Match, resolution.Candidates, test.category)
+			}
+		case CategoryDecisionCreate:
+			if resolution.Match != nil || len(resolution.Candidates) != 0 {
+				t.Errorf("%q: match %+v and candidates %+v, want none", test.phrase, resolution.Match, resolution.Candidates)
+			}
+		}
+		for _, candidate := range resolution.Candidates {
+			if candidate.Category.Type != models.CategoryTypeExpense {
+				t.Errorf("%q: candidate %q of type %q", test.phrase, candidate.Category.Name, candidate.Category.Type)
+			}
+		}
+	}
+}
+
+func TestCategoryNameOf(t *testing.T) {
+	tests := []struct{ phrase, want string }{
+		{"на подарунки", "подарунки"},
+		{"для дітей", "дітей"},
+		{"на за подарунки", "подарунки"},
+		// Only leading stop words are removed, and one word is always kept
+		{"кафе та ресторани", "кафе та ресторани"},
+		{"на", "на"},
+	}
+	for _, test := range tests {
+		if got := categoryNameOf(test.phrase, ukrainianCategoryWords); got != test.want {
+			t.Errorf("categoryNameOf(%q) = %q, want %q", test.phrase, got, test.want)
+		}
+	}
+	if got := categoryNameOf("на подарунки", nil); got != "на подарунки" {
+		t.Errorf("categoryNameOf without words = %q", got)
+	}
+}
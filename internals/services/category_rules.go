@@ -0,0 +1,454 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/KashyretsIvanna/voice-balance/database"
+	models "github.com/KashyretsIvanna/voice-balance/internals/model"
+	repositories "github.com/KashyretsIvanna/voice-balance/internals/repositories"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ErrInvalidCategoryRule is returned when a category rule or applying the rules does not pass validation
+var ErrInvalidCategoryRule = errors.New("invalid category rule")
+
+const (
+	// maxCategoryRuleKeywords limits the keywords of one category rule
+	maxCategoryRuleKeywords = 50
+	// categoryRuleBatchSize limits the transactions loaded and saved at once when the rules are applied
+	categoryRuleBatchSize = 500
+	// maxCategoryRuleChanges limits the changes listed when the rules are applied, all of them are made
+	maxCategoryRuleChanges = 1000
+)
+
+// CategoryRuleUpdate holds the category rule fields a user is allowed to change.
+// Nil fields are left untouched, ClearMinAmount and ClearMaxAmount remove a bound of the amount.
+type CategoryRuleUpdate struct {
+	Name           *string            `json:"name" example:"кава"`
+	CategoryID     *uuid.UUID         `json:"category_id"`
+	Priority       *int               `json:"priority"`
+	Keywords       *models.StringList `json:"keywords" swaggertype:"array,string"`
+	Pattern        *string            `json:"pattern" example:"^(аптека|pharmacy)"`
+	MinAmount      *models.Money      `json:"min_amount" swaggertype:"number"`
+	MaxAmount      *models.Money      `json:"max_amount" swaggertype:"number"`
+	ClearMinAmount bool               `json:"clear_min_amount"`
+	ClearMaxAmount bool               `json:"clear_max_amount"`
+	Weekdays       *models.StringList `json:"weekdays" swaggertype:"array,string"`
+}
+
+// CategoryRuleApply selects the transactions the category rules are applied to again
+type CategoryRuleApply struct {
+	// Dates are YYYY-MM-DD in the time zone of the user or RFC3339, both ends are included
+	StartDate string `json:"start_date" example:"2024-01-01"`
+	EndDate   string `json:"end_date" example:"2024-12-31"`
+	// Only transactions of these categories are recategorized, all transactions when empty
+	CategoryIDs []uuid.UUID `json:"category_ids"`
+	// DryRun lists the changes without saving them
+	DryRun bool `json:"dry_run"`
+}
+
+// CategoryRuleChange is a transaction a category rule moves into another category
+type CategoryRuleChange struct {
+	TransactionID  uuid.UUID    `json:"transaction_id"`
+	Description    string       `json:"description"`
+	Amount         models.Money `json:"amount" swaggertype:"number"`
+	Currency       string       `json:"currency"`
+	Date           time.Time    `json:"date"`
+	FromCategoryID uuid.UUID    `json:"from_category_id"`
+	FromCategory   string       `json:"from_category"`
+	ToCategoryID   uuid.UUID    `json:"to_category_id"`
+	ToCategory     string       `json:"to_category"`
+	RuleID         uuid.UUID    `json:"rule_id"`
+	RuleName       string       `json:"rule_name"`
+}
+
+// CategoryRuleApplication is the outcome of applying the category rules to existing transactions
+type CategoryRuleApplication struct {
+	DryRun bool `json:"dry_run"`
+	// Checked counts the transactions the rules were tried on
+	Checked int `json:"checked"`
+	// Changed counts the transactions a rule moves into another category
+	Changed int `json:"changed"`
+	// Changes lists the first of them, Truncated is set when there are more
+	Changes   []CategoryRuleChange `json:"changes"`
+	Truncated bool                 `json:"truncated"`
+	// Updated counts the transactions saved into their new category, zero on a dry run. Transactions
+	// deleted or moved into another category meanwhile are left as they are and not counted.
+	Updated int64 `json:"updated"`
+}
+
+// categoryRuleMatcher is a category rule ready to be tried on transactions
+type categoryRuleMatcher struct {
+	rule         models.CategoryRule
+	categoryName string
+	categoryType string
+	pattern      *regexp.Regexp
+	weekdays     map[time.Weekday]bool
+}
+
+// matches tells whether all conditions of the rule that are set hold for the transaction.
+// The weekday is the one of the date in location.
+func (m *categoryRuleMatcher) matches(transaction *models.Transaction, location *time.Location) bool {
+	description := strings.TrimSpace(transaction.Description)
+	if len(m.rule.Keywords) > 0 {
+		lower := strings.ToLower(description)
+		found := false
+		for _, keyword := range m.rule.Keywords {
+			if strings.Contains(lower, keyword) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if m.pattern != nil && !m.pattern.MatchString(description) {
+		return false
+	}
+	if m.rule.MinAmount != nil && transaction.Amount < *m.rule.MinAmount {
+		return false
+	}
+	if m.rule.MaxAmount != nil && transaction.Amount > *m.rule.MaxAmount {
+		return false
+	}
+	if len(m.weekdays) > 0 && !m.weekdays[transaction.Date.In(location).Weekday()] {
+		return false
+	}
+	return true
+}
+
+// compileCategoryRulePattern compiles the pattern of a rule ignoring case
+func compileCategoryRulePattern(pattern string) (*regexp.Regexp, error) {
+	if pattern == "" {
+		return nil, nil
+	}
+	return regexp.Compile("(?i)" + pattern)
+}
+
+// validateCategoryRule normalizes the fields of a category rule and checks them.
+// Keywords and weekdays are trimmed, lower cased and deduplicated.
+func validateCategoryRule(rule *models.CategoryRule) error {
+	rule.Name = strings.TrimSpace(rule.Name)
+	if rule.Name == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalidCategoryRule)
+	}
+	if len([]rune(rule.Name)) > 100 {
+		return fmt.Errorf("%w: name must be at most 100 characters", ErrInvalidCategoryRule)
+	}
+
+	keywords := models.StringList{}
+	for _, keyword := range rule.Keywords {
+		keyword = strings.ToLower(strings.TrimSpace(keyword))
+		if keyword == "" || containsString(keywords, keyword) {
+			continue
+		}
+		if len([]rune(keyword)) > 100 {
+			return fmt.Errorf("%w: keyword %q must be at most 100 characters", ErrInvalidCategoryRule, keyword)
+		}
+		keywords = append(keywords, keyword)
+	}
+	if len(keywords) > maxCategoryRuleKeywords {
+		return fmt.Errorf("%w: a rule has at most %d keywords", ErrInvalidCategoryRule, maxCategoryRuleKeywords)
+	}
+	rule.Keywords = keywords
+
+	rule.Pattern = strings.TrimSpace(rule.Pattern)
+	if len([]rune(rule.Pattern)) > 255 {
+		return fmt.Errorf("%w: pattern must be at most 255 characters", ErrInvalidCategoryRule)
+	}
+	if _, err := compileCategoryRulePattern(rule.Pattern); err != nil {
+		return fmt.Errorf("%w: pattern: %v", ErrInvalidCategoryRule, err)
+	}
+
+	if rule.MinAmount != nil && *rule.MinAmount < 0 || rule.MaxAmount != nil && *rule.MaxAmount < 0 {
+		return fmt.Errorf("%w: amounts must not be negative", ErrInvalidCategoryRule)
+	}
+	if rule.MinAmount != nil && rule.MaxAmount != nil && *rule.MinAmount > *rule.MaxAmount {
+		return fmt.Errorf("%w: min_amount is greater than max_amount", ErrInvalidCategoryRule)
+	}
+
+	days := models.StringList{}
+	for _, day := range rule.Weekdays {
+		day = strings.ToLower(strings.TrimSpace(day))
+		if _, ok := weekdays[day]; !ok {
+			return fmt.Errorf("%w: weekday %q must be one of monday to sunday", ErrInvalidCategoryRule, day)
+		}
+		if !containsString(days, day) {
+			days = append(days, day)
+		}
+	}
+	rule.Weekdays = days
+
+	if len(rule.Keywords) == 0 && rule.Pattern == "" && rule.MinAmount == nil && rule.MaxAmount == nil && len(rule.Weekdays) == 0 {
+		return fmt.Errorf("%w: set keywords, a pattern, an amount range or weekdays", ErrInvalidCategoryRule)
+	}
+
+	_, err := repositories.FindCategoryByID(database.DB, rule.UserID, rule.CategoryID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return fmt.Errorf("%w: category %s not found", ErrInvalidCategoryRule, rule.CategoryID)
+	}
+	return err
+}
+
+// CreateCategoryRule saves a new category rule of the user
+func CreateCategoryRule(rule *models.CategoryRule) error {
+	if err := validateCategoryRule(rule); err != nil {
+		return err
+	}
+	return repositories.SaveCategoryRule(rule)
+}
+
+// GetCategoryRules returns the category rules of the user in the order they are tried
+func GetCategoryRules(userID uuid.UUID) ([]models.CategoryRule, error) {
+	return repositories.FindCategoryRules(userID)
+}
+
+func GetCategoryRule(userID, ruleID uuid.UUID) (*models.CategoryRule, error) {
+	return repositories.FindCategoryRuleByID(userID, ruleID)
+}
+
+func UpdateCategoryRule(userID, ruleID uuid.UUID, update CategoryRuleUpdate) (*models.CategoryRule, error) {
+	rule, err := repositories.FindCategoryRuleByID(userID, ruleID)
+	if err != nil {
+		return nil, err
+	}
+
+	if update.Name != nil {
+		rule.Name = *update.Name
+	}
+	if update.CategoryID != nil {
+		rule.CategoryID = *update.CategoryID
+	}
+	if update.Priority != nil {
+		rule.Priority = *update.Priority
+	}
+	if update.Keywords != nil {
+		rule.Keywords = *update.Keywords
+	}
+	if update.Pattern != nil {
+		rule.Pattern = *update.Pattern
+	}
+	if update.MinAmount != nil {
+		rule.MinAmount = update.MinAmount
+	}
+	if update.ClearMinAmount {
+		rule.MinAmount = nil
+	}
+	if update.MaxAmount != nil {
+		rule.MaxAmount = update.MaxAmount
+	}
+	if update.ClearMaxAmount {
+		rule.MaxAmount = nil
+	}
+	if update.Weekdays != nil {
+		rule.Weekdays = *update.Weekdays
+	}
+
+	if err := validateCategoryRule(rule); err != nil {
+		return nil, err
+	}
+	if err := repositories.UpdateCategoryRule(rule); err != nil {
+		return nil, err
+	}
+	return rule, nil
+}
+
+func DeleteCategoryRule(userID, ruleID uuid.UUID) error {
+	rule, err := repositories.FindCategoryRuleByID(userID, ruleID)
+	if err != nil {
+		return err
+	}
+	return repositories.SoftDeleteCategoryRule(rule)
+}
+
+// categoryRuleMatchers returns the category rules of the user in the order they are tried
+// together with the time zone of the user weekdays are taken in
+func categoryRuleMatchers(userID uuid.UUID) ([]categoryRuleMatcher, *time.Location, error) {
+	rules, err := repositories.FindCategoryRules(userID)
+	if err != nil || len(rules) == 0 {
+		return nil, nil, err
+	}
+	byID, err := categoryIndex(userID)
+	if err != nil {
+		return nil, nil, err
+	}
+	location, err := UserLocation(userID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return newCategoryRuleMatchers(rules, byID), location, nil
+}
+
+// newCategoryRuleMatchers prepares the rules of existing categories in the order they are tried:
+// lower priorities first and older rules first among equal priorities
+func newCategoryRuleMatchers(rules []models.CategoryRule, byID map[uuid.UUID]*models.Category) []categoryRuleMatcher {
+	matchers := make([]categoryRuleMatcher, 0, len(rules))
+	for _, rule := range rules {
+		category, ok := byID[rule.CategoryID]
+		if !ok {
+			continue
+		}
+		pattern, err := compileCategoryRulePattern(rule.Pattern)
+		if err != nil {
+			// Patterns are checked when they are saved, a broken one only disables its rule
+			log.Printf("category rule %s has an invalid pattern: %v", rule.ID, err)
+			continue
+		}
+		matcher := categoryRuleMatcher{rule: rule, categoryName: category.Name, categoryType: category.Type, pattern: pattern}
+		if len(rule.Weekdays) > 0 {
+			matcher.weekdays = make(map[time.Weekday]bool, len(rule.Weekdays))
+			for _, day := range rule.Weekdays {
+				matcher.weekdays[weekdays[day]] = true
+			}
+		}
+		matchers = append(matchers, matcher)
+	}
+
+	sort.SliceStable(matchers, func(i, j int) bool {
+		a, b := matchers[i].rule, matchers[j].rule
+		if a.Priority != b.Priority {
+			return a.Priority < b.Priority
+		}
+		return a.CreatedAt.Before(b.CreatedAt)
+	})
+	return matchers
+}
+
+// firstMatchingRule returns the first matcher the transaction matches, or nil if none does.
+// Unless categoryType is empty, rules of categories of another type are skipped.
+func firstMatchingRule(matchers []categoryRuleMatcher, transaction *models.Transaction, categoryType string, location *time.Location) *categoryRuleMatcher {
+	for i := range matchers {
+		if categoryType != "" && matchers[i].categoryType != categoryType {
+			continue
+		}
+		if matchers[i].matches(transaction, location) {
+			return &matchers[i]
+		}
+	}
+	return nil
+}
+
+// matchCategoryRule returns the first category rule of the user the transaction matches,
+// or nil if none does. Unless categoryType is empty, rules of categories of another type are skipped.
+func matchCategoryRule(transaction *models.Transaction, categoryType string) (*models.CategoryRule, error) {
+	matchers, location, err := categoryRuleMatchers(transaction.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if matcher := firstMatchingRule(matchers, transaction, categoryType, location); matcher != nil {
+		return &matcher.rule, nil
+	}
+	return nil, nil
+}
+
+// ApplyCategoryRules tries the category rules on existing transactions of the user and moves each
+// transaction into the category of the first rule it matches. Only rules of the type of the current
+// category of a transaction are tried, so expenses stay expenses. With DryRun the changes are only listed.
+// Transactions are loaded and saved in batches of categoryRuleBatchSize, each batch in a database
+// transaction of its own, and at most maxCategoryRuleChanges changes are listed.
+func ApplyCategoryRules(userID uuid.UUID, apply CategoryRuleApply) (*CategoryRuleApplication, error) {
+	location, err := UserLocation(userID)
+	if err != nil {
+		return nil, err
+	}
+	var start, end *time.Time
+	if apply.StartDate != "" {
+		t, err := parseRangeDate(apply.StartDate, location, false)
+		if err != nil {
+			return nil, fmt.Errorf("%w: start_date %q must be YYYY-MM-DD or RFC3339", ErrInvalidDateRange, apply.StartDate)
+		}
+		start = &t
+	}
+	if apply.EndDate != "" {
+		t, err := parseRangeDate(apply.EndDate, location, true)
+		if err != nil {
+			return nil, fmt.Errorf("%w: end_date %q must be YYYY-MM-DD or RFC3339", ErrInvalidDateRange, apply.EndDate)
+		}
+		end = &t
+	}
+	if start != nil && end != nil && end.Before(*start) {
+		return nil, fmt.Errorf("%w: end_date is before start_date", ErrInvalidDateRange)
+	}
+
+	application := &CategoryRuleApplication{DryRun: apply.DryRun, Changes: []CategoryRuleChange{}}
+	matchers, location, err := categoryRuleMatchers(userID)
+	if err != nil || len(matchers) == 0 {
+		return application, err
+	}
+
+	var after *models.Transaction
+	for {
+		transactions, err := repositories.FindTransactionsPage(userID, start, end, apply.CategoryIDs, after, categoryRuleBatchSize)
+		if err != nil {
+			return nil, err
+		}
+
+		groups := application.add(matchers, transactions, location)
+		if !apply.DryRun && len(groups) > 0 {
+			updated, err := repositories.RecategorizeTransactionGroups(userID, groups)
+			if err != nil {
+				return nil, err
+			}
+			application.Updated += updated
+		}
+
+		if len(transactions) < categoryRuleBatchSize {
+			return application, nil
+		}
+		after = &transactions[len(transactions)-1]
+	}
+}
+
+// add tries the rules on a batch of transactions and counts and lists the changes. It returns
+// the IDs of the transactions to move grouped by their current and their new category.
+func (application *CategoryRuleApplication) add(matchers []categoryRuleMatcher, transactions []models.Transaction, location *time.Location) map[repositories.CategoryMove][]uuid.UUID {
+	groups := make(map[repositories.CategoryMove][]uuid.UUID)
+	for i := range transactions {
+		transaction := &transactions[i]
+		application.Checked++
+
+		matcher := firstMatchingRule(matchers, transaction, transaction.Category.Type, location)
+		if matcher == nil || matcher.rule.CategoryID == transaction.CategoryID {
+			continue
+		}
+		move := repositories.CategoryMove{From: transaction.CategoryID, To: matcher.rule.CategoryID}
+		groups[move] = append(groups[move], transaction.ID)
+
+		application.Changed++
+		if len(application.Changes) >= maxCategoryRuleChanges {
+			application.Truncated = true
+			continue
+		}
+		application.Changes = append(application.Changes, CategoryRuleChange{
+			TransactionID:  transaction.ID,
+			Description:    transaction.Description,
+			Amount:         transaction.Amount,
+			Currency:       transaction.Currency,
+			Date:           transaction.Date,
+			FromCategoryID: transaction.CategoryID,
+			FromCategory:   transaction.Category.Name,
+			ToCategoryID:   matcher.rule.CategoryID,
+			ToCategory:     matcher.categoryName,
+			RuleID:         matcher.rule.ID,
+			RuleName:       matcher.rule.Name,
+		})
+	}
+	return groups
+}
+
+func containsUUID(ids []uuid.UUID, id uuid.UUID) bool {
+	for _, other := range ids {
+		if other == id {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,201 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	models "github.com/KashyretsIvanna/voice-balance/internals/model"
+	repositories "github.com/KashyretsIvanna/voice-balance/internals/repositories"
+	"github.com/google/uuid"
+)
+
+// ruleCategories is a category index with an expense and an income category
+func ruleCategories() (map[uuid.UUID]*models.Category, *models.Category, *models.Category) {
+	coffee := &models.Category{ID: uuid.New(), Name: "кава", Type: models.CategoryTypeExpense}
+	salary := &models.Category{ID: uuid.New(), Name: "зарплата", Type: models.CategoryTypeIncome}
+	return map[uuid.UUID]*models.Category{coffee.ID: coffee, salary.ID: salary}, coffee, salary
+}
+
+func ruleMatcher(t *testing.T, rule models.CategoryRule) *categoryRuleMatcher {
+	t.Helper()
+	byID, coffee, _ := ruleCategories()
+	rule.CategoryID = coffee.ID
+	matchers := newCategoryRuleMatchers([]models.CategoryRule{rule}, byID)
+	if len(matchers) != 1 {
+		t.Fatalf("the rule %+v was not prepared", rule)
+	}
+	return &matchers[0]
+}
+
+func TestCategoryRuleMatchesDescription(t *testing.T) {
+	keywords := ruleMatcher(t, models.CategoryRule{Keywords: models.StringList{"кава", "coffee"}})
+	pattern := ruleMatcher(t, models.CategoryRule{Pattern: `^(аптека|pharmacy)(\s|$)`})
+	both := ruleMatcher(t, models.CategoryRule{Keywords: models.StringList{"кава"}, Pattern: `^starbucks`})
+
+	tests := []struct {
+		matcher     *categoryRuleMatcher
+		description string
+		want        bool
+	}{
+		{keywords, "Кава з молоком", true},
+		{keywords, "  iced COFFEE ", true},
+		{keywords, "чай", false},
+		{keywords, "", false},
+		{pattern, "Аптека Доброго дня", true},
+		{pattern, "PHARMACY 24", true},
+		{pattern, "нова аптека", false},
+		{pattern, "аптекар", false},
+		{both, "Starbucks кава", true},
+		{both, "Starbucks чай", false},
+		{both, "кава starbucks", false},
+	}
+	for _, test := range tests {
+		transaction := &models.Transaction{Description: test.description, Date: dispatchStart}
+		if got := test.matcher.matches(transaction, time.UTC); got != test.want {
+			t.Errorf("rule %+v on %q: %v, want %v", test.matcher.rule, test.description, got, test.want)
+		}
+	}
+}
+
+func TestCategoryRuleMatchesAmount(t *testing.T) {
+	min, max := models.Money(5000), models.Money(20000)
+	between := ruleMatcher(t, models.CategoryRule{MinAmount: &min, MaxAmount: &max})
+	atLeast := ruleMatcher(t, models.CategoryRule{MinAmount: &min})
+	atMost := ruleMatcher(t, models.CategoryRule{MaxAmount: &max})
+
+	tests := []struct {
+		matcher *categoryRuleMatcher
+		amount  models.Money
+		want    bool
+	}{
+		// Both bounds are included
+		{between, 5000, true},
+		{between, 20000, true},
+		{between, 4999, false},
+		{between, 20001, false},
+		{atLeast, 1000000, true},
+		{atLeast, 1, false},
+		{atMost, 1, true},
+		{atMost, 20001, false},
+	}
+	for _, test := range tests {
+		transaction := &models.Transaction{Amount: test.amount, Date: dispatchStart}
+		if got := test.matcher.matches(transaction, time.UTC); got != test.want {
+			t.Errorf("rule %+v on %v: %v, want %v", test.matcher.rule, test.amount, got, test.want)
+		}
+	}
+}
+
+func TestCategoryRuleMatchesWeekdayOfUser(t *testing.T) {
+	kyiv := time.FixedZone("EET", 2*60*60)
+	weekend := ruleMatcher(t, models.CategoryRule{Weekdays: models.StringList{"saturday", "sunday"}})
+
+	tests := []struct {
+		date time.Time
+		want bool
+	}{
+		{time.Date(2024, time.March, 9, 12, 0, 0, 0, kyiv), true},
+		{time.Date(2024, time.March, 10, 23, 0, 0, 0, kyiv), true},
+		{time.Date(2024, time.March, 11, 9, 0, 0, 0, kyiv), false},
+		// Friday 23:30 in UTC is Saturday in Kyiv
+		{time.Date(2024, time.March, 8, 23, 30, 0, 0, time.UTC), true},
+		// Sunday 22:30 in UTC is Monday in Kyiv
+		{time.Date(2024, time.March, 10, 22, 30, 0, 0, time.UTC), false},
+	}
+	for _, test := range tests {
+		transaction := &models.Transaction{Date: test.date}
+		if got := weekend.matches(transaction, kyiv); got != test.want {
+			t.Errorf("weekend rule on %v: %v, want %v", test.date, got, test.want)
+		}
+	}
+}
+
+func TestCategoryRulePriority(t *testing.T) {
+	byID, coffee, salary := ruleCategories()
+	snacks := &models.Category{ID: uuid.New(), Name: "перекуси", Type: models.CategoryTypeExpense}
+	byID[snacks.ID] = snacks
+	created := time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+	rules := []models.CategoryRule{
+		{ID: uuid.New(), Name: "late", CategoryID: snacks.ID, Priority: 10, Keywords: models.StringList{"кава"}, CreatedAt: created},
+		{ID: uuid.New(), Name: "newer", CategoryID: snacks.ID, Priority: 1, Keywords: models.StringList{"кава"}, CreatedAt: created.Add(time.Hour)},
+		{ID: uuid.New(), Name: "older", CategoryID: coffee.ID, Priority: 1, Keywords: models.StringList{"кава"}, CreatedAt: created},
+		{ID: uuid.New(), Name: "income", CategoryID: salary.ID, Priority: -5, Keywords: models.StringList{"кава"}, CreatedAt: created},
+		{ID: uuid.New(), Name: "broken", CategoryID: coffee.ID, Priority: -10, Pattern: "(", CreatedAt: created},
+		{ID: uuid.New(), Name: "deleted category", CategoryID: uuid.New(), Priority: -10, Keywords: models.StringList{"кава"}, CreatedAt: created},
+	}
+	matchers := newCategoryRuleMatchers(rules, byID)
+
+	var names []string
+	for _, matcher := range matchers {
+		names = append(names, matcher.rule.Name)
+	}
+	if want := []string{"income", "older", "newer", "late"}; !equalStrings(names, want) {
+		t.Errorf("rules tried in the order %v, want %v", names, want)
+	}
+
+	transaction := &models.Transaction{Description: "кава", Date: dispatchStart}
+	if matcher := firstMatchingRule(matchers, transaction, models.CategoryTypeExpense, time.UTC); matcher == nil || matcher.rule.Name != "older" {
+		t.Errorf("an expense matched %+v, want the older rule of the lowest expense priority", matcher)
+	}
+	if matcher := firstMatchingRule(matchers, transaction, "", time.UTC); matcher == nil || matcher.rule.Name != "income" {
+		t.Errorf("without a type %+v matched, want the rule of the lowest priority", matcher)
+	}
+	transaction.Description = "чай"
+	if matcher := firstMatchingRule(matchers, transaction, "", time.UTC); matcher != nil {
+		t.Errorf("%+v matched a transaction no rule describes", matcher)
+	}
+}
+
+func TestApplicationAddListsChangesUpToLimit(t *testing.T) {
+	byID, coffee, salary := ruleCategories()
+	other := &models.Category{ID: uuid.New(), Name: "інше", Type: models.CategoryTypeExpense}
+	byID[other.ID] = other
+	matchers := newCategoryRuleMatchers([]models.CategoryRule{
+		{ID: uuid.New(), Name: "кава", CategoryID: coffee.ID, Keywords: models.StringList{"кава"}},
+	}, byID)
+
+	transactions := make([]models.Transaction, 0, maxCategoryRuleChanges+10)
+	for i := 0; i < maxCategoryRuleChanges+5; i++ {
+		transactions = append(transactions, models.Transaction{ID: uuid.New(), Description: "кава", CategoryID: other.ID, Category: *other})
+	}
+	// Already in the category of the rule, and an income the expense rule is not tried on
+	transactions = append(transactions,
+		models.Transaction{ID: uuid.New(), Description: "кава", CategoryID: coffee.ID, Category: *coffee},
+		models.Transaction{ID: uuid.New(), Description: "кава", CategoryID: salary.ID, Category: *salary})
+
+	application := &CategoryRuleApplication{Changes: []CategoryRuleChange{}}
+	toCoffee := repositories.CategoryMove{From: other.ID, To: coffee.ID}
+	groups := application.add(matchers, transactions[:categoryRuleBatchSize], time.UTC)
+	if application.Truncated || len(groups[toCoffee]) != categoryRuleBatchSize {
+		t.Fatalf("the first batch moved %d transactions, truncated %v", len(groups[toCoffee]), application.Truncated)
+	}
+	groups = application.add(matchers, transactions[categoryRuleBatchSize:], time.UTC)
+
+	if application.Checked != len(transactions) {
+		t.Errorf("checked %d, want %d", application.Checked, len(transactions))
+	}
+	if application.Changed != maxCategoryRuleChanges+5 || len(application.Changes) != maxCategoryRuleChanges || !application.Truncated {
+		t.Errorf("changed %d, listed %d, truncated %v", application.Changed, len(application.Changes), application.Truncated)
+	}
+	// Changes that are not listed are still made
+	if moved := len(groups[toCoffee]); moved != maxCategoryRuleChanges+5-categoryRuleBatchSize {
+		t.Errorf("the second batch moved %d transactions", moved)
+	}
+	change := application.Changes[0]
+	if change.FromCategory != "інше" || change.ToCategoryID != coffee.ID || change.RuleName != "кава" {
+		t.Errorf("unexpected change %+v", change)
+	}
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,221 @@
+package services
+
+import (
+	"errors"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/KashyretsIvanna/voice-balance/database/dbtest"
+	models "github.com/KashyretsIvanna/voice-balance/internals/model"
+	"github.com/google/uuid"
+)
+
+// categoryTree is an expense tree food > groceries > fruit and an income category
+func categoryTree() (byID map[uuid.UUID]*models.Category, food, groceries, fruit, salary *models.Category) {
+	food = &models.Category{ID: uuid.New(), Name: "Їжа", Type: models.CategoryTypeExpense}
+	groceries = &models.Category{ID: uuid.New(), Name: "Продукти", Type: models.CategoryTypeExpense, ParentID: &food.ID}
+	fruit = &models.Category{ID: uuid.New(), Name: "Фрукти", Type: models.CategoryTypeExpense, ParentID: &groceries.ID}
+	salary = &models.Category{ID: uuid.New(), Name: "Зарплата", Type: models.CategoryTypeIncome}
+	byID = map[uuid.UUID]*models.Category{food.ID: food, groceries.ID: groceries, fruit.ID: fruit, salary.ID: salary}
+	return byID, food, groceries, fruit, salary
+}
+
+func TestHasAncestor(t *testing.T) {
+	byID, food, groceries, fruit, salary := categoryTree()
+
+	tests := []struct {
+		category *models.Category
+		ancestor uuid.UUID
+		want     bool
+	}{
+		{fruit, groceries.ID, true},
+		{fruit, food.ID, true},
+		{groceries, fruit.ID, false},
+		{food, food.ID, false},
+		{fruit, salary.ID, false},
+	}
+	for _, test := range tests {
+		if got := hasAncestor(byID, test.category, test.ancestor); got != test.want {
+			t.Errorf("hasAncestor(%q, %q) = %v, want %v", test.category.Name, byID[test.ancestor].Name, got, test.want)
+		}
+	}
+
+	// A cycle already in the data does not hang
+	food.ParentID = &fruit.ID
+	if hasAncestor(byID, fruit, salary.ID) {
+		t.Errorf("a category in a cycle has an unrelated ancestor")
+	}
+}
+
+func TestCheckCategoryTree(t *testing.T) {
+	byID, food, groceries, fruit, salary := categoryTree()
+	withParent := func(category *models.Category, parentID uuid.UUID) *models.Category {
+		changed := *category
+		changed.ParentID = &parentID
+		return &changed
+	}
+	withType := func(category *models.Category, categoryType string) *models.Category {
+		changed := *category
+		changed.Type = categoryType
+		return &changed
+	}
+	missing := uuid.New()
+
+	tests := []struct {
+		name     string
+		category *models.Category
+		valid    bool
+	}{
+		{"three levels", fruit, true},
+		{"new subcategory", &models.Category{ID: uuid.New(), Name: "Овочі", Type: models.CategoryTypeExpense, ParentID: &groceries.ID}, true},
+		{"moved to the top", withParent(fruit, food.ID), true},
+		{"its own parent", withParent(food, food.ID), false},
+		// food > groceries > food
+		{"parent is its child", withParent(food, groceries.ID), false},
+		{"parent is its grandchild", withParent(food, fruit.ID), false},
+		{"income under an expense parent", &models.Category{ID: uuid.New(), Name: "Кешбек", Type: models.CategoryTypeIncome, ParentID: &food.ID}, false},
+		{"expense under an income parent", withParent(fruit, salary.ID), false},
+		{"parent not found", withParent(fruit, missing), false},
+		// The subcategories of a category keep its type
+		{"type changed under subcategories", withType(groceries, models.CategoryTypeIncome), false},
+		{"type changed without subcategories", withType(&models.Category{ID: fruit.ID, Name: fruit.Name}, models.CategoryTypeIncome), true},
+	}
+	for _, test := range tests {
+		err := checkCategoryTreeOf(byID, test.category)
+		if test.valid && err != nil {
+			t.Errorf("%s: %v", test.name, err)
+		}
+		if !test.valid && !errors.Is(err, ErrInvalidCategory) {
+			t.Errorf("%s: %v, want ErrInvalidCategory", test.name, err)
+		}
+	}
+}
+
+// categoryNames returns "type name" of each category, sorted
+func categoryNames(categories []models.Category) []string {
+	names := make([]string, len(categories))
+	for i, category := range categories {
+		names[i] = category.Type + " " + category.Name
+	}
+	sort.Strings(names)
+	return names
+}
+
+// defaultCategoryNames returns "type name" of each default category of a language, sorted
+func defaultCategoryNames(t *testing.T, code string) []string {
+	t.Helper()
+	language, err := LookupLanguage(code)
+	if err != nil {
+		t.Fatal(err)
+	}
+	names := make([]string, len(language.DefaultCategories))
+	for i, category := range language.DefaultCategories {
+		names[i] = category.Type + " " + category.Name
+	}
+	sort.Strings(names)
+	return names
+}
+
+func TestSeedDefaultCategoriesOnPostgres(t *testing.T) {
+	db := dbtest.Use(t)
+
+	for _, code := range SupportedLanguageCodes() {
+		user := saveTestUser(t, db)
+		// Seeding again, after a failed registration for example, adds nothing
+		for run := 0; run < 2; run++ {
+			if err := SeedDefaultCategories(user.ID, code); err != nil {
+				t.Fatalf("%s, run %d: %v", code, run+1, err)
+			}
+		}
+		categories, err := GetCategories(user.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, want := categoryNames(categories), defaultCategoryNames(t, code); !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: categories %q, want %q", code, got, want)
+		}
+	}
+
+	// Categories the user has under another case are not repeated, unknown languages get the default one
+	user := saveTestUser(t, db)
+	saveTestCategory(t, db, user.ID, "Продукти", models.CategoryTypeExpense)
+	if err := SeedDefaultCategories(user.ID, "xx"); err != nil {
+		t.Fatal(err)
+	}
+	categories, err := GetCategories(user.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := len(categories), len(defaultCategoryNames(t, DefaultLanguageCode)); got != want {
+		t.Errorf("%d categories, want the %d default ones of %s: %q", got, want, DefaultLanguageCode, categoryNames(categories))
+	}
+}
+
+func TestResetCategoriesOnPostgres(t *testing.T) {
+	db := dbtest.Use(t)
+	user := saveTestUser(t, db)
+	if err := db.Model(user).Update("language", "en").Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := SeedDefaultCategories(user.ID, "en"); err != nil {
+		t.Fatal(err)
+	}
+	day := time.Date(2024, time.May, 1, 12, 0, 0, 0, time.UTC)
+
+	// A deleted default category is added again
+	defaults, err := GetCategories(user.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, category := range defaults {
+		if category.Name == "groceries" {
+			if err := DeleteCategory(user.ID, category.ID); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	// Categories of the user's own go, unless something uses them
+	saveTestCategory(t, db, user.ID, "unused", models.CategoryTypeExpense)
+	pets := saveTestCategory(t, db, user.ID, "pets", models.CategoryTypeExpense)
+	saveTestTransaction(t, db, &models.Transaction{Amount: 1000, Date: day, UserID: user.ID, CategoryID: pets.ID})
+	// Deleted transactions may be restored, they keep their category too
+	hobby := saveTestCategory(t, db, user.ID, "hobby", models.CategoryTypeExpense)
+	deletedAt := day
+	saveTestTransaction(t, db, &models.Transaction{Amount: 2000, Date: day, UserID: user.ID, CategoryID: hobby.ID, DeletedAt: &deletedAt})
+	// Subcategories of deleted categories become top-level categories
+	unusedParent := saveTestCategory(t, db, user.ID, "side jobs", models.CategoryTypeIncome)
+	bonus := &models.Category{Name: "bonus", Type: models.CategoryTypeIncome, UserID: user.ID, ParentID: &unusedParent.ID}
+	if err := db.Create(bonus).Error; err != nil {
+		t.Fatal(err)
+	}
+	saveTestTransaction(t, db, &models.Transaction{Amount: 3000, Date: day, UserID: user.ID, CategoryID: bonus.ID})
+	// Categories of other users are left alone
+	other := saveTestUser(t, db)
+	saveTestCategory(t, db, other.ID, "unused", models.CategoryTypeExpense)
+
+	categories, err := ResetCategories(user.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := append(defaultCategoryNames(t, "en"), "expense hobby", "expense pets", "income bonus")
+	sort.Strings(want)
+	if got := categoryNames(categories); !reflect.DeepEqual(got, want) {
+		t.Errorf("categories %q, want %q", got, want)
+	}
+	for _, category := range categories {
+		if category.ID == bonus.ID && category.ParentID != nil {
+			t.Errorf("the subcategory of a deleted category still has parent %s", category.ParentID)
+		}
+	}
+
+	othersCategories, err := GetCategories(other.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(othersCategories) != 1 {
+		t.Errorf("categories of another user %q, want the one of their own", categoryNames(othersCategories))
+	}
+}
@@ -0,0 +1,247 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/KashyretsIvanna/voice-balance/config"
+	models "github.com/KashyretsIvanna/voice-balance/internals/model"
+	repositories "github.com/KashyretsIvanna/voice-balance/internals/repositories"
+	"github.com/google/uuid"
+)
+
+var (
+	// ErrInvalidCurrency is returned for currency codes that are not three letters
+	ErrInvalidCurrency = errors.New("invalid currency")
+	// ErrInvalidExchangeRate is returned for imported rates that do not pass validation
+	ErrInvalidExchangeRate = errors.New("invalid exchange rate")
+	// ErrMissingExchangeRates is returned when amounts cannot be converted for lack of rates
+	ErrMissingExchangeRates = errors.New("missing exchange rates")
+	// ErrInvalidAmount is returned for amounts that are not positive or are more precise than their currency
+	ErrInvalidAmount = errors.New("invalid amount")
+)
+
+const (
+	// maxExchangeRateSyncDays limits the days fetched from a provider at once
+	maxExchangeRateSyncDays = 366
+	// exchangeRateImportSource is the source of rates imported through the API
+	exchangeRateImportSource = "import"
+)
+
+var currencyCodeRegex = regexp.MustCompile(`^[A-Z]{3}$`)
+
+// Currencies without minor units, their amounts are whole
+var wholeCurrencies = map[string]bool{
+	"BIF": true, "CLP": true, "DJF": true, "GNF": true, "ISK": true, "JPY": true, "KMF": true, "KRW": true,
+	"PYG": true, "RWF": true, "UGX": true, "VND": true, "VUV": true, "XAF": true, "XOF": true, "XPF": true,
+}
+
+// Currencies with thousandths, models.Money cannot hold their amounts
+var thousandthsCurrencies = map[string]bool{
+	"BHD": true, "IQD": true, "JOD": true, "KWD": true, "LYD": true, "OMR": true, "TND": true,
+}
+
+// NormalizeCurrency returns the upper case ISO 4217 code of a currency, empty for an empty code
+func NormalizeCurrency(code string) (string, error) {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	if code != "" && !currencyCodeRegex.MatchString(code) {
+		return "", fmt.Errorf("%w: %q is not a three letter ISO 4217 code", ErrInvalidCurrency, code)
+	}
+	if thousandthsCurrencies[code] {
+		return "", fmt.Errorf("%w: amounts in %s have three decimals, only currencies with at most two are supported", ErrInvalidCurrency, code)
+	}
+	return code, nil
+}
+
+// checkAmount checks that an amount is greater than zero and has no more decimals than its currency
+func checkAmount(amount models.Money, currency string) error {
+	if amount <= 0 {
+		return fmt.Errorf("%w: amount must be greater than zero", ErrInvalidAmount)
+	}
+	if wholeCurrencies[currency] && amount%100 != 0 {
+		return fmt.Errorf("%w: amounts in %s have no decimals", ErrInvalidAmount, currency)
+	}
+	return nil
+}
+
+// transactionCurrency normalizes the currency of a new transaction, the currency of the user when it is empty
+func transactionCurrency(userID uuid.UUID, code string) (string, error) {
+	currency, err := NormalizeCurrency(code)
+	if err != nil || currency != "" {
+		return currency, err
+	}
+	settings, err := GetUserSettings(userID)
+	if err != nil {
+		return "", err
+	}
+	return settings.Currency, nil
+}
+
+// reportingCurrency sets the currency of the filter to the one of the user when it is empty and
+// checks that every transaction of the user can be converted into it
+func reportingCurrency(userID uuid.UUID, filter repositories.CategoryTotalsFilter) (repositories.CategoryTotalsFilter, error) {
+	if filter.Currency == "" {
+		settings, err := GetUserSettings(userID)
+		if err != nil {
+			return filter, err
+		}
+		filter.Currency = settings.Currency
+	}
+
+	missing, err := repositories.FindCurrenciesWithoutRates(userID, filter.Currency)
+	if err != nil {
+		return filter, err
+	}
+	if len(missing) > 0 {
+		return filter, fmt.Errorf("%w: no exchange rates for %s, import them or sync them from the provider",
+			ErrMissingExchangeRates, strings.Join(missing, ", "))
+	}
+	return filter, nil
+}
+
+// ExchangeRateInput is one imported rate: the price of one unit of the currency in UAH on the date
+type ExchangeRateInput struct {
+	Currency string  `json:"currency" example:"USD"`
+	Date     string  `json:"date" example:"2025-03-01"`
+	Rate     float64 `json:"rate" example:"41.52"`
+}
+
+// ImportExchangeRates validates and saves rates, rates already stored for the same currency and date are replaced
+func ImportExchangeRates(inputs []ExchangeRateInput) ([]models.ExchangeRate, error) {
+	rates := make([]models.ExchangeRate, 0, len(inputs))
+	for i, input := range inputs {
+		currency, err := NormalizeCurrency(input.Currency)
+		if err != nil || currency == "" || currency == models.DefaultCurrency {
+			return nil, fmt.Errorf("%w: rates[%d]: currency must be a three letter code other than %s", ErrInvalidExchangeRate, i, models.DefaultCurrency)
+		}
+		date, err := time.Parse("2006-01-02", input.Date)
+		if err != nil {
+			return nil, fmt.Errorf("%w: rates[%d]: date must be YYYY-MM-DD", ErrInvalidExchangeRate, i)
+		}
+		if input.Rate <= 0 || math.IsInf(input.Rate, 0) || math.IsNaN(input.Rate) {
+			return nil, fmt.Errorf("%w: rates[%d]: rate must be greater than zero", ErrInvalidExchangeRate, i)
+		}
+		rates = append(rates, models.ExchangeRate{Currency: currency, Date: date, Rate: input.Rate, Source: exchangeRateImportSource})
+	}
+
+	if err := repositories.SaveExchangeRates(rates); err != nil {
+		return nil, err
+	}
+	return rates, nil
+}
+
+// GetExchangeRates returns the stored rates between start and end, of one currency when it is given
+func GetExchangeRates(currency string, start, end time.Time) ([]models.ExchangeRate, error) {
+	currency, err := NormalizeCurrency(currency)
+	if err != nil {
+		return nil, err
+	}
+	return repositories.FindExchangeRates(currency, start, end)
+}
+
+// ExchangeRateProvider fetches official exchange rates
+type ExchangeRateProvider interface {
+	Name() string
+	// Rates returns the prices in UAH of the currencies the provider knows on a date
+	Rates(ctx context.Context, date time.Time) ([]models.ExchangeRate, error)
+}
+
+// NewExchangeRateProvider returns the provider named by EXCHANGE_RATE_PROVIDER, the National
+// Bank of Ukraine by default. It returns nil for "none".
+func NewExchangeRateProvider() (ExchangeRateProvider, error) {
+	switch provider := strings.ToLower(strings.TrimSpace(config.Config("EXCHANGE_RATE_PROVIDER"))); provider {
+	case "", "nbu":
+		return &NBUProvider{BaseURL: config.Config("NBU_API_URL")}, nil
+	case "none":
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("unknown exchange rate provider %q", provider)
+	}
+}
+
+// SyncExchangeRates fetches the rates of every day from start to end from the provider and saves them
+func SyncExchangeRates(ctx context.Context, provider ExchangeRateProvider, start, end time.Time) (int, error) {
+	start = time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, time.UTC)
+	end = time.Date(end.Year(), end.Month(), end.Day(), 0, 0, 0, 0, time.UTC)
+	if days := int(end.Sub(start).Hours()/24) + 1; days > maxExchangeRateSyncDays {
+		return 0, fmt.Errorf("%w: at most %d days can be synced at once", ErrInvalidDateRange, maxExchangeRateSyncDays)
+	}
+
+	saved := 0
+	for day := start; !day.After(end); day = day.AddDate(0, 0, 1) {
+		rates, err := provider.Rates(ctx, day)
+		if err != nil {
+			return saved, fmt.Errorf("%s rates of %s: %w", provider.Name(), day.Format("2006-01-02"), err)
+		}
+		if err := repositories.SaveExchangeRates(rates); err != nil {
+			return saved, err
+		}
+		saved += len(rates)
+	}
+	return saved, nil
+}
+
+// defaultNBUURL is the exchange rate API of the National Bank of Ukraine
+const defaultNBUURL = "https://bank.gov.ua/NBUStatService/v1/statdirectory/exchange"
+
+// NBUProvider fetches the official rates of the National Bank of Ukraine
+type NBUProvider struct {
+	// BaseURL defaults to the public API
+	BaseURL string
+	// Client defaults to http.DefaultClient
+	Client *http.Client
+}
+
+// nbuRate is one entry of the NBU API response
+type nbuRate struct {
+	Code string  `json:"cc"`
+	Rate float64 `json:"rate"`
+}
+
+func (p *NBUProvider) Name() string { return "nbu" }
+
+func (p *NBUProvider) Rates(ctx context.Context, date time.Time) ([]models.ExchangeRate, error) {
+	baseURL := p.BaseURL
+	if baseURL == "" {
+		baseURL = defaultNBUURL
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, baseURL+"?json&date="+date.Format("20060102"), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	client := p.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("NBU responded with %s", resp.Status)
+	}
+
+	var entries []nbuRate
+	if err := json.NewDecoder(resp.Body).Decode(&entries); err != nil {
+		return nil, err
+	}
+
+	day := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC)
+	rates := make([]models.ExchangeRate, 0, len(entries))
+	for _, entry := range entries {
+		if !currencyCodeRegex.MatchString(entry.Code) || entry.Rate <= 0 {
+			continue
+		}
+		rates = append(rates, models.ExchangeRate{Currency: entry.Code, Date: day, Rate: entry.Rate, Source: p.Name()})
+	}
+	return rates, nil
+}
@@ -0,0 +1,57 @@
+package services
+
+import (
+	"errors"
+	"testing"
+
+	models "github.com/KashyretsIvanna/voice-balance/internals/model"
+)
+
+func TestCheckAmountFollowsMinorUnitsOfCurrency(t *testing.T) {
+	tests := []struct {
+		amount   models.Money
+		currency string
+		valid    bool
+	}{
+		{1250, "UAH", true},
+		{1, "USD", true},
+		{0, "UAH", false},
+		{-100, "UAH", false},
+		// Yen and won have no minor units
+		{150000, "JPY", true},
+		{150050, "JPY", false},
+		{1, "KRW", false},
+	}
+	for _, test := range tests {
+		err := checkAmount(test.amount, test.currency)
+		if valid := err == nil; valid != test.valid {
+			t.Errorf("checkAmount(%v, %s) = %v, want valid %v", test.amount, test.currency, err, test.valid)
+		}
+		if err != nil && !errors.Is(err, ErrInvalidAmount) {
+			t.Errorf("checkAmount(%v, %s) error %v is not ErrInvalidAmount", test.amount, test.currency, err)
+		}
+	}
+}
+
+func TestNormalizeCurrency(t *testing.T) {
+	tests := []struct {
+		code string
+		want string
+	}{
+		{"uah", "UAH"},
+		{" jpy ", "JPY"},
+		{"", ""},
+	}
+	for _, test := range tests {
+		if got, err := NormalizeCurrency(test.code); err != nil || got != test.want {
+			t.Errorf("NormalizeCurrency(%q) = %q, %v, want %q", test.code, got, err, test.want)
+		}
+	}
+
+	// Money keeps hundredths, currencies with thousandths like the Kuwaiti dinar cannot be held
+	for _, code := range []string{"KWD", "bhd", "TND", "US", "USDT", "гр1"} {
+		if _, err := NormalizeCurrency(code); !errors.Is(err, ErrInvalidCurrency) {
+			t.Errorf("NormalizeCurrency(%q) error = %v, want ErrInvalidCurrency", code, err)
+		}
+	}
+}
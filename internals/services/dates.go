@@ -0,0 +1,474 @@
+package services
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// defaultReminderHour is the time reminders named by a date only are due at
+const defaultReminderHour = 9
+
+// dateUnit is a unit of "через тиждень" and "3 дні тому"
+type dateUnit int
+
+const (
+	unitMinute dateUnit = iota
+	unitHour
+	unitDay
+	unitWeek
+	unitMonth
+	unitYear
+)
+
+// DateWords is the vocabulary of date and time phrases in one language.
+// Entries are lower case, written with the ASCII apostrophe and may consist of several words.
+type DateWords struct {
+	// Days relative to today, e.g. "вчора": -1
+	RelativeDays map[string]int
+	Weekdays     map[string]time.Weekday
+	// Month names as they follow a day, e.g. "березня"
+	Months map[string]time.Month
+	// Words turning a weekday into the next or the previous one, e.g. "наступного"
+	NextWords []string
+	LastWords []string
+	// Prepositions before a weekday or a date that belong to the phrase, e.g. "в"
+	Prepositions []string
+	// "через" before and "тому" after an amount of units
+	InWords  []string
+	AgoWords []string
+	Units    map[string]dateUnit
+	// "о" before a time, "вечора" and "ранку" after it
+	AtWords      []string
+	EveningWords []string
+	MorningWords []string
+	// Words following a year or an hour, e.g. "року" and "годині"
+	YearWords []string
+	HourWords []string
+}
+
+// ParsedDate is a date recognized in a command
+type ParsedDate struct {
+	Time time.Time
+	// HasTime is set when the phrase named the time of day, otherwise Time has the clock of now
+	HasTime bool
+	// Phrase is the date phrase as it was found, Rest is the command without it
+	Phrase string
+	Rest   string
+}
+
+// dateTokenRegex splits a command into ISO dates, "15.03.2025" dates, "9:30" times, numbers and words.
+// Dotted numbers are taken whole, so "12.345" is not read as "12.34" and "5".
+var dateTokenRegex = regexp.MustCompile(`\d{4}-\d{2}-\d{2}(?:t\d{2}:\d{2}(?::\d{2})?)?|\d+(?:[:.]\d+){1,2}|\d+|[\p{L}']+`)
+
+type dateToken struct {
+	text       string
+	start, end int
+}
+
+// dateParser matches date phrases in the tokens of one command
+type dateParser struct {
+	words    *DateWords
+	numerals *Numerals
+	tokens   []dateToken
+	now      time.Time
+	future   bool
+}
+
+// ParseDatePhrase finds a date like "вчора", "в понеділок", "15 березня", "через тиждень" or
+// "завтра о 9" in the text of a command. Dates are computed from now and in its location.
+// Ambiguous dates like a weekday or a day without a year are taken in the future for
+// reminders (future set) and in the past otherwise.
+func ParseDatePhrase(language *Language, text string, now time.Time, future bool) (ParsedDate, bool) {
+	text = apostropheReplacer.Replace(strings.ToLower(text))
+
+	p := &dateParser{words: language.Grammar.Dates, numerals: language.Grammar.Numerals, now: now, future: future}
+	if p.words == nil {
+		return ParsedDate{}, false
+	}
+	for _, match := range dateTokenRegex.FindAllStringIndex(text, -1) {
+		p.tokens = append(p.tokens, dateToken{text: text[match[0]:match[1]], start: match[0], end: match[1]})
+	}
+
+	var (
+		date                 time.Time
+		dateFound, exactTime bool
+		dateStart, dateEnd   int
+	)
+	for i := range p.tokens {
+		if date, dateEnd, exactTime, dateFound = p.matchDate(i); dateFound {
+			dateStart = i
+			break
+		}
+	}
+
+	var (
+		hour, minute       int
+		timeFound          bool
+		timeStart, timeEnd int
+	)
+	for i := range p.tokens {
+		if dateFound && i >= dateStart && i < dateEnd {
+			continue
+		}
+		if hour, minute, timeEnd, timeFound = p.matchTime(i); timeFound {
+			timeStart = i
+			break
+		}
+	}
+
+	if !dateFound && !timeFound {
+		return ParsedDate{}, false
+	}
+
+	result := ParsedDate{Time: date, HasTime: exactTime || timeFound}
+	if !dateFound {
+		result.Time = now
+	}
+	if timeFound {
+		y, m, d := result.Time.Date()
+		result.Time = time.Date(y, m, d, hour, minute, 0, 0, now.Location())
+		if !dateFound && future && result.Time.Before(now) {
+			// "о 9" after nine o'clock means tomorrow
+			result.Time = result.Time.AddDate(0, 0, 1)
+		}
+	}
+
+	var spans [][2]int
+	if dateFound {
+		spans = append(spans, [2]int{p.tokens[dateStart].start, p.tokens[dateEnd-1].end})
+	}
+	if timeFound {
+		spans = append(spans, [2]int{p.tokens[timeStart].start, p.tokens[timeEnd-1].end})
+	}
+	result.Phrase, result.Rest = cutSpans(text, spans)
+
+	return result, true
+}
+
+// matchDate matches a date starting at tokens[i] and returns it with the index after it.
+// exact is set for dates like "через дві години" that name the time as well.
+func (p *dateParser) matchDate(i int) (date time.Time, end int, exact bool, ok bool) {
+	// Prepositions are part of the phrase, "в понеділок" is removed from a reminder as a whole
+	start := i
+	if n := p.matchPhrase(i, p.words.Prepositions); n > 0 {
+		i += n
+	}
+
+	if offset, n := matchPhraseValue(p, i, p.words.RelativeDays); n > 0 {
+		return p.now.AddDate(0, 0, offset), i + n, false, true
+	}
+
+	if date, end, ok := p.matchWeekday(i); ok {
+		return date, end, false, true
+	}
+
+	if date, end, exact, ok := p.matchDayMonth(i); ok {
+		return date, end, exact, true
+	}
+
+	if i != start {
+		// A preposition alone is not a date, try the phrase from the next token
+		return time.Time{}, 0, false, false
+	}
+
+	return p.matchOffset(i)
+}
+
+func (p *dateParser) matchWeekday(i int) (time.Time, int, bool) {
+	next, last := false, false
+	if n := p.matchPhrase(i, p.words.NextWords); n > 0 {
+		next, i = true, i+n
+	} else if n := p.matchPhrase(i, p.words.LastWords); n > 0 {
+		last, i = true, i+n
+	}
+
+	weekday, n := matchPhraseValue(p, i, p.words.Weekdays)
+	if n == 0 {
+		return time.Time{}, 0, false
+	}
+
+	days := int(weekday - p.now.Weekday())
+	switch {
+	case next || (p.future && !last):
+		// The next such day after today
+		if days <= 0 {
+			days += 7
+		}
+	default:
+		// The last such day before today, or today when it is that day and nothing says "last"
+		if days > 0 || (last && days == 0) {
+			days -= 7
+		}
+	}
+	return p.now.AddDate(0, 0, days), i + n, true
+}
+
+// matchDayMonth matches "15 березня", "15 березня 2025 року", "15.03", "15.03.2025", "2025-03-15"
+// and "2025-03-15t09:00", the last one sets exact. "15.03" without a year looks like an amount,
+// it is a date only when it is the whole text or the text has another number for the amount.
+func (p *dateParser) matchDayMonth(i int) (date time.Time, end int, exact bool, ok bool) {
+	if i >= len(p.tokens) {
+		return time.Time{}, 0, false, false
+	}
+	token := p.tokens[i].text
+	loc := p.now.Location()
+
+	if len(token) >= 10 && token[4] == '-' {
+		layout := "2006-01-02"
+		if len(token) > 10 {
+			layout = map[int]string{16: "2006-01-02t15:04", 19: "2006-01-02t15:04:05"}[len(token)]
+		}
+		date, err := time.ParseInLocation(layout, token, loc)
+		if err != nil {
+			return time.Time{}, 0, false, false
+		}
+		if len(token) == 10 {
+			date = withClock(date, p.now)
+		}
+		return date, i + 1, len(token) > 10, true
+	}
+
+	if parts := strings.Split(token, "."); len(parts) >= 2 {
+		if i > 0 && p.matchPhrase(i-1, p.words.AtWords) > 0 {
+			// "о 9.30" is a time
+			return time.Time{}, 0, false, false
+		}
+		if i+1 < len(p.tokens) && hasAnyPrefix(p.tokens[i+1].text, p.numerals.currencyPrefixes()) {
+			// "12.50 грн" is an amount
+			return time.Time{}, 0, false, false
+		}
+		if len(parts) == 2 && len(p.tokens) > 1 && !p.hasAmountBesides(i) {
+			// "кава 12.5" is an amount, "кава 50 12.05" is an amount and a date
+			return time.Time{}, 0, false, false
+		}
+		if len(parts) > 3 || len(parts[0]) > 2 || len(parts[1]) > 2 || (len(parts) == 3 && len(parts[2]) != 2 && len(parts[2]) != 4) {
+			return time.Time{}, 0, false, false
+		}
+		day, dayErr := strconv.Atoi(parts[0])
+		month, monthErr := strconv.Atoi(parts[1])
+		if dayErr != nil || monthErr != nil || month < 1 || month > 12 {
+			return time.Time{}, 0, false, false
+		}
+		year := 0
+		if len(parts) == 3 {
+			year, _ = strconv.Atoi(parts[2])
+			if year < 100 {
+				year += 2000
+			}
+		}
+		return p.dayMonthDate(day, time.Month(month), year), i + 1, false, p.validDay(day, time.Month(month))
+	}
+
+	day, err := strconv.Atoi(token)
+	if err != nil || i+1 >= len(p.tokens) {
+		return time.Time{}, 0, false, false
+	}
+	month, n := matchPhraseValue(p, i+1, p.words.Months)
+	if n == 0 || !p.validDay(day, month) {
+		return time.Time{}, 0, false, false
+	}
+	end = i + 1 + n
+
+	year := 0
+	if end < len(p.tokens) {
+		if value, err := strconv.Atoi(p.tokens[end].text); err == nil && value >= 1000 {
+			year = value
+			end++
+			end += p.matchPhrase(end, p.words.YearWords)
+		}
+	}
+	return p.dayMonthDate(day, month, year), end, false, true
+}
+
+// hasAmountBesides reports whether a token other than tokens[i] is a number
+func (p *dateParser) hasAmountBesides(i int) bool {
+	for j, token := range p.tokens {
+		if _, _, ok := readNumber(p.numerals, []string{token.text}, 0); ok && j != i {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *dateParser) validDay(day int, month time.Month) bool {
+	return day >= 1 && day <= 31 && time.Date(2024, month, day, 0, 0, 0, 0, time.UTC).Month() == month
+}
+
+// dayMonthDate picks the year of a date named without one: the closest one in the future
+// for reminders and in the past otherwise
+func (p *dateParser) dayMonthDate(day int, month time.Month, year int) time.Time {
+	if year != 0 {
+		return withClock(time.Date(year, month, day, 0, 0, 0, 0, p.now.Location()), p.now)
+	}
+
+	date := withClock(time.Date(p.now.Year(), month, day, 0, 0, 0, 0, p.now.Location()), p.now)
+	today := startOfDay(p.now)
+	switch {
+	case p.future && date.Before(today):
+		date = date.AddDate(1, 0, 0)
+	case !p.future && date.After(today.AddDate(0, 0, 1)):
+		date = date.AddDate(-1, 0, 0)
+	}
+	return date
+}
+
+// matchOffset matches "через 3 дні", "через тиждень" and "тиждень тому"
+func (p *dateParser) matchOffset(i int) (time.Time, int, bool, bool) {
+	sign := 1
+	n := p.matchPhrase(i, p.words.InWords)
+	if n > 0 {
+		i += n
+	}
+
+	amount := 1.0
+	if value, end, ok := readNumber(p.numerals, p.tokenTexts(), i); ok {
+		amount, _ = value.Float64()
+		i = end
+	}
+
+	unit, unitLength := matchPhraseValue(p, i, p.words.Units)
+	if unitLength == 0 {
+		return time.Time{}, 0, false, false
+	}
+	i += unitLength
+
+	if n == 0 {
+		agoLength := p.matchPhrase(i, p.words.AgoWords)
+		if agoLength == 0 {
+			return time.Time{}, 0, false, false
+		}
+		sign, i = -1, i+agoLength
+	}
+
+	count := sign * int(amount)
+	switch unit {
+	case unitMinute:
+		return p.now.Add(time.Duration(count) * time.Minute), i, true, true
+	case unitHour:
+		return p.now.Add(time.Duration(amount*float64(sign)*60) * time.Minute), i, true, true
+	case unitDay:
+		return p.now.AddDate(0, 0, count), i, false, true
+	case unitWeek:
+		return p.now.AddDate(0, 0, 7*count), i, false, true
+	case unitMonth:
+		return p.now.AddDate(0, count, 0), i, false, true
+	default:
+		return p.now.AddDate(count, 0, 0), i, false, true
+	}
+}
+
+// matchTime matches "о 9", "о 9:30", "о 7 вечора" and "at 9 pm"
+func (p *dateParser) matchTime(i int) (hour, minute, end int, ok bool) {
+	n := p.matchPhrase(i, p.words.AtWords)
+	if n == 0 || i+n >= len(p.tokens) {
+		return 0, 0, 0, false
+	}
+	i += n
+
+	token := p.tokens[i].text
+	parts := strings.FieldsFunc(token, func(r rune) bool { return r == ':' || r == '.' })
+	if len(parts) > 2 {
+		return 0, 0, 0, false
+	}
+	hour, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return 0, 0, 0, false
+	}
+	if len(parts) == 2 {
+		if minute, err = strconv.Atoi(parts[1]); err != nil {
+			return 0, 0, 0, false
+		}
+	}
+	i++
+	i += p.matchPhrase(i, p.words.HourWords)
+
+	if n := p.matchPhrase(i, p.words.EveningWords); n > 0 {
+		if hour < 12 {
+			hour += 12
+		}
+		i += n
+	} else if n := p.matchPhrase(i, p.words.MorningWords); n > 0 {
+		if hour == 12 {
+			hour = 0
+		}
+		i += n
+	}
+
+	if hour > 23 || minute > 59 {
+		return 0, 0, 0, false
+	}
+	return hour, minute, i, true
+}
+
+// matchPhrase returns the number of tokens of the longest phrase starting at tokens[i], or 0
+func (p *dateParser) matchPhrase(i int, phrases []string) int {
+	longest := 0
+	for _, phrase := range phrases {
+		words := strings.Fields(phrase)
+		if len(words) <= longest || i+len(words) > len(p.tokens) {
+			continue
+		}
+		matched := true
+		for j, word := range words {
+			if p.tokens[i+j].text != word {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			longest = len(words)
+		}
+	}
+	return longest
+}
+
+// matchPhraseValue is matchPhrase for phrases mapped to values, it returns the value of the longest phrase
+func matchPhraseValue[V any](p *dateParser, i int, phrases map[string]V) (value V, n int) {
+	for phrase, v := range phrases {
+		if length := p.matchPhrase(i, []string{phrase}); length > n {
+			value, n = v, length
+		}
+	}
+	return value, n
+}
+
+func (p *dateParser) tokenTexts() []string {
+	texts := make([]string, len(p.tokens))
+	for i, token := range p.tokens {
+		texts[i] = token.text
+	}
+	return texts
+}
+
+// withClock returns the date with the time of day of clock
+func withClock(date, clock time.Time) time.Time {
+	y, m, d := date.Date()
+	return time.Date(y, m, d, clock.Hour(), clock.Minute(), clock.Second(), 0, clock.Location())
+}
+
+func startOfDay(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, t.Location())
+}
+
+// cutSpans cuts the byte ranges out of the text. It returns the cut pieces and the rest
+// of the text, both with the spaces left behind collapsed.
+func cutSpans(text string, spans [][2]int) (cut, rest string) {
+	if len(spans) == 2 && spans[1][0] < spans[0][0] {
+		spans[0], spans[1] = spans[1], spans[0]
+	}
+
+	var cutPieces, restPieces []string
+	last := 0
+	for _, span := range spans {
+		restPieces = append(restPieces, text[last:span[0]])
+		cutPieces = append(cutPieces, text[span[0]:span[1]])
+		last = span[1]
+	}
+	restPieces = append(restPieces, text[last:])
+
+	return strings.Join(strings.Fields(strings.Join(cutPieces, " ")), " "),
+		strings.Join(strings.Fields(strings.Join(restPieces, " ")), " ")
+}
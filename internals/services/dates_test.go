@@ -0,0 +1,101 @@
+package services
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseDatePhrase(t *testing.T) {
+	kyiv := time.FixedZone("EEST", 3*60*60)
+	// A Wednesday
+	now := time.Date(2024, time.May, 15, 10, 30, 0, 0, kyiv)
+	day := func(year int, month time.Month, d int) time.Time {
+		return time.Date(year, month, d, 10, 30, 0, 0, kyiv)
+	}
+
+	tests := []struct {
+		text   string
+		future bool
+		want   time.Time
+		phrase string
+		rest   string
+	}{
+		{"кава вчора", false, day(2024, time.May, 14), "вчора", "кава"},
+		{"сьогодні таксі", false, day(2024, time.May, 15), "сьогодні", "таксі"},
+		{"нагадай завтра", true, day(2024, time.May, 16), "завтра", "нагадай"},
+
+		// A weekday is the last one for expenses and the next one for reminders
+		{"в понеділок продукти", false, day(2024, time.May, 13), "в понеділок", "продукти"},
+		{"в понеділок оплатити", true, day(2024, time.May, 20), "в понеділок", "оплатити"},
+		{"в середу", false, day(2024, time.May, 15), "в середу", ""},
+		{"в середу", true, day(2024, time.May, 22), "в середу", ""},
+		{"минулої середи", false, day(2024, time.May, 8), "минулої середи", ""},
+		{"наступної п'ятниці", false, day(2024, time.May, 17), "наступної п'ятниці", ""},
+		{"у п’ятницю", true, day(2024, time.May, 17), "у п'ятницю", ""},
+
+		{"12.05", false, day(2024, time.May, 12), "12.05", ""},
+		{"12.06", false, day(2023, time.June, 12), "12.06", ""},
+		{"12.06", true, day(2024, time.June, 12), "12.06", ""},
+		{"кава 50 12.05", false, day(2024, time.May, 12), "12.05", "кава 50"},
+		{"кава п'ятдесят 12.05", false, day(2024, time.May, 12), "12.05", "кава п'ятдесят"},
+		{"кава 12.05.2024", false, day(2024, time.May, 12), "12.05.2024", "кава"},
+		{"кава 12.05.23", false, day(2023, time.May, 12), "12.05.23", "кава"},
+		{"кава 1.1.2025", false, day(2025, time.January, 1), "1.1.2025", "кава"},
+		{"12 травня кава", false, day(2024, time.May, 12), "12 травня", "кава"},
+		{"2024-05-12", false, day(2024, time.May, 12), "2024-05-12", ""},
+	}
+	for _, test := range tests {
+		parsed, ok := ParseDatePhrase(DefaultLanguage(), test.text, now, test.future)
+		if !ok {
+			t.Errorf("%q: no date", test.text)
+			continue
+		}
+		if !parsed.Time.Equal(test.want) || parsed.Phrase != test.phrase || parsed.Rest != test.rest {
+			t.Errorf("%q: %v, phrase %q, rest %q, want %v, %q, %q", test.text, parsed.Time, parsed.Phrase, parsed.Rest, test.want, test.phrase, test.rest)
+		}
+	}
+}
+
+func TestParseDatePhraseLeavesAmounts(t *testing.T) {
+	now := time.Date(2024, time.May, 15, 10, 30, 0, 0, time.UTC)
+	for _, text := range []string{
+		"кава 12.5",
+		"кава 12.05",
+		"витратив 12.50 на каву",
+		"12.5 кава",
+		"кава 12.345",
+		"кава 12,5",
+		"кава 12.50 грн",
+		"кава 31.12",
+		"кава 15.13.2024",
+		"кава 123.05.2024",
+	} {
+		if parsed, ok := ParseDatePhrase(DefaultLanguage(), text, now, false); ok {
+			t.Errorf("%q: date %v from %q", text, parsed.Time, parsed.Phrase)
+		}
+	}
+}
+
+func TestVoiceCommandWithDecimalAmount(t *testing.T) {
+	tests := []struct {
+		command string
+		amount  string
+		date    string
+	}{
+		{"витратив 12.5 на каву", "12.5", ""},
+		{"витратив 12.05 на каву", "12.05", ""},
+		{"витратив 50 на каву 12.05", "50", "12.05"},
+		{"витратив 50 на каву 12.05.2024", "50", "12.05.2024"},
+		{"витратив 12.5 на каву вчора", "12.5", "вчора"},
+	}
+	for _, test := range tests {
+		result, err := GetActionFromVoice(test.command)
+		if err != nil {
+			t.Fatalf("%q: %v", test.command, err)
+		}
+		date, _ := result["date"].(string)
+		if result["amount"] != test.amount || date != test.date {
+			t.Errorf("%q: amount %v, date %q, want %s, %q", test.command, result["amount"], date, test.amount, test.date)
+		}
+	}
+}
@@ -0,0 +1,288 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/vertexai/genai"
+	"github.com/KashyretsIvanna/voice-balance/config"
+	"google.golang.org/api/option"
+)
+
+// Default Gemini settings used when they are not configured
+const (
+	defaultGeminiLocation = "us-central1"
+	defaultGeminiModel    = "gemini-1.5-flash-001"
+)
+
+// IntentParser turns a transcribed command in the given language into an action like
+// { "amount": 10, "category": "продукти", "type": "expense" }
+type IntentParser interface {
+	ParseIntent(ctx context.Context, command string, language *Language) (map[string]interface{}, error)
+}
+
+// NewIntentParser returns the parser selected by INTENT_PARSER ("gemini" by default,
+// "openai" or "rules"). The parsers listed in INTENT_PARSER_FALLBACK ("rules" by
+// default, "none" to disable) are tried in order when the primary one fails.
+func NewIntentParser() (IntentParser, error) {
+	primary := strings.ToLower(strings.TrimSpace(config.Config("INTENT_PARSER")))
+	if primary == "" {
+		primary = "gemini"
+	}
+
+	fallback := strings.ToLower(strings.TrimSpace(config.Config("INTENT_PARSER_FALLBACK")))
+	if fallback == "" {
+		fallback = "rules"
+	}
+
+	names := []string{primary}
+	if fallback != "none" {
+		for _, name := range strings.Split(fallback, ",") {
+			if name = strings.TrimSpace(name); name != "" && name != primary {
+				names = append(names, name)
+			}
+		}
+	}
+
+	chain := &FallbackIntentParser{}
+	for _, name := range names {
+		parser, err := newIntentParserByName(name)
+		if err != nil {
+			return nil, err
+		}
+		chain.Names = append(chain.Names, name)
+		chain.Parsers = append(chain.Parsers, parser)
+	}
+
+	if len(chain.Parsers) == 1 {
+		return chain.Parsers[0], nil
+	}
+	return chain, nil
+}
+
+func newIntentParserByName(name string) (IntentParser, error) {
+	switch name {
+	case "gemini":
+		location := config.Config("GEMINI_LOCATION")
+		if location == "" {
+			location = defaultGeminiLocation
+		}
+		model := config.Config("GEMINI_MODEL")
+		if model == "" {
+			model = defaultGeminiModel
+		}
+		return &GeminiIntentParser{
+			ProjectID:       config.Config("GEMINI_PROJECT_ID"),
+			Location:        location,
+			Model:           model,
+			CredentialsFile: config.Config("CLOUD_JSON_PATH"),
+		}, nil
+	case "openai":
+		return &OpenAIIntentParser{
+			BaseURL: config.Config("LLM_BASE_URL"),
+			APIKey:  config.Config("LLM_API_KEY"),
+			Model:   config.Config("LLM_MODEL"),
+		}, nil
+	case "rules":
+		return &RuleIntentParser{}, nil
+	default:
+		return nil, fmt.Errorf("unknown intent parser %q", name)
+	}
+}
+
+// FallbackIntentParser tries its parsers in order and returns the first successful result
+type FallbackIntentParser struct {
+	Names   []string
+	Parsers []IntentParser
+}
+
+func (p *FallbackIntentParser) ParseIntent(ctx context.Context, command string, language *Language) (map[string]interface{}, error) {
+	var errs []error
+	for i, parser := range p.Parsers {
+		parsed, err := parser.ParseIntent(ctx, command, language)
+		if err == nil {
+			return parsed, nil
+		}
+
+		name := fmt.Sprintf("parser %d", i)
+		if i < len(p.Names) {
+			name = p.Names[i]
+		}
+		log.Printf("intent parser %s failed: %v", name, err)
+		errs = append(errs, fmt.Errorf("%s: %w", name, err))
+	}
+	return nil, errors.Join(errs...)
+}
+
+// RuleIntentParser recognizes commands with keyword and regex rules, without any network calls
+type RuleIntentParser struct{}
+
+func (p *RuleIntentParser) ParseIntent(ctx context.Context, command string, language *Language) (map[string]interface{}, error) {
+	return GetActionFromVoiceIn(language, command)
+}
+
+// intentPrompt asks a language model to answer with the action of the command as JSON
+func intentPrompt(language *Language, command string) string {
+	return fmt.Sprintf(language.PromptTemplate, command)
+}
+
+// parseIntentJSON extracts the JSON object from a model answer, which may be
+// wrapped into a ```json code block or surrounded by text
+func parseIntentJSON(text string) (map[string]interface{}, error) {
+	text = toStringSlice(parseJSONParts([]string{strings.TrimSpace(text)}))[0]
+
+	var parsed map[string]interface{}
+	if err := json.Unmarshal([]byte(text), &parsed); err == nil {
+		return parsed, nil
+	}
+
+	start, end := strings.Index(text, "{"), strings.LastIndex(text, "}")
+	if start < 0 || end < start {
+		return nil, fmt.Errorf("no JSON object found in the response")
+	}
+	if err := json.Unmarshal([]byte(text[start:end+1]), &parsed); err != nil {
+		return nil, fmt.Errorf("error unmarshalling content part to JSON: %w", err)
+	}
+	return parsed, nil
+}
+
+// GeminiIntentParser interprets commands with a Gemini model on Vertex AI
+type GeminiIntentParser struct {
+	ProjectID       string
+	Location        string
+	Model           string
+	CredentialsFile string
+}
+
+func (p *GeminiIntentParser) ParseIntent(ctx context.Context, command string, language *Language) (map[string]interface{}, error) {
+	if p.ProjectID == "" {
+		return nil, fmt.Errorf("GEMINI_PROJECT_ID is not set")
+	}
+
+	// Initialize the client with credentials
+	client, err := genai.NewClient(ctx, p.ProjectID, p.Location, option.WithCredentialsFile(p.CredentialsFile))
+	if err != nil {
+		return nil, fmt.Errorf("error creating client: %w", err)
+	}
+	defer client.Close()
+
+	gemini := client.GenerativeModel(p.Model)
+
+	// Generate content
+	resp, err := gemini.GenerateContent(ctx, genai.Text(intentPrompt(language, command)))
+	if err != nil {
+		return nil, fmt.Errorf("error generating content: %w", err)
+	}
+
+	// Format the response to JSON
+	rb, err := json.Marshal(resp)
+	if err != nil {
+		return nil, fmt.Errorf("error formatting response to JSON: %w", err)
+	}
+
+	// Parse the response into a struct
+	var data Data
+	if err := json.Unmarshal(rb, &data); err != nil {
+		return nil, fmt.Errorf("error unmarshalling response into Data struct: %w", err)
+	}
+
+	// Validate if the response has Candidates and Content
+	if len(data.Candidates) == 0 {
+		return nil, fmt.Errorf("no candidates found in the response")
+	}
+
+	// Ensure at least one Part is available
+	content := data.Candidates[0].Content
+	if len(content.Parts) == 0 {
+		return nil, fmt.Errorf("no content parts found in the response")
+	}
+
+	return parseIntentJSON(content.Parts[0])
+}
+
+// OpenAIIntentParser interprets commands with any server implementing the OpenAI
+// chat completions API, e.g. a local llama.cpp server or Ollama
+type OpenAIIntentParser struct {
+	BaseURL string
+	APIKey  string
+	Model   string
+}
+
+type chatMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type chatCompletionRequest struct {
+	Model          string            `json:"model"`
+	Messages       []chatMessage     `json:"messages"`
+	Temperature    float64           `json:"temperature"`
+	ResponseFormat map[string]string `json:"response_format,omitempty"`
+}
+
+type chatCompletionResponse struct {
+	Choices []struct {
+		Message chatMessage `json:"message"`
+	} `json:"choices"`
+}
+
+func (p *OpenAIIntentParser) ParseIntent(ctx context.Context, command string, language *Language) (map[string]interface{}, error) {
+	if p.BaseURL == "" {
+		return nil, fmt.Errorf("LLM_BASE_URL is not set")
+	}
+
+	body, err := json.Marshal(chatCompletionRequest{
+		Model:          p.Model,
+		Messages:       []chatMessage{{Role: "user", Content: intentPrompt(language, command)}},
+		Temperature:    0,
+		ResponseFormat: map[string]string{"type": "json_object"},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	endpoint := strings.TrimRight(p.BaseURL, "/") + "/chat/completions"
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if p.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+p.APIKey)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error calling the language model: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("language model returned %s: %s", resp.Status, strings.TrimSpace(string(respBody)))
+	}
+
+	var completion chatCompletionResponse
+	if err := json.Unmarshal(respBody, &completion); err != nil {
+		return nil, fmt.Errorf("error unmarshalling chat completion: %w", err)
+	}
+	if len(completion.Choices) == 0 {
+		return nil, fmt.Errorf("no choices found in the response")
+	}
+
+	return parseIntentJSON(completion.Choices[0].Message.Content)
+}
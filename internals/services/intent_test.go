@@ -0,0 +1,212 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	models "github.com/KashyretsIvanna/voice-balance/internals/model"
+)
+
+// stubIntentParser returns its result or its error and counts the commands it was given
+type stubIntentParser struct {
+	result map[string]interface{}
+	err    error
+	calls  int
+}
+
+func (p *stubIntentParser) ParseIntent(ctx context.Context, command string, language *Language) (map[string]interface{}, error) {
+	p.calls++
+	return p.result, p.err
+}
+
+// chatServer answers chat completions with content
+func chatServer(t *testing.T, content string) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var completion chatCompletionResponse
+		completion.Choices = append(completion.Choices, struct {
+			Message chatMessage `json:"message"`
+		}{chatMessage{Role: "assistant", Content: content}})
+		json.NewEncoder(w).Encode(completion)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestFallbackIntentParserReturnsFirstResult(t *testing.T) {
+	primary := &stubIntentParser{result: map[string]interface{}{"type": "expense"}}
+	fallback := &stubIntentParser{result: map[string]interface{}{"type": "income"}}
+	chain := &FallbackIntentParser{Names: []string{"primary", "fallback"}, Parsers: []IntentParser{primary, fallback}}
+
+	parsed, err := chain.ParseIntent(context.Background(), "витратив 50 на каву", DefaultLanguage())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parsed["type"] != "expense" || fallback.calls != 0 {
+		t.Errorf("parsed %v with %d fallback calls, want the primary result only", parsed, fallback.calls)
+	}
+}
+
+func TestFallbackIntentParserFallsBackOnError(t *testing.T) {
+	primary := &stubIntentParser{err: errors.New("quota exceeded")}
+	fallback := &stubIntentParser{result: map[string]interface{}{"type": "income"}}
+	chain := &FallbackIntentParser{Names: []string{"gemini", "rules"}, Parsers: []IntentParser{primary, fallback}}
+
+	parsed, err := chain.ParseIntent(context.Background(), "отримав 100", DefaultLanguage())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parsed["type"] != "income" || primary.calls != 1 || fallback.calls != 1 {
+		t.Errorf("parsed %v after %d and %d calls, want the fallback result", parsed, primary.calls, fallback.calls)
+	}
+}
+
+func TestFallbackIntentParserFallsBackOnUnparseableAnswer(t *testing.T) {
+	server := chatServer(t, "Sorry, I cannot help with that.")
+	fallback := &stubIntentParser{result: map[string]interface{}{"type": "expense"}}
+	chain := &FallbackIntentParser{
+		Names:   []string{"openai", "rules"},
+		Parsers: []IntentParser{&OpenAIIntentParser{BaseURL: server.URL}, fallback},
+	}
+
+	parsed, err := chain.ParseIntent(context.Background(), "витратив 50 на каву", DefaultLanguage())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parsed["type"] != "expense" || fallback.calls != 1 {
+		t.Errorf("parsed %v with %d fallback calls, want the fallback result", parsed, fallback.calls)
+	}
+}
+
+func TestFallbackIntentParserJoinsErrors(t *testing.T) {
+	quota, offline := errors.New("quota exceeded"), errors.New("connection refused")
+	chain := &FallbackIntentParser{
+		Names:   []string{"gemini"},
+		Parsers: []IntentParser{&stubIntentParser{err: quota}, &stubIntentParser{err: offline}},
+	}
+
+	parsed, err := chain.ParseIntent(context.Background(), "щось", DefaultLanguage())
+	if parsed != nil || err == nil {
+		t.Fatalf("parsed %v, %v, want an error", parsed, err)
+	}
+	if !errors.Is(err, quota) || !errors.Is(err, offline) {
+		t.Errorf("error %q does not hold the errors of both parsers", err)
+	}
+	// Parsers without a name are named by their position
+	if want := "gemini: quota exceeded\nparser 1: connection refused"; err.Error() != want {
+		t.Errorf("error %q, want %q", err, want)
+	}
+}
+
+func TestParseIntentJSON(t *testing.T) {
+	want := map[string]interface{}{"type": "витрати", "amount": "50", "category": "кава"}
+	object := `{"type": "витрати", "amount": "50", "category": "кава"}`
+
+	for _, text := range []string{
+		object,
+		"  " + object + "\n",
+		"```json\n" + object + "\n```",
+		"Ось результат:\n```json\n" + object + "\n```\nГотово.",
+		"The action is " + object + ", I hope it helps.",
+	} {
+		parsed, err := parseIntentJSON(text)
+		if err != nil {
+			t.Errorf("parseIntentJSON(%q): %v", text, err)
+			continue
+		}
+		if !reflect.DeepEqual(parsed, want) {
+			t.Errorf("parseIntentJSON(%q) = %v, want %v", text, parsed, want)
+		}
+	}
+
+	for _, text := range []string{
+		"",
+		"Sorry, I cannot help with that.",
+		"} not an object {",
+		"```json\n{\"type\": \"витрати\",\n```",
+		`{"type": "витрати", "amount": }`,
+		`["витрати", 50]`,
+	} {
+		if parsed, err := parseIntentJSON(text); err == nil {
+			t.Errorf("parseIntentJSON(%q) = %v, want an error", text, parsed)
+		}
+	}
+}
+
+func TestOpenAIIntentParserReadsFencedAnswer(t *testing.T) {
+	server := chatServer(t, "```json\n{\"type\": \"дохід\", \"amount\": 100}\n```")
+
+	parsed, err := (&OpenAIIntentParser{BaseURL: server.URL + "/"}).ParseIntent(context.Background(), "отримав 100", DefaultLanguage())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parsed["type"] != "дохід" || parsed["amount"] != 100.0 {
+		t.Errorf("parsed %v", parsed)
+	}
+	if _, err := (&OpenAIIntentParser{}).ParseIntent(context.Background(), "отримав 100", DefaultLanguage()); err == nil || !strings.Contains(err.Error(), "LLM_BASE_URL") {
+		t.Errorf("a parser without a base URL: %v", err)
+	}
+}
+
+func TestRuleGrammars(t *testing.T) {
+	useFakeVoice(t)
+	now := time.Date(2024, time.May, 10, 12, 0, 0, 0, time.UTC)
+	tomorrowAtNine := time.Date(2024, time.May, 11, 9, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		language string
+		command  string
+		want     VoiceAction
+	}{
+		{"uk", "витратив 250 гривень на продукти", ExpenseAction{Type: VoiceActionExpense, Amount: 25000, Category: "продукти", Currency: "UAH"}},
+		{"uk", "отримав 5000 гривень за категорією зарплата", IncomeAction{Type: VoiceActionIncome, Amount: 500000, Category: "зарплата", Currency: "UAH"}},
+		{"uk", "нагадай завтра о 9 сплатити оренду", ReminderAction{Type: VoiceActionReminder, Text: "сплатити оренду", DueDate: &tomorrowAtNine}},
+		{"uk", "статистика за місяць", StatisticsAction{Type: VoiceActionStatistics, Range: RangeMonth}},
+		// "тиждень" contains "день"
+		{"uk", "статистика витрат за тиждень", StatisticsAction{Type: VoiceActionStatistics, Range: RangeWeek, CategoryType: models.CategoryTypeExpense}},
+		{"uk", "статистика доходів за сьогодні", StatisticsAction{Type: VoiceActionStatistics, Range: RangeDay, CategoryType: models.CategoryTypeIncome}},
+
+		{"en", "spent 100 on coffee", ExpenseAction{Type: VoiceActionExpense, Amount: 10000, Category: "coffee"}},
+		{"en", "spent 20 dollars on taxi", ExpenseAction{Type: VoiceActionExpense, Amount: 2000, Category: "taxi", Currency: "USD"}},
+		{"en", "received 5000 from salary", IncomeAction{Type: VoiceActionIncome, Amount: 500000, Category: "salary"}},
+		{"en", "earned 300", IncomeAction{Type: VoiceActionIncome, Amount: 30000, Category: "general"}},
+		{"en", "remind me tomorrow at 9 to pay rent", ReminderAction{Type: VoiceActionReminder, Text: "pay rent", DueDate: &tomorrowAtNine}},
+		{"en", "statistics for this month", StatisticsAction{Type: VoiceActionStatistics, Range: RangeMonth}},
+		{"en", "statistics of expenses for this week", StatisticsAction{Type: VoiceActionStatistics, Range: RangeWeek, CategoryType: models.CategoryTypeExpense}},
+
+		{"ru", "потратил 100 на кофе", ExpenseAction{Type: VoiceActionExpense, Amount: 10000, Category: "кофе"}},
+		{"ru", "получил 5000", IncomeAction{Type: VoiceActionIncome, Amount: 500000, Category: "общая"}},
+		{"ru", "напомни завтра в 9 оплатить аренду", ReminderAction{Type: VoiceActionReminder, Text: "оплатить аренду", DueDate: &tomorrowAtNine}},
+		{"ru", "статистика за месяц", StatisticsAction{Type: VoiceActionStatistics, Range: RangeMonth}},
+		// "сегодня" contains "год"
+		{"ru", "статистика за сегодня", StatisticsAction{Type: VoiceActionStatistics, Range: RangeDay}},
+		{"ru", "статистика за год", StatisticsAction{Type: VoiceActionStatistics, Range: RangeYear}},
+	}
+	for _, test := range tests {
+		language, err := LookupLanguage(test.language)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err, action := AskAi(test.command, language, now)
+		if err != nil {
+			t.Errorf("%s %q: %v", test.language, test.command, err)
+			continue
+		}
+		if !reflect.DeepEqual(action, test.want) {
+			t.Errorf("%s %q: got %#v, want %#v", test.language, test.command, action, test.want)
+		}
+	}
+
+	// Commands of another language are not understood
+	english, _ := LookupLanguage("en")
+	if err, action := AskAi("витратив 250 гривень на продукти", english, now); err == nil {
+		t.Errorf("a Ukrainian command in English gave %#v", action)
+	}
+}
@@ -0,0 +1,423 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	models "github.com/KashyretsIvanna/voice-balance/internals/model"
+)
+
+// ErrUnsupportedLanguage is returned for languages without a grammar and a prompt
+var ErrUnsupportedLanguage = errors.New("unsupported language")
+
+// DefaultLanguageCode is used when neither the request nor the user settings name a language
+const DefaultLanguageCode = "uk"
+
+// Grammar holds the keywords and patterns the rule-based parser recognizes in one language.
+// Keywords are matched as lower case substrings of the command.
+type Grammar struct {
+	StatisticsKeywords []string
+	ExpenseKeywords    []string
+	IncomeKeywords     []string
+	// Keywords of a reminder are removed from its text, longer ones go first
+	ReminderKeywords []string
+
+	// Words telling whether statistics are asked for incomes or expenses
+	IncomeWords  []string
+	ExpenseWords []string
+
+	// Words naming the period of statistics
+	RangeWords map[StatisticsRange][]string
+
+	// Beginnings of the words naming currencies by their ISO 4217 codes,
+	// amounts without one are in the currency of the user
+	CurrencyWords map[string][]string
+
+	// Number words of spoken amounts, without them only digits are recognized
+	Numerals *Numerals
+
+	// Date and time phrases, without them commands are dated now
+	Dates *DateWords
+
+	// Words and endings ignored when spoken categories are matched with the ones of the user,
+	// without them phrases are compared as they are
+	CategoryWords *CategoryWords
+
+	// Patterns with one group capturing the category of an expense or an income
+	ExpenseCategoryPattern string
+	IncomeCategoryPattern  string
+
+	// Categories used when the command does not name one
+	UnspecifiedCategory   string
+	DefaultIncomeCategory string
+}
+
+// Language bundles everything needed to understand voice commands in one language
+type Language struct {
+	Code           string
+	RecognizerCode string
+	Grammar        Grammar
+	// PromptTemplate asks a language model for the action as JSON, %s is replaced with the command
+	PromptTemplate string
+	// ReminderTemplate is the text of reminder notifications: %[1]s is the title, %[2]s the due date.
+	// ReminderAmountTemplate is added to it for reminders with an amount: %[1]s is the amount, %[2]s its currency.
+	ReminderTemplate       string
+	ReminderAmountTemplate string
+	// DefaultCategories are given to new users, names are lower case like the ones of voice commands
+	DefaultCategories []DefaultCategory
+}
+
+// DefaultCategory is a category every user starts with
+type DefaultCategory struct {
+	Name string
+	Type string
+}
+
+var languages = map[string]*Language{
+	"uk": {
+		Code:           "uk",
+		RecognizerCode: "uk-UA",
+		Grammar: Grammar{
+			StatisticsKeywords: []string{"статистика", "статистику"},
+			ExpenseKeywords:    []string{"додай витрату", "додай витрати", "витратив", "витратила"},
+			IncomeKeywords:     []string{"додай дохід", "отримав", "отримала"},
+			ReminderKeywords:   []string{"нагадай"},
+			IncomeWords:        []string{"доход"},
+			ExpenseWords:       []string{"витрат"},
+			RangeWords: map[StatisticsRange][]string{
+				RangeDay:   {"день", "сьогодні"},
+				RangeWeek:  {"тиждень"},
+				RangeMonth: {"місяць"},
+				RangeYear:  {"рік"},
+			},
+			CurrencyWords: map[string][]string{
+				"UAH": {"грн", "грив"},
+				"USD": {"долар", "бакс"},
+				"EUR": {"євро"},
+				"PLN": {"злот"},
+			},
+			Numerals:               ukrainianNumerals,
+			Dates:                  ukrainianDates,
+			CategoryWords:          ukrainianCategoryWords,
+			ExpenseCategoryPattern: `(?:^|\s)на\s+(\p{L}+)`,
+			IncomeCategoryPattern:  `(?:^|\s)за\s+категорією\s+(\p{L}+)`,
+			UnspecifiedCategory:    "не вказано",
+			DefaultIncomeCategory:  "загальна",
+		},
+		ReminderTemplate:       "Нагадування: %[1]s\nТермін: %[2]s",
+		ReminderAmountTemplate: "\nСума: %[1]s %[2]s",
+		DefaultCategories: []DefaultCategory{
+			{Name: "продукти", Type: models.CategoryTypeExpense},
+			{Name: "кафе та ресторани", Type: models.CategoryTypeExpense},
+			{Name: "транспорт", Type: models.CategoryTypeExpense},
+			{Name: "житло", Type: models.CategoryTypeExpense},
+			{Name: "комунальні послуги", Type: models.CategoryTypeExpense},
+			{Name: "здоров'я", Type: models.CategoryTypeExpense},
+			{Name: "одяг", Type: models.CategoryTypeExpense},
+			{Name: "розваги", Type: models.CategoryTypeExpense},
+			{Name: "зв'язок", Type: models.CategoryTypeExpense},
+			{Name: "інше", Type: models.CategoryTypeExpense},
+			{Name: "зарплата", Type: models.CategoryTypeIncome},
+			{Name: "подарунки", Type: models.CategoryTypeIncome},
+			{Name: "загальна", Type: models.CategoryTypeIncome},
+		},
+		PromptTemplate: `Я створюю додаток ведення балансу. Ти експерт розпізнавання команд від користувача.
+		Тобі потрібно розпізнати команду та вивести результат в форматі JSON. Якщо якусь з інформації користувач не надав, поверни відповідний ключ з пустою строкою.
+		Є кілька типів команд, які підтримує додаток: додавання витрат або
+		доходів, створення нагадувань, статистика. Приклад відповіді, яку я
+		очікую, якщо запит на додавання витрат або додавання доходів:
+		{ "amount": 0, "category": "не вказано", "type": "expense" }.
+
+		Type повинен бути: "income" для доходів, "expense" для витрат або "".
+		Amount: число так, як його назвав користувач, без округлення, category - вказує
+		на що витрати чи доходи (наприклад, продукти).
+		Наступний тип команди - створення нагадувань. Приклад
+		відповіді яку я очікую: { "category": "оплатити рахунок за електроенергію", "type": "reminder" },
+		де category - текст нагадування. Type - завжди "reminder". Наступний тип команди - відобразити статистику.
+		Приклад відповіді яку я очікую: { "category": "", "range": "week", "type": "statistics" }. Range повинен бути "day", "week", "month" або "year",
+		category - "income", "expense" або "". Якщо не визначено тип команди чи користувач говорить дивні запити, повертай type пустим рядком.
+
+		Якщо користувач назвав дату чи час витрати, доходу або нагадування (наприклад, "вчора", "в понеділок", "15 березня", "через тиждень", "завтра о 9"),
+		поверни їх дослівно в ключі "date" і не додавай їх до category, інакше поверни "date" з пустою строкою.
+		Якщо користувач назвав валюту витрати чи доходу, поверни її трилітерний код ISO 4217 (наприклад, "USD" для доларів) в ключі "currency",
+		інакше поверни "currency" з пустою строкою.
+
+		Розпізнай наступний текст та поверни результат: %s.
+		`,
+	},
+	"en": {
+		Code:           "en",
+		RecognizerCode: "en-US",
+		Grammar: Grammar{
+			StatisticsKeywords: []string{"statistics", "stats", "summary"},
+			ExpenseKeywords:    []string{"add expense", "add an expense", "spent"},
+			IncomeKeywords:     []string{"add income", "earned", "received"},
+			ReminderKeywords:   []string{"remind me to", "remind me", "remind"},
+			IncomeWords:        []string{"income"},
+			ExpenseWords:       []string{"expense", "spending"},
+			RangeWords: map[StatisticsRange][]string{
+				RangeDay:   {"day", "today"},
+				RangeWeek:  {"week"},
+				RangeMonth: {"month"},
+				RangeYear:  {"year"},
+			},
+			CurrencyWords: map[string][]string{
+				"UAH": {"uah", "hryvni", "hryvna"},
+				"USD": {"usd", "dollar", "buck"},
+				"EUR": {"eur"},
+				"PLN": {"pln", "zlot"},
+			},
+			Dates:                  englishDates,
+			CategoryWords:          englishCategoryWords,
+			ExpenseCategoryPattern: `(?:^|\s)(?:on|for)\s+(\p{L}+)`,
+			IncomeCategoryPattern:  `(?:^|\s)(?:category|from)\s+(\p{L}+)`,
+			UnspecifiedCategory:    "unspecified",
+			DefaultIncomeCategory:  "general",
+		},
+		ReminderTemplate:       "Reminder: %[1]s\nDue: %[2]s",
+		ReminderAmountTemplate: "\nAmount: %[1]s %[2]s",
+		DefaultCategories: []DefaultCategory{
+			{Name: "groceries", Type: models.CategoryTypeExpense},
+			{Name: "cafes and restaurants", Type: models.CategoryTypeExpense},
+			{Name: "transport", Type: models.CategoryTypeExpense},
+			{Name: "housing", Type: models.CategoryTypeExpense},
+			{Name: "utilities", Type: models.CategoryTypeExpense},
+			{Name: "health", Type: models.CategoryTypeExpense},
+			{Name: "clothes", Type: models.CategoryTypeExpense},
+			{Name: "entertainment", Type: models.CategoryTypeExpense},
+			{Name: "phone and internet", Type: models.CategoryTypeExpense},
+			{Name: "other", Type: models.CategoryTypeExpense},
+			{Name: "salary", Type: models.CategoryTypeIncome},
+			{Name: "gifts", Type: models.CategoryTypeIncome},
+			{Name: "general", Type: models.CategoryTypeIncome},
+		},
+		PromptTemplate: `I am building a personal balance app. You are an expert in recognizing user commands.
+		Recognize the command and return the result as JSON. If the user did not provide some information, return the key with an empty string.
+		The app supports these commands: adding expenses or incomes, creating reminders and showing statistics.
+		For adding an expense or an income I expect: { "amount": 0, "category": "unspecified", "type": "expense" }.
+		Type must be "income", "expense" or "". Amount is the number exactly as the user said it, not rounded, category tells what the money was spent on or earned from (for example, groceries).
+		For creating a reminder I expect: { "category": "pay the electricity bill", "type": "reminder" }, where category is the text of the reminder.
+		For showing statistics I expect: { "category": "", "range": "week", "type": "statistics" }. Range must be "day", "week", "month" or "year",
+		category is "income", "expense" or "". If the command cannot be recognized, return an empty type.
+
+		If the user named the date or the time of an expense, an income or a reminder (for example, "yesterday", "on monday", "15 march", "in a week", "tomorrow at 9"),
+		return it word for word in the "date" key and do not add it to category, otherwise return "date" with an empty string.
+		If the user named the currency of an expense or an income, return its three letter ISO 4217 code (for example, "USD" for dollars) in the "currency" key,
+		otherwise return "currency" with an empty string.
+
+		Recognize the following text and return the result: %s.
+		`,
+	},
+	"ru": {
+		Code:           "ru",
+		RecognizerCode: "ru-RU",
+		Grammar: Grammar{
+			StatisticsKeywords: []string{"статистика", "статистику"},
+			ExpenseKeywords:    []string{"добавь расход", "добавь расходы", "потратил", "потратила"},
+			IncomeKeywords:     []string{"добавь доход", "получил", "получила"},
+			ReminderKeywords:   []string{"напомни"},
+			IncomeWords:        []string{"доход"},
+			ExpenseWords:       []string{"расход"},
+			RangeWords: map[StatisticsRange][]string{
+				RangeDay:   {"день", "сегодня"},
+				RangeWeek:  {"недел"},
+				RangeMonth: {"месяц"},
+				RangeYear:  {"год"},
+			},
+			CurrencyWords: map[string][]string{
+				"UAH": {"грн", "грив"},
+				"USD": {"доллар", "бакс"},
+				"EUR": {"евро"},
+				"PLN": {"злот"},
+			},
+			Dates:                  russianDates,
+			CategoryWords:          russianCategoryWords,
+			ExpenseCategoryPattern: `(?:^|\s)на\s+(\p{L}+)`,
+			IncomeCategoryPattern:  `(?:^|\s)(?:по\s+категории|за)\s+(\p{L}+)`,
+			UnspecifiedCategory:    "не указано",
+			DefaultIncomeCategory:  "общая",
+		},
+		ReminderTemplate:       "Напоминание: %[1]s\nСрок: %[2]s",
+		ReminderAmountTemplate: "\nСумма: %[1]s %[2]s",
+		DefaultCategories: []DefaultCategory{
+			{Name: "продукты", Type: models.CategoryTypeExpense},
+			{Name: "кафе и рестораны", Type: models.CategoryTypeExpense},
+			{Name: "транспорт", Type: models.CategoryTypeExpense},
+			{Name: "жильё", Type: models.CategoryTypeExpense},
+			{Name: "коммунальные услуги", Type: models.CategoryTypeExpense},
+			{Name: "здоровье", Type: models.CategoryTypeExpense},
+			{Name: "одежда", Type: models.CategoryTypeExpense},
+			{Name: "развлечения", Type: models.CategoryTypeExpense},
+			{Name: "связь", Type: models.CategoryTypeExpense},
+			{Name: "другое", Type: models.CategoryTypeExpense},
+			{Name: "зарплата", Type: models.CategoryTypeIncome},
+			{Name: "подарки", Type: models.CategoryTypeIncome},
+			{Name: "общая", Type: models.CategoryTypeIncome},
+		},
+		PromptTemplate: `Я создаю приложение для ведения баланса. Ты эксперт по распознаванию команд пользователя.
+		Распознай команду и выведи результат в формате JSON. Если пользователь не указал какую-то информацию, верни соответствующий ключ с пустой строкой.
+		Приложение поддерживает команды: добавление расходов или доходов, создание напоминаний, статистика.
+		Для добавления расхода или дохода я ожидаю: { "amount": 0, "category": "не указано", "type": "expense" }.
+		Type должен быть "income" для доходов, "expense" для расходов или "". Amount - число так, как его назвал пользователь, без округления, category - на что расход или откуда доход (например, продукты).
+		Для создания напоминания я ожидаю: { "category": "оплатить счёт за электроэнергию", "type": "reminder" }, где category - текст напоминания.
+		Для статистики я ожидаю: { "category": "", "range": "week", "type": "statistics" }. Range должен быть "day", "week", "month" или "year",
+		category - "income", "expense" или "". Если тип команды не определён, возвращай пустой type.
+
+		Если пользователь назвал дату или время расхода, дохода или напоминания (например, "вчера", "в понедельник", "15 марта", "через неделю", "завтра в 9"),
+		верни их дословно в ключе "date" и не добавляй их в category, иначе верни "date" с пустой строкой.
+		Если пользователь назвал валюту расхода или дохода, верни её трёхбуквенный код ISO 4217 (например, "USD" для долларов) в ключе "currency",
+		иначе верни "currency" с пустой строкой.
+
+		Распознай следующий текст и верни результат: %s.
+		`,
+	},
+}
+
+var ukrainianDates = &DateWords{
+	RelativeDays: map[string]int{"сьогодні": 0, "завтра": 1, "післязавтра": 2, "вчора": -1, "учора": -1, "позавчора": -2},
+	Weekdays: map[string]time.Weekday{
+		"понеділок": time.Monday, "понеділка": time.Monday,
+		"вівторок": time.Tuesday, "вівторка": time.Tuesday,
+		"середа": time.Wednesday, "середу": time.Wednesday, "середи": time.Wednesday,
+		"четвер": time.Thursday, "четверга": time.Thursday,
+		"п'ятниця": time.Friday, "п'ятницю": time.Friday, "п'ятниці": time.Friday,
+		"субота": time.Saturday, "суботу": time.Saturday, "суботи": time.Saturday,
+		"неділя": time.Sunday, "неділю": time.Sunday, "неділі": time.Sunday,
+	},
+	Months: map[string]time.Month{
+		"січня": time.January, "лютого": time.February, "березня": time.March, "квітня": time.April,
+		"травня": time.May, "червня": time.June, "липня": time.July, "серпня": time.August,
+		"вересня": time.September, "жовтня": time.October, "листопада": time.November, "грудня": time.December,
+	},
+	NextWords:    []string{"наступний", "наступного", "наступної", "наступну", "наступна"},
+	LastWords:    []string{"минулий", "минулого", "минулої", "минулу", "минула"},
+	Prepositions: []string{"в", "у", "на"},
+	InWords:      []string{"через"},
+	AgoWords:     []string{"тому"},
+	Units: map[string]dateUnit{
+		"хвилину": unitMinute, "хвилини": unitMinute, "хвилин": unitMinute,
+		"годину": unitHour, "години": unitHour, "годин": unitHour,
+		"день": unitDay, "дні": unitDay, "днів": unitDay, "добу": unitDay,
+		"тиждень": unitWeek, "тижні": unitWeek, "тижнів": unitWeek,
+		"місяць": unitMonth, "місяці": unitMonth, "місяців": unitMonth,
+		"рік": unitYear, "роки": unitYear, "років": unitYear,
+	},
+	AtWords:      []string{"о", "об"},
+	EveningWords: []string{"вечора", "ввечері", "дня"},
+	MorningWords: []string{"ранку", "зранку", "ночі"},
+	YearWords:    []string{"року", "рік"},
+	HourWords:    []string{"годині", "год"},
+}
+
+var englishDates = &DateWords{
+	RelativeDays: map[string]int{
+		"today": 0, "tomorrow": 1, "the day after tomorrow": 2, "day after tomorrow": 2,
+		"yesterday": -1, "the day before yesterday": -2, "day before yesterday": -2,
+	},
+	Weekdays: map[string]time.Weekday{
+		"monday": time.Monday, "tuesday": time.Tuesday, "wednesday": time.Wednesday, "thursday": time.Thursday,
+		"friday": time.Friday, "saturday": time.Saturday, "sunday": time.Sunday,
+	},
+	Months: map[string]time.Month{
+		"january": time.January, "february": time.February, "march": time.March, "april": time.April,
+		"may": time.May, "june": time.June, "july": time.July, "august": time.August,
+		"september": time.September, "october": time.October, "november": time.November, "december": time.December,
+	},
+	NextWords:    []string{"next"},
+	LastWords:    []string{"last"},
+	Prepositions: []string{"on"},
+	InWords:      []string{"in", "in a", "in an"},
+	AgoWords:     []string{"ago"},
+	Units: map[string]dateUnit{
+		"minute": unitMinute, "minutes": unitMinute, "hour": unitHour, "hours": unitHour,
+		"day": unitDay, "days": unitDay, "week": unitWeek, "weeks": unitWeek,
+		"month": unitMonth, "months": unitMonth, "year": unitYear, "years": unitYear,
+	},
+	AtWords:      []string{"at"},
+	EveningWords: []string{"pm", "p m"},
+	MorningWords: []string{"am", "a m"},
+	HourWords:    []string{"o'clock"},
+}
+
+var russianDates = &DateWords{
+	RelativeDays: map[string]int{"сегодня": 0, "завтра": 1, "послезавтра": 2, "вчера": -1, "позавчера": -2},
+	Weekdays: map[string]time.Weekday{
+		"понедельник": time.Monday, "понедельника": time.Monday,
+		"вторник": time.Tuesday, "вторника": time.Tuesday,
+		"среда": time.Wednesday, "среду": time.Wednesday, "среды": time.Wednesday,
+		"четверг": time.Thursday, "четверга": time.Thursday,
+		"пятница": time.Friday, "пятницу": time.Friday, "пятницы": time.Friday,
+		"суббота": time.Saturday, "субботу": time.Saturday, "субботы": time.Saturday,
+		"воскресенье": time.Sunday, "воскресенья": time.Sunday,
+	},
+	Months: map[string]time.Month{
+		"января": time.January, "февраля": time.February, "марта": time.March, "апреля": time.April,
+		"мая": time.May, "июня": time.June, "июля": time.July, "августа": time.August,
+		"сентября": time.September, "октября": time.October, "ноября": time.November, "декабря": time.December,
+	},
+	NextWords:    []string{"следующий", "следующего", "следующей", "следующую", "следующая"},
+	LastWords:    []string{"прошлый", "прошлого", "прошлой", "прошлую", "прошлая"},
+	Prepositions: []string{"в", "во", "на"},
+	InWords:      []string{"через"},
+	AgoWords:     []string{"назад"},
+	Units: map[string]dateUnit{
+		"минуту": unitMinute, "минуты": unitMinute, "минут": unitMinute,
+		"час": unitHour, "часа": unitHour, "часов": unitHour,
+		"день": unitDay, "дня": unitDay, "дней": unitDay, "сутки": unitDay,
+		"неделю": unitWeek, "недели": unitWeek, "недель": unitWeek,
+		"месяц": unitMonth, "месяца": unitMonth, "месяцев": unitMonth,
+		"год": unitYear, "года": unitYear, "лет": unitYear,
+	},
+	AtWords:      []string{"в", "во"},
+	EveningWords: []string{"вечера", "дня"},
+	MorningWords: []string{"утра", "ночи"},
+	YearWords:    []string{"года", "год"},
+	HourWords:    []string{"часов", "часа", "час"},
+}
+
+var ukrainianCategoryWords = &CategoryWords{
+	StopWords: []string{"на", "за", "для", "в", "у", "до", "з", "із", "зі", "та", "і", "й"},
+	Endings: []string{
+		"ами", "ями", "ові", "еві", "ого", "ому", "ах", "ях", "ою", "ею", "ам", "ям", "ом", "ем",
+		"ів", "їв", "ий", "ій", "ої", "ей", "і", "и", "а", "я", "у", "ю", "о", "е", "є", "ь", "ї",
+	},
+}
+
+var englishCategoryWords = &CategoryWords{
+	StopWords: []string{"on", "for", "in", "at", "to", "the", "a", "an", "and", "of"},
+	Endings:   []string{"ies", "ing", "es", "s", "y"},
+}
+
+var russianCategoryWords = &CategoryWords{
+	StopWords: []string{"на", "за", "для", "в", "во", "к", "с", "со", "и"},
+	Endings: []string{
+		"ами", "ями", "ого", "ему", "ому", "ах", "ях", "ов", "ев", "ей", "ой", "ом", "ем", "ам", "ям",
+		"ую", "юю", "ая", "яя", "ое", "ее", "ые", "ие", "ый", "ий", "а", "я", "у", "ю", "о", "е", "ы", "и", "ь",
+	},
+}
+
+// LookupLanguage finds a supported language by its code, e.g. "uk" or "uk-UA"
+func LookupLanguage(code string) (*Language, error) {
+	base := strings.ToLower(strings.TrimSpace(code))
+	if i := strings.IndexAny(base, "-_"); i >= 0 {
+		base = base[:i]
+	}
+
+	language, ok := languages[base]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnsupportedLanguage, code)
+	}
+	return language, nil
+}
+
+// DefaultLanguage returns the language used when none is chosen
+func DefaultLanguage() *Language {
+	return languages[DefaultLanguageCode]
+}
+
+// SupportedLanguageCodes lists the codes accepted by LookupLanguage
+func SupportedLanguageCodes() []string {
+	return []string{"uk", "en", "ru"}
+}
@@ -0,0 +1,106 @@
+package services
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/KashyretsIvanna/voice-balance/database/dbtest"
+	models "github.com/KashyretsIvanna/voice-balance/internals/model"
+)
+
+func TestLookupLanguage(t *testing.T) {
+	tests := []struct {
+		code string
+		want string // empty for unsupported codes
+	}{
+		{"uk", "uk"},
+		{"en", "en"},
+		{"ru", "ru"},
+		// Case, spaces and regions are ignored
+		{"EN", "en"},
+		{" ru ", "ru"},
+		{"uk-UA", "uk"},
+		{"en_US", "en"},
+		{"", ""},
+		{"de", ""},
+		{"english", ""},
+		{"ua", ""},
+		{"-uk", ""},
+	}
+	for _, test := range tests {
+		language, err := LookupLanguage(test.code)
+		if test.want == "" {
+			if !errors.Is(err, ErrUnsupportedLanguage) {
+				t.Errorf("%q: %v, want ErrUnsupportedLanguage", test.code, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%q: %v", test.code, err)
+		} else if language.Code != test.want {
+			t.Errorf("%q: got %s, want %s", test.code, language.Code, test.want)
+		}
+	}
+}
+
+func TestResolveLanguageOnPostgres(t *testing.T) {
+	db := dbtest.Use(t)
+	english := saveTestUser(t, db)
+	if err := db.Model(english).Update("language", "en").Error; err != nil {
+		t.Fatal(err)
+	}
+	// Settings saved before a language was dropped fall back to the default one
+	dropped := saveTestUser(t, db)
+	if err := db.Model(dropped).Update("language", "de").Error; err != nil {
+		t.Fatal(err)
+	}
+	defaults := saveTestUser(t, db)
+
+	tests := []struct {
+		name      string
+		user      *models.User
+		requested string
+		want      string
+	}{
+		{"setting", english, "", "en"},
+		{"requested over the setting", english, "ru-RU", "ru"},
+		{"unsupported setting", dropped, "", DefaultLanguageCode},
+		{"requested over an unsupported setting", dropped, "en", "en"},
+		{"default setting", defaults, "", DefaultLanguageCode},
+	}
+	for _, test := range tests {
+		language, err := ResolveLanguage(test.user.ID, test.requested)
+		if err != nil {
+			t.Errorf("%s: %v", test.name, err)
+		} else if language.Code != test.want {
+			t.Errorf("%s: got %s, want %s", test.name, language.Code, test.want)
+		}
+	}
+
+	// An unsupported request is rejected rather than replaced
+	if _, err := ResolveLanguage(english.ID, "de"); !errors.Is(err, ErrUnsupportedLanguage) {
+		t.Errorf("requested de: %v, want ErrUnsupportedLanguage", err)
+	}
+}
+
+func TestUpdateLanguageSettingOnPostgres(t *testing.T) {
+	db := dbtest.Use(t)
+	user := saveTestUser(t, db)
+
+	code := "EN-gb"
+	settings, err := UpdateUserSettings(user.ID, UserSettingsUpdate{Language: &code})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if settings.Language != "en" {
+		t.Errorf("language %q saved, want en", settings.Language)
+	}
+
+	code = "de"
+	if _, err := UpdateUserSettings(user.ID, UserSettingsUpdate{Language: &code}); !errors.Is(err, ErrInvalidSettings) {
+		t.Errorf("language de: %v, want ErrInvalidSettings", err)
+	}
+	if language, err := ResolveLanguage(user.ID, ""); err != nil || language.Code != "en" {
+		t.Errorf("language after a rejected update %v, %v, want en", language, err)
+	}
+}
@@ -0,0 +1,243 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net"
+	"net/http"
+	"net/smtp"
+	"strings"
+	"time"
+
+	"github.com/KashyretsIvanna/voice-balance/config"
+	models "github.com/KashyretsIvanna/voice-balance/internals/model"
+	repositories "github.com/KashyretsIvanna/voice-balance/internals/repositories"
+)
+
+// Channels reminders are delivered through
+const (
+	ChannelInbox   = "inbox"
+	ChannelEmail   = "email"
+	ChannelWebhook = "webhook"
+)
+
+// ReminderMessage is the notification of a reminder coming due
+type ReminderMessage struct {
+	User     *models.User
+	Reminder *models.Reminder
+	// DueAt is the due date of the reminder or the end of its snooze
+	DueAt   time.Time
+	Subject string
+	Body    string
+}
+
+// Notifier delivers reminder notifications through one channel
+type Notifier interface {
+	Channel() string
+	Notify(ctx context.Context, message *ReminderMessage) error
+}
+
+// NewNotifiers returns the notifiers of the channels listed in REMINDER_CHANNELS,
+// only the in-app inbox when it is empty
+func NewNotifiers() ([]Notifier, error) {
+	channels := config.Config("REMINDER_CHANNELS")
+	if strings.TrimSpace(channels) == "" {
+		channels = ChannelInbox
+	}
+
+	var notifiers []Notifier
+	for _, channel := range strings.Split(channels, ",") {
+		switch channel = strings.ToLower(strings.TrimSpace(channel)); channel {
+		case ChannelInbox:
+			notifiers = append(notifiers, &InboxNotifier{})
+		case ChannelEmail:
+			notifier := &SMTPNotifier{
+				Host:     config.Config("SMTP_HOST"),
+				Port:     config.Config("SMTP_PORT"),
+				Username: config.Config("SMTP_USERNAME"),
+				Password: config.Config("SMTP_PASSWORD"),
+				From:     config.Config("SMTP_FROM"),
+			}
+			if notifier.Host == "" || notifier.From == "" {
+				return nil, fmt.Errorf("email reminders need SMTP_HOST and SMTP_FROM")
+			}
+			notifiers = append(notifiers, notifier)
+		case ChannelWebhook:
+			notifier := &WebhookNotifier{
+				URL:    config.Config("REMINDER_WEBHOOK_URL"),
+				Secret: config.Config("REMINDER_WEBHOOK_SECRET"),
+			}
+			if notifier.URL == "" {
+				return nil, fmt.Errorf("webhook reminders need REMINDER_WEBHOOK_URL")
+			}
+			notifiers = append(notifiers, notifier)
+		case "":
+		default:
+			return nil, fmt.Errorf("unknown reminder channel %q", channel)
+		}
+	}
+	return notifiers, nil
+}
+
+// InboxNotifier puts notifications into the in-app inbox of the user
+type InboxNotifier struct{}
+
+func (n *InboxNotifier) Channel() string { return ChannelInbox }
+
+func (n *InboxNotifier) Notify(ctx context.Context, message *ReminderMessage) error {
+	return repositories.SaveNotification(&models.Notification{
+		UserID:     message.User.ID,
+		ReminderID: &message.Reminder.ID,
+		Title:      message.Subject,
+		Body:       message.Body,
+	})
+}
+
+// SMTPNotifier emails notifications to the address of the user. STARTTLS is used when
+// the server offers it, without a username no authentication is done.
+type SMTPNotifier struct {
+	Host     string
+	Port     string
+	Username string
+	Password string
+	From     string
+}
+
+func (n *SMTPNotifier) Channel() string { return ChannelEmail }
+
+func (n *SMTPNotifier) Notify(ctx context.Context, message *ReminderMessage) error {
+	if message.User.Email == "" {
+		return fmt.Errorf("the user has no email address")
+	}
+
+	port := n.Port
+	if port == "" {
+		port = "25"
+	}
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(n.Host, port))
+	if err != nil {
+		return err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	client, err := smtp.NewClient(conn, n.Host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(&tls.Config{ServerName: n.Host}); err != nil {
+			return err
+		}
+	}
+	if n.Username != "" {
+		if err := client.Auth(smtp.PlainAuth("", n.Username, n.Password, n.Host)); err != nil {
+			return err
+		}
+	}
+
+	if err := client.Mail(n.From); err != nil {
+		return err
+	}
+	if err := client.Rcpt(message.User.Email); err != nil {
+		return err
+	}
+	writer, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := writer.Write(n.email(message)); err != nil {
+		return err
+	}
+	if err := writer.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
+
+// email formats the message as a plain text email
+func (n *SMTPNotifier) email(message *ReminderMessage) []byte {
+	var email bytes.Buffer
+	fmt.Fprintf(&email, "From: %s\r\n", n.From)
+	fmt.Fprintf(&email, "To: %s\r\n", message.User.Email)
+	fmt.Fprintf(&email, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", message.Subject))
+	email.WriteString("MIME-Version: 1.0\r\n")
+	email.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	email.WriteString("Content-Transfer-Encoding: 8bit\r\n\r\n")
+	email.WriteString(strings.ReplaceAll(message.Body, "\n", "\r\n"))
+	email.WriteString("\r\n")
+	return email.Bytes()
+}
+
+// WebhookNotifier posts notifications as JSON to a URL. With a secret the body is signed
+// with HMAC-SHA256 in the X-Signature header.
+type WebhookNotifier struct {
+	URL    string
+	Secret string
+	// Client defaults to http.DefaultClient
+	Client *http.Client
+}
+
+// webhookPayload is the body posted by WebhookNotifier
+type webhookPayload struct {
+	Event    string           `json:"event"`
+	UserID   string           `json:"user_id"`
+	DueAt    time.Time        `json:"due_at"`
+	Subject  string           `json:"subject"`
+	Body     string           `json:"body"`
+	Reminder *models.Reminder `json:"reminder"`
+}
+
+func (n *WebhookNotifier) Channel() string { return ChannelWebhook }
+
+func (n *WebhookNotifier) Notify(ctx context.Context, message *ReminderMessage) error {
+	payload, err := json.Marshal(webhookPayload{
+		Event:    "reminder.due",
+		UserID:   message.User.ID.String(),
+		DueAt:    message.DueAt,
+		Subject:  message.Subject,
+		Body:     message.Body,
+		Reminder: message.Reminder,
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.URL, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if n.Secret != "" {
+		mac := hmac.New(sha256.New, []byte(n.Secret))
+		mac.Write(payload)
+		req.Header.Set("X-Signature", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	}
+
+	client := n.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook responded with %s", resp.Status)
+	}
+	return nil
+}
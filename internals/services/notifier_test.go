@@ -0,0 +1,129 @@
+package services
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	models "github.com/KashyretsIvanna/voice-balance/internals/model"
+	"github.com/google/uuid"
+)
+
+func testReminderMessage() *ReminderMessage {
+	user := &models.User{ID: uuid.New(), Email: "user@example.com"}
+	reminder := &models.Reminder{ID: uuid.New(), UserID: user.ID, Title: "Оплатити інтернет"}
+	return &ReminderMessage{User: user, Reminder: reminder, DueAt: dispatchStart, Subject: reminder.Title, Body: "Нагадування: Оплатити інтернет"}
+}
+
+type webhookRequest struct {
+	signature string
+	body      []byte
+}
+
+func webhookServer(t *testing.T, status int) (*httptest.Server, <-chan webhookRequest) {
+	t.Helper()
+	requests := make(chan webhookRequest, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		requests <- webhookRequest{signature: r.Header.Get("X-Signature"), body: body}
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(server.Close)
+	return server, requests
+}
+
+func TestWebhookSignsBody(t *testing.T) {
+	server, requests := webhookServer(t, http.StatusNoContent)
+	notifier := &WebhookNotifier{URL: server.URL, Secret: "s3cret"}
+	message := testReminderMessage()
+
+	if err := notifier.Notify(context.Background(), message); err != nil {
+		t.Fatal(err)
+	}
+	request := <-requests
+
+	mac := hmac.New(sha256.New, []byte("s3cret"))
+	mac.Write(request.body)
+	want := "sha256=" + hex.EncodeToString(mac.Sum(nil))
+	if !hmac.Equal([]byte(request.signature), []byte(want)) {
+		t.Errorf("X-Signature = %q, want %q", request.signature, want)
+	}
+
+	// A signature made with another secret does not match
+	other := hmac.New(sha256.New, []byte("other"))
+	other.Write(request.body)
+	if request.signature == "sha256="+hex.EncodeToString(other.Sum(nil)) {
+		t.Errorf("the signature does not depend on the secret")
+	}
+
+	var payload webhookPayload
+	if err := json.Unmarshal(request.body, &payload); err != nil {
+		t.Fatal(err)
+	}
+	if payload.Event != "reminder.due" || payload.UserID != message.User.ID.String() || !payload.DueAt.Equal(message.DueAt) || payload.Subject != message.Subject {
+		t.Errorf("unexpected payload %+v", payload)
+	}
+}
+
+func TestWebhookWithoutSecretIsNotSigned(t *testing.T) {
+	server, requests := webhookServer(t, http.StatusOK)
+	notifier := &WebhookNotifier{URL: server.URL}
+
+	if err := notifier.Notify(context.Background(), testReminderMessage()); err != nil {
+		t.Fatal(err)
+	}
+	if request := <-requests; request.signature != "" {
+		t.Errorf("X-Signature = %q without a secret", request.signature)
+	}
+}
+
+func TestWebhookFailsOnErrorStatus(t *testing.T) {
+	server, _ := webhookServer(t, http.StatusBadGateway)
+	notifier := &WebhookNotifier{URL: server.URL, Secret: "s3cret"}
+
+	err := notifier.Notify(context.Background(), testReminderMessage())
+	if err == nil || !strings.Contains(err.Error(), "502") {
+		t.Errorf("Notify() error = %v, want the 502 status", err)
+	}
+}
+
+func TestSMTPNotifierSendsEncodedSubject(t *testing.T) {
+	smtpServer := newFakeSMTPServer(t, 0)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := smtpServer.notifier().Notify(ctx, testReminderMessage()); err != nil {
+		t.Fatal(err)
+	}
+	messages := smtpServer.received()
+	if len(messages) != 1 {
+		t.Fatalf("the SMTP server got %d messages, want 1", len(messages))
+	}
+	email := messages[0]
+	for _, want := range []string{"From: reminders@example.com\r\n", "To: user@example.com\r\n", "Subject: =?utf-8?q?", "Content-Type: text/plain; charset=UTF-8\r\n", "Нагадування: Оплатити інтернет"} {
+		if !strings.Contains(email, want) {
+			t.Errorf("the email has no %q:\n%s", want, email)
+		}
+	}
+}
+
+func TestSMTPNotifierFailsOnRejectedRecipient(t *testing.T) {
+	smtpServer := newFakeSMTPServer(t, 1)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := smtpServer.notifier().Notify(ctx, testReminderMessage()); err == nil {
+		t.Fatal("a rejected recipient was not reported")
+	}
+	if n := len(smtpServer.received()); n != 0 {
+		t.Errorf("the SMTP server got %d messages, want none", n)
+	}
+}
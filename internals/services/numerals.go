@@ -0,0 +1,273 @@
+package services
+
+import (
+	"math"
+	"math/big"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// numeral is a number word: a value added to the number or a multiplier like "тисяча"
+type numeral struct {
+	value      float64
+	multiplier bool
+}
+
+// Numerals is the vocabulary of spoken amounts in one language. Words are lower case
+// and written with the ASCII apostrophe.
+type Numerals struct {
+	Words map[string]numeral
+	// Prefixes of the currency and of its hundredth, e.g. "грив" and "коп"
+	CurrencyPrefixes []string
+	FractionPrefixes []string
+	// Words allowed between the whole part and the fraction, e.g. "і"
+	Conjunctions []string
+}
+
+var ukrainianNumerals = &Numerals{
+	Words: map[string]numeral{
+		"нуль": {value: 0},
+
+		"один": {value: 1}, "одна": {value: 1}, "одне": {value: 1}, "одну": {value: 1},
+		"одного": {value: 1}, "однієї": {value: 1},
+		"два": {value: 2}, "дві": {value: 2}, "двох": {value: 2},
+		"три": {value: 3}, "трьох": {value: 3},
+		"чотири": {value: 4}, "чотирьох": {value: 4},
+		"п'ять": {value: 5}, "п'яти": {value: 5},
+		"шість": {value: 6}, "шести": {value: 6},
+		"сім": {value: 7}, "семи": {value: 7},
+		"вісім": {value: 8}, "восьми": {value: 8},
+		"дев'ять": {value: 9}, "дев'яти": {value: 9},
+		"півтори": {value: 1.5}, "півтора": {value: 1.5},
+
+		"десять": {value: 10}, "десяти": {value: 10},
+		"одинадцять": {value: 11}, "одинадцяти": {value: 11},
+		"дванадцять": {value: 12}, "дванадцяти": {value: 12},
+		"тринадцять": {value: 13}, "тринадцяти": {value: 13},
+		"чотирнадцять": {value: 14}, "чотирнадцяти": {value: 14},
+		"п'ятнадцять": {value: 15}, "п'ятнадцяти": {value: 15},
+		"шістнадцять": {value: 16}, "шістнадцяти": {value: 16},
+		"сімнадцять": {value: 17}, "сімнадцяти": {value: 17},
+		"вісімнадцять": {value: 18}, "вісімнадцяти": {value: 18},
+		"дев'ятнадцять": {value: 19}, "дев'ятнадцяти": {value: 19},
+
+		"двадцять": {value: 20}, "двадцяти": {value: 20},
+		"тридцять": {value: 30}, "тридцяти": {value: 30},
+		"сорок": {value: 40}, "сорока": {value: 40},
+		"п'ятдесят": {value: 50}, "п'ятдесяти": {value: 50},
+		"шістдесят": {value: 60}, "шістдесяти": {value: 60},
+		"сімдесят": {value: 70}, "сімдесяти": {value: 70},
+		"вісімдесят": {value: 80}, "вісімдесяти": {value: 80},
+		"дев'яносто": {value: 90}, "дев'яноста": {value: 90},
+
+		"сто": {value: 100}, "ста": {value: 100},
+		"двісті": {value: 200}, "двохсот": {value: 200},
+		"триста": {value: 300}, "трьохсот": {value: 300},
+		"чотириста": {value: 400}, "чотирьохсот": {value: 400},
+		"п'ятсот": {value: 500}, "п'ятисот": {value: 500},
+		"шістсот": {value: 600}, "шестисот": {value: 600},
+		"сімсот": {value: 700}, "семисот": {value: 700},
+		"вісімсот": {value: 800}, "восьмисот": {value: 800},
+		"дев'ятсот": {value: 900}, "дев'ятисот": {value: 900},
+
+		"тисяча": {value: 1e3, multiplier: true}, "тисячі": {value: 1e3, multiplier: true},
+		"тисяч": {value: 1e3, multiplier: true}, "тисячу": {value: 1e3, multiplier: true},
+		"тисячею": {value: 1e3, multiplier: true},
+		"мільйон": {value: 1e6, multiplier: true}, "мільйона": {value: 1e6, multiplier: true},
+		"мільйони": {value: 1e6, multiplier: true}, "мільйонів": {value: 1e6, multiplier: true},
+		"мільярд": {value: 1e9, multiplier: true}, "мільярда": {value: 1e9, multiplier: true},
+		"мільярди": {value: 1e9, multiplier: true}, "мільярдів": {value: 1e9, multiplier: true},
+	},
+	CurrencyPrefixes: []string{"грив", "грн"},
+	FractionPrefixes: []string{"коп"},
+	Conjunctions:     []string{"і", "й", "та"},
+}
+
+// Apostrophes speech recognizers and keyboards use in words like "п’ять"
+var apostropheReplacer = strings.NewReplacer("’", "'", "ʼ", "'", "`", "'", "′", "'")
+
+// numeralTokenRegex splits a command into numbers like "12,50" and words
+var numeralTokenRegex = regexp.MustCompile(`\d+(?:[.,]\d+)?|[\p{L}']+`)
+
+// ParseAmount finds the amount in the text, written with digits, with words or mixed,
+// e.g. "двісті п'ятдесят гривень і сорок копійок" or "2 тисячі". Without numerals only
+// digits are recognized. A number followed by the currency or by kopiyky is the amount,
+// "одну каву за 50 грн" costs 50; without one the largest number is. The amount is returned
+// as the exact decimal that was said, "12.345" stays 12.345, so callers decide whether it is
+// too precise.
+func ParseAmount(numerals *Numerals, text string) (string, bool) {
+	tokens := numeralTokenRegex.FindAllString(apostropheReplacer.Replace(strings.ToLower(text)), -1)
+
+	var largest *big.Rat
+	for i := 0; i < len(tokens); {
+		amount, next, named, ok := readAmount(numerals, tokens, i)
+		if !ok {
+			i++
+			continue
+		}
+		if named {
+			return decimalString(amount), true
+		}
+		if largest == nil || amount.Cmp(largest) > 0 {
+			largest = amount
+		}
+		i = next
+	}
+	if largest == nil {
+		return "", false
+	}
+	return decimalString(largest), true
+}
+
+// readAmount reads the number starting at tokens[start] with the currency and the kopiyky
+// that follow it. named is set when the number is followed by the currency or by kopiyky.
+func readAmount(numerals *Numerals, tokens []string, start int) (amount *big.Rat, end int, named bool, ok bool) {
+	hundred := big.NewRat(100, 1)
+	amount, next, ok := readNumber(numerals, tokens, start)
+	if !ok {
+		return nil, start, false, false
+	}
+
+	// "сорок копійок" alone is a fraction
+	if next < len(tokens) && hasAnyPrefix(tokens[next], numerals.fractionPrefixes()) {
+		return amount.Quo(amount, hundred), next + 1, true, true
+	}
+
+	// "двісті гривень (і) сорок копійок"
+	if next >= len(tokens) || !hasAnyPrefix(tokens[next], numerals.currencyPrefixes()) {
+		return amount, next, false, true
+	}
+	next++
+	cents := next
+	if cents < len(tokens) && numerals.isConjunction(tokens[cents]) {
+		cents++
+	}
+	if fraction, end, ok := readNumber(numerals, tokens, cents); ok &&
+		end < len(tokens) && hasAnyPrefix(tokens[end], numerals.fractionPrefixes()) {
+		amount.Add(amount, fraction.Quo(fraction, hundred))
+		next = end + 1
+	}
+	return amount, next, true, true
+}
+
+// decimalString writes a number with as many decimals as it has. Spoken and written
+// numbers are decimal fractions, so their expansion ends.
+func decimalString(value *big.Rat) string {
+	scaled := new(big.Rat).Set(value)
+	ten := big.NewRat(10, 1)
+	decimals := 0
+	for !scaled.IsInt() && decimals < 20 {
+		scaled.Mul(scaled, ten)
+		decimals++
+	}
+	return value.FloatString(decimals)
+}
+
+// readNumber reads the number starting at tokens[start]. It returns the number and the index
+// of the first token after it. Components have to go from larger to smaller, so in
+// "п'ять двадцять" only "п'ять" is read.
+func readNumber(numerals *Numerals, tokens []string, start int) (*big.Rat, int, bool) {
+	total, current := new(big.Rat), new(big.Rat)
+	// Components added to the current group have to be less than limit
+	limit, multiplierLimit := math.Inf(1), math.Inf(1)
+
+	i := start
+	for ; i < len(tokens); i++ {
+		token := tokens[i]
+
+		if unicode.IsDigit([]rune(token)[0]) {
+			value, ok := new(big.Rat).SetString(strings.Replace(token, ",", ".", 1))
+			if !ok {
+				break
+			}
+			if float, _ := value.Float64(); float >= limit {
+				break
+			}
+			current.Add(current, value)
+			// Nothing but a multiplier may follow a number written with digits
+			limit = 1
+			continue
+		}
+
+		if numerals == nil {
+			break
+		}
+		word, ok := numerals.Words[token]
+		if !ok {
+			break
+		}
+
+		if word.multiplier {
+			if word.value >= multiplierLimit {
+				break
+			}
+			if current.Sign() == 0 {
+				// "тисяча" means one thousand
+				current.SetInt64(1)
+			}
+			total.Add(total, current.Mul(current, new(big.Rat).SetFloat64(word.value)))
+			current = new(big.Rat)
+			limit, multiplierLimit = word.value, word.value
+			continue
+		}
+
+		if word.value >= limit {
+			break
+		}
+		current.Add(current, new(big.Rat).SetFloat64(word.value))
+		limit = numeralScale(word.value)
+	}
+
+	if i == start {
+		return nil, start, false
+	}
+	return total.Add(total, current), i, true
+}
+
+// numeralScale is the order of a component: after "двісті" only tens and units may follow
+func numeralScale(value float64) float64 {
+	switch {
+	case value >= 100:
+		return 100
+	case value >= 20:
+		return 10
+	default:
+		return 1
+	}
+}
+
+func (n *Numerals) currencyPrefixes() []string {
+	if n == nil {
+		return nil
+	}
+	return n.CurrencyPrefixes
+}
+
+func (n *Numerals) fractionPrefixes() []string {
+	if n == nil {
+		return nil
+	}
+	return n.FractionPrefixes
+}
+
+func (n *Numerals) isConjunction(token string) bool {
+	if n == nil {
+		return false
+	}
+	for _, conjunction := range n.Conjunctions {
+		if token == conjunction {
+			return true
+		}
+	}
+	return false
+}
+
+func hasAnyPrefix(token string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(token, prefix) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,97 @@
+package services
+
+import "testing"
+
+func TestVoiceAmountIsNotRounded(t *testing.T) {
+	grammar := &DefaultLanguage().Grammar
+	tests := []struct {
+		command string
+		amount  string
+		valid   bool
+	}{
+		{"витратив 12.345 на каву", "12.345", false},
+		{"витратив 12,345 на каву", "12.345", false},
+		{"витратив 12.5 на каву", "12.5", true},
+		{"витратив 0.07 на каву", "0.07", true},
+		{"витратив 12.50 на каву", "12.5", true},
+		{"витратив 12.5 копійок", "0.125", false},
+		{"витратив двісті гривень сорок копійок", "200.4", true},
+		{"витратив 99 гривень 99 копійок", "99.99", true},
+		{"витратив півтори тисячі", "1500", true},
+		{"витратив півтори гривні", "1.5", true},
+	}
+	for _, test := range tests {
+		amount := findAmount(grammar, test.command)
+		if amount != test.amount {
+			t.Errorf("findAmount(%q) = %q, want %q", test.command, amount, test.amount)
+		}
+		if _, err := decimalValue(amount); (err == nil) != test.valid {
+			t.Errorf("decimalValue(%q) = %v, want valid %v", amount, err, test.valid)
+		}
+	}
+}
+
+func TestParseAmount(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		// Compound numbers
+		{"двісті п'ятдесят", "250"},
+		{"дві тисячі триста сорок п'ять", "2345"},
+		{"тисяча", "1000"},
+		{"півтори тисячі", "1500"},
+		{"один мільйон двісті тисяч", "1200000"},
+		{"2 тисячі", "2000"},
+		{"3 тисячі 500", "3500"},
+		{"сто двадцять", "120"},
+
+		// Gender and case forms
+		{"одна гривня", "1"},
+		{"одну гривню", "1"},
+		{"двох гривень", "2"},
+		{"дві гривні", "2"},
+		{"п'ятисот гривень", "500"},
+		{"сорока гривень", "40"},
+		{"тисячу гривень", "1000"},
+		{"двохсот п'ятдесяти гривень", "250"},
+
+		// Apostrophes of recognizers and keyboards
+		{"п’ять гривень", "5"},
+		{"пʼять гривень", "5"},
+		{"п`ять гривень", "5"},
+		{"дев′ятсот гривень", "900"},
+
+		// Hryvni and kopiyky
+		{"двісті гривень сорок копійок", "200.4"},
+		{"двісті гривень і сорок копійок", "200.4"},
+		{"5 гривень та 50 копійок", "5.5"},
+		{"сто гривень 5 копійок", "100.05"},
+		{"сорок копійок", "0.4"},
+		{"99 грн 99 коп", "99.99"},
+
+		// The number next to the currency is the amount, otherwise the largest one
+		{"купив одну каву за 50 грн", "50"},
+		{"купив одну каву за п'ятдесят гривень", "50"},
+		{"дві кави по 25 гривень", "25"},
+		{"заплатив 300 за 2 квитки", "300"},
+		{"купив одну каву за п'ятдесят", "50"},
+	}
+	for _, test := range tests {
+		got, ok := ParseAmount(ukrainianNumerals, test.text)
+		if !ok || got != test.want {
+			t.Errorf("ParseAmount(%q) = %q, %v, want %q", test.text, got, ok, test.want)
+		}
+	}
+
+	if got, ok := ParseAmount(ukrainianNumerals, "кава"); ok {
+		t.Errorf("an amount %q in a text without numbers", got)
+	}
+	// Without numerals only digits are read
+	if got, ok := ParseAmount(nil, "купив 2 кави за 50"); !ok || got != "50" {
+		t.Errorf("without numerals: %q, %v, want 50", got, ok)
+	}
+	if got, ok := ParseAmount(nil, "п'ять гривень"); ok {
+		t.Errorf("without numerals %q was read from words", got)
+	}
+}
@@ -0,0 +1,411 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/KashyretsIvanna/voice-balance/config"
+	"github.com/KashyretsIvanna/voice-balance/database"
+	models "github.com/KashyretsIvanna/voice-balance/internals/model"
+	repositories "github.com/KashyretsIvanna/voice-balance/internals/repositories"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ErrInvalidRecurringTransaction is returned when a recurring transaction does not pass validation
+var ErrInvalidRecurringTransaction = errors.New("invalid recurring transaction")
+
+// ErrRecurringTransactionBusy is returned when the scheduler kept posting occurrences of a
+// recurring transaction while its schedule was being changed
+var ErrRecurringTransactionBusy = errors.New("recurring transaction is being posted, try again")
+
+const (
+	// defaultSchedulerInterval is how often due occurrences are posted without RECURRING_SCHEDULER_INTERVAL
+	defaultSchedulerInterval = time.Minute
+	// schedulerBatchSize limits the recurring transactions loaded at once by the scheduler
+	schedulerBatchSize = 100
+	// maxOccurrenceSearch limits the occurrences stepped through to find the next one
+	maxOccurrenceSearch = 100000
+	// maxUpdateAttempts limits how often a schedule change is retried while the scheduler posts occurrences
+	maxUpdateAttempts = 3
+	// MaxPreviewOccurrences limits the occurrences returned by PreviewRecurringTransaction
+	MaxPreviewOccurrences = 100
+)
+
+var recurringFrequencies = map[string]bool{
+	models.FrequencyDaily:   true,
+	models.FrequencyWeekly:  true,
+	models.FrequencyMonthly: true,
+	models.FrequencyYearly:  true,
+}
+
+// RecurringTransactionUpdate holds the recurring transaction fields a user is allowed to change.
+// Nil fields are left untouched, ClearEndDate removes the end date.
+type RecurringTransactionUpdate struct {
+	CategoryID   *uuid.UUID    `json:"category_id"`
+	Amount       *models.Money `json:"amount" swaggertype:"number" example:"12000"`
+	Currency     *string       `json:"currency" example:"UAH"`
+	Description  *string       `json:"description" example:"оренда"`
+	Frequency    *string       `json:"frequency" example:"monthly"`
+	Interval     *int          `json:"interval" example:"1"`
+	StartDate    *time.Time    `json:"start_date"`
+	EndDate      *time.Time    `json:"end_date"`
+	ClearEndDate bool          `json:"clear_end_date"`
+}
+
+func validateRecurringTransaction(recurring *models.RecurringTransaction) error {
+	currency, err := transactionCurrency(recurring.UserID, recurring.Currency)
+	if errors.Is(err, ErrInvalidCurrency) {
+		return fmt.Errorf("%w: %v", ErrInvalidRecurringTransaction, err)
+	}
+	if err != nil {
+		return err
+	}
+	recurring.Currency = currency
+
+	if err := checkAmount(recurring.Amount, recurring.Currency); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidRecurringTransaction, err)
+	}
+
+	recurring.Description = strings.TrimSpace(recurring.Description)
+	if len([]rune(recurring.Description)) > 255 {
+		return fmt.Errorf("%w: description must be at most 255 characters", ErrInvalidRecurringTransaction)
+	}
+
+	recurring.Frequency = strings.ToLower(strings.TrimSpace(recurring.Frequency))
+	if !recurringFrequencies[recurring.Frequency] {
+		return fmt.Errorf("%w: frequency must be daily, weekly, monthly or yearly", ErrInvalidRecurringTransaction)
+	}
+	if recurring.Interval == 0 {
+		recurring.Interval = 1
+	}
+	if recurring.Interval < 0 {
+		return fmt.Errorf("%w: interval must be greater than zero", ErrInvalidRecurringTransaction)
+	}
+
+	if recurring.StartDate.IsZero() {
+		return fmt.Errorf("%w: start_date is required", ErrInvalidRecurringTransaction)
+	}
+	if recurring.EndDate != nil && recurring.EndDate.Before(recurring.StartDate) {
+		return fmt.Errorf("%w: end_date is before start_date", ErrInvalidRecurringTransaction)
+	}
+
+	_, err = repositories.FindCategoryByID(database.DB, recurring.UserID, recurring.CategoryID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return fmt.Errorf("%w: category %s not found", ErrInvalidRecurringTransaction, recurring.CategoryID)
+	}
+	return err
+}
+
+// CreateRecurringTransaction validates and saves a recurring transaction. Occurrences between
+// a start date in the past and now are posted on the next run of the scheduler.
+func CreateRecurringTransaction(recurring *models.RecurringTransaction) error {
+	if err := validateRecurringTransaction(recurring); err != nil {
+		return err
+	}
+
+	location, err := UserLocation(recurring.UserID)
+	if err != nil {
+		return err
+	}
+	recurring.NextDate = nextOccurrence(recurring, recurring.StartDate.Add(-time.Nanosecond), location)
+
+	return repositories.SaveRecurringTransaction(recurring)
+}
+
+func GetRecurringTransactions(userID uuid.UUID) ([]models.RecurringTransaction, error) {
+	return repositories.FindRecurringTransactions(userID)
+}
+
+func GetRecurringTransaction(userID, recurringID uuid.UUID) (*models.RecurringTransaction, error) {
+	return repositories.FindRecurringTransactionByID(userID, recurringID)
+}
+
+// UpdateRecurringTransaction changes a recurring transaction. When the schedule changes,
+// the next occurrence is the first one of the new schedule after the last posted occurrence.
+func UpdateRecurringTransaction(userID, recurringID uuid.UUID, update RecurringTransactionUpdate) (*models.RecurringTransaction, error) {
+	for attempt := 0; attempt < maxUpdateAttempts; attempt++ {
+		recurring, err := repositories.FindRecurringTransactionByID(userID, recurringID)
+		if err != nil {
+			return nil, err
+		}
+
+		updated, err := updateRecurringTransaction(recurring, update)
+		if err != nil {
+			return nil, err
+		}
+		if updated {
+			return recurring, nil
+		}
+	}
+	return nil, ErrRecurringTransactionBusy
+}
+
+// updateRecurringTransaction applies the update to the loaded recurring transaction and saves
+// the changed columns. It returns false without saving anything when the scheduler moved the
+// next occurrence since the recurring transaction was loaded.
+func updateRecurringTransaction(recurring *models.RecurringTransaction, update RecurringTransactionUpdate) (bool, error) {
+	scheduleChanged := update.Frequency != nil || update.Interval != nil || update.StartDate != nil ||
+		update.EndDate != nil || update.ClearEndDate
+	loadedNextDate := recurring.NextDate
+
+	if update.CategoryID != nil {
+		recurring.CategoryID = *update.CategoryID
+	}
+	if update.Amount != nil {
+		recurring.Amount = *update.Amount
+	}
+	if update.Currency != nil {
+		recurring.Currency = *update.Currency
+	}
+	if update.Description != nil {
+		recurring.Description = *update.Description
+	}
+	if update.Frequency != nil {
+		recurring.Frequency = *update.Frequency
+	}
+	if update.Interval != nil {
+		recurring.Interval = *update.Interval
+	}
+	if update.StartDate != nil {
+		recurring.StartDate = *update.StartDate
+	}
+	if update.EndDate != nil {
+		recurring.EndDate = update.EndDate
+	}
+	if update.ClearEndDate {
+		recurring.EndDate = nil
+	}
+
+	if err := validateRecurringTransaction(recurring); err != nil {
+		return false, err
+	}
+
+	// Only the changed columns are saved, the scheduler may be posting occurrences meanwhile.
+	// The columns are taken after validation, which normalizes them.
+	columns := map[string]interface{}{}
+	if update.CategoryID != nil {
+		columns["category_id"] = recurring.CategoryID
+	}
+	if update.Amount != nil {
+		columns["amount"] = recurring.Amount
+	}
+	if update.Currency != nil {
+		columns["currency"] = recurring.Currency
+	}
+	if update.Description != nil {
+		columns["description"] = recurring.Description
+	}
+
+	if scheduleChanged {
+		location, err := UserLocation(recurring.UserID)
+		if err != nil {
+			return false, err
+		}
+		last, err := repositories.LastRecurringOccurrence(recurring.ID)
+		if err != nil {
+			return false, err
+		}
+
+		after := recurring.StartDate.Add(-time.Nanosecond)
+		if last != nil && last.After(after) {
+			after = *last
+		}
+		recurring.NextDate = nextOccurrence(recurring, after, location)
+
+		columns["frequency"] = recurring.Frequency
+		columns["interval"] = recurring.Interval
+		columns["start_date"] = recurring.StartDate
+		columns["end_date"] = recurring.EndDate
+		columns["next_date"] = recurring.NextDate
+	}
+
+	if len(columns) == 0 {
+		return true, nil
+	}
+	return repositories.UpdateRecurringTransaction(recurring, columns, loadedNextDate)
+}
+
+func DeleteRecurringTransaction(userID, recurringID uuid.UUID) error {
+	recurring, err := repositories.FindRecurringTransactionByID(userID, recurringID)
+	if err != nil {
+		return err
+	}
+	return repositories.SoftDeleteRecurringTransaction(recurring)
+}
+
+// PreviewRecurringTransaction returns up to count upcoming occurrences that are not posted yet
+func PreviewRecurringTransaction(userID, recurringID uuid.UUID, count int) ([]time.Time, error) {
+	recurring, err := repositories.FindRecurringTransactionByID(userID, recurringID)
+	if err != nil {
+		return nil, err
+	}
+
+	location, err := UserLocation(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if count > MaxPreviewOccurrences {
+		count = MaxPreviewOccurrences
+	}
+	occurrences := make([]time.Time, 0, count)
+	for next := recurring.NextDate; next != nil && len(occurrences) < count; next = nextOccurrence(recurring, *next, location) {
+		occurrences = append(occurrences, *next)
+	}
+	return occurrences, nil
+}
+
+// StartRecurringScheduler posts due occurrences of recurring transactions in the background
+// every RECURRING_SCHEDULER_INTERVAL, a minute by default, until ctx is done. Every replica
+// may run it, occurrences are still posted once.
+func StartRecurringScheduler(ctx context.Context) {
+	interval := defaultSchedulerInterval
+	if value := config.Config("RECURRING_SCHEDULER_INTERVAL"); value != "" {
+		parsed, err := time.ParseDuration(value)
+		if err != nil || parsed <= 0 {
+			log.Printf("recurring scheduler: invalid interval %q, using %s", value, interval)
+		} else {
+			interval = parsed
+		}
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			if posted, err := PostDueRecurringTransactions(time.Now()); err != nil {
+				log.Printf("recurring scheduler: %v", err)
+			} else if posted > 0 {
+				log.Printf("recurring scheduler: posted %d transactions", posted)
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// PostDueRecurringTransactions posts every occurrence due at or before now as a transaction
+// and returns the number of transactions posted
+func PostDueRecurringTransactions(now time.Time) (int, error) {
+	locations := map[uuid.UUID]*time.Location{}
+	posted := 0
+
+	for {
+		due, err := repositories.FindDueRecurringTransactions(now, schedulerBatchSize)
+		if err != nil {
+			return posted, err
+		}
+
+		progressed := false
+		for i := range due {
+			recurring := &due[i]
+
+			location, ok := locations[recurring.UserID]
+			if !ok {
+				location, err = UserLocation(recurring.UserID)
+				if err != nil {
+					return posted, err
+				}
+				locations[recurring.UserID] = location
+			}
+
+			count, err := postOccurrences(recurring, now, location)
+			posted += count
+			if err != nil {
+				return posted, err
+			}
+			progressed = progressed || count > 0
+		}
+
+		// Stop when everything is posted or other replicas are posting the rest
+		if len(due) < schedulerBatchSize || !progressed {
+			return posted, nil
+		}
+	}
+}
+
+// postOccurrences posts the occurrences of one recurring transaction due at or before now
+func postOccurrences(recurring *models.RecurringTransaction, now time.Time, location *time.Location) (int, error) {
+	posted := 0
+	for recurring.NextDate != nil && !recurring.NextDate.After(now) {
+		occurrence := *recurring.NextDate
+		transaction := &models.Transaction{
+			Amount:         recurring.Amount,
+			Currency:       recurring.Currency,
+			Description:    recurring.Description,
+			Date:           occurrence,
+			UserID:         recurring.UserID,
+			CategoryID:     recurring.CategoryID,
+			RecurringID:    &recurring.ID,
+			OccurrenceDate: &occurrence,
+		}
+
+		claimed, err := repositories.PostRecurringOccurrence(recurring, transaction, nextOccurrence(recurring, occurrence, location))
+		if err != nil {
+			return posted, err
+		}
+		if !claimed {
+			// Another replica is posting it, unless the occurrence was posted before and skipped
+			if recurring.NextDate != nil && recurring.NextDate.Equal(occurrence) {
+				return posted, nil
+			}
+			continue
+		}
+		posted++
+	}
+	return posted, nil
+}
+
+// nextOccurrence returns the first occurrence of the schedule after the given time,
+// or nil when the schedule ends before it. Occurrences are counted from the start date
+// in the time zone of the user, so monthly ones keep their day and clock time.
+func nextOccurrence(recurring *models.RecurringTransaction, after time.Time, location *time.Location) *time.Time {
+	for n := 0; n < maxOccurrenceSearch; n++ {
+		occurrence := nthOccurrence(recurring, n, location)
+		if recurring.EndDate != nil && occurrence.After(*recurring.EndDate) {
+			return nil
+		}
+		if occurrence.After(after) {
+			return &occurrence
+		}
+	}
+	return nil
+}
+
+// nthOccurrence returns the occurrence n steps after the start date, the start date itself for 0
+func nthOccurrence(recurring *models.RecurringTransaction, n int, location *time.Location) time.Time {
+	start := recurring.StartDate.In(location)
+	steps := n * recurring.Interval
+
+	switch recurring.Frequency {
+	case models.FrequencyDaily:
+		return start.AddDate(0, 0, steps)
+	case models.FrequencyWeekly:
+		return start.AddDate(0, 0, 7*steps)
+	case models.FrequencyYearly:
+		return addMonthsClamped(start, 12*steps)
+	default:
+		return addMonthsClamped(start, steps)
+	}
+}
+
+// addMonthsClamped adds months keeping the day of month, days missing in the target month
+// become its last day: a rent due on the 31st is posted on the 30th in April
+func addMonthsClamped(t time.Time, months int) time.Time {
+	first := time.Date(t.Year(), t.Month()+time.Month(months), 1, 0, 0, 0, 0, t.Location())
+	day := t.Day()
+	if last := first.AddDate(0, 1, -1).Day(); day > last {
+		day = last
+	}
+	return time.Date(first.Year(), first.Month(), day, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), t.Location())
+}
@@ -0,0 +1,184 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	models "github.com/KashyretsIvanna/voice-balance/internals/model"
+	repositories "github.com/KashyretsIvanna/voice-balance/internals/repositories"
+	"github.com/google/uuid"
+)
+
+// ErrInvalidReminder is returned when a reminder does not pass validation
+var ErrInvalidReminder = errors.New("invalid reminder")
+
+// ReminderUpdate holds the reminder fields a user is allowed to change.
+// Nil fields are left untouched.
+type ReminderUpdate struct {
+	Title       *string       `json:"title"`
+	Amount      *models.Money `json:"amount" swaggertype:"number"`
+	DueDate     *time.Time    `json:"due_date"`
+	IsCompleted *bool         `json:"is_completed"`
+}
+
+// ReminderSnooze tells how long to snooze a reminder: until a time or for a number of minutes
+type ReminderSnooze struct {
+	Until   *time.Time `json:"until"`
+	Minutes int        `json:"minutes" example:"30"`
+}
+
+func validateReminder(reminder *models.Reminder) error {
+	reminder.Title = strings.TrimSpace(reminder.Title)
+	if reminder.Title == "" {
+		return fmt.Errorf("%w: title is required", ErrInvalidReminder)
+	}
+	if len([]rune(reminder.Title)) > 100 {
+		return fmt.Errorf("%w: title must be at most 100 characters", ErrInvalidReminder)
+	}
+	if reminder.DueDate.IsZero() {
+		return fmt.Errorf("%w: due_date is required", ErrInvalidReminder)
+	}
+	if reminder.Amount < 0 {
+		return fmt.Errorf("%w: amount must not be negative", ErrInvalidReminder)
+	}
+	if reminder.Amount > 0 {
+		// Amounts of reminders are in the currency of the user
+		settings, err := GetUserSettings(reminder.UserID)
+		if err != nil {
+			return err
+		}
+		if err := checkAmount(reminder.Amount, settings.Currency); err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidReminder, err)
+		}
+	}
+	return nil
+}
+
+func CreateReminder(reminder *models.Reminder) error {
+	if err := validateReminder(reminder); err != nil {
+		return err
+	}
+	return repositories.SaveReminder(reminder)
+}
+
+func GetReminders(userID uuid.UUID, filter repositories.ReminderFilter) ([]models.Reminder, error) {
+	return repositories.FindReminders(userID, filter)
+}
+
+func GetReminder(userID, reminderID uuid.UUID) (*models.Reminder, error) {
+	return repositories.FindReminderByID(userID, reminderID)
+}
+
+func UpdateReminder(userID, reminderID uuid.UUID, update ReminderUpdate) (*models.Reminder, error) {
+	reminder, err := repositories.FindReminderByID(userID, reminderID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Only the changed columns are saved, the dispatcher may be delivering the reminder meanwhile
+	columns := map[string]interface{}{}
+	if update.Title != nil {
+		reminder.Title = *update.Title
+	}
+	if update.Amount != nil {
+		reminder.Amount = *update.Amount
+		columns["amount"] = reminder.Amount
+	}
+	if update.DueDate != nil && !update.DueDate.Equal(reminder.DueDate) {
+		reminder.DueDate = *update.DueDate
+		// The reminder is notified again at the new due date
+		reminder.SnoozedUntil, reminder.NotifiedAt, reminder.RetryAt = nil, nil, nil
+		reminder.Attempts = 0
+		columns["due_date"] = reminder.DueDate
+		columns["snoozed_until"], columns["notified_at"], columns["retry_at"] = nil, nil, nil
+		columns["attempts"] = 0
+	}
+	if update.IsCompleted != nil {
+		reminder.IsCompleted = *update.IsCompleted
+		columns["is_completed"] = reminder.IsCompleted
+	}
+
+	if err := validateReminder(reminder); err != nil {
+		return nil, err
+	}
+	if update.Title != nil {
+		// The title is saved as validateReminder trimmed it
+		columns["title"] = reminder.Title
+	}
+	if len(columns) == 0 {
+		return reminder, nil
+	}
+	if err := repositories.UpdateReminder(reminder, columns); err != nil {
+		return nil, err
+	}
+	return reminder, nil
+}
+
+func CompleteReminder(userID, reminderID uuid.UUID) (*models.Reminder, error) {
+	completed := true
+	return UpdateReminder(userID, reminderID, ReminderUpdate{IsCompleted: &completed})
+}
+
+func DeleteReminder(userID, reminderID uuid.UUID) error {
+	reminder, err := repositories.FindReminderByID(userID, reminderID)
+	if err != nil {
+		return err
+	}
+	return repositories.SoftDeleteReminder(reminder)
+}
+
+// SnoozeReminder notifies about the reminder again at the end of the snooze, the due date stays as it is
+func SnoozeReminder(userID, reminderID uuid.UUID, snooze ReminderSnooze) (*models.Reminder, error) {
+	reminder, err := repositories.FindReminderByID(userID, reminderID)
+	if err != nil {
+		return nil, err
+	}
+	if reminder.IsCompleted {
+		return nil, fmt.Errorf("%w: a completed reminder cannot be snoozed", ErrInvalidReminder)
+	}
+
+	now := time.Now()
+	var until time.Time
+	switch {
+	case snooze.Until != nil && snooze.Minutes != 0:
+		return nil, fmt.Errorf("%w: give either until or minutes", ErrInvalidReminder)
+	case snooze.Until != nil:
+		until = *snooze.Until
+	case snooze.Minutes > 0:
+		until = now.Add(time.Duration(snooze.Minutes) * time.Minute)
+	default:
+		return nil, fmt.Errorf("%w: minutes must be greater than zero", ErrInvalidReminder)
+	}
+	if !until.After(now) {
+		return nil, fmt.Errorf("%w: until must be in the future", ErrInvalidReminder)
+	}
+
+	reminder.SnoozedUntil = &until
+	reminder.NotifiedAt, reminder.RetryAt = nil, nil
+	reminder.Attempts = 0
+	columns := map[string]interface{}{"snoozed_until": until, "notified_at": nil, "retry_at": nil, "attempts": 0}
+	if err := repositories.UpdateReminder(reminder, columns); err != nil {
+		return nil, err
+	}
+	return reminder, nil
+}
+
+// GetReminderDeliveries returns the delivery log of a reminder of the user
+func GetReminderDeliveries(userID, reminderID uuid.UUID) ([]models.ReminderDelivery, error) {
+	if _, err := repositories.FindReminderByID(userID, reminderID); err != nil {
+		return nil, err
+	}
+	return repositories.FindReminderDeliveries(userID, reminderID)
+}
+
+// GetNotifications returns the in-app inbox of the user
+func GetNotifications(userID uuid.UUID, unreadOnly bool) ([]models.Notification, error) {
+	return repositories.FindNotifications(userID, unreadOnly)
+}
+
+// MarkNotificationRead marks a notification in the inbox of the user as read
+func MarkNotificationRead(userID, notificationID uuid.UUID) (*models.Notification, error) {
+	return repositories.MarkNotificationRead(userID, notificationID, time.Now())
+}
@@ -0,0 +1,235 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/KashyretsIvanna/voice-balance/config"
+	"github.com/KashyretsIvanna/voice-balance/database"
+	models "github.com/KashyretsIvanna/voice-balance/internals/model"
+	repositories "github.com/KashyretsIvanna/voice-balance/internals/repositories"
+	"github.com/google/uuid"
+)
+
+// Defaults of ReminderDispatcher
+const (
+	defaultDispatchInterval   = time.Minute
+	defaultDeliveryAttempts   = 5
+	defaultDeliveryRetryDelay = time.Minute
+	defaultDeliveryTimeout    = 30 * time.Second
+	dispatchBatchSize         = 100
+)
+
+// ReminderStore keeps the reminders the dispatcher delivers and their delivery log.
+// repositories.ReminderRepository keeps them in the database.
+type ReminderStore interface {
+	FindDueReminders(now time.Time, limit int) ([]models.Reminder, error)
+	ClaimReminder(reminder *models.Reminder, now, leaseUntil time.Time) (bool, error)
+	FindUser(userID uuid.UUID) (*models.User, error)
+	UpdateReminderColumns(reminderID uuid.UUID, columns map[string]interface{}) error
+	SaveReminderDelivery(delivery *models.ReminderDelivery) error
+	FindDeliveredChannels(reminderID uuid.UUID, dueAt time.Time) ([]string, error)
+}
+
+// ReminderDispatcher delivers reminders coming due through its notifiers. A channel that fails
+// is retried with a delay doubled after every attempt, channels that succeeded are not repeated.
+// Every attempt is written to the delivery log.
+type ReminderDispatcher struct {
+	Store     ReminderStore
+	Clock     Clock
+	Notifiers []Notifier
+	// MaxAttempts is the number of attempts after which a notification is given up
+	MaxAttempts int
+	// RetryDelay is the delay before the first retry
+	RetryDelay time.Duration
+	// Timeout limits one delivery through one channel
+	Timeout time.Duration
+}
+
+// NewReminderDispatcher returns a dispatcher with the notifiers from REMINDER_CHANNELS
+// and REMINDER_MAX_ATTEMPTS attempts
+func NewReminderDispatcher() (*ReminderDispatcher, error) {
+	notifiers, err := NewNotifiers()
+	if err != nil {
+		return nil, err
+	}
+
+	dispatcher := &ReminderDispatcher{
+		Store:       repositories.NewReminderRepository(database.DB),
+		Clock:       SystemClock,
+		Notifiers:   notifiers,
+		MaxAttempts: defaultDeliveryAttempts,
+		RetryDelay:  defaultDeliveryRetryDelay,
+		Timeout:     defaultDeliveryTimeout,
+	}
+	if value := config.Config("REMINDER_MAX_ATTEMPTS"); value != "" {
+		attempts, err := strconv.Atoi(value)
+		if err != nil || attempts <= 0 {
+			return nil, fmt.Errorf("REMINDER_MAX_ATTEMPTS must be a positive number, got %q", value)
+		}
+		dispatcher.MaxAttempts = attempts
+	}
+	return dispatcher, nil
+}
+
+// StartReminderDispatcher delivers due reminders in the background every REMINDER_DISPATCH_INTERVAL,
+// a minute by default, until ctx is done. Every replica may run it, a reminder is delivered by one of them.
+func StartReminderDispatcher(ctx context.Context) {
+	dispatcher, err := NewReminderDispatcher()
+	if err != nil {
+		log.Printf("reminder dispatcher: %v, reminders will not be delivered", err)
+		return
+	}
+
+	interval := defaultDispatchInterval
+	if value := config.Config("REMINDER_DISPATCH_INTERVAL"); value != "" {
+		parsed, err := time.ParseDuration(value)
+		if err != nil || parsed <= 0 {
+			log.Printf("reminder dispatcher: invalid interval %q, using %s", value, interval)
+		} else {
+			interval = parsed
+		}
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			if delivered, err := dispatcher.DispatchDue(ctx); err != nil {
+				log.Printf("reminder dispatcher: %v", err)
+			} else if delivered > 0 {
+				log.Printf("reminder dispatcher: delivered %d reminders", delivered)
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// DispatchDue delivers every reminder due by the clock and returns the number of reminders delivered
+func (d *ReminderDispatcher) DispatchDue(ctx context.Context) (int, error) {
+	due, err := d.Store.FindDueReminders(d.Clock.Now(), dispatchBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	delivered := 0
+	for i := range due {
+		if ctx.Err() != nil {
+			return delivered, ctx.Err()
+		}
+
+		ok, err := d.deliver(ctx, &due[i])
+		if err != nil {
+			return delivered, err
+		}
+		if ok {
+			delivered++
+		}
+	}
+	return delivered, nil
+}
+
+// deliver sends one reminder through every notifier that has not delivered it yet.
+// It returns true when the reminder is delivered through all of them.
+func (d *ReminderDispatcher) deliver(ctx context.Context, reminder *models.Reminder) (bool, error) {
+	now := d.Clock.Now()
+
+	// The lease covers the slowest delivery through all channels
+	lease := now.Add(time.Duration(len(d.Notifiers)+1) * d.Timeout)
+	claimed, err := d.Store.ClaimReminder(reminder, now, lease)
+	if err != nil || !claimed {
+		return false, err
+	}
+
+	dueAt := reminder.DueDate
+	if reminder.SnoozedUntil != nil {
+		dueAt = *reminder.SnoozedUntil
+	}
+
+	user, err := d.Store.FindUser(reminder.UserID)
+	if err != nil {
+		return false, err
+	}
+	message := newReminderMessage(user, reminder, dueAt)
+
+	delivered, err := d.Store.FindDeliveredChannels(reminder.ID, dueAt)
+	if err != nil {
+		return false, err
+	}
+
+	failed := false
+	for _, notifier := range d.Notifiers {
+		if includes(delivered, notifier.Channel()) {
+			continue
+		}
+
+		notifyCtx, cancel := context.WithTimeout(ctx, d.Timeout)
+		notifyErr := notifier.Notify(notifyCtx, message)
+		cancel()
+
+		delivery := &models.ReminderDelivery{
+			ReminderID: reminder.ID,
+			UserID:     reminder.UserID,
+			Channel:    notifier.Channel(),
+			DueAt:      dueAt,
+			Attempt:    reminder.Attempts,
+			Status:     models.DeliverySent,
+		}
+		if notifyErr != nil {
+			failed = true
+			delivery.Status = models.DeliveryFailed
+			delivery.Error = notifyErr.Error()
+		}
+		if err := d.Store.SaveReminderDelivery(delivery); err != nil {
+			return false, err
+		}
+	}
+
+	columns := map[string]interface{}{"notified_at": now, "retry_at": nil, "attempts": 0}
+	switch {
+	case !failed:
+	case reminder.Attempts >= d.MaxAttempts:
+		log.Printf("reminder %s: delivery given up after %d attempts", reminder.ID, reminder.Attempts)
+	default:
+		// 1, 2, 4... retry delays
+		columns = map[string]interface{}{"retry_at": now.Add(d.RetryDelay << (reminder.Attempts - 1))}
+	}
+	if err := d.Store.UpdateReminderColumns(reminder.ID, columns); err != nil {
+		return false, err
+	}
+	return !failed, nil
+}
+
+// newReminderMessage writes the notification of a reminder in the language and time zone of the user
+func newReminderMessage(user *models.User, reminder *models.Reminder, dueAt time.Time) *ReminderMessage {
+	language, err := LookupLanguage(user.Language)
+	if err != nil {
+		language = DefaultLanguage()
+	}
+	location, err := time.LoadLocation(user.TimeZone)
+	if err != nil || user.TimeZone == "" {
+		location, _ = time.LoadLocation(DefaultTimeZone)
+	}
+
+	body := fmt.Sprintf(language.ReminderTemplate, reminder.Title, dueAt.In(location).Format("02.01.2006 15:04"))
+	if reminder.Amount > 0 {
+		body += fmt.Sprintf(language.ReminderAmountTemplate, reminder.Amount, user.Currency)
+	}
+
+	return &ReminderMessage{
+		User:     user,
+		Reminder: reminder,
+		DueAt:    dueAt,
+		Subject:  reminder.Title,
+		Body:     body,
+	}
+}
@@ -0,0 +1,463 @@
+package services
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	models "github.com/KashyretsIvanna/voice-balance/internals/model"
+	"github.com/google/uuid"
+)
+
+// memoryReminderStore keeps reminders in memory and claims them like ReminderRepository does in SQL
+type memoryReminderStore struct {
+	mu         sync.Mutex
+	reminders  map[uuid.UUID]*models.Reminder
+	users      map[uuid.UUID]*models.User
+	deliveries []models.ReminderDelivery
+}
+
+func newMemoryReminderStore() *memoryReminderStore {
+	return &memoryReminderStore{reminders: map[uuid.UUID]*models.Reminder{}, users: map[uuid.UUID]*models.User{}}
+}
+
+func (s *memoryReminderStore) add(user *models.User, reminder *models.Reminder) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.users[user.ID] = user
+	s.reminders[reminder.ID] = reminder
+}
+
+func (s *memoryReminderStore) get(id uuid.UUID) models.Reminder {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return *s.reminders[id]
+}
+
+func (s *memoryReminderStore) FindDueReminders(now time.Time, limit int) ([]models.Reminder, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var due []models.Reminder
+	for _, r := range s.reminders {
+		dueAt := r.DueDate
+		if r.SnoozedUntil != nil {
+			dueAt = *r.SnoozedUntil
+		}
+		if r.DeletedAt == nil && !r.IsCompleted && r.NotifiedAt == nil && !dueAt.After(now) &&
+			(r.RetryAt == nil || !r.RetryAt.After(now)) && len(due) < limit {
+			due = append(due, *r)
+		}
+	}
+	return due, nil
+}
+
+func (s *memoryReminderStore) ClaimReminder(reminder *models.Reminder, now, leaseUntil time.Time) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	r := s.reminders[reminder.ID]
+	if r.Attempts != reminder.Attempts || r.NotifiedAt != nil || r.RetryAt != nil && r.RetryAt.After(now) {
+		return false, nil
+	}
+	r.RetryAt = &leaseUntil
+	r.Attempts++
+	reminder.RetryAt = &leaseUntil
+	reminder.Attempts++
+	return true, nil
+}
+
+func (s *memoryReminderStore) FindUser(userID uuid.UUID) (*models.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	user, ok := s.users[userID]
+	if !ok {
+		return nil, errors.New("user not found")
+	}
+	return user, nil
+}
+
+func (s *memoryReminderStore) UpdateReminderColumns(reminderID uuid.UUID, columns map[string]interface{}) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	r := s.reminders[reminderID]
+	for column, value := range columns {
+		switch column {
+		case "notified_at":
+			t := value.(time.Time)
+			r.NotifiedAt = &t
+		case "retry_at":
+			if value == nil {
+				r.RetryAt = nil
+			} else {
+				t := value.(time.Time)
+				r.RetryAt = &t
+			}
+		case "attempts":
+			r.Attempts = value.(int)
+		default:
+			return fmt.Errorf("unexpected column %q", column)
+		}
+	}
+	return nil
+}
+
+func (s *memoryReminderStore) SaveReminderDelivery(delivery *models.ReminderDelivery) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.deliveries = append(s.deliveries, *delivery)
+	return nil
+}
+
+func (s *memoryReminderStore) FindDeliveredChannels(reminderID uuid.UUID, dueAt time.Time) ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var channels []string
+	for _, d := range s.deliveries {
+		if d.ReminderID == reminderID && d.DueAt.Equal(dueAt) && d.Status == models.DeliverySent && !includes(channels, d.Channel) {
+			channels = append(channels, d.Channel)
+		}
+	}
+	return channels, nil
+}
+
+func (s *memoryReminderStore) statuses(channel string) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var statuses []string
+	for _, d := range s.deliveries {
+		if d.Channel == channel {
+			statuses = append(statuses, d.Status)
+		}
+	}
+	return statuses
+}
+
+// fakeSMTPServer accepts mail on a local port. The first rejections RCPT commands are refused.
+type fakeSMTPServer struct {
+	listener net.Listener
+
+	mu         sync.Mutex
+	rejections int
+	messages   []string
+}
+
+func newFakeSMTPServer(t *testing.T, rejections int) *fakeSMTPServer {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := &fakeSMTPServer{listener: listener, rejections: rejections}
+	t.Cleanup(func() { listener.Close() })
+	go server.serve()
+	return server
+}
+
+func (s *fakeSMTPServer) notifier() *SMTPNotifier {
+	host, port, _ := net.SplitHostPort(s.listener.Addr().String())
+	return &SMTPNotifier{Host: host, Port: port, From: "reminders@example.com"}
+}
+
+func (s *fakeSMTPServer) received() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.messages...)
+}
+
+func (s *fakeSMTPServer) serve() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		go s.session(conn)
+	}
+}
+
+func (s *fakeSMTPServer) session(conn net.Conn) {
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+	reply := func(line string) { fmt.Fprintf(conn, "%s\r\n", line) }
+
+	reply("220 fake ESMTP")
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return
+		}
+		command := strings.ToUpper(strings.TrimSpace(line))
+		switch {
+		case strings.HasPrefix(command, "EHLO"), strings.HasPrefix(command, "HELO"):
+			reply("250 fake")
+		case strings.HasPrefix(command, "MAIL FROM"):
+			reply("250 OK")
+		case strings.HasPrefix(command, "RCPT TO"):
+			s.mu.Lock()
+			reject := s.rejections > 0
+			if reject {
+				s.rejections--
+			}
+			s.mu.Unlock()
+			if reject {
+				reply("450 mailbox unavailable")
+			} else {
+				reply("250 OK")
+			}
+		case command == "DATA":
+			reply("354 go ahead")
+			var data strings.Builder
+			for {
+				line, err := reader.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if line == ".\r\n" {
+					break
+				}
+				data.WriteString(line)
+			}
+			s.mu.Lock()
+			s.messages = append(s.messages, data.String())
+			s.mu.Unlock()
+			reply("250 queued")
+		case command == "RSET", command == "NOOP":
+			reply("250 OK")
+		case command == "QUIT":
+			reply("221 bye")
+			return
+		default:
+			reply("502 not implemented")
+		}
+	}
+}
+
+// failingNotifier fails its first failures deliveries
+type failingNotifier struct {
+	channel  string
+	failures int
+	calls    int
+}
+
+func (n *failingNotifier) Channel() string { return n.channel }
+
+func (n *failingNotifier) Notify(ctx context.Context, message *ReminderMessage) error {
+	n.calls++
+	if n.calls <= n.failures {
+		return errors.New("unavailable")
+	}
+	return nil
+}
+
+var dispatchStart = time.Date(2024, time.May, 10, 9, 0, 0, 0, time.UTC)
+
+func newTestDispatcher(store ReminderStore, clock Clock, notifiers ...Notifier) *ReminderDispatcher {
+	return &ReminderDispatcher{
+		Store:       store,
+		Clock:       clock,
+		Notifiers:   notifiers,
+		MaxAttempts: 3,
+		RetryDelay:  time.Minute,
+		Timeout:     5 * time.Second,
+	}
+}
+
+func dueReminder(store *memoryReminderStore) *models.Reminder {
+	user := &models.User{ID: uuid.New(), Email: "user@example.com", Language: "en", TimeZone: "Europe/Kyiv", Currency: "UAH"}
+	reminder := &models.Reminder{ID: uuid.New(), UserID: user.ID, Title: "Pay the rent", Amount: 1200000, DueDate: dispatchStart.Add(-time.Minute)}
+	store.add(user, reminder)
+	return reminder
+}
+
+func dispatch(t *testing.T, dispatcher *ReminderDispatcher) int {
+	t.Helper()
+	delivered, err := dispatcher.DispatchDue(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return delivered
+}
+
+func TestDispatcherEmailsDueReminder(t *testing.T) {
+	store := newMemoryReminderStore()
+	reminder := dueReminder(store)
+	smtpServer := newFakeSMTPServer(t, 0)
+	clock := NewFakeClock(dispatchStart)
+	dispatcher := newTestDispatcher(store, clock, smtpServer.notifier())
+
+	if delivered := dispatch(t, dispatcher); delivered != 1 {
+		t.Fatalf("delivered %d reminders, want 1", delivered)
+	}
+	messages := smtpServer.received()
+	if len(messages) != 1 {
+		t.Fatalf("the SMTP server got %d messages, want 1", len(messages))
+	}
+	if !strings.Contains(messages[0], "To: user@example.com") || !strings.Contains(messages[0], "Pay the rent") {
+		t.Errorf("unexpected email:\n%s", messages[0])
+	}
+	if got := store.get(reminder.ID); got.NotifiedAt == nil || !got.NotifiedAt.Equal(dispatchStart) || got.RetryAt != nil {
+		t.Errorf("reminder after delivery: notified_at %v retry_at %v", got.NotifiedAt, got.RetryAt)
+	}
+
+	// A delivered reminder is not sent again
+	clock.Advance(time.Hour)
+	if delivered := dispatch(t, dispatcher); delivered != 0 || len(smtpServer.received()) != 1 {
+		t.Errorf("a delivered reminder was sent again")
+	}
+}
+
+func TestDispatcherClaimsReminderOnce(t *testing.T) {
+	store := newMemoryReminderStore()
+	reminder := dueReminder(store)
+	smtpServer := newFakeSMTPServer(t, 0)
+	clock := NewFakeClock(dispatchStart)
+	first := newTestDispatcher(store, clock, smtpServer.notifier())
+	second := newTestDispatcher(store, clock, smtpServer.notifier())
+
+	// Both replicas found the reminder due before either claimed it
+	due, _ := store.FindDueReminders(clock.Now(), 10)
+	firstCopy, secondCopy := due[0], due[0]
+
+	ok, err := first.deliver(context.Background(), &firstCopy)
+	if err != nil || !ok {
+		t.Fatalf("first replica: delivered %v, %v", ok, err)
+	}
+	ok, err = second.deliver(context.Background(), &secondCopy)
+	if err != nil || ok {
+		t.Fatalf("second replica: delivered %v, %v", ok, err)
+	}
+	if n := len(smtpServer.received()); n != 1 {
+		t.Errorf("the reminder was emailed %d times, want once", n)
+	}
+	if statuses := store.statuses(ChannelEmail); len(statuses) != 1 {
+		t.Errorf("delivery log %v, want one entry", statuses)
+	}
+	if got := store.get(reminder.ID); got.NotifiedAt == nil {
+		t.Errorf("the reminder is not marked as notified")
+	}
+}
+
+func TestDispatcherRetriesAfterExpiredLease(t *testing.T) {
+	store := newMemoryReminderStore()
+	reminder := dueReminder(store)
+	smtpServer := newFakeSMTPServer(t, 0)
+	clock := NewFakeClock(dispatchStart)
+	dispatcher := newTestDispatcher(store, clock, smtpServer.notifier())
+
+	// A replica claimed the reminder and stopped before finishing the delivery
+	lease := clock.Now().Add(time.Minute)
+	claimed := store.get(reminder.ID)
+	if ok, _ := store.ClaimReminder(&claimed, clock.Now(), lease); !ok {
+		t.Fatal("the reminder could not be claimed")
+	}
+
+	clock.Advance(30 * time.Second)
+	if delivered := dispatch(t, dispatcher); delivered != 0 {
+		t.Fatalf("a reminder under lease was delivered")
+	}
+
+	clock.Set(lease)
+	if delivered := dispatch(t, dispatcher); delivered != 1 {
+		t.Fatalf("the reminder was not delivered after its lease expired")
+	}
+	if n := len(smtpServer.received()); n != 1 {
+		t.Errorf("the reminder was emailed %d times, want once", n)
+	}
+}
+
+func TestDispatcherRetriesWithBackoff(t *testing.T) {
+	store := newMemoryReminderStore()
+	reminder := dueReminder(store)
+	// The SMTP server refuses the first two attempts
+	smtpServer := newFakeSMTPServer(t, 2)
+	webhook := &failingNotifier{channel: ChannelWebhook}
+	clock := NewFakeClock(dispatchStart)
+	dispatcher := newTestDispatcher(store, clock, smtpServer.notifier(), webhook)
+
+	if delivered := dispatch(t, dispatcher); delivered != 0 {
+		t.Fatalf("a failed delivery was counted")
+	}
+	if got := store.get(reminder.ID); got.RetryAt == nil || !got.RetryAt.Equal(dispatchStart.Add(time.Minute)) || got.NotifiedAt != nil {
+		t.Fatalf("after the first attempt retry_at is %v, want %v", got.RetryAt, dispatchStart.Add(time.Minute))
+	}
+
+	clock.Advance(59 * time.Second)
+	if dispatch(t, dispatcher); webhook.calls != 1 {
+		t.Fatalf("the reminder was retried before retry_at")
+	}
+
+	// The second retry waits twice as long
+	clock.Set(dispatchStart.Add(time.Minute))
+	dispatch(t, dispatcher)
+	if got := store.get(reminder.ID); got.RetryAt == nil || !got.RetryAt.Equal(dispatchStart.Add(3*time.Minute)) {
+		t.Fatalf("after the second attempt retry_at is %v, want %v", got.RetryAt, dispatchStart.Add(3*time.Minute))
+	}
+
+	clock.Set(dispatchStart.Add(3 * time.Minute))
+	if delivered := dispatch(t, dispatcher); delivered != 1 {
+		t.Fatalf("the third attempt did not deliver the reminder")
+	}
+
+	// The webhook succeeded at once and is not repeated by the retries
+	if webhook.calls != 1 {
+		t.Errorf("the webhook was called %d times, want once", webhook.calls)
+	}
+	if statuses := store.statuses(ChannelEmail); strings.Join(statuses, ",") != "failed,failed,sent" {
+		t.Errorf("email delivery log %v, want failed, failed, sent", statuses)
+	}
+	if got := store.get(reminder.ID); got.NotifiedAt == nil || got.RetryAt != nil || got.Attempts != 0 {
+		t.Errorf("reminder after delivery: notified_at %v retry_at %v attempts %d", got.NotifiedAt, got.RetryAt, got.Attempts)
+	}
+}
+
+func TestDispatcherGivesUpAfterMaxAttempts(t *testing.T) {
+	store := newMemoryReminderStore()
+	reminder := dueReminder(store)
+	webhook := &failingNotifier{channel: ChannelWebhook, failures: 100}
+	clock := NewFakeClock(dispatchStart)
+	dispatcher := newTestDispatcher(store, clock, webhook)
+
+	for i := 0; i < 10; i++ {
+		dispatch(t, dispatcher)
+		clock.Advance(time.Hour)
+	}
+	if webhook.calls != dispatcher.MaxAttempts {
+		t.Errorf("the webhook was called %d times, want %d", webhook.calls, dispatcher.MaxAttempts)
+	}
+	if got := store.get(reminder.ID); got.NotifiedAt == nil {
+		t.Errorf("the reminder was not given up")
+	}
+}
+
+func TestDispatcherTimesOutSlowChannel(t *testing.T) {
+	store := newMemoryReminderStore()
+	reminder := dueReminder(store)
+	release := make(chan struct{})
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-release:
+		}
+	}))
+	defer slow.Close()
+	defer close(release)
+	clock := NewFakeClock(dispatchStart)
+	dispatcher := newTestDispatcher(store, clock, &WebhookNotifier{URL: slow.URL})
+	dispatcher.Timeout = 50 * time.Millisecond
+
+	if delivered := dispatch(t, dispatcher); delivered != 0 {
+		t.Fatalf("a timed out delivery was counted")
+	}
+	if statuses := store.statuses(ChannelWebhook); len(statuses) != 1 || statuses[0] != models.DeliveryFailed {
+		t.Errorf("webhook delivery log %v, want one failed entry", statuses)
+	}
+	if got := store.get(reminder.ID); got.RetryAt == nil {
+		t.Errorf("no retry was scheduled")
+	}
+}
@@ -0,0 +1,138 @@
+package services
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/KashyretsIvanna/voice-balance/database/dbtest"
+	models "github.com/KashyretsIvanna/voice-balance/internals/model"
+	repositories "github.com/KashyretsIvanna/voice-balance/internals/repositories"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+func TestCreateReminderValidatesOnPostgres(t *testing.T) {
+	db := dbtest.Use(t)
+	user := saveTestUser(t, db)
+	due := time.Now().Add(24 * time.Hour).UTC().Truncate(time.Second)
+
+	tests := []struct {
+		name     string
+		reminder models.Reminder
+		valid    bool
+	}{
+		{"title and due date", models.Reminder{Title: "Сплатити оренду", DueDate: due}, true},
+		{"with an amount", models.Reminder{Title: "Інтернет", DueDate: due, Amount: 25000}, true},
+		{"blank title", models.Reminder{Title: "   ", DueDate: due}, false},
+		{"title too long", models.Reminder{Title: strings.Repeat("я", 101), DueDate: due}, false},
+		{"no due date", models.Reminder{Title: "Оренда"}, false},
+		{"negative amount", models.Reminder{Title: "Оренда", DueDate: due, Amount: -100}, false},
+	}
+	for _, test := range tests {
+		reminder := test.reminder
+		reminder.UserID = user.ID
+		err := CreateReminder(&reminder)
+		if test.valid && err != nil {
+			t.Errorf("%s: %v", test.name, err)
+		}
+		if !test.valid && !errors.Is(err, ErrInvalidReminder) {
+			t.Errorf("%s: %v, want ErrInvalidReminder", test.name, err)
+		}
+	}
+
+	reminders, err := GetReminders(user.ID, repositories.ReminderFilter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(reminders) != 2 {
+		t.Fatalf("%d reminders saved, want the 2 valid ones", len(reminders))
+	}
+}
+
+func TestReminderOwnershipAndDeletionOnPostgres(t *testing.T) {
+	db := dbtest.Use(t)
+	owner := saveTestUser(t, db)
+	other := saveTestUser(t, db)
+	due := time.Now().Add(24 * time.Hour).UTC().Truncate(time.Second)
+
+	reminder := &models.Reminder{Title: "  Сплатити оренду ", DueDate: due, UserID: owner.ID}
+	if err := CreateReminder(reminder); err != nil {
+		t.Fatal(err)
+	}
+	if reminder.Title != "Сплатити оренду" {
+		t.Errorf("title %q was not trimmed", reminder.Title)
+	}
+
+	// Another user cannot tell the reminder exists
+	title := "Чужа"
+	completed := true
+	notFound := map[string]error{
+		"get": func() error { _, err := GetReminder(other.ID, reminder.ID); return err }(),
+		"update": func() error {
+			_, err := UpdateReminder(other.ID, reminder.ID, ReminderUpdate{Title: &title})
+			return err
+		}(),
+		"complete": func() error { _, err := CompleteReminder(other.ID, reminder.ID); return err }(),
+		"snooze": func() error {
+			_, err := SnoozeReminder(other.ID, reminder.ID, ReminderSnooze{Minutes: 30})
+			return err
+		}(),
+		"deliveries": func() error { _, err := GetReminderDeliveries(other.ID, reminder.ID); return err }(),
+		"delete":     DeleteReminder(other.ID, reminder.ID),
+		"missing":    DeleteReminder(owner.ID, uuid.New()),
+	}
+	for name, err := range notFound {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			t.Errorf("%s by another user: %v, want gorm.ErrRecordNotFound", name, err)
+		}
+	}
+	if others, err := GetReminders(other.ID, repositories.ReminderFilter{}); err != nil || len(others) != 0 {
+		t.Errorf("reminders of another user %v, %v", others, err)
+	}
+
+	// Invalid updates change nothing
+	blank := " "
+	negative := models.Money(-1)
+	for _, update := range []ReminderUpdate{{Title: &blank}, {Amount: &negative}} {
+		if _, err := UpdateReminder(owner.ID, reminder.ID, update); !errors.Is(err, ErrInvalidReminder) {
+			t.Errorf("update %+v: %v, want ErrInvalidReminder", update, err)
+		}
+	}
+	title = " Сплатити оренду до 5-го "
+	if _, err := UpdateReminder(owner.ID, reminder.ID, ReminderUpdate{Title: &title, IsCompleted: &completed}); err != nil {
+		t.Fatal(err)
+	}
+	stored, err := GetReminder(owner.ID, reminder.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.Title != "Сплатити оренду до 5-го" || !stored.IsCompleted || stored.Amount != 0 {
+		t.Errorf("stored reminder %q, completed %v, amount %v", stored.Title, stored.IsCompleted, stored.Amount)
+	}
+	if _, err := SnoozeReminder(owner.ID, reminder.ID, ReminderSnooze{Minutes: 30}); !errors.Is(err, ErrInvalidReminder) {
+		t.Errorf("snoozing a completed reminder: %v, want ErrInvalidReminder", err)
+	}
+
+	// Deleted reminders are kept in the database and are gone for the owner
+	if err := DeleteReminder(owner.ID, reminder.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := GetReminder(owner.ID, reminder.ID); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Errorf("deleted reminder: %v, want gorm.ErrRecordNotFound", err)
+	}
+	if err := DeleteReminder(owner.ID, reminder.ID); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Errorf("deleting again: %v, want gorm.ErrRecordNotFound", err)
+	}
+	if reminders, err := GetReminders(owner.ID, repositories.ReminderFilter{}); err != nil || len(reminders) != 0 {
+		t.Errorf("reminders after deletion %v, %v", reminders, err)
+	}
+	var row models.Reminder
+	if err := db.First(&row, "id = ?", reminder.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if row.DeletedAt == nil {
+		t.Errorf("the reminder row was not marked as deleted")
+	}
+}
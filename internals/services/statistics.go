@@ -1,12 +1,455 @@
 package services
 
 import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"time"
 
 	models "github.com/KashyretsIvanna/voice-balance/internals/model"
 	repositories "github.com/KashyretsIvanna/voice-balance/internals/repositories"
 	"github.com/google/uuid"
 )
 
-func GetStatistics(userID uuid.UUID, startDate, endDate string) ([]models.Transaction, error) {
-	return repositories.FindTransactionsByCategoryAndDate(userID, startDate, endDate)
+// ErrInvalidDateRange is returned for dates that cannot be parsed and ranges ending before they start
+var ErrInvalidDateRange = errors.New("invalid date range")
+
+// CategoryStatistics sums up the transactions of one category
+type CategoryStatistics struct {
+	CategoryID uuid.UUID    `json:"category_id"`
+	Name       string       `json:"name" example:"продукти"`
+	Type       string       `json:"type" example:"expense"`
+	Total      models.Money `json:"total" swaggertype:"number" example:"1250.5"`
+	Count      int64        `json:"count" example:"12"`
+	Average    models.Money `json:"average" swaggertype:"number" example:"104.21"`
+	// Share is the percentage of the total of all categories of the same type
+	Share float64 `json:"share" example:"35.2"`
+	// Subcategories are set on rolled up statistics, the totals above include them
+	Subcategories []CategoryStatistics `json:"subcategories,omitempty"`
+}
+
+// CategoryStatisticsReport holds the totals of a user in a date range per category,
+// split into incomes and expenses
+type CategoryStatisticsReport struct {
+	StartDate time.Time            `json:"start_date"`
+	EndDate   time.Time            `json:"end_date"`
+	Currency  string               `json:"currency" example:"UAH"`
+	Income    models.Money         `json:"income" swaggertype:"number"`
+	Expense   models.Money         `json:"expense" swaggertype:"number"`
+	Net       models.Money         `json:"net" swaggertype:"number"`
+	Incomes   []CategoryStatistics `json:"incomes"`
+	Expenses  []CategoryStatistics `json:"expenses"`
+}
+
+// StatisticsReport sums up the transactions of a user in a date range
+type StatisticsReport struct {
+	Range      StatisticsRange      `json:"range"`
+	StartDate  time.Time            `json:"start_date"`
+	EndDate    time.Time            `json:"end_date"`
+	Currency   string               `json:"currency"`
+	Income     models.Money         `json:"income" swaggertype:"number"`
+	Expense    models.Money         `json:"expense" swaggertype:"number"`
+	Balance    models.Money         `json:"balance" swaggertype:"number"`
+	Categories []CategoryStatistics `json:"categories"`
+}
+
+// maxTimeSeriesPoints limits the number of buckets of one time series
+const maxTimeSeriesPoints = 1000
+
+// TimeSeriesPoint holds the totals of one calendar bucket
+type TimeSeriesPoint struct {
+	Start   time.Time    `json:"start"`
+	End     time.Time    `json:"end"`
+	Income  models.Money `json:"income" swaggertype:"number"`
+	Expense models.Money `json:"expense" swaggertype:"number"`
+	Net     models.Money `json:"net" swaggertype:"number"`
+	Count   int64        `json:"count"`
+}
+
+// TimeSeries holds income and expense totals of a user per calendar bucket. Buckets
+// without transactions are included with zero totals.
+type TimeSeries struct {
+	Bucket    StatisticsRange   `json:"bucket" example:"week"`
+	TimeZone  string            `json:"time_zone" example:"Europe/Kyiv"`
+	WeekStart string            `json:"week_start" example:"monday"`
+	StartDate time.Time         `json:"start_date"`
+	EndDate   time.Time         `json:"end_date"`
+	Currency  string            `json:"currency" example:"UAH"`
+	Points    []TimeSeriesPoint `json:"points"`
+}
+
+// ParseDateRange reads the bounds of a range given as plain dates (YYYY-MM-DD) or RFC3339
+// timestamps. Plain dates are taken in location and the end date covers the whole day.
+// Without start the range begins on the first day of the month of now, without end it
+// lasts until the end of the day of now.
+func ParseDateRange(startValue, endValue string, location *time.Location, now time.Time) (time.Time, time.Time, error) {
+	now = now.In(location)
+
+	start := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, location)
+	if startValue != "" {
+		parsed, err := parseRangeDate(startValue, location, false)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("%w: start_date %q must be YYYY-MM-DD or RFC3339", ErrInvalidDateRange, startValue)
+		}
+		start = parsed
+	}
+
+	end := startOfDay(now).AddDate(0, 0, 1).Add(-time.Nanosecond)
+	if endValue != "" {
+		parsed, err := parseRangeDate(endValue, location, true)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("%w: end_date %q must be YYYY-MM-DD or RFC3339", ErrInvalidDateRange, endValue)
+		}
+		end = parsed
+	}
+
+	if end.Before(start) {
+		return time.Time{}, time.Time{}, fmt.Errorf("%w: end_date is before start_date", ErrInvalidDateRange)
+	}
+	return start, end, nil
+}
+
+func parseRangeDate(value string, location *time.Location, endOfDay bool) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+
+	t, err := time.ParseInLocation("2006-01-02", value, location)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if endOfDay {
+		t = t.AddDate(0, 0, 1).Add(-time.Nanosecond)
+	}
+	return t, nil
+}
+
+// GetCategoryStatistics aggregates the transactions of a user between start and end per category.
+// The filter may limit the report to one category type or one category. Amounts are converted
+// into the currency of the filter, the one of the user when it is empty.
+func GetCategoryStatistics(userID uuid.UUID, start, end time.Time, filter repositories.CategoryTotalsFilter) (*CategoryStatisticsReport, error) {
+	filter, err := reportingCurrency(userID, filter)
+	if err != nil {
+		return nil, err
+	}
+	return categoryStatistics(userID, start, end, filter)
+}
+
+// categoryStatistics is GetCategoryStatistics for a filter with the currency resolved
+func categoryStatistics(userID uuid.UUID, start, end time.Time, filter repositories.CategoryTotalsFilter) (*CategoryStatisticsReport, error) {
+	totals, err := repositories.SumTransactionsByCategory(userID, start, end, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &CategoryStatisticsReport{
+		StartDate: start,
+		EndDate:   end,
+		Currency:  filter.Currency,
+		Incomes:   []CategoryStatistics{},
+		Expenses:  []CategoryStatistics{},
+	}
+	for _, total := range totals {
+		switch total.CategoryType {
+		case models.CategoryTypeIncome:
+			report.Income += total.Total
+		case models.CategoryTypeExpense:
+			report.Expense += total.Total
+		}
+		report.Net += SignedAmount(total.CategoryType, total.Total)
+	}
+
+	for _, total := range totals {
+		statistics := CategoryStatistics{
+			CategoryID: total.CategoryID,
+			Name:       total.CategoryName,
+			Type:       total.CategoryType,
+			Total:      total.Total,
+			Count:      total.Count,
+		}
+		if total.Count > 0 {
+			statistics.Average = total.Total.Scale(1 / float64(total.Count))
+		}
+
+		switch total.CategoryType {
+		case models.CategoryTypeIncome:
+			statistics.Share = sharePercent(total.Total, report.Income)
+			report.Incomes = append(report.Incomes, statistics)
+		case models.CategoryTypeExpense:
+			statistics.Share = sharePercent(total.Total, report.Expense)
+			report.Expenses = append(report.Expenses, statistics)
+		}
+	}
+
+	if filter.Rollup {
+		byID, err := categoryIndex(userID)
+		if err != nil {
+			return nil, err
+		}
+		report.Incomes = rollupCategoryStatistics(report.Incomes, byID, filter.CategoryID, report.Income)
+		report.Expenses = rollupCategoryStatistics(report.Expenses, byID, filter.CategoryID, report.Expense)
+	}
+
+	return report, nil
+}
+
+// categoryNode is a category of rolled up statistics with its subcategories
+type categoryNode struct {
+	statistics CategoryStatistics
+	children   []*categoryNode
+}
+
+// rollupCategoryStatistics nests the statistics of subcategories into the ones of their parents.
+// Totals, counts, averages and shares of a parent include its subcategories, parents without
+// transactions of their own are added up to top, the top-level categories when it is nil.
+// The largest totals come first on every level.
+func rollupCategoryStatistics(flat []CategoryStatistics, byID map[uuid.UUID]*models.Category, top *uuid.UUID, whole models.Money) []CategoryStatistics {
+	nodes := make(map[uuid.UUID]*categoryNode, len(flat))
+	var pending []uuid.UUID
+	for _, statistics := range flat {
+		nodes[statistics.CategoryID] = &categoryNode{statistics: statistics}
+		pending = append(pending, statistics.CategoryID)
+	}
+
+	// Link every node to its parent, adding the parents missing in the statistics
+	var roots []*categoryNode
+	for len(pending) > 0 {
+		id := pending[0]
+		pending = pending[1:]
+
+		category, ok := byID[id]
+		if !ok || category.ParentID == nil || byID[*category.ParentID] == nil || top != nil && id == *top {
+			roots = append(roots, nodes[id])
+			continue
+		}
+		parent, ok := nodes[*category.ParentID]
+		if !ok {
+			parentCategory := byID[*category.ParentID]
+			parent = &categoryNode{statistics: CategoryStatistics{
+				CategoryID: parentCategory.ID,
+				Name:       parentCategory.Name,
+				Type:       parentCategory.Type,
+			}}
+			nodes[parentCategory.ID] = parent
+			pending = append(pending, parentCategory.ID)
+		}
+		parent.children = append(parent.children, nodes[id])
+	}
+
+	return rollupNodes(roots, whole)
+}
+
+// rollupNodes adds the totals of the subcategories of the nodes to theirs and returns their statistics
+func rollupNodes(nodes []*categoryNode, whole models.Money) []CategoryStatistics {
+	result := make([]CategoryStatistics, 0, len(nodes))
+	for _, node := range nodes {
+		statistics := node.statistics
+		if len(node.children) > 0 {
+			statistics.Subcategories = rollupNodes(node.children, whole)
+			for _, subcategory := range statistics.Subcategories {
+				statistics.Total += subcategory.Total
+				statistics.Count += subcategory.Count
+			}
+			statistics.Average = 0
+			if statistics.Count > 0 {
+				statistics.Average = statistics.Total.Scale(1 / float64(statistics.Count))
+			}
+			statistics.Share = sharePercent(statistics.Total, whole)
+		}
+		result = append(result, statistics)
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].Total > result[j].Total
+	})
+	return result
+}
+
+// GetStatisticsTimeSeries groups the income and expense totals of a user between start and end
+// into calendar buckets of the user's time zone. Weeks start on the day from the user settings.
+// Amounts are converted into the currency of the filter, the one of the user when it is empty.
+func GetStatisticsTimeSeries(userID uuid.UUID, bucket StatisticsRange, start, end time.Time, filter repositories.CategoryTotalsFilter) (*TimeSeries, error) {
+	filter, err := reportingCurrency(userID, filter)
+	if err != nil {
+		return nil, err
+	}
+	return statisticsTimeSeries(userID, bucket, start, end, filter)
+}
+
+// statisticsTimeSeries is GetStatisticsTimeSeries for a filter with the currency resolved
+func statisticsTimeSeries(userID uuid.UUID, bucket StatisticsRange, start, end time.Time, filter repositories.CategoryTotalsFilter) (*TimeSeries, error) {
+	if bucket != RangeDay && bucket != RangeWeek && bucket != RangeMonth && bucket != RangeYear {
+		return nil, fmt.Errorf("%w: bucket must be day, week, month or year", ErrInvalidDateRange)
+	}
+
+	settings, err := GetUserSettings(userID)
+	if err != nil {
+		return nil, err
+	}
+	location, err := settings.Location()
+	if err != nil {
+		return nil, err
+	}
+	weekStart := settings.FirstDayOfWeek()
+
+	series, err := newTimeSeries(bucket, start.In(location), end.In(location), location, weekStart)
+	if err != nil {
+		return nil, err
+	}
+	series.WeekStart = settings.WeekStart
+	series.Currency = filter.Currency
+
+	totals, err := repositories.SumTransactionsByPeriod(userID, series.StartDate, series.EndDate, string(bucket), location.String(), periodWeekOffset(bucket, weekStart), filter)
+	if err != nil {
+		return nil, err
+	}
+	series.addPeriodTotals(totals, location)
+	return series, nil
+}
+
+// newTimeSeries returns zero-filled buckets covering start to end, the first one may begin before start
+func newTimeSeries(bucket StatisticsRange, start, end time.Time, location *time.Location, weekStart time.Weekday) (*TimeSeries, error) {
+	series := &TimeSeries{
+		Bucket:    bucket,
+		TimeZone:  location.String(),
+		StartDate: start,
+		EndDate:   end,
+		Points:    []TimeSeriesPoint{},
+	}
+	for bucketStart := bucketStartOf(start, bucket, weekStart); !bucketStart.After(end); {
+		if len(series.Points) == maxTimeSeriesPoints {
+			return nil, fmt.Errorf("%w: more than %d %s buckets, choose a larger bucket or a shorter range", ErrInvalidDateRange, maxTimeSeriesPoints, bucket)
+		}
+		next := nextBucket(bucketStart, bucket)
+		series.Points = append(series.Points, TimeSeriesPoint{Start: bucketStart, End: next.Add(-time.Nanosecond)})
+		bucketStart = next
+	}
+	return series, nil
+}
+
+// periodWeekOffset returns the days SumTransactionsByPeriod shifts dates by. Postgres weeks start
+// on Monday, other first days are handled by shifting the dates; other buckets are not shifted.
+func periodWeekOffset(bucket StatisticsRange, weekStart time.Weekday) int {
+	if bucket != RangeWeek {
+		return 0
+	}
+	return (int(time.Monday) - int(weekStart) + 7) % 7
+}
+
+// addPeriodTotals adds the totals of SumTransactionsByPeriod to the buckets they begin
+func (series *TimeSeries) addPeriodTotals(totals []repositories.PeriodTotal, location *time.Location) {
+	index := make(map[time.Time]int, len(series.Points))
+	for i, point := range series.Points {
+		index[point.Start] = i
+	}
+
+	for _, total := range totals {
+		// The period is a wall clock time of the user's time zone
+		p := total.Period
+		i, ok := index[time.Date(p.Year(), p.Month(), p.Day(), 0, 0, 0, 0, location)]
+		if !ok {
+			continue
+		}
+
+		point := &series.Points[i]
+		switch total.CategoryType {
+		case models.CategoryTypeIncome:
+			point.Income += total.Total
+		case models.CategoryTypeExpense:
+			point.Expense += total.Total
+		}
+		point.Net += SignedAmount(total.CategoryType, total.Total)
+		point.Count += total.Count
+	}
+}
+
+// bucketStartOf returns the start of the calendar bucket containing t
+func bucketStartOf(t time.Time, bucket StatisticsRange, weekStart time.Weekday) time.Time {
+	switch bucket {
+	case RangeWeek:
+		return startOfWeek(startOfDay(t), weekStart)
+	case RangeMonth:
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location())
+	case RangeYear:
+		return time.Date(t.Year(), time.January, 1, 0, 0, 0, 0, t.Location())
+	default:
+		return startOfDay(t)
+	}
+}
+
+// nextBucket returns the start of the bucket following the one starting at start
+func nextBucket(start time.Time, bucket StatisticsRange) time.Time {
+	switch bucket {
+	case RangeWeek:
+		return start.AddDate(0, 0, 7)
+	case RangeMonth:
+		return start.AddDate(0, 1, 0)
+	case RangeYear:
+		return start.AddDate(1, 0, 0)
+	default:
+		return start.AddDate(0, 0, 1)
+	}
+}
+
+// startOfWeek returns the last weekStart on or before day
+func startOfWeek(day time.Time, weekStart time.Weekday) time.Time {
+	offset := (int(day.Weekday()) - int(weekStart) + 7) % 7
+	return day.AddDate(0, 0, -offset)
+}
+
+// sharePercent returns the percentage of part in whole rounded to hundredths
+func sharePercent(part, whole models.Money) float64 {
+	if whole == 0 {
+		return 0
+	}
+	return roundHundredths(float64(part) / float64(whole) * 100)
+}
+
+func roundHundredths(value float64) float64 {
+	return math.Round(value*100) / 100
+}
+
+// StatisticsRangeBounds returns the calendar period that contains now, weeks start on weekStart.
+// RangeAll covers everything up to now.
+func StatisticsRangeBounds(statisticsRange StatisticsRange, now time.Time, weekStart time.Weekday) (time.Time, time.Time) {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+
+	var start, end time.Time
+	switch statisticsRange {
+	case RangeDay:
+		start, end = today, today.AddDate(0, 0, 1)
+	case RangeWeek:
+		start = startOfWeek(today, weekStart)
+		end = start.AddDate(0, 0, 7)
+	case RangeMonth:
+		start = time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+		end = start.AddDate(0, 1, 0)
+	case RangeYear:
+		start = time.Date(now.Year(), time.January, 1, 0, 0, 0, 0, now.Location())
+		end = start.AddDate(1, 0, 0)
+	default:
+		return time.Time{}, now
+	}
+	return start, end.Add(-time.Nanosecond)
+}
+
+// GetStatisticsReport sums up income and expenses of a user between start and end.
+// If categoryType is not empty only transactions of that category type are included.
+func GetStatisticsReport(userID uuid.UUID, statisticsRange StatisticsRange, start, end time.Time, categoryType string) (*StatisticsReport, error) {
+	categories, err := GetCategoryStatistics(userID, start, end, repositories.CategoryTotalsFilter{CategoryType: categoryType})
+	if err != nil {
+		return nil, err
+	}
+
+	return &StatisticsReport{
+		Range:      statisticsRange,
+		StartDate:  start,
+		EndDate:    end,
+		Currency:   categories.Currency,
+		Income:     categories.Income,
+		Expense:    categories.Expense,
+		Balance:    categories.Net,
+		Categories: append(categories.Incomes, categories.Expenses...),
+	}, nil
 }
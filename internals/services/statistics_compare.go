@@ -0,0 +1,197 @@
+package services
+
+import (
+	"sort"
+	"time"
+
+	models "github.com/KashyretsIvanna/voice-balance/internals/model"
+	repositories "github.com/KashyretsIvanna/voice-balance/internals/repositories"
+	"github.com/google/uuid"
+)
+
+// Values of CategoryComparison.Status
+const (
+	ComparisonNew         = "new"
+	ComparisonDisappeared = "disappeared"
+	ComparisonChanged     = "changed"
+	ComparisonUnchanged   = "unchanged"
+)
+
+// Change is the difference of a value between the previous and the current period.
+// Percent is nil when the previous value is zero.
+type Change struct {
+	Current  models.Money `json:"current" swaggertype:"number"`
+	Previous models.Money `json:"previous" swaggertype:"number"`
+	Delta    models.Money `json:"delta" swaggertype:"number"`
+	Percent  *float64     `json:"percent"`
+}
+
+// CategoryComparison is the change of the total of one category
+type CategoryComparison struct {
+	CategoryID uuid.UUID `json:"category_id"`
+	Name       string    `json:"name" example:"продукти"`
+	Type       string    `json:"type" example:"expense"`
+	Status     string    `json:"status" example:"changed"`
+	Change
+}
+
+// ComparisonPeriod is the range of one of the compared periods
+type ComparisonPeriod struct {
+	StartDate time.Time `json:"start_date"`
+	EndDate   time.Time `json:"end_date"`
+}
+
+// ComparisonReport compares the totals of a user in two periods
+type ComparisonReport struct {
+	Current    ComparisonPeriod     `json:"current"`
+	Previous   ComparisonPeriod     `json:"previous"`
+	Currency   string               `json:"currency" example:"UAH"`
+	Income     Change               `json:"income"`
+	Expense    Change               `json:"expense"`
+	Net        Change               `json:"net"`
+	Categories []CategoryComparison `json:"categories"`
+	// Categories with transactions only in the current or only in the previous period
+	New         []CategoryComparison `json:"new"`
+	Disappeared []CategoryComparison `json:"disappeared"`
+	// Categories with the largest increase and decrease of the total
+	TopIncreases []CategoryComparison `json:"top_increases"`
+	TopDecreases []CategoryComparison `json:"top_decreases"`
+}
+
+// PreviousPeriod returns the period of the same length right before start and end
+func PreviousPeriod(start, end time.Time) (time.Time, time.Time) {
+	length := end.Sub(start) + time.Nanosecond
+	previousEnd := start.Add(-time.Nanosecond)
+	return previousEnd.Add(-length + time.Nanosecond), previousEnd
+}
+
+// CompareStatistics compares the category totals of a user between the current and the previous
+// period. The movers lists hold at most limit categories each.
+func CompareStatistics(userID uuid.UUID, current, previous ComparisonPeriod, filter repositories.CategoryTotalsFilter, limit int) (*ComparisonReport, error) {
+	filter, err := reportingCurrency(userID, filter)
+	if err != nil {
+		return nil, err
+	}
+	currentStatistics, err := categoryStatistics(userID, current.StartDate, current.EndDate, filter)
+	if err != nil {
+		return nil, err
+	}
+	previousStatistics, err := categoryStatistics(userID, previous.StartDate, previous.EndDate, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	return compareCategoryStatistics(current, previous, currentStatistics, previousStatistics, limit), nil
+}
+
+// compareCategoryStatistics is CompareStatistics with the statistics of both periods loaded
+func compareCategoryStatistics(current, previous ComparisonPeriod, currentStatistics, previousStatistics *CategoryStatisticsReport, limit int) *ComparisonReport {
+	report := &ComparisonReport{
+		Current:      current,
+		Previous:     previous,
+		Currency:     currentStatistics.Currency,
+		Income:       newChange(currentStatistics.Income, previousStatistics.Income),
+		Expense:      newChange(currentStatistics.Expense, previousStatistics.Expense),
+		Net:          newChange(currentStatistics.Net, previousStatistics.Net),
+		Categories:   []CategoryComparison{},
+		New:          []CategoryComparison{},
+		Disappeared:  []CategoryComparison{},
+		TopIncreases: []CategoryComparison{},
+		TopDecreases: []CategoryComparison{},
+	}
+
+	previousTotals := map[uuid.UUID]CategoryStatistics{}
+	for _, category := range append(previousStatistics.Incomes, previousStatistics.Expenses...) {
+		previousTotals[category.CategoryID] = category
+	}
+
+	for _, category := range append(currentStatistics.Incomes, currentStatistics.Expenses...) {
+		before, existed := previousTotals[category.CategoryID]
+		delete(previousTotals, category.CategoryID)
+
+		comparison := CategoryComparison{
+			CategoryID: category.CategoryID,
+			Name:       category.Name,
+			Type:       category.Type,
+			Change:     newChange(category.Total, before.Total),
+		}
+		switch {
+		case !existed:
+			comparison.Status = ComparisonNew
+			report.New = append(report.New, comparison)
+		case comparison.Delta == 0:
+			comparison.Status = ComparisonUnchanged
+		default:
+			comparison.Status = ComparisonChanged
+		}
+		report.Categories = append(report.Categories, comparison)
+	}
+
+	for _, category := range previousTotals {
+		comparison := CategoryComparison{
+			CategoryID: category.CategoryID,
+			Name:       category.Name,
+			Type:       category.Type,
+			Status:     ComparisonDisappeared,
+			Change:     newChange(0, category.Total),
+		}
+		report.Disappeared = append(report.Disappeared, comparison)
+		report.Categories = append(report.Categories, comparison)
+	}
+
+	// The largest changes first, either way. Disappeared categories come from a map,
+	// so equal changes are ordered by name to keep the report the same on every request.
+	sort.SliceStable(report.Categories, func(i, j int) bool {
+		a, b := report.Categories[i], report.Categories[j]
+		if abs(a.Delta) != abs(b.Delta) {
+			return abs(a.Delta) > abs(b.Delta)
+		}
+		return comparisonNameLess(a, b)
+	})
+	sort.SliceStable(report.Disappeared, func(i, j int) bool {
+		a, b := report.Disappeared[i], report.Disappeared[j]
+		if a.Previous != b.Previous {
+			return a.Previous > b.Previous
+		}
+		return comparisonNameLess(a, b)
+	})
+
+	for _, category := range report.Categories {
+		if category.Delta > 0 && len(report.TopIncreases) < limit {
+			report.TopIncreases = append(report.TopIncreases, category)
+		}
+		if category.Delta < 0 && len(report.TopDecreases) < limit {
+			report.TopDecreases = append(report.TopDecreases, category)
+		}
+	}
+
+	return report
+}
+
+// comparisonNameLess orders categories by name, then by ID for categories with the same name
+func comparisonNameLess(a, b CategoryComparison) bool {
+	if a.Name != b.Name {
+		return a.Name < b.Name
+	}
+	return a.CategoryID.String() < b.CategoryID.String()
+}
+
+func newChange(current, previous models.Money) Change {
+	change := Change{
+		Current:  current,
+		Previous: previous,
+		Delta:    current - previous,
+	}
+	if previous != 0 {
+		percent := sharePercent(change.Delta, abs(previous))
+		change.Percent = &percent
+	}
+	return change
+}
+
+func abs(amount models.Money) models.Money {
+	if amount < 0 {
+		return -amount
+	}
+	return amount
+}
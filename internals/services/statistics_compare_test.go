@@ -0,0 +1,146 @@
+package services
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	models "github.com/KashyretsIvanna/voice-balance/internals/model"
+	"github.com/google/uuid"
+)
+
+func TestPreviousPeriod(t *testing.T) {
+	endOfDay := func(year int, month time.Month, day int) time.Time {
+		return time.Date(year, month, day+1, 0, 0, 0, 0, time.UTC).Add(-time.Nanosecond)
+	}
+	tests := []struct {
+		name               string
+		start, end         time.Time
+		wantStart, wantEnd time.Time
+	}{
+		{"31 days before March of a leap year",
+			time.Date(2024, time.March, 1, 0, 0, 0, 0, time.UTC), endOfDay(2024, time.March, 31),
+			time.Date(2024, time.January, 30, 0, 0, 0, 0, time.UTC), endOfDay(2024, time.February, 29)},
+		{"29 days before February",
+			time.Date(2024, time.February, 1, 0, 0, 0, 0, time.UTC), endOfDay(2024, time.February, 29),
+			time.Date(2024, time.January, 3, 0, 0, 0, 0, time.UTC), endOfDay(2024, time.January, 31)},
+		{"into the previous year",
+			time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC), endOfDay(2024, time.January, 31),
+			time.Date(2023, time.December, 1, 0, 0, 0, 0, time.UTC), endOfDay(2023, time.December, 31)},
+		{"two weeks over the end of a month",
+			time.Date(2024, time.April, 25, 0, 0, 0, 0, time.UTC), endOfDay(2024, time.May, 8),
+			time.Date(2024, time.April, 11, 0, 0, 0, 0, time.UTC), endOfDay(2024, time.April, 24)},
+		{"one day",
+			time.Date(2024, time.May, 1, 0, 0, 0, 0, time.UTC), endOfDay(2024, time.May, 1),
+			time.Date(2024, time.April, 30, 0, 0, 0, 0, time.UTC), endOfDay(2024, time.April, 30)},
+		{"hours of a day",
+			time.Date(2024, time.May, 1, 10, 30, 0, 0, time.UTC), time.Date(2024, time.May, 1, 18, 0, 0, 0, time.UTC),
+			time.Date(2024, time.May, 1, 2, 59, 59, 999999999, time.UTC), time.Date(2024, time.May, 1, 10, 29, 59, 999999999, time.UTC)},
+	}
+	for _, test := range tests {
+		start, end := PreviousPeriod(test.start, test.end)
+		if !start.Equal(test.wantStart) || !end.Equal(test.wantEnd) {
+			t.Errorf("%s: got %v - %v, want %v - %v", test.name, start, end, test.wantStart, test.wantEnd)
+		}
+		if end.Sub(start) != test.end.Sub(test.start) {
+			t.Errorf("%s: the previous period lasts %v, the current one %v", test.name, end.Sub(start), test.end.Sub(test.start))
+		}
+	}
+}
+
+func comparisonNames(comparisons []CategoryComparison) string {
+	names := make([]string, 0, len(comparisons))
+	for _, comparison := range comparisons {
+		names = append(names, comparison.Name)
+	}
+	return strings.Join(names, ", ")
+}
+
+func TestCompareCategoryStatistics(t *testing.T) {
+	category := func(name, categoryType string) CategoryStatistics {
+		return CategoryStatistics{CategoryID: uuid.New(), Name: name, Type: categoryType}
+	}
+	withTotal := func(category CategoryStatistics, total models.Money) CategoryStatistics {
+		category.Total = total
+		return category
+	}
+	salary := category("Зарплата", models.CategoryTypeIncome)
+	gifts := category("Подарунки", models.CategoryTypeIncome)
+	groceries := category("Продукти", models.CategoryTypeExpense)
+	rent := category("Оренда", models.CategoryTypeExpense)
+	taxi := category("Таксі", models.CategoryTypeExpense)
+	cinema := category("Кіно", models.CategoryTypeExpense)
+	books := category("Книги", models.CategoryTypeExpense)
+
+	previous := &CategoryStatisticsReport{
+		Currency: "UAH", Income: 50000, Expense: 14000, Net: 36000,
+		Incomes:  []CategoryStatistics{withTotal(salary, 50000)},
+		Expenses: []CategoryStatistics{withTotal(rent, 10000), withTotal(groceries, 3000), withTotal(cinema, 500), withTotal(books, 500)},
+	}
+	current := &CategoryStatisticsReport{
+		Currency: "UAH", Income: 52000, Expense: 14000, Net: 38000,
+		Incomes:  []CategoryStatistics{withTotal(salary, 50000), withTotal(gifts, 2000)},
+		Expenses: []CategoryStatistics{withTotal(rent, 8000), withTotal(groceries, 4500), withTotal(taxi, 1500)},
+	}
+
+	want := map[uuid.UUID]struct {
+		status  string
+		delta   models.Money
+		percent *float64
+	}{
+		salary.CategoryID:    {ComparisonUnchanged, 0, floatOf(0)},
+		gifts.CategoryID:     {ComparisonNew, 2000, nil},
+		groceries.CategoryID: {ComparisonChanged, 1500, floatOf(50)},
+		rent.CategoryID:      {ComparisonChanged, -2000, floatOf(-20)},
+		taxi.CategoryID:      {ComparisonNew, 1500, nil},
+		cinema.CategoryID:    {ComparisonDisappeared, -500, floatOf(-100)},
+		books.CategoryID:     {ComparisonDisappeared, -500, floatOf(-100)},
+	}
+
+	// Disappeared categories are collected from a map, the order must not depend on it
+	for run := 0; run < 20; run++ {
+		report := compareCategoryStatistics(ComparisonPeriod{}, ComparisonPeriod{}, current, previous, 2)
+
+		if report.Income.Delta != 2000 || report.Expense.Delta != 0 || report.Net.Delta != 2000 {
+			t.Fatalf("income, expense and net deltas %v, %v, %v, want 20.00, 0.00, 20.00", report.Income.Delta, report.Expense.Delta, report.Net.Delta)
+		}
+		for _, comparison := range report.Categories {
+			expected := want[comparison.CategoryID]
+			percentMatches := (comparison.Percent == nil) == (expected.percent == nil) &&
+				(expected.percent == nil || *comparison.Percent == *expected.percent)
+			if comparison.Status != expected.status || comparison.Delta != expected.delta || !percentMatches {
+				t.Fatalf("%s: %s by %v (%v%%), want %s by %v (%v%%)", comparison.Name,
+					comparison.Status, comparison.Delta, valueOrNil(comparison.Percent), expected.status, expected.delta, valueOrNil(expected.percent))
+			}
+		}
+
+		lists := []struct {
+			name        string
+			comparisons []CategoryComparison
+			want        string
+		}{
+			// Equal changes are ordered by name
+			{"categories", report.Categories, "Оренда, Подарунки, Продукти, Таксі, Книги, Кіно, Зарплата"},
+			{"new", report.New, "Подарунки, Таксі"},
+			{"disappeared", report.Disappeared, "Книги, Кіно"},
+			{"top increases", report.TopIncreases, "Подарунки, Продукти"},
+			{"top decreases", report.TopDecreases, "Оренда, Книги"},
+		}
+		for _, list := range lists {
+			if got := comparisonNames(list.comparisons); got != list.want {
+				t.Fatalf("%s: got %s, want %s", list.name, got, list.want)
+			}
+		}
+	}
+}
+
+func floatOf(value float64) *float64 {
+	return &value
+}
+
+func valueOrNil(value *float64) interface{} {
+	if value == nil {
+		return nil
+	}
+	return *value
+}
@@ -0,0 +1,316 @@
+package services
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/KashyretsIvanna/voice-balance/database/dbtest"
+	models "github.com/KashyretsIvanna/voice-balance/internals/model"
+	repositories "github.com/KashyretsIvanna/voice-balance/internals/repositories"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type testTransaction struct {
+	date         time.Time
+	categoryType string
+	amount       models.Money
+}
+
+// saveStatisticsTransactions saves the transactions for a new user in Kyiv whose weeks start on weekStart
+func saveStatisticsTransactions(t *testing.T, db *gorm.DB, weekStart string, transactions []testTransaction) *models.User {
+	t.Helper()
+	user := saveTestUser(t, db)
+	if err := db.Model(user).Updates(map[string]interface{}{"time_zone": "Europe/Kyiv", "week_start": weekStart}).Error; err != nil {
+		t.Fatal(err)
+	}
+	categories := map[string]*models.Category{
+		models.CategoryTypeIncome:  saveTestCategory(t, db, user.ID, "Зарплата", models.CategoryTypeIncome),
+		models.CategoryTypeExpense: saveTestCategory(t, db, user.ID, "Продукти", models.CategoryTypeExpense),
+	}
+	for _, transaction := range transactions {
+		saveTestTransaction(t, db, &models.Transaction{
+			UserID:     user.ID,
+			CategoryID: categories[transaction.categoryType].ID,
+			Amount:     transaction.amount,
+			Date:       transaction.date,
+		})
+	}
+	return user
+}
+
+func kyivLocation(t *testing.T) *time.Location {
+	t.Helper()
+	kyiv, err := time.LoadLocation("Europe/Kyiv")
+	if err != nil {
+		t.Skip(err)
+	}
+	return kyiv
+}
+
+func TestTimeSeriesMonthsOnPostgres(t *testing.T) {
+	db := dbtest.Use(t)
+	kyiv := kyivLocation(t)
+	day := func(month time.Month, d int) time.Time { return time.Date(2024, month, d, 12, 0, 0, 0, kyiv) }
+	user := saveStatisticsTransactions(t, db, "sunday", []testTransaction{
+		{day(time.January, 15), models.CategoryTypeExpense, 10000},
+		{day(time.February, 1), models.CategoryTypeIncome, 500000},
+		{day(time.February, 29), models.CategoryTypeExpense, 2550},
+		{day(time.March, 31), models.CategoryTypeExpense, 100},
+		// Midnight of April 1 in Kyiv is still March 31 in UTC
+		{time.Date(2024, time.April, 1, 0, 30, 0, 0, kyiv), models.CategoryTypeIncome, 700},
+	})
+
+	series, err := GetStatisticsTimeSeries(user.ID, RangeMonth, day(time.January, 10), day(time.April, 10), repositories.CategoryTotalsFilter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []struct {
+		start           time.Time
+		income, expense models.Money
+		count           int64
+	}{
+		{time.Date(2024, time.January, 1, 0, 0, 0, 0, kyiv), 0, 10000, 1},
+		{time.Date(2024, time.February, 1, 0, 0, 0, 0, kyiv), 500000, 2550, 2},
+		{time.Date(2024, time.March, 1, 0, 0, 0, 0, kyiv), 0, 100, 1},
+		{time.Date(2024, time.April, 1, 0, 0, 0, 0, kyiv), 700, 0, 1},
+	}
+	if len(series.Points) != len(want) {
+		t.Fatalf("got %d points, want %d", len(series.Points), len(want))
+	}
+	for i, w := range want {
+		point := series.Points[i]
+		if !point.Start.Equal(w.start) || point.Income != w.income || point.Expense != w.expense || point.Count != w.count {
+			t.Errorf("point %d = %v income %v expense %v count %d, want %v income %v expense %v count %d",
+				i, point.Start, point.Income, point.Expense, point.Count, w.start, w.income, w.expense, w.count)
+		}
+		if point.Net != w.income-w.expense {
+			t.Errorf("point %d net = %v, want %v", i, point.Net, w.income-w.expense)
+		}
+	}
+}
+
+func TestTimeSeriesWeeksOnPostgres(t *testing.T) {
+	db := dbtest.Use(t)
+	kyiv := kyivLocation(t)
+	// 2024-05-05 is a Sunday. Shortly after midnight in Kyiv it is still the day before in UTC.
+	transactions := []testTransaction{
+		{time.Date(2024, time.May, 4, 20, 0, 0, 0, kyiv), models.CategoryTypeExpense, 100},
+		{time.Date(2024, time.May, 5, 0, 30, 0, 0, kyiv), models.CategoryTypeExpense, 1000},
+		{time.Date(2024, time.May, 5, 9, 0, 0, 0, kyiv), models.CategoryTypeExpense, 200},
+		{time.Date(2024, time.May, 6, 0, 30, 0, 0, kyiv), models.CategoryTypeExpense, 2000},
+		{time.Date(2024, time.May, 11, 23, 0, 0, 0, kyiv), models.CategoryTypeExpense, 400},
+	}
+	start, end := time.Date(2024, time.May, 1, 0, 0, 0, 0, kyiv), time.Date(2024, time.May, 12, 0, 0, 0, 0, kyiv)
+
+	tests := []struct {
+		weekStart string
+		weekday   time.Weekday
+		expenses  []models.Money
+	}{
+		{"sunday", time.Sunday, []models.Money{100, 3600, 0}},
+		{"monday", time.Monday, []models.Money{1300, 2400}},
+		{"saturday", time.Saturday, []models.Money{0, 3300, 400}},
+	}
+	for _, test := range tests {
+		user := saveStatisticsTransactions(t, db, test.weekStart, transactions)
+		series, err := GetStatisticsTimeSeries(user.ID, RangeWeek, start, end, repositories.CategoryTotalsFilter{})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(series.Points) != len(test.expenses) {
+			t.Fatalf("weeks from %s: got %d points, want %d", test.weekStart, len(series.Points), len(test.expenses))
+		}
+		for i, expense := range test.expenses {
+			if series.Points[i].Start.Weekday() != test.weekday {
+				t.Errorf("weeks from %s: point %d starts on %v", test.weekStart, i, series.Points[i].Start.Weekday())
+			}
+			if series.Points[i].Expense != expense {
+				t.Errorf("weeks from %s: point %d expense = %v, want %v", test.weekStart, i, series.Points[i].Expense, expense)
+			}
+		}
+	}
+}
+
+func TestPeriodWeekOffset(t *testing.T) {
+	tests := []struct {
+		bucket    StatisticsRange
+		weekStart time.Weekday
+		want      int
+	}{
+		{RangeWeek, time.Monday, 0},
+		{RangeWeek, time.Sunday, 1},
+		{RangeWeek, time.Saturday, 2},
+		{RangeDay, time.Sunday, 0},
+		{RangeMonth, time.Sunday, 0},
+		{RangeYear, time.Saturday, 0},
+	}
+	for _, test := range tests {
+		if got := periodWeekOffset(test.bucket, test.weekStart); got != test.want {
+			t.Errorf("periodWeekOffset(%s, %v) = %d, want %d", test.bucket, test.weekStart, got, test.want)
+		}
+	}
+}
+
+func TestRollupCategoryStatistics(t *testing.T) {
+	byID, food, groceries, fruit, _ := categoryTree()
+	transport := &models.Category{ID: uuid.New(), Name: "Транспорт", Type: models.CategoryTypeExpense}
+	byID[transport.ID] = transport
+
+	// Food has no transactions of its own, so it is missing from the flat statistics
+	const whole = models.Money(5500)
+	flat := []CategoryStatistics{
+		{CategoryID: transport.ID, Name: transport.Name, Total: 500, Count: 1, Average: 500, Share: sharePercent(500, whole)},
+		{CategoryID: groceries.ID, Name: groceries.Name, Total: 2000, Count: 2, Average: 1000, Share: sharePercent(2000, whole)},
+		{CategoryID: fruit.ID, Name: fruit.Name, Total: 3000, Count: 1, Average: 3000, Share: sharePercent(3000, whole)},
+	}
+
+	type node struct {
+		id      uuid.UUID
+		total   models.Money
+		count   int64
+		average models.Money
+		share   float64
+	}
+	check := func(name string, got CategoryStatistics, want node) {
+		t.Helper()
+		if got.CategoryID != want.id || got.Total != want.total || got.Count != want.count || got.Average != want.average || got.Share != want.share {
+			t.Errorf("%s: got %s total %v count %d average %v share %v, want %s total %v count %d average %v share %v",
+				name, got.Name, got.Total, got.Count, got.Average, got.Share, byID[want.id].Name, want.total, want.count, want.average, want.share)
+		}
+	}
+
+	roots := rollupCategoryStatistics(flat, byID, nil, whole)
+	if len(roots) != 2 {
+		t.Fatalf("got %d top categories, want food and transport", len(roots))
+	}
+	check("top 1", roots[0], node{food.ID, 5000, 3, 1667, 90.91})
+	check("top 2", roots[1], node{transport.ID, 500, 1, 500, 9.09})
+	if roots[0].Name != food.Name || roots[0].Type != food.Type {
+		t.Errorf("the added parent is %q of type %q, want %q of type %q", roots[0].Name, roots[0].Type, food.Name, food.Type)
+	}
+	if len(roots[0].Subcategories) != 1 {
+		t.Fatalf("food has %d subcategories, want groceries", len(roots[0].Subcategories))
+	}
+	check("level 2", roots[0].Subcategories[0], node{groceries.ID, 5000, 3, 1667, 90.91})
+	if subcategories := roots[0].Subcategories[0].Subcategories; len(subcategories) != 1 {
+		t.Fatalf("groceries has %d subcategories, want fruit", len(subcategories))
+	}
+	check("level 3", roots[0].Subcategories[0].Subcategories[0], node{fruit.ID, 3000, 1, 3000, 54.55})
+
+	// Statistics of one category stop at it
+	roots = rollupCategoryStatistics(flat[1:], byID, &groceries.ID, whole)
+	if len(roots) != 1 {
+		t.Fatalf("got %d top categories under groceries, want 1", len(roots))
+	}
+	check("top", roots[0], node{groceries.ID, 5000, 3, 1667, 90.91})
+}
+
+func TestCategoryStatisticsOnPostgres(t *testing.T) {
+	db := dbtest.Use(t)
+	user := saveTestUser(t, db)
+	groceries := saveTestCategory(t, db, user.ID, "Продукти", models.CategoryTypeExpense)
+	transport := saveTestCategory(t, db, user.ID, "Транспорт", models.CategoryTypeExpense)
+	cafes := saveTestCategory(t, db, user.ID, "Кафе", models.CategoryTypeExpense)
+	clothes := saveTestCategory(t, db, user.ID, "Одяг", models.CategoryTypeExpense)
+	salary := saveTestCategory(t, db, user.ID, "Зарплата", models.CategoryTypeIncome)
+	gifts := saveTestCategory(t, db, user.ID, "Подарунки", models.CategoryTypeIncome)
+
+	day := func(d int) time.Time { return time.Date(2024, time.May, d, 12, 0, 0, 0, time.UTC) }
+	deleted := day(20)
+	for _, transaction := range []*models.Transaction{
+		{CategoryID: groceries.ID, Amount: 1000, Date: day(1)},
+		{CategoryID: groceries.ID, Amount: 2000, Date: day(2)},
+		{CategoryID: groceries.ID, Amount: 3001, Date: day(3)},
+		{CategoryID: transport.ID, Amount: 999, Date: day(4)},
+		{CategoryID: cafes.ID, Amount: 500, Date: day(5)},
+		// Deleted transactions are left out, a category with nothing else is missing
+		{CategoryID: cafes.ID, Amount: 4000, Date: day(6), DeletedAt: &deleted},
+		{CategoryID: clothes.ID, Amount: 9000, Date: day(7), DeletedAt: &deleted},
+		{CategoryID: salary.ID, Amount: 20000, Date: day(10)},
+		{CategoryID: salary.ID, Amount: 30000, Date: day(25)},
+		{CategoryID: gifts.ID, Amount: 10000, Date: day(31)},
+		// Outside of the range
+		{CategoryID: groceries.ID, Amount: 7000, Date: time.Date(2024, time.June, 1, 12, 0, 0, 0, time.UTC)},
+		{CategoryID: salary.ID, Amount: 7000, Date: time.Date(2024, time.April, 30, 12, 0, 0, 0, time.UTC)},
+	} {
+		transaction.UserID = user.ID
+		saveTestTransaction(t, db, transaction)
+	}
+	// Transactions of other users are left out
+	other := saveTestUser(t, db)
+	othersGroceries := saveTestCategory(t, db, other.ID, "Продукти", models.CategoryTypeExpense)
+	saveTestTransaction(t, db, &models.Transaction{UserID: other.ID, CategoryID: othersGroceries.ID, Amount: 5000, Date: day(2)})
+
+	start := time.Date(2024, time.May, 1, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2024, time.May, 31, 23, 59, 59, 0, time.UTC)
+	report, err := GetCategoryStatistics(user.ID, start, end, repositories.CategoryTotalsFilter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Currency != models.DefaultCurrency || report.Income != 60000 || report.Expense != 7500 || report.Net != 52500 {
+		t.Errorf("income %v, expense %v, net %v in %s, want 60000, 7500 and 52500 in %s",
+			report.Income, report.Expense, report.Net, report.Currency, models.DefaultCurrency)
+	}
+
+	type want struct {
+		id      uuid.UUID
+		total   models.Money
+		count   int64
+		average models.Money
+		share   float64
+	}
+	check := func(name string, got []CategoryStatistics, wants []want) {
+		t.Helper()
+		if len(got) != len(wants) {
+			t.Fatalf("%s: %d categories %+v, want %d", name, len(got), got, len(wants))
+		}
+		shares := 0.0
+		for i, w := range wants {
+			g := got[i]
+			if g.CategoryID != w.id || g.Total != w.total || g.Count != w.count || g.Average != w.average || g.Share != w.share {
+				t.Errorf("%s %d: %s with total %v, count %d, average %v and share %v, want %s with %v, %d, %v and %v",
+					name, i, g.Name, g.Total, g.Count, g.Average, g.Share, w.id, w.total, w.count, w.average, w.share)
+			}
+			shares += g.Share
+		}
+		if math.Abs(shares-100) > 0.001 {
+			t.Errorf("%s: shares sum up to %v", name, shares)
+		}
+	}
+	// The largest totals come first, averages are rounded to minor units
+	check("expenses", report.Expenses, []want{
+		{groceries.ID, 6001, 3, 2000, 80.01},
+		{transport.ID, 999, 1, 999, 13.32},
+		{cafes.ID, 500, 1, 500, 6.67},
+	})
+	check("incomes", report.Incomes, []want{
+		{salary.ID, 50000, 2, 25000, 83.33},
+		{gifts.ID, 10000, 1, 10000, 16.67},
+	})
+
+	// Shares of a type filter are of that type only
+	report, err = GetCategoryStatistics(user.ID, start, end, repositories.CategoryTotalsFilter{CategoryType: models.CategoryTypeIncome})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Expense != 0 || len(report.Expenses) != 0 {
+		t.Errorf("expenses %v in %+v with an income filter", report.Expense, report.Expenses)
+	}
+	check("filtered incomes", report.Incomes, []want{
+		{salary.ID, 50000, 2, 25000, 83.33},
+		{gifts.ID, 10000, 1, 10000, 16.67},
+	})
+
+	// An empty range gives empty lists, not null ones
+	report, err = GetCategoryStatistics(user.ID, time.Date(2023, time.January, 1, 0, 0, 0, 0, time.UTC), time.Date(2023, time.December, 31, 0, 0, 0, 0, time.UTC), repositories.CategoryTotalsFilter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Income != 0 || report.Expense != 0 || report.Net != 0 || report.Incomes == nil || report.Expenses == nil || len(report.Incomes)+len(report.Expenses) != 0 {
+		t.Errorf("report of an empty range %+v", report)
+	}
+}
@@ -1,10 +1,257 @@
 package services
 
 import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/KashyretsIvanna/voice-balance/database"
 	models "github.com/KashyretsIvanna/voice-balance/internals/model"
 	repositories "github.com/KashyretsIvanna/voice-balance/internals/repositories"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
 )
 
-func CreateTransaction(transaction *models.Transaction) error {
-	return repositories.SaveTransaction(transaction)
+// ErrInvalidTransaction is returned when a transaction or a bulk operation does not pass validation
+var ErrInvalidTransaction = errors.New("invalid transaction")
+
+// maxBulkTransactions limits the transactions changed by one bulk operation
+const maxBulkTransactions = 500
+
+// CreatedTransaction is a saved transaction with the warnings of the budgets it pushed over their threshold
+type CreatedTransaction struct {
+	*models.Transaction
+	BudgetWarning  bool            `json:"budget_warning"`
+	BudgetWarnings []BudgetWarning `json:"budget_warnings,omitempty"`
+	// Set when the category was chosen by a category rule
+	CategoryRuleID *uuid.UUID `json:"category_rule_id,omitempty"`
+}
+
+// TransactionUpdate holds the transaction fields a user is allowed to change.
+// Nil fields are left untouched.
+type TransactionUpdate struct {
+	CategoryID  *uuid.UUID    `json:"category_id"`
+	Amount      *models.Money `json:"amount" swaggertype:"number" example:"250.5"`
+	Currency    *string       `json:"currency" example:"UAH"`
+	Description *string       `json:"description" example:"продукти"`
+	Date        *time.Time    `json:"date"`
+}
+
+// TransactionRestore moves a restored transaction into another category, it is required
+// when the category of the transaction was deleted
+type TransactionRestore struct {
+	CategoryID *uuid.UUID `json:"category_id"`
+}
+
+// TransactionBulkDelete lists the transactions to delete at once
+type TransactionBulkDelete struct {
+	IDs []uuid.UUID `json:"ids"`
+}
+
+// TransactionBulkRecategorize lists the transactions to move into a category at once
+type TransactionBulkRecategorize struct {
+	IDs        []uuid.UUID `json:"ids"`
+	CategoryID uuid.UUID   `json:"category_id"`
+}
+
+// validateTransaction normalizes the currency of a transaction and checks its fields.
+// Invalid currencies and amounts are reported with ErrInvalidCurrency and ErrInvalidAmount.
+func validateTransaction(transaction *models.Transaction) error {
+	currency, err := transactionCurrency(transaction.UserID, transaction.Currency)
+	if err != nil {
+		return err
+	}
+	transaction.Currency = currency
+	if err := checkAmount(transaction.Amount, currency); err != nil {
+		return err
+	}
+
+	transaction.Description = strings.TrimSpace(transaction.Description)
+	if len([]rune(transaction.Description)) > 255 {
+		return fmt.Errorf("%w: description must be at most 255 characters", ErrInvalidTransaction)
+	}
+	if transaction.Date.IsZero() {
+		return fmt.Errorf("%w: date is required", ErrInvalidTransaction)
+	}
+
+	_, err = repositories.FindCategoryByID(database.DB, transaction.UserID, transaction.CategoryID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return fmt.Errorf("%w: category %s not found", ErrInvalidTransaction, transaction.CategoryID)
+	}
+	return err
+}
+
+// CreateTransaction saves the transaction and checks the budgets of its category.
+// Transactions without a currency are in the currency of the user, amounts must be
+// greater than zero and not more precise than the currency. Transactions without a date are dated now.
+// Transactions without a category get the category of the first category rule of the user they match.
+func CreateTransaction(transaction *models.Transaction) (*CreatedTransaction, error) {
+	if transaction.Date.IsZero() {
+		transaction.Date = time.Now()
+	}
+
+	var rule *models.CategoryRule
+	if transaction.CategoryID == uuid.Nil {
+		var err error
+		rule, err = matchCategoryRule(transaction, "")
+		if err != nil {
+			return nil, err
+		}
+		if rule == nil {
+			return nil, fmt.Errorf("%w: category_id is required, no category rule matches the transaction", ErrInvalidTransaction)
+		}
+		transaction.CategoryID = rule.CategoryID
+	}
+
+	if err := validateTransaction(transaction); err != nil {
+		return nil, err
+	}
+
+	if err := repositories.SaveTransaction(transaction); err != nil {
+		return nil, err
+	}
+
+	created := &CreatedTransaction{Transaction: transaction}
+	if rule != nil {
+		created.CategoryRuleID = &rule.ID
+	}
+	warnings, err := checkBudgets(transaction)
+	if err != nil {
+		// The transaction is saved, a failed check must not report it as lost
+		log.Printf("budget check of transaction %s failed: %v", transaction.ID, err)
+		return created, nil
+	}
+	created.BudgetWarning = len(warnings) > 0
+	created.BudgetWarnings = warnings
+	return created, nil
+}
+
+func GetTransaction(userID, transactionID uuid.UUID) (*models.Transaction, error) {
+	return repositories.FindTransactionByID(userID, transactionID, false)
+}
+
+func UpdateTransaction(userID, transactionID uuid.UUID, update TransactionUpdate) (*models.Transaction, error) {
+	transaction, err := repositories.FindTransactionByID(userID, transactionID, false)
+	if err != nil {
+		return nil, err
+	}
+
+	if update.CategoryID != nil {
+		transaction.CategoryID = *update.CategoryID
+	}
+	if update.Amount != nil {
+		transaction.Amount = *update.Amount
+	}
+	if update.Currency != nil {
+		currency, err := NormalizeCurrency(*update.Currency)
+		if err != nil {
+			return nil, err
+		}
+		if currency == "" {
+			return nil, fmt.Errorf("%w: currency must not be empty", ErrInvalidCurrency)
+		}
+		transaction.Currency = currency
+	}
+	if update.Description != nil {
+		transaction.Description = *update.Description
+	}
+	if update.Date != nil {
+		transaction.Date = *update.Date
+	}
+
+	if err := validateTransaction(transaction); err != nil {
+		return nil, err
+	}
+	if err := repositories.UpdateTransaction(transaction); err != nil {
+		return nil, err
+	}
+	return repositories.FindTransactionByID(userID, transactionID, false)
+}
+
+func DeleteTransaction(userID, transactionID uuid.UUID) error {
+	transaction, err := repositories.FindTransactionByID(userID, transactionID, false)
+	if err != nil {
+		return err
+	}
+	return repositories.SoftDeleteTransaction(transaction)
+}
+
+// RestoreTransaction brings back a deleted transaction of the user. A transaction whose
+// category was deleted meanwhile is only restored into another category.
+func RestoreTransaction(userID, transactionID uuid.UUID, restore TransactionRestore) (*models.Transaction, error) {
+	transaction, err := repositories.FindTransactionByID(userID, transactionID, true)
+	if err != nil {
+		return nil, err
+	}
+
+	categoryID := transaction.CategoryID
+	if restore.CategoryID != nil {
+		categoryID = *restore.CategoryID
+	}
+	_, err = repositories.FindCategoryByID(database.DB, userID, categoryID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		if restore.CategoryID == nil {
+			return nil, fmt.Errorf("%w: category %s of the transaction was deleted, category_id is required", ErrInvalidTransaction, categoryID)
+		}
+		return nil, fmt.Errorf("%w: category %s not found", ErrInvalidTransaction, categoryID)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	transaction.CategoryID = categoryID
+	if err := repositories.RestoreTransaction(transaction); err != nil {
+		return nil, err
+	}
+	return repositories.FindTransactionByID(userID, transactionID, false)
+}
+
+// BulkDeleteTransactions deletes transactions of the user in one database transaction,
+// nothing is deleted when one of them is not found
+func BulkDeleteTransactions(userID uuid.UUID, bulk TransactionBulkDelete) (int64, error) {
+	ids, err := bulkTransactionIDs(bulk.IDs)
+	if err != nil {
+		return 0, err
+	}
+	return repositories.SoftDeleteTransactions(userID, ids)
+}
+
+// BulkRecategorizeTransactions moves transactions of the user into one of the user's categories
+// in one database transaction, nothing is moved when one of them is not found
+func BulkRecategorizeTransactions(userID uuid.UUID, bulk TransactionBulkRecategorize) (int64, error) {
+	ids, err := bulkTransactionIDs(bulk.IDs)
+	if err != nil {
+		return 0, err
+	}
+
+	_, err = repositories.FindCategoryByID(database.DB, userID, bulk.CategoryID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return 0, fmt.Errorf("%w: category %s not found", ErrInvalidTransaction, bulk.CategoryID)
+	}
+	if err != nil {
+		return 0, err
+	}
+	return repositories.RecategorizeTransactions(userID, ids, bulk.CategoryID)
+}
+
+// bulkTransactionIDs checks the IDs of a bulk operation and removes repeated ones
+func bulkTransactionIDs(ids []uuid.UUID) ([]uuid.UUID, error) {
+	if len(ids) == 0 {
+		return nil, fmt.Errorf("%w: ids are required", ErrInvalidTransaction)
+	}
+	if len(ids) > maxBulkTransactions {
+		return nil, fmt.Errorf("%w: at most %d transactions can be changed at once", ErrInvalidTransaction, maxBulkTransactions)
+	}
+
+	seen := make(map[uuid.UUID]bool, len(ids))
+	unique := make([]uuid.UUID, 0, len(ids))
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+	return unique, nil
 }
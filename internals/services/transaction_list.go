@@ -0,0 +1,265 @@
+package services
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	models "github.com/KashyretsIvanna/voice-balance/internals/model"
+	repositories "github.com/KashyretsIvanna/voice-balance/internals/repositories"
+	"github.com/google/uuid"
+)
+
+// Page sizes of ListTransactions
+const (
+	defaultTransactionPageSize = 50
+	MaxTransactionPageSize     = 200
+)
+
+// maxTransactionSearch limits the length of the text transactions are searched for
+const maxTransactionSearch = 100
+
+// Values of TransactionQuery.Sort
+const (
+	TransactionSortDate   = "date"
+	TransactionSortAmount = "amount"
+)
+
+// Values of TransactionQuery.GroupBy
+const (
+	TransactionGroupCategory = "category"
+	TransactionGroupDay      = "day"
+)
+
+// TransactionQuery selects a page of the transactions of a user
+type TransactionQuery struct {
+	CategoryIDs  []uuid.UUID
+	CategoryType string
+	// Dates are YYYY-MM-DD in the time zone of the user or RFC3339, both ends are included
+	StartDate, EndDate   string
+	MinAmount, MaxAmount *models.Money
+	Search               string
+	Deleted              bool
+	// Sort is "date", the default, or "amount"
+	Sort      string
+	Ascending bool
+	// Cursor is the NextCursor of the previous page, it must be used with the same sort order
+	Cursor string
+	Limit  int
+	// GroupBy is "", "category" or "day"
+	GroupBy string
+}
+
+// TransactionPage is one page of transactions in the order of the query
+type TransactionPage struct {
+	// Transactions of the page, empty when they are grouped
+	Transactions []models.Transaction `json:"transactions,omitempty"`
+	// Groups hold the transactions of the page in the order of their first transaction
+	Groups     []TransactionGroup `json:"groups,omitempty"`
+	NextCursor string             `json:"next_cursor,omitempty"`
+	HasMore    bool               `json:"has_more"`
+}
+
+// TransactionGroup holds the transactions of a page sharing a category or a day
+type TransactionGroup struct {
+	// Key is the category ID or the day as YYYY-MM-DD in the time zone of the user
+	Key          string               `json:"key"`
+	CategoryName string               `json:"category_name,omitempty"`
+	CategoryType string               `json:"category_type,omitempty"`
+	Transactions []models.Transaction `json:"transactions"`
+}
+
+// transactionCursor is the position after the last transaction of a page
+type transactionCursor struct {
+	Sort      string        `json:"s"`
+	Ascending bool          `json:"a,omitempty"`
+	Date      *time.Time    `json:"d,omitempty"`
+	Amount    *models.Money `json:"m,omitempty"`
+	ID        uuid.UUID     `json:"i"`
+}
+
+// ListTransactions returns a page of the transactions of a user matching the query.
+// Pages are taken with a keyset on the sort value and the ID, so transactions added
+// or deleted meanwhile do not shift the following pages.
+func ListTransactions(userID uuid.UUID, query TransactionQuery) (*TransactionPage, error) {
+	filter, err := transactionListFilter(userID, query)
+	if err != nil {
+		return nil, err
+	}
+
+	// One more transaction tells whether there is a next page
+	transactions, err := repositories.ListTransactions(userID, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	var location *time.Location
+	if query.GroupBy == TransactionGroupDay {
+		if location, err = UserLocation(userID); err != nil {
+			return nil, err
+		}
+	}
+	return newTransactionPage(transactions, filter, query.GroupBy, location), nil
+}
+
+// newTransactionPage makes a page of the transactions listed with the filter, the one past the
+// limit only tells there is a next page. Days are grouped by in the location.
+func newTransactionPage(transactions []models.Transaction, filter repositories.TransactionListFilter, groupBy string, location *time.Location) *TransactionPage {
+	page := &TransactionPage{}
+	if limit := filter.Limit - 1; len(transactions) > limit {
+		transactions = transactions[:limit]
+		page.HasMore = true
+		page.NextCursor = encodeTransactionCursor(filter.SortColumn, filter.Ascending, transactions[len(transactions)-1])
+	}
+
+	switch groupBy {
+	case TransactionGroupCategory:
+		page.Groups = groupTransactions(transactions, func(transaction models.Transaction) string {
+			return transaction.CategoryID.String()
+		})
+		for i := range page.Groups {
+			category := page.Groups[i].Transactions[0].Category
+			page.Groups[i].CategoryName = category.Name
+			page.Groups[i].CategoryType = category.Type
+		}
+	case TransactionGroupDay:
+		page.Groups = groupTransactions(transactions, func(transaction models.Transaction) string {
+			return transaction.Date.In(location).Format("2006-01-02")
+		})
+	default:
+		page.Transactions = transactions
+		if page.Transactions == nil {
+			page.Transactions = []models.Transaction{}
+		}
+	}
+	return page
+}
+
+// transactionListFilter checks the query, fills in its defaults and turns it into a repository filter
+func transactionListFilter(userID uuid.UUID, query TransactionQuery) (repositories.TransactionListFilter, error) {
+	filter := repositories.TransactionListFilter{
+		Deleted:      query.Deleted,
+		CategoryIDs:  query.CategoryIDs,
+		CategoryType: query.CategoryType,
+		MinAmount:    query.MinAmount,
+		MaxAmount:    query.MaxAmount,
+		Search:       strings.TrimSpace(query.Search),
+		Ascending:    query.Ascending,
+	}
+
+	if query.Sort == "" {
+		query.Sort = TransactionSortDate
+	}
+	if query.Sort != TransactionSortDate && query.Sort != TransactionSortAmount {
+		return filter, fmt.Errorf("%w: sort must be date or amount", ErrInvalidTransaction)
+	}
+	filter.SortColumn = query.Sort
+
+	if query.Limit == 0 {
+		query.Limit = defaultTransactionPageSize
+	}
+	if query.Limit < 1 || query.Limit > MaxTransactionPageSize {
+		return filter, fmt.Errorf("%w: limit must be between 1 and %d", ErrInvalidTransaction, MaxTransactionPageSize)
+	}
+	filter.Limit = query.Limit + 1
+
+	if query.GroupBy != "" && query.GroupBy != TransactionGroupCategory && query.GroupBy != TransactionGroupDay {
+		return filter, fmt.Errorf("%w: group_by must be category or day", ErrInvalidTransaction)
+	}
+	if filter.CategoryType != "" && filter.CategoryType != models.CategoryTypeIncome && filter.CategoryType != models.CategoryTypeExpense {
+		return filter, fmt.Errorf("%w: type must be income or expense", ErrInvalidTransaction)
+	}
+	if filter.MinAmount != nil && filter.MaxAmount != nil && *filter.MinAmount > *filter.MaxAmount {
+		return filter, fmt.Errorf("%w: min_amount is greater than max_amount", ErrInvalidTransaction)
+	}
+	if len([]rune(filter.Search)) > maxTransactionSearch {
+		return filter, fmt.Errorf("%w: search must be at most %d characters", ErrInvalidTransaction, maxTransactionSearch)
+	}
+
+	if query.StartDate != "" || query.EndDate != "" {
+		location, err := UserLocation(userID)
+		if err != nil {
+			return filter, err
+		}
+		if query.StartDate != "" {
+			start, err := parseRangeDate(query.StartDate, location, false)
+			if err != nil {
+				return filter, fmt.Errorf("%w: start_date %q must be YYYY-MM-DD or RFC3339", ErrInvalidDateRange, query.StartDate)
+			}
+			filter.Start = &start
+		}
+		if query.EndDate != "" {
+			end, err := parseRangeDate(query.EndDate, location, true)
+			if err != nil {
+				return filter, fmt.Errorf("%w: end_date %q must be YYYY-MM-DD or RFC3339", ErrInvalidDateRange, query.EndDate)
+			}
+			filter.End = &end
+		}
+		if filter.Start != nil && filter.End != nil && filter.End.Before(*filter.Start) {
+			return filter, fmt.Errorf("%w: end_date is before start_date", ErrInvalidDateRange)
+		}
+	}
+
+	if query.Cursor != "" {
+		cursor, err := decodeTransactionCursor(query.Cursor)
+		if err != nil {
+			return filter, err
+		}
+		if cursor.Sort != query.Sort || cursor.Ascending != query.Ascending {
+			return filter, fmt.Errorf("%w: cursor belongs to another sort order", ErrInvalidTransaction)
+		}
+		filter.AfterID = &cursor.ID
+		if cursor.Sort == TransactionSortAmount {
+			filter.AfterValue = *cursor.Amount
+		} else {
+			filter.AfterValue = *cursor.Date
+		}
+	}
+	return filter, nil
+}
+
+// encodeTransactionCursor returns the opaque cursor of the page following the transaction
+func encodeTransactionCursor(sort string, ascending bool, last models.Transaction) string {
+	cursor := transactionCursor{Sort: sort, Ascending: ascending, ID: last.ID}
+	if sort == TransactionSortAmount {
+		cursor.Amount = &last.Amount
+	} else {
+		cursor.Date = &last.Date
+	}
+
+	data, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeTransactionCursor(value string) (transactionCursor, error) {
+	var cursor transactionCursor
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err == nil {
+		err = json.Unmarshal(data, &cursor)
+	}
+	valid := err == nil && cursor.ID != uuid.Nil &&
+		(cursor.Sort == TransactionSortDate && cursor.Date != nil || cursor.Sort == TransactionSortAmount && cursor.Amount != nil)
+	if !valid {
+		return cursor, fmt.Errorf("%w: invalid cursor", ErrInvalidTransaction)
+	}
+	return cursor, nil
+}
+
+// groupTransactions splits transactions by key keeping their order within and between the groups
+func groupTransactions(transactions []models.Transaction, key func(models.Transaction) string) []TransactionGroup {
+	groups := []TransactionGroup{}
+	index := make(map[string]int)
+	for _, transaction := range transactions {
+		k := key(transaction)
+		i, ok := index[k]
+		if !ok {
+			i = len(groups)
+			index[k] = i
+			groups = append(groups, TransactionGroup{Key: k})
+		}
+		groups[i].Transactions = append(groups[i].Transactions, transaction)
+	}
+	return groups
+}
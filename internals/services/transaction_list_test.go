@@ -0,0 +1,257 @@
+package services
+
+import (
+	"encoding/base64"
+	"errors"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/KashyretsIvanna/voice-balance/database/dbtest"
+	models "github.com/KashyretsIvanna/voice-balance/internals/model"
+	repositories "github.com/KashyretsIvanna/voice-balance/internals/repositories"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+func TestTransactionCursorRoundTrip(t *testing.T) {
+	last := models.Transaction{ID: uuid.New(), Amount: 12550, Date: time.Date(2024, time.May, 1, 9, 30, 0, 0, time.UTC)}
+
+	for _, sort := range []string{TransactionSortDate, TransactionSortAmount} {
+		for _, ascending := range []bool{false, true} {
+			cursor, err := decodeTransactionCursor(encodeTransactionCursor(sort, ascending, last))
+			if err != nil {
+				t.Fatalf("%s ascending %v: %v", sort, ascending, err)
+			}
+			if cursor.Sort != sort || cursor.Ascending != ascending || cursor.ID != last.ID {
+				t.Errorf("%s ascending %v: decoded %+v", sort, ascending, cursor)
+			}
+			if sort == TransactionSortDate && (cursor.Date == nil || !cursor.Date.Equal(last.Date) || cursor.Amount != nil) {
+				t.Errorf("date cursor holds date %v and amount %v, want %v only", cursor.Date, cursor.Amount, last.Date)
+			}
+			if sort == TransactionSortAmount && (cursor.Amount == nil || *cursor.Amount != last.Amount || cursor.Date != nil) {
+				t.Errorf("amount cursor holds amount %v and date %v, want %v only", cursor.Amount, cursor.Date, last.Amount)
+			}
+		}
+	}
+}
+
+func TestDecodeTransactionCursorRejectsInvalid(t *testing.T) {
+	encode := func(data string) string { return base64.RawURLEncoding.EncodeToString([]byte(data)) }
+	for _, value := range []string{
+		"not base64!",
+		encode("not json"),
+		encode(`{"s":"date","i":"` + uuid.NewString() + `"}`),
+		encode(`{"s":"amount","d":"2024-05-01T09:30:00Z","i":"` + uuid.NewString() + `"}`),
+		encode(`{"s":"date","d":"2024-05-01T09:30:00Z"}`),
+		encode(`{"s":"description","d":"2024-05-01T09:30:00Z","i":"` + uuid.NewString() + `"}`),
+	} {
+		if _, err := decodeTransactionCursor(value); !errors.Is(err, ErrInvalidTransaction) {
+			t.Errorf("decodeTransactionCursor(%q) = %v, want ErrInvalidTransaction", value, err)
+		}
+	}
+}
+
+func TestTransactionListFilterRejectsCursorOfAnotherSort(t *testing.T) {
+	last := models.Transaction{ID: uuid.New(), Amount: 12550, Date: time.Date(2024, time.May, 1, 9, 30, 0, 0, time.UTC)}
+	cursor := encodeTransactionCursor(TransactionSortDate, false, last)
+
+	for _, query := range []TransactionQuery{
+		{Sort: TransactionSortAmount, Cursor: cursor},
+		{Sort: TransactionSortDate, Ascending: true, Cursor: cursor},
+	} {
+		if _, err := transactionListFilter(uuid.New(), query); !errors.Is(err, ErrInvalidTransaction) {
+			t.Errorf("sort %q ascending %v with a date descending cursor: %v, want ErrInvalidTransaction", query.Sort, query.Ascending, err)
+		}
+	}
+
+	// The default sort is the one of the cursor
+	filter, err := transactionListFilter(uuid.New(), TransactionQuery{Cursor: cursor})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if after, ok := filter.AfterValue.(time.Time); !ok || !after.Equal(last.Date) || filter.AfterID == nil || *filter.AfterID != last.ID {
+		t.Errorf("filter continues after %v %v, want %v %v", filter.AfterValue, filter.AfterID, last.Date, last.ID)
+	}
+}
+
+// listedTransactions are transactions as the repository returns them, newest first
+func listedTransactions(count int) []models.Transaction {
+	transactions := make([]models.Transaction, count)
+	for i := range transactions {
+		transactions[i] = models.Transaction{ID: uuid.New(), Amount: models.Money(1000 * (i + 1)), Date: time.Date(2024, time.May, 10-i, 12, 0, 0, 0, time.UTC)}
+	}
+	return transactions
+}
+
+func TestNewTransactionPageHasMoreOnlyPastLimit(t *testing.T) {
+	// A limit of 3 lists up to 4 transactions
+	filter := repositories.TransactionListFilter{SortColumn: TransactionSortDate, Limit: 4}
+
+	for _, count := range []int{0, 2, 3} {
+		page := newTransactionPage(listedTransactions(count), filter, "", nil)
+		if page.HasMore || page.NextCursor != "" || len(page.Transactions) != count {
+			t.Errorf("%d transactions: %d listed, has_more %v, next_cursor %q, want all and no next page",
+				count, len(page.Transactions), page.HasMore, page.NextCursor)
+		}
+	}
+
+	transactions := listedTransactions(4)
+	page := newTransactionPage(transactions, filter, "", nil)
+	if !page.HasMore || len(page.Transactions) != 3 {
+		t.Fatalf("4 transactions: %d listed, has_more %v, want 3 and a next page", len(page.Transactions), page.HasMore)
+	}
+	cursor, err := decodeTransactionCursor(page.NextCursor)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cursor.ID != transactions[2].ID || !cursor.Date.Equal(transactions[2].Date) {
+		t.Errorf("next_cursor continues after %v %v, want the last listed transaction %v", cursor.Date, cursor.ID, transactions[2].ID)
+	}
+}
+
+func TestNewTransactionPageGroups(t *testing.T) {
+	food := models.Category{ID: uuid.New(), Name: "Продукти", Type: models.CategoryTypeExpense}
+	salary := models.Category{ID: uuid.New(), Name: "Зарплата", Type: models.CategoryTypeIncome}
+	kyiv, err := time.LoadLocation("Europe/Kyiv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	transactions := []models.Transaction{
+		// 01:30 on May 2 in Kyiv
+		{ID: uuid.New(), Amount: 1000, Date: time.Date(2024, time.May, 1, 22, 30, 0, 0, time.UTC), CategoryID: food.ID, Category: food},
+		{ID: uuid.New(), Amount: 2000, Date: time.Date(2024, time.May, 1, 20, 0, 0, 0, time.UTC), CategoryID: salary.ID, Category: salary},
+		{ID: uuid.New(), Amount: 3000, Date: time.Date(2024, time.May, 1, 8, 0, 0, 0, time.UTC), CategoryID: food.ID, Category: food},
+	}
+	filter := repositories.TransactionListFilter{SortColumn: TransactionSortDate, Limit: 51}
+
+	amounts := func(group TransactionGroup) []models.Money {
+		var amounts []models.Money
+		for _, transaction := range group.Transactions {
+			amounts = append(amounts, transaction.Amount)
+		}
+		return amounts
+	}
+
+	page := newTransactionPage(transactions, filter, TransactionGroupCategory, nil)
+	if page.Transactions != nil || len(page.Groups) != 2 {
+		t.Fatalf("groups %+v, want one per category and no ungrouped transactions", page.Groups)
+	}
+	if group := page.Groups[0]; group.Key != food.ID.String() || group.CategoryName != food.Name ||
+		group.CategoryType != food.Type || !reflect.DeepEqual(amounts(group), []models.Money{1000, 3000}) {
+		t.Errorf("first group %q %q %q with %v, want the food transactions 1000 and 3000", group.Key, group.CategoryName, group.CategoryType, amounts(group))
+	}
+	if group := page.Groups[1]; group.Key != salary.ID.String() || !reflect.DeepEqual(amounts(group), []models.Money{2000}) {
+		t.Errorf("second group %q with %v, want the salary transaction 2000", group.Key, amounts(group))
+	}
+
+	page = newTransactionPage(transactions, filter, TransactionGroupDay, kyiv)
+	if len(page.Groups) != 2 {
+		t.Fatalf("groups %+v, want one per day in Kyiv", page.Groups)
+	}
+	if group := page.Groups[0]; group.Key != "2024-05-02" || !reflect.DeepEqual(amounts(group), []models.Money{1000}) {
+		t.Errorf("first day %q with %v, want 2024-05-02 with 1000", group.Key, amounts(group))
+	}
+	if group := page.Groups[1]; group.Key != "2024-05-01" || group.CategoryName != "" || !reflect.DeepEqual(amounts(group), []models.Money{2000, 3000}) {
+		t.Errorf("second day %q with %v, want 2024-05-01 with 2000 and 3000", group.Key, amounts(group))
+	}
+}
+
+// saveTestUser saves a user with the default settings
+func saveTestUser(t *testing.T, db *gorm.DB) *models.User {
+	t.Helper()
+	user := &models.User{Email: uuid.NewString() + "@example.com", Password: "-"}
+	if err := db.Create(user).Error; err != nil {
+		t.Fatal(err)
+	}
+	return user
+}
+
+func saveTestCategory(t *testing.T, db *gorm.DB, userID uuid.UUID, name, categoryType string) *models.Category {
+	t.Helper()
+	category := &models.Category{Name: name, Type: categoryType, UserID: userID}
+	if err := db.Create(category).Error; err != nil {
+		t.Fatal(err)
+	}
+	return category
+}
+
+func saveTestTransaction(t *testing.T, db *gorm.DB, transaction *models.Transaction) *models.Transaction {
+	t.Helper()
+	if transaction.Currency == "" {
+		transaction.Currency = models.DefaultCurrency
+	}
+	if err := db.Create(transaction).Error; err != nil {
+		t.Fatal(err)
+	}
+	return transaction
+}
+
+func TestListTransactionsPagesThroughAllOnPostgres(t *testing.T) {
+	db := dbtest.Use(t)
+	user := saveTestUser(t, db)
+	food := saveTestCategory(t, db, user.ID, "Продукти", models.CategoryTypeExpense)
+	salary := saveTestCategory(t, db, user.ID, "Зарплата", models.CategoryTypeIncome)
+
+	// Repeated amounts and dates are ordered by ID across pages
+	day := time.Date(2024, time.May, 1, 12, 0, 0, 0, time.UTC)
+	var expenses []models.Transaction
+	for i, amount := range []models.Money{500, 1000, 1000, 1000, 2500, 500, 7000} {
+		transaction := saveTestTransaction(t, db, &models.Transaction{
+			UserID: user.ID, CategoryID: food.ID, Amount: amount, Date: day.AddDate(0, 0, i%3),
+		})
+		expenses = append(expenses, *transaction)
+	}
+	saveTestTransaction(t, db, &models.Transaction{UserID: user.ID, CategoryID: salary.ID, Amount: 1000, Date: day})
+
+	tests := []struct {
+		sort      string
+		ascending bool
+		less      func(a, b models.Transaction) bool
+	}{
+		{TransactionSortAmount, true, func(a, b models.Transaction) bool {
+			return a.Amount < b.Amount || a.Amount == b.Amount && a.ID.String() < b.ID.String()
+		}},
+		{TransactionSortDate, false, func(a, b models.Transaction) bool {
+			return a.Date.After(b.Date) || a.Date.Equal(b.Date) && a.ID.String() > b.ID.String()
+		}},
+	}
+	for _, test := range tests {
+		want := append([]models.Transaction(nil), expenses...)
+		sort.Slice(want, func(i, j int) bool { return test.less(want[i], want[j]) })
+
+		var listed []uuid.UUID
+		query := TransactionQuery{CategoryType: models.CategoryTypeExpense, Sort: test.sort, Ascending: test.ascending, Limit: 3}
+		for pages := 0; ; pages++ {
+			if pages > len(want) {
+				t.Fatalf("%s: the pages do not end", test.sort)
+			}
+			page, err := ListTransactions(user.ID, query)
+			if err != nil {
+				t.Fatalf("%s: %v", test.sort, err)
+			}
+			for _, transaction := range page.Transactions {
+				listed = append(listed, transaction.ID)
+				if transaction.Category.ID != food.ID {
+					t.Errorf("%s: transaction %s listed without its category", test.sort, transaction.ID)
+				}
+			}
+			if page.HasMore != (page.NextCursor != "") {
+				t.Errorf("%s: has_more %v with next_cursor %q", test.sort, page.HasMore, page.NextCursor)
+			}
+			if !page.HasMore {
+				break
+			}
+			query.Cursor = page.NextCursor
+		}
+
+		var wantIDs []uuid.UUID
+		for _, transaction := range want {
+			wantIDs = append(wantIDs, transaction.ID)
+		}
+		if !reflect.DeepEqual(listed, wantIDs) {
+			t.Errorf("%s ascending %v: listed %v, want %v", test.sort, test.ascending, listed, wantIDs)
+		}
+	}
+}
@@ -0,0 +1,148 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/KashyretsIvanna/voice-balance/database/dbtest"
+	models "github.com/KashyretsIvanna/voice-balance/internals/model"
+	repositories "github.com/KashyretsIvanna/voice-balance/internals/repositories"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+func storedTransaction(t *testing.T, db *gorm.DB, transactionID uuid.UUID) *models.Transaction {
+	t.Helper()
+	stored := &models.Transaction{}
+	if err := db.First(stored, "id = ?", transactionID).Error; err != nil {
+		t.Fatal(err)
+	}
+	return stored
+}
+
+func TestRestoreTransactionOfDeletedCategoryOnPostgres(t *testing.T) {
+	db := dbtest.Use(t)
+	user := saveTestUser(t, db)
+	food := saveTestCategory(t, db, user.ID, "Їжа", models.CategoryTypeExpense)
+	groceries := saveTestCategory(t, db, user.ID, "Продукти", models.CategoryTypeExpense)
+	stranger := saveTestCategory(t, db, saveTestUser(t, db).ID, "Продукти", models.CategoryTypeExpense)
+	transaction := saveTestTransaction(t, db, &models.Transaction{
+		UserID: user.ID, CategoryID: food.ID, Amount: 12000, Date: time.Date(2024, time.May, 1, 12, 0, 0, 0, time.UTC),
+	})
+
+	if err := DeleteTransaction(user.ID, transaction.ID); err != nil {
+		t.Fatal(err)
+	}
+	// A deleted transaction may be restored, it still uses its category
+	if err := DeleteCategory(user.ID, food.ID); !errors.Is(err, ErrCategoryInUse) {
+		t.Fatalf("deleting the category of a deleted transaction: %v, want ErrCategoryInUse", err)
+	}
+
+	// Categories deleted before deleted transactions counted as uses
+	if err := repositories.SoftDeleteCategory(db, food); err != nil {
+		t.Fatal(err)
+	}
+	for _, restore := range []TransactionRestore{{}, {CategoryID: &food.ID}, {CategoryID: &stranger.ID}} {
+		if _, err := RestoreTransaction(user.ID, transaction.ID, restore); !errors.Is(err, ErrInvalidTransaction) {
+			t.Errorf("restoring into %v: %v, want ErrInvalidTransaction", restore.CategoryID, err)
+		}
+	}
+	if stored := storedTransaction(t, db, transaction.ID); stored.DeletedAt == nil || stored.CategoryID != food.ID {
+		t.Fatalf("a rejected restore changed the transaction: deleted at %v, category %s", stored.DeletedAt, stored.CategoryID)
+	}
+
+	restored, err := RestoreTransaction(user.ID, transaction.ID, TransactionRestore{CategoryID: &groceries.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if restored.DeletedAt != nil || restored.CategoryID != groceries.ID || restored.Category.Name != groceries.Name {
+		t.Errorf("restored with deleted at %v into %s %q, want it in %q", restored.DeletedAt, restored.CategoryID, restored.Category.Name, groceries.Name)
+	}
+	if stored := storedTransaction(t, db, transaction.ID); stored.DeletedAt != nil || stored.CategoryID != groceries.ID {
+		t.Errorf("stored deleted at %v in category %s, want it restored into %s", stored.DeletedAt, stored.CategoryID, groceries.ID)
+	}
+
+	// A transaction whose category is kept is restored into it
+	if err := DeleteTransaction(user.ID, transaction.ID); err != nil {
+		t.Fatal(err)
+	}
+	restored, err = RestoreTransaction(user.ID, transaction.ID, TransactionRestore{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if restored.CategoryID != groceries.ID {
+		t.Errorf("restored into %s, want %s", restored.CategoryID, groceries.ID)
+	}
+}
+
+func TestBulkTransactionChangesAreAllOrNothingOnPostgres(t *testing.T) {
+	db := dbtest.Use(t)
+	user := saveTestUser(t, db)
+	food := saveTestCategory(t, db, user.ID, "Продукти", models.CategoryTypeExpense)
+	cafe := saveTestCategory(t, db, user.ID, "Кафе", models.CategoryTypeExpense)
+	other := saveTestUser(t, db)
+	othersCategory := saveTestCategory(t, db, other.ID, "Продукти", models.CategoryTypeExpense)
+
+	date := time.Date(2024, time.May, 1, 12, 0, 0, 0, time.UTC)
+	var ids []uuid.UUID
+	for _, amount := range []models.Money{1000, 2000, 3000} {
+		transaction := saveTestTransaction(t, db, &models.Transaction{UserID: user.ID, CategoryID: food.ID, Amount: amount, Date: date})
+		ids = append(ids, transaction.ID)
+	}
+	othersTransaction := saveTestTransaction(t, db, &models.Transaction{UserID: other.ID, CategoryID: othersCategory.ID, Amount: 500, Date: date})
+	deleted := saveTestTransaction(t, db, &models.Transaction{UserID: user.ID, CategoryID: food.ID, Amount: 700, Date: date, DeletedAt: &date})
+
+	unchanged := func(name string) {
+		t.Helper()
+		for _, id := range ids {
+			if stored := storedTransaction(t, db, id); stored.DeletedAt != nil || stored.CategoryID != food.ID {
+				t.Errorf("%s: transaction %s deleted at %v in category %s, want it untouched", name, id, stored.DeletedAt, stored.CategoryID)
+			}
+		}
+	}
+
+	for name, batch := range map[string][]uuid.UUID{
+		"a missing transaction":         append(ids[:2:2], uuid.New()),
+		"a transaction of another user": append(ids[:2:2], othersTransaction.ID),
+		"a deleted transaction":         append(ids[:2:2], deleted.ID),
+	} {
+		if _, err := BulkRecategorizeTransactions(user.ID, TransactionBulkRecategorize{IDs: batch, CategoryID: cafe.ID}); !errors.Is(err, gorm.ErrRecordNotFound) {
+			t.Errorf("recategorizing with %s: %v, want gorm.ErrRecordNotFound", name, err)
+		}
+		unchanged("recategorizing with " + name)
+
+		if _, err := BulkDeleteTransactions(user.ID, TransactionBulkDelete{IDs: batch}); !errors.Is(err, gorm.ErrRecordNotFound) {
+			t.Errorf("deleting with %s: %v, want gorm.ErrRecordNotFound", name, err)
+		}
+		unchanged("deleting with " + name)
+	}
+	if _, err := BulkRecategorizeTransactions(user.ID, TransactionBulkRecategorize{IDs: ids, CategoryID: othersCategory.ID}); !errors.Is(err, ErrInvalidTransaction) {
+		t.Errorf("recategorizing into a category of another user: %v, want ErrInvalidTransaction", err)
+	}
+	unchanged("recategorizing into a category of another user")
+	if stored := storedTransaction(t, db, othersTransaction.ID); stored.DeletedAt != nil {
+		t.Errorf("the transaction of another user was deleted")
+	}
+
+	// Repeated IDs are changed once
+	moved, err := BulkRecategorizeTransactions(user.ID, TransactionBulkRecategorize{IDs: append(ids, ids[0]), CategoryID: cafe.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if moved != int64(len(ids)) {
+		t.Errorf("%d transactions moved, want %d", moved, len(ids))
+	}
+	removed, err := BulkDeleteTransactions(user.ID, TransactionBulkDelete{IDs: ids})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if removed != int64(len(ids)) {
+		t.Errorf("%d transactions deleted, want %d", removed, len(ids))
+	}
+	for _, id := range ids {
+		if stored := storedTransaction(t, db, id); stored.DeletedAt == nil || stored.CategoryID != cafe.ID {
+			t.Errorf("transaction %s deleted at %v in category %s, want it moved into %s and deleted", id, stored.DeletedAt, stored.CategoryID, cafe.ID)
+		}
+	}
+}
@@ -0,0 +1,268 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	speech "cloud.google.com/go/speech/apiv1"
+	"cloud.google.com/go/speech/apiv1/speechpb"
+	"github.com/KashyretsIvanna/voice-balance/config"
+	"google.golang.org/api/option"
+)
+
+// defaultSampleRateHertz is used when a request does not specify the sample rate
+const defaultSampleRateHertz = 48000
+
+// TranscriptionRequest is the audio passed to a Transcriber
+type TranscriptionRequest struct {
+	Audio        []byte
+	Format       AudioFormat
+	LanguageCode string
+}
+
+// Transcriber turns recorded speech into text
+type Transcriber interface {
+	Transcribe(ctx context.Context, req TranscriptionRequest) (string, error)
+}
+
+// NewTranscriber returns the speech-to-text backend selected by STT_PROVIDER:
+// "google" (default), "local" or "fake"
+func NewTranscriber() (Transcriber, error) {
+	switch provider := strings.ToLower(config.Config("STT_PROVIDER")); provider {
+	case "", "google":
+		return &GoogleTranscriber{CredentialsFile: config.Config("CLOUD_JSON_PATH")}, nil
+	case "local":
+		return &LocalTranscriber{
+			URL:     config.Config("STT_LOCAL_URL"),
+			Command: config.Config("STT_LOCAL_COMMAND"),
+		}, nil
+	case "fake":
+		return &FakeTranscriber{Text: config.Config("STT_FAKE_TEXT")}, nil
+	default:
+		return nil, fmt.Errorf("unknown speech-to-text provider %q", provider)
+	}
+}
+
+func withDefaults(req TranscriptionRequest) TranscriptionRequest {
+	if req.Format.Codec == "" {
+		req.Format.Codec = CodecPCM16
+	}
+	if req.Format.SampleRateHertz == 0 {
+		req.Format.SampleRateHertz = defaultSampleRateHertz
+	}
+	if req.LanguageCode == "" {
+		req.LanguageCode = DefaultLanguage().RecognizerCode
+	}
+	return req
+}
+
+// GoogleTranscriber recognizes speech with Google Cloud Speech-to-Text
+type GoogleTranscriber struct {
+	CredentialsFile string
+}
+
+// googleEncodings maps the codecs Google decodes natively to their encodings
+var googleEncodings = map[string]speechpb.RecognitionConfig_AudioEncoding{
+	CodecPCM16: speechpb.RecognitionConfig_LINEAR16,
+	CodecMulaw: speechpb.RecognitionConfig_MULAW,
+	CodecFLAC:  speechpb.RecognitionConfig_FLAC,
+	CodecAMR:   speechpb.RecognitionConfig_AMR,
+	CodecAMRWB: speechpb.RecognitionConfig_AMR_WB,
+}
+
+// googleRecognitionConfig builds the recognition settings matching the audio format
+func googleRecognitionConfig(req TranscriptionRequest) (*speechpb.RecognitionConfig, error) {
+	recognitionConfig := &speechpb.RecognitionConfig{
+		SampleRateHertz: req.Format.SampleRateHertz,
+		LanguageCode:    req.LanguageCode,
+	}
+
+	if req.Format.Codec == CodecOpus {
+		recognitionConfig.Encoding = speechpb.RecognitionConfig_OGG_OPUS
+		if req.Format.Container == "webm" {
+			recognitionConfig.Encoding = speechpb.RecognitionConfig_WEBM_OPUS
+		}
+	} else {
+		encoding, ok := googleEncodings[req.Format.Codec]
+		if !ok {
+			return nil, fmt.Errorf("%w: Google Speech-to-Text cannot decode %s audio", ErrUnsupportedAudioFormat, req.Format.Codec)
+		}
+		recognitionConfig.Encoding = encoding
+	}
+
+	if req.Format.Channels > 1 {
+		recognitionConfig.AudioChannelCount = req.Format.Channels
+	}
+	return recognitionConfig, nil
+}
+
+func (t *GoogleTranscriber) Transcribe(ctx context.Context, req TranscriptionRequest) (string, error) {
+	req = withDefaults(req)
+
+	recognitionConfig, err := googleRecognitionConfig(req)
+	if err != nil {
+		return "", err
+	}
+
+	// Initialize Google Cloud Speech client with credentials
+	client, err := speech.NewClient(ctx, option.WithCredentialsFile(t.CredentialsFile))
+	if err != nil {
+		return "", err
+	}
+	defer client.Close()
+
+	// Configure the recognition request
+	resp, err := client.Recognize(ctx, &speechpb.RecognizeRequest{
+		Config: recognitionConfig,
+		Audio: &speechpb.RecognitionAudio{
+			AudioSource: &speechpb.RecognitionAudio_Content{
+				Content: req.Audio,
+			},
+		},
+	})
+	if err != nil {
+		return "", err
+	}
+
+	// Collect the transcription result
+	transcription := ""
+	for _, result := range resp.Results {
+		for _, alt := range result.Alternatives {
+			transcription += alt.Transcript + " "
+		}
+	}
+
+	return transcription, nil
+}
+
+// LocalTranscriber recognizes speech without cloud services. It either posts the
+// audio to an HTTP server (URL) or runs a program such as whisper.cpp or Vosk (Command).
+//
+// The HTTP server receives the raw audio with "language", "sample_rate" and "codec" query
+// parameters and answers with plain text or a JSON object with a "text" field.
+// In Command the placeholders {file}, {lang} and {rate} are replaced with the path to
+// the recorded audio, the language code and the sample rate; the transcript is read
+// from the standard output.
+type LocalTranscriber struct {
+	URL     string
+	Command string
+}
+
+func (t *LocalTranscriber) Transcribe(ctx context.Context, req TranscriptionRequest) (string, error) {
+	req = withDefaults(req)
+
+	switch {
+	case t.URL != "":
+		return t.transcribeHTTP(ctx, req)
+	case t.Command != "":
+		return t.transcribeCommand(ctx, req)
+	default:
+		return "", fmt.Errorf("local speech-to-text needs STT_LOCAL_URL or STT_LOCAL_COMMAND")
+	}
+}
+
+func (t *LocalTranscriber) transcribeHTTP(ctx context.Context, req TranscriptionRequest) (string, error) {
+	endpoint, err := url.Parse(t.URL)
+	if err != nil {
+		return "", err
+	}
+	query := endpoint.Query()
+	query.Set("language", req.LanguageCode)
+	query.Set("sample_rate", strconv.Itoa(int(req.Format.SampleRateHertz)))
+	query.Set("codec", req.Format.Codec)
+	endpoint.RawQuery = query.Encode()
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint.String(), bytes.NewReader(req.Audio))
+	if err != nil {
+		return "", err
+	}
+	httpReq.Header.Set("Content-Type", "application/octet-stream")
+
+	resp, err := http.DefaultClient.Do(httpReq)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("local speech-to-text server returned %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+
+	var parsed struct {
+		Text string `json:"text"`
+	}
+	if err := json.Unmarshal(body, &parsed); err == nil {
+		return parsed.Text, nil
+	}
+	return strings.TrimSpace(string(body)), nil
+}
+
+func (t *LocalTranscriber) transcribeCommand(ctx context.Context, req TranscriptionRequest) (string, error) {
+	// Create a temporary file to store the audio content
+	tempFile, err := os.CreateTemp("", "audio-*")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(tempFile.Name())
+
+	if _, err := tempFile.Write(req.Audio); err != nil {
+		tempFile.Close()
+		return "", err
+	}
+	if err := tempFile.Close(); err != nil {
+		return "", err
+	}
+
+	replacer := strings.NewReplacer(
+		"{file}", tempFile.Name(),
+		"{lang}", req.LanguageCode,
+		"{rate}", strconv.Itoa(int(req.Format.SampleRateHertz)),
+	)
+	args := strings.Fields(t.Command)
+	if len(args) == 0 {
+		return "", fmt.Errorf("local speech-to-text command is empty")
+	}
+	for i, arg := range args {
+		args[i] = replacer.Replace(arg)
+	}
+
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("local speech-to-text command failed: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	return strings.TrimSpace(string(out)), nil
+}
+
+// FakeTranscriber is a deterministic backend for tests and local development.
+// It returns Text, or the uploaded bytes themselves when Text is empty and they are valid UTF-8.
+type FakeTranscriber struct {
+	Text string
+}
+
+func (t *FakeTranscriber) Transcribe(ctx context.Context, req TranscriptionRequest) (string, error) {
+	if t.Text != "" {
+		return t.Text, nil
+	}
+	if !utf8.Valid(req.Audio) {
+		return "", fmt.Errorf("fake speech-to-text expects UTF-8 text as audio")
+	}
+	return strings.TrimSpace(string(req.Audio)), nil
+}
@@ -0,0 +1,239 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"sync"
+
+	speech "cloud.google.com/go/speech/apiv1"
+	"cloud.google.com/go/speech/apiv1/speechpb"
+	"google.golang.org/api/option"
+)
+
+// TranscriptResult is a piece of a transcript produced while audio is streamed.
+// Interim results may still change, final ones do not.
+type TranscriptResult struct {
+	Text    string `json:"text"`
+	IsFinal bool   `json:"is_final"`
+	Error   string `json:"error,omitempty"`
+}
+
+// TranscriptionStream receives audio in chunks and publishes transcripts as they are recognized.
+// Results is closed once the audio is recognized after CloseSend or when recognition fails.
+type TranscriptionStream interface {
+	Send(chunk []byte) error
+	CloseSend() error
+	Results() <-chan TranscriptResult
+}
+
+// StreamingTranscriber is implemented by backends that recognize audio while it is being recorded
+type StreamingTranscriber interface {
+	StartStream(ctx context.Context, format AudioFormat, languageCode string) (TranscriptionStream, error)
+}
+
+// LongRunningTranscriber is implemented by backends with a dedicated API for long recordings
+type LongRunningTranscriber interface {
+	TranscribeLong(ctx context.Context, req TranscriptionRequest) (string, error)
+}
+
+// StartTranscriptionStream opens a stream on the transcriber. Backends without streaming
+// support get the audio as a whole once the stream is closed.
+func StartTranscriptionStream(ctx context.Context, transcriber Transcriber, format AudioFormat, languageCode string) (TranscriptionStream, error) {
+	if streaming, ok := transcriber.(StreamingTranscriber); ok {
+		return streaming.StartStream(ctx, format, languageCode)
+	}
+	return &bufferedStream{
+		ctx:          ctx,
+		transcriber:  transcriber,
+		format:       format,
+		languageCode: languageCode,
+		results:      make(chan TranscriptResult, 1),
+	}, nil
+}
+
+// bufferedStream collects the chunks and transcribes them in one request on CloseSend
+type bufferedStream struct {
+	ctx          context.Context
+	transcriber  Transcriber
+	format       AudioFormat
+	languageCode string
+	results      chan TranscriptResult
+
+	mu     sync.Mutex
+	audio  bytes.Buffer
+	closed bool
+}
+
+func (s *bufferedStream) Send(chunk []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return errors.New("the stream is closed")
+	}
+	s.audio.Write(chunk)
+	return nil
+}
+
+func (s *bufferedStream) CloseSend() error {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return nil
+	}
+	s.closed = true
+	audio := s.audio.Bytes()
+	s.mu.Unlock()
+
+	go func() {
+		defer close(s.results)
+
+		text, err := s.transcriber.Transcribe(s.ctx, TranscriptionRequest{
+			Audio:        audio,
+			Format:       s.format,
+			LanguageCode: s.languageCode,
+		})
+		if err != nil {
+			s.results <- TranscriptResult{Error: err.Error(), IsFinal: true}
+			return
+		}
+		s.results <- TranscriptResult{Text: text, IsFinal: true}
+	}()
+	return nil
+}
+
+func (s *bufferedStream) Results() <-chan TranscriptResult {
+	return s.results
+}
+
+// googleStream forwards chunks to Google StreamingRecognize and publishes interim results
+type googleStream struct {
+	client  *speech.Client
+	stream  speechpb.Speech_StreamingRecognizeClient
+	results chan TranscriptResult
+	mu      sync.Mutex
+}
+
+func (t *GoogleTranscriber) StartStream(ctx context.Context, format AudioFormat, languageCode string) (TranscriptionStream, error) {
+	req := withDefaults(TranscriptionRequest{Format: format, LanguageCode: languageCode})
+	recognitionConfig, err := googleRecognitionConfig(req)
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := speech.NewClient(ctx, option.WithCredentialsFile(t.CredentialsFile))
+	if err != nil {
+		return nil, err
+	}
+
+	stream, err := client.StreamingRecognize(ctx)
+	if err != nil {
+		client.Close()
+		return nil, err
+	}
+
+	// The first message carries the configuration, the following ones the audio
+	err = stream.Send(&speechpb.StreamingRecognizeRequest{
+		StreamingRequest: &speechpb.StreamingRecognizeRequest_StreamingConfig{
+			StreamingConfig: &speechpb.StreamingRecognitionConfig{
+				Config:         recognitionConfig,
+				InterimResults: true,
+			},
+		},
+	})
+	if err != nil {
+		client.Close()
+		return nil, err
+	}
+
+	s := &googleStream{client: client, stream: stream, results: make(chan TranscriptResult, 16)}
+	go s.receive()
+	return s, nil
+}
+
+func (s *googleStream) receive() {
+	defer s.client.Close()
+	defer close(s.results)
+
+	for {
+		resp, err := s.stream.Recv()
+		if err == io.EOF {
+			return
+		}
+		if err != nil {
+			s.results <- TranscriptResult{Error: err.Error(), IsFinal: true}
+			return
+		}
+
+		for _, result := range resp.Results {
+			if len(result.Alternatives) == 0 {
+				continue
+			}
+			s.results <- TranscriptResult{
+				Text:    result.Alternatives[0].Transcript,
+				IsFinal: result.IsFinal,
+			}
+		}
+	}
+}
+
+func (s *googleStream) Send(chunk []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.stream.Send(&speechpb.StreamingRecognizeRequest{
+		StreamingRequest: &speechpb.StreamingRecognizeRequest_AudioContent{AudioContent: chunk},
+	})
+}
+
+func (s *googleStream) CloseSend() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.stream.CloseSend()
+}
+
+func (s *googleStream) Results() <-chan TranscriptResult {
+	return s.results
+}
+
+// TranscribeLong recognizes recordings longer than a minute with LongRunningRecognize
+func (t *GoogleTranscriber) TranscribeLong(ctx context.Context, req TranscriptionRequest) (string, error) {
+	req = withDefaults(req)
+
+	recognitionConfig, err := googleRecognitionConfig(req)
+	if err != nil {
+		return "", err
+	}
+
+	client, err := speech.NewClient(ctx, option.WithCredentialsFile(t.CredentialsFile))
+	if err != nil {
+		return "", err
+	}
+	defer client.Close()
+
+	op, err := client.LongRunningRecognize(ctx, &speechpb.LongRunningRecognizeRequest{
+		Config: recognitionConfig,
+		Audio: &speechpb.RecognitionAudio{
+			AudioSource: &speechpb.RecognitionAudio_Content{Content: req.Audio},
+		},
+	})
+	if err != nil {
+		return "", err
+	}
+
+	resp, err := op.Wait(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	transcription := ""
+	for _, result := range resp.Results {
+		if len(result.Alternatives) > 0 {
+			transcription += result.Alternatives[0].Transcript + " "
+		}
+	}
+	return transcription, nil
+}
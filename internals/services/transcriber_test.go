@@ -0,0 +1,170 @@
+package services
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+func TestNewTranscriberSelectsProvider(t *testing.T) {
+	tests := []struct {
+		provider string
+		want     interface{}
+	}{
+		{"", &GoogleTranscriber{}},
+		{"google", &GoogleTranscriber{}},
+		{"Google", &GoogleTranscriber{}},
+		{"local", &LocalTranscriber{}},
+		{"fake", &FakeTranscriber{}},
+	}
+	for _, test := range tests {
+		t.Setenv("STT_PROVIDER", test.provider)
+		t.Setenv("STT_FAKE_TEXT", "витрати 100 на каву")
+		t.Setenv("STT_LOCAL_URL", "http://localhost:9000/transcribe")
+
+		transcriber, err := NewTranscriber()
+		if err != nil {
+			t.Fatalf("STT_PROVIDER=%q: %v", test.provider, err)
+		}
+		switch want := test.want.(type) {
+		case *GoogleTranscriber:
+			if _, ok := transcriber.(*GoogleTranscriber); !ok {
+				t.Errorf("STT_PROVIDER=%q gave %T, want %T", test.provider, transcriber, want)
+			}
+		case *LocalTranscriber:
+			local, ok := transcriber.(*LocalTranscriber)
+			if !ok || local.URL != "http://localhost:9000/transcribe" {
+				t.Errorf("STT_PROVIDER=%q gave %#v, want a local transcriber with STT_LOCAL_URL", test.provider, transcriber)
+			}
+		case *FakeTranscriber:
+			fake, ok := transcriber.(*FakeTranscriber)
+			if !ok || fake.Text != "витрати 100 на каву" {
+				t.Errorf("STT_PROVIDER=%q gave %#v, want a fake transcriber with STT_FAKE_TEXT", test.provider, transcriber)
+			}
+		}
+	}
+
+	t.Setenv("STT_PROVIDER", "whisper")
+	if _, err := NewTranscriber(); err == nil {
+		t.Errorf("an unknown provider was accepted")
+	}
+}
+
+func TestFakeTranscriber(t *testing.T) {
+	ctx := context.Background()
+
+	text, err := (&FakeTranscriber{Text: "дохід 5000"}).Transcribe(ctx, TranscriptionRequest{Audio: []byte{0xff, 0xfe}})
+	if err != nil || text != "дохід 5000" {
+		t.Errorf("with Text: %q, %v", text, err)
+	}
+
+	// Without Text the uploaded bytes are the transcript
+	text, err = (&FakeTranscriber{}).Transcribe(ctx, TranscriptionRequest{Audio: []byte("  витрати 250 продукти\n")})
+	if err != nil || text != "витрати 250 продукти" {
+		t.Errorf("without Text: %q, %v", text, err)
+	}
+
+	if _, err := (&FakeTranscriber{}).Transcribe(ctx, TranscriptionRequest{Audio: []byte{0xff, 0xfe}}); err == nil {
+		t.Errorf("binary audio was accepted without Text")
+	}
+}
+
+func TestLocalTranscriberHTTP(t *testing.T) {
+	tests := []struct {
+		name     string
+		response string
+		want     string
+	}{
+		{"json", `{"text": "витрати 100"}`, "витрати 100"},
+		{"plain text", "  витрати 100\n", "витрати 100"},
+	}
+	for _, test := range tests {
+		var query map[string]string
+		var audio []byte
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			query = map[string]string{
+				"language":    r.URL.Query().Get("language"),
+				"sample_rate": r.URL.Query().Get("sample_rate"),
+				"codec":       r.URL.Query().Get("codec"),
+			}
+			audio, _ = io.ReadAll(r.Body)
+			io.WriteString(w, test.response)
+		}))
+
+		transcriber := &LocalTranscriber{URL: server.URL + "/transcribe"}
+		text, err := transcriber.Transcribe(context.Background(), TranscriptionRequest{Audio: []byte("RIFF")})
+		server.Close()
+		if err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+		if text != test.want {
+			t.Errorf("%s: transcript %q, want %q", test.name, text, test.want)
+		}
+		// Requests without a format fall back to 48 kHz PCM in the default language
+		if query["language"] != DefaultLanguage().RecognizerCode || query["sample_rate"] != "48000" || query["codec"] != CodecPCM16 {
+			t.Errorf("%s: query %v", test.name, query)
+		}
+		if string(audio) != "RIFF" {
+			t.Errorf("%s: the server got %q", test.name, audio)
+		}
+	}
+}
+
+func TestLocalTranscriberHTTPError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "model not loaded", http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	_, err := (&LocalTranscriber{URL: server.URL}).Transcribe(context.Background(), TranscriptionRequest{Audio: []byte("RIFF")})
+	if err == nil || !strings.Contains(err.Error(), "model not loaded") {
+		t.Errorf("error = %v, want the message of the server", err)
+	}
+}
+
+func TestLocalTranscriberCommand(t *testing.T) {
+	if _, err := exec.LookPath("echo"); err != nil {
+		t.Skip("echo is not available")
+	}
+	transcriber := &LocalTranscriber{Command: "echo {lang} {rate}"}
+	text, err := transcriber.Transcribe(context.Background(), TranscriptionRequest{
+		Audio:        []byte("RIFF"),
+		Format:       AudioFormat{Codec: CodecPCM16, SampleRateHertz: 16000},
+		LanguageCode: "en-US",
+	})
+	if err != nil || text != "en-US 16000" {
+		t.Errorf("transcript %q, %v, want \"en-US 16000\"", text, err)
+	}
+}
+
+func TestLocalTranscriberPrefersURL(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "from the server")
+	}))
+	defer server.Close()
+
+	transcriber := &LocalTranscriber{URL: server.URL, Command: "false"}
+	text, err := transcriber.Transcribe(context.Background(), TranscriptionRequest{Audio: []byte("RIFF")})
+	if err != nil || text != "from the server" {
+		t.Errorf("transcript %q, %v, want the one of the server", text, err)
+	}
+
+	if _, err := (&LocalTranscriber{}).Transcribe(context.Background(), TranscriptionRequest{}); err == nil {
+		t.Errorf("a local transcriber without URL and Command did not fail")
+	}
+}
+
+func TestTranscribeAudioDataWithFakeProvider(t *testing.T) {
+	t.Setenv("STT_PROVIDER", "fake")
+	t.Setenv("STT_FAKE_TEXT", "")
+
+	// The fake provider takes the upload as is, plain text is not rejected as unknown audio
+	text, err := TranscribeAudioData(context.Background(), []byte("витрати 120 кава"), DefaultLanguage(), false)
+	if err != nil || text != "витрати 120 кава" {
+		t.Errorf("transcript %q, %v", text, err)
+	}
+}
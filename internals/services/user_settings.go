@@ -0,0 +1,154 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	models "github.com/KashyretsIvanna/voice-balance/internals/model"
+	repositories "github.com/KashyretsIvanna/voice-balance/internals/repositories"
+	"github.com/google/uuid"
+)
+
+// ErrInvalidSettings is returned when user settings fail validation
+var ErrInvalidSettings = errors.New("invalid settings")
+
+// Settings used for users who did not choose them
+const (
+	DefaultTimeZone  = "Europe/Kyiv"
+	DefaultWeekStart = "monday"
+)
+
+// weekdays maps the names accepted as the first day of week
+var weekdays = map[string]time.Weekday{
+	"sunday": time.Sunday, "monday": time.Monday, "tuesday": time.Tuesday, "wednesday": time.Wednesday,
+	"thursday": time.Thursday, "friday": time.Friday, "saturday": time.Saturday,
+}
+
+// UserSettings are the preferences of a user
+type UserSettings struct {
+	Language  string `json:"language" example:"uk"`
+	TimeZone  string `json:"time_zone" example:"Europe/Kyiv"`
+	WeekStart string `json:"week_start" example:"monday"`
+	// Currency of new transactions and of statistics
+	Currency string `json:"currency" example:"UAH"`
+}
+
+// UserSettingsUpdate holds the settings to change, nil fields are left as they are
+type UserSettingsUpdate struct {
+	Language  *string `json:"language" example:"en"`
+	TimeZone  *string `json:"time_zone" example:"Europe/Warsaw"`
+	WeekStart *string `json:"week_start" example:"sunday"`
+	Currency  *string `json:"currency" example:"USD"`
+}
+
+// GetUserSettings returns the settings of the user
+func GetUserSettings(userID uuid.UUID) (*UserSettings, error) {
+	user, err := repositories.GetUserByID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	settings := &UserSettings{Language: user.Language, TimeZone: user.TimeZone, WeekStart: user.WeekStart, Currency: user.Currency}
+	if settings.Language == "" {
+		settings.Language = DefaultLanguageCode
+	}
+	if settings.TimeZone == "" {
+		settings.TimeZone = DefaultTimeZone
+	}
+	if settings.WeekStart == "" {
+		settings.WeekStart = DefaultWeekStart
+	}
+	if settings.Currency == "" {
+		settings.Currency = models.DefaultCurrency
+	}
+	return settings, nil
+}
+
+// UpdateUserSettings validates and saves the changed settings of the user
+func UpdateUserSettings(userID uuid.UUID, update UserSettingsUpdate) (*UserSettings, error) {
+	columns := map[string]interface{}{}
+
+	if update.Language != nil {
+		language, err := LookupLanguage(*update.Language)
+		if err != nil {
+			return nil, fmt.Errorf("%w: language must be one of %v", ErrInvalidSettings, SupportedLanguageCodes())
+		}
+		columns["language"] = language.Code
+	}
+
+	if update.TimeZone != nil {
+		location, err := time.LoadLocation(*update.TimeZone)
+		if err != nil || *update.TimeZone == "" || *update.TimeZone == "Local" {
+			return nil, fmt.Errorf("%w: unknown time zone %q", ErrInvalidSettings, *update.TimeZone)
+		}
+		columns["time_zone"] = location.String()
+	}
+
+	if update.WeekStart != nil {
+		weekStart := strings.ToLower(strings.TrimSpace(*update.WeekStart))
+		if _, ok := weekdays[weekStart]; !ok {
+			return nil, fmt.Errorf("%w: week_start must be the English name of a weekday, e.g. monday", ErrInvalidSettings)
+		}
+		columns["week_start"] = weekStart
+	}
+
+	if update.Currency != nil {
+		currency, err := NormalizeCurrency(*update.Currency)
+		if err != nil || currency == "" {
+			return nil, fmt.Errorf("%w: currency must be a three letter ISO 4217 code, e.g. UAH", ErrInvalidSettings)
+		}
+		columns["currency"] = currency
+	}
+
+	if len(columns) > 0 {
+		if err := repositories.UpdateUserColumns(userID, columns); err != nil {
+			return nil, err
+		}
+	}
+	return GetUserSettings(userID)
+}
+
+// ResolveLanguage returns the language voice commands of the user are understood in:
+// the requested one when given, otherwise the one from the user settings and the default
+// language when the settings hold none that is supported. ErrUnsupportedLanguage is
+// returned only for a requested language.
+func ResolveLanguage(userID uuid.UUID, requested string) (*Language, error) {
+	if requested != "" {
+		return LookupLanguage(requested)
+	}
+
+	settings, err := GetUserSettings(userID)
+	if err != nil {
+		return nil, err
+	}
+	language, err := LookupLanguage(settings.Language)
+	if err != nil {
+		return DefaultLanguage(), nil
+	}
+	return language, nil
+}
+
+// Location returns the time zone of the settings
+func (s *UserSettings) Location() (*time.Location, error) {
+	return time.LoadLocation(s.TimeZone)
+}
+
+// FirstDayOfWeek returns the day weeks start on in statistics
+func (s *UserSettings) FirstDayOfWeek() time.Weekday {
+	if weekday, ok := weekdays[s.WeekStart]; ok {
+		return weekday
+	}
+	return time.Monday
+}
+
+// UserLocation returns the time zone of the user. Dates in voice commands like "вчора"
+// are resolved in it.
+func UserLocation(userID uuid.UUID) (*time.Location, error) {
+	settings, err := GetUserSettings(userID)
+	if err != nil {
+		return nil, err
+	}
+	return settings.Location()
+}
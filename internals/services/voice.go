@@ -6,15 +6,9 @@ import (
 	"fmt"
 	"io"
 	"mime/multipart"
-	"os"
 	"regexp"
 	"strings"
-
-	"cloud.google.com/go/speech/apiv1"
-	"cloud.google.com/go/speech/apiv1/speechpb" // Updated import for speech types
-	"cloud.google.com/go/vertexai/genai"
-	"github.com/KashyretsIvanna/voice-balance/config"
-	"google.golang.org/api/option"
+	"time"
 )
 
 type Candidate struct {
@@ -70,7 +64,7 @@ func parseJSONParts(parts []string) []interface{} {
 	return parsedParts
 }
 
-func ParseText(file *multipart.FileHeader) (string, error) {
+func ParseText(file *multipart.FileHeader, language *Language) (string, error) {
 
 	// Open the uploaded file
 	src, err := file.Open()
@@ -80,85 +74,92 @@ func ParseText(file *multipart.FileHeader) (string, error) {
 	}
 	defer src.Close()
 
-	// Create a temporary file to store the audio content
-	tempFile, err := os.CreateTemp("./", "audio-*")
+	// Read the audio file data
+	audioData, err := io.ReadAll(src)
 	if err != nil {
 		return "", err
 
 	}
-	defer os.Remove(tempFile.Name())
-	defer tempFile.Close()
-
-	// Copy uploaded file content to the temp file
-	if _, err := io.Copy(tempFile, src); err != nil {
-		return "", err
 
-	}
+	return TranscribeAudioData(context.Background(), audioData, language, false)
+}
 
-	// Initialize Google Cloud Speech client with credentials
-	ctx := context.Background()
-	client, err := speech.NewClient(ctx, option.WithCredentialsFile(config.Config("CLOUD_JSON_PATH")))
+// TranscribeAudioData detects the format of the audio and transcribes speech in the
+// given language with the configured backend. With long set, backends with a
+// dedicated API for long recordings use it.
+func TranscribeAudioData(ctx context.Context, audioData []byte, language *Language, long bool) (string, error) {
+	transcriber, err := NewTranscriber()
 	if err != nil {
 		return "", err
-
 	}
-	defer client.Close()
-
-	fmt.Print(tempFile.Name())
-	// Read the audio file data
-	audioData, err := os.ReadFile(tempFile.Name())
-	if err != nil {
-		return "", err
 
+	// Detect the audio format and transcode what the recognizer cannot decode.
+	// The fake transcriber takes the upload as is, it may be plain text.
+	var format AudioFormat
+	if _, fake := transcriber.(*FakeTranscriber); !fake {
+		audioData, format, err = PrepareAudio(ctx, audioData)
+		if err != nil {
+			return "", err
+		}
 	}
 
-	// Configure the recognition request
-	req := &speechpb.RecognizeRequest{
-		Config: &speechpb.RecognitionConfig{
-			Encoding:        speechpb.RecognitionConfig_LINEAR16, // Set based on audio format
-			SampleRateHertz: 48000,                               // Adjust sample rate as needed
-			LanguageCode:    "uk-UA",                             // Language code for transcription
-		},
-		Audio: &speechpb.RecognitionAudio{
-			AudioSource: &speechpb.RecognitionAudio_Content{
-				Content: audioData,
-			},
-		},
+	req := TranscriptionRequest{
+		Audio:        audioData,
+		Format:       format,
+		LanguageCode: language.RecognizerCode,
 	}
 
 	// Perform transcription
-	resp, err := client.Recognize(ctx, req)
-	if err != nil {
-		return "", err
-
+	if longRunning, ok := transcriber.(LongRunningTranscriber); ok && long {
+		return longRunning.TranscribeLong(ctx, req)
 	}
+	return transcriber.Transcribe(ctx, req)
+}
 
-	// Collect the transcription result
-	transcription := ""
-	for _, result := range resp.Results {
-		for _, alt := range result.Alternatives {
-			transcription += alt.Transcript + " "
-		}
+// Функція для обробки голосових команд
+func GetActionFromVoice(command string) (map[string]interface{}, error) {
+	return GetActionFromVoiceIn(DefaultLanguage(), command)
+}
+
+// GetActionFromVoiceIn recognizes a command with the grammar of the language.
+// The result holds the canonical action types, ranges and category types.
+func GetActionFromVoiceIn(language *Language, command string) (map[string]interface{}, error) {
+	grammar := &language.Grammar
+
+	// Перетворюємо команду в нижній регістр для полегшення порівнянь
+	command = apostropheReplacer.Replace(strings.ToLower(command))
+
+	// Статистика рахується за періодом, слова "сьогодні" чи "тиждень" в ній не є датою
+	if includes(grammar.StatisticsKeywords, command) {
+		return handleShowStatistics(grammar, command), nil
 	}
 
-	return transcription, nil
+	// Дата вирізається окремо, щоб "15 березня" не стало сумою, а "завтра" - текстом нагадування.
+	// Фраза повертається як є, її перетворює на дату ValidateVoiceAction.
+	datePhrase := ""
+	if parsed, ok := ParseDatePhrase(language, command, time.Now(), true); ok {
+		datePhrase, command = parsed.Phrase, parsed.Rest
+	}
 
+	result, err := matchVoiceCommand(grammar, command)
+	if err != nil {
+		return result, err
+	}
+	if datePhrase != "" {
+		result["date"] = datePhrase
+	}
+	return result, nil
 }
 
-// Функція для обробки голосових команд
-func GetActionFromVoice(command string) (map[string]interface{}, error) {
-	// Перетворюємо команду в нижній регістр для полегшення порівнянь
-	command = strings.ToLower(command)
-
-	// Обробка запитів на статистику витрат і доходів
-	if includes([]string{"статистика", "статистику"}, command) {
-		return handleShowStatistics(command), nil
-	} else if includes([]string{"додай витрату", "додай витрати"}, command) {
-		return handleAddExpense(command), nil
-	} else if includes([]string{"додай дохід"}, command) {
-		return handleAddIncome(command), nil
-	} else if includes([]string{"нагадай"}, command) {
-		return handleAddReminder(command), nil
+// matchVoiceCommand picks the handler of the command by its keywords
+func matchVoiceCommand(grammar *Grammar, command string) (map[string]interface{}, error) {
+	// Обробка запитів на додавання витрат, доходів і нагадувань
+	if includes(grammar.ExpenseKeywords, command) {
+		return handleAddExpense(grammar, command), nil
+	} else if includes(grammar.IncomeKeywords, command) {
+		return handleAddIncome(grammar, command), nil
+	} else if includes(grammar.ReminderKeywords, command) {
+		return handleAddReminder(grammar, command), nil
 	} else {
 		return map[string]interface{}{}, fmt.Errorf("Error getting type of action")
 	}
@@ -175,261 +176,137 @@ func includes(slice []string, str string) bool {
 	return false
 }
 
-func handleAddExpense(command string) map[string]interface{} {
-	// Define regex patterns to extract category and amount
-	// amountPattern := `.*?\s+([а-яА-ЯіІїЇєЄґҐ\s]+)\s*грн.*?`
-	amountPattern := `.*?\s+(\d+(\.\d+)?)\s*грн.*?`
-
-	categoryPattern := `.*?на\s+([а-яА-ЯіІїЇєЄґҐ]+).*?`
-	// Use regex to extract amount and category
-	amountRegex := regexp.MustCompile(amountPattern)
-	categoryRegex := regexp.MustCompile(categoryPattern)
-
-	// Find the amount and category in the command
-	amountMatches := amountRegex.FindStringSubmatch(command)
-	categoryMatches := categoryRegex.FindStringSubmatch(command)
-
+// findAmount returns the amount named in the command or "0" when there is none
+func findAmount(grammar *Grammar, command string) string {
+	amount, ok := ParseAmount(grammar.Numerals, command)
+	if !ok {
+		return "0"
+	}
+	return amount
+}
 
-	// Default category if not found
-	category := "не вказано"
-	if len(categoryMatches) > 0 {
-		category = strings.TrimSpace(categoryMatches[1])
+// findCategory returns the first group of the pattern or the fallback when it does not match
+func findCategory(pattern, command, fallback string) string {
+	matches := regexp.MustCompile(pattern).FindStringSubmatch(command)
+	if len(matches) < 2 {
+		return fallback
 	}
+	return strings.TrimSpace(matches[1])
+}
 
-	// Default amount if not found
-	amount := "нуль"
-	if len(amountMatches) > 0 {
-		amount = amountMatches[1] // Extracts the amount from the match
+// findCurrency returns the code of the currency named in the command or "" when there is none
+func findCurrency(grammar *Grammar, command string) string {
+	for _, word := range strings.Fields(command) {
+		if code := currencyOfWord(grammar, word); code != "" {
+			return code
+		}
 	}
+	return ""
+}
 
-	// Print the parsed results for debugging
+// currencyOfWord returns the code of the currency the word names or ""
+func currencyOfWord(grammar *Grammar, word string) string {
+	for code, prefixes := range grammar.CurrencyWords {
+		for _, prefix := range prefixes {
+			if strings.HasPrefix(word, prefix) {
+				return code
+			}
+		}
+	}
+	return ""
+}
 
+func handleAddExpense(grammar *Grammar, command string) map[string]interface{} {
 	// Return the parsed data in a map
 	result := map[string]interface{}{
-		"amount":   amount,
-		"category": category,
-		"type":     "витрати",
+		"amount":   findAmount(grammar, command),
+		"category": findCategory(grammar.ExpenseCategoryPattern, command, grammar.UnspecifiedCategory),
+		"currency": findCurrency(grammar, command),
+		"type":     string(VoiceActionExpense),
 	}
 	return result
 }
 
 // Функція для додавання доходу
-func handleAddIncome(command string) map[string]interface{} {
-	// Регулярні вирази для вилучення суми та категорії
-	amountPattern := `.*?\s+(\d+(\.\d+)?)\s*за.*?`
-	categoryPattern := `.*?\s+за\s+категорією\s+([а-яА-ЯіІїЇєЄґҐ]+).*?`
-
-	amountRegex := regexp.MustCompile(amountPattern)
-	categoryRegex := regexp.MustCompile(categoryPattern)
-
-	// Ініціалізуємо значення
-	amount := "0"
-	category := "загальна"
-
-	// Шукаємо суму в команді
-	amountMatches := amountRegex.FindStringSubmatch(command)
-	if len(amountMatches) > 1 {
-		amount = amountMatches[1] // Наприклад: "два" -> 2
-	}
-
-	// Шукаємо категорію в команді
-	categoryMatches := categoryRegex.FindStringSubmatch(command)
-	if len(categoryMatches) > 1 {
-		category = categoryMatches[1] // Наприклад: "зарплата"
-	}
-
+func handleAddIncome(grammar *Grammar, command string) map[string]interface{} {
 	result := map[string]interface{}{
-		"amount":   amount,
-		"category": category,
-		"type":     "доходи",
+		"amount":   findAmount(grammar, command),
+		"category": findCategory(grammar.IncomeCategoryPattern, command, grammar.DefaultIncomeCategory),
+		"currency": findCurrency(grammar, command),
+		"type":     string(VoiceActionIncome),
 	}
 	return result
 }
 
 // Функція для додавання нагадування
-func handleAddReminder(command string) map[string]interface{} {
-	input := "Нагадай оплатити рахунок за електроенергію"
-
-	// Видалення слова "нагадай" (незалежно від регістру)
-	reminder := strings.Replace(strings.ToLower(input), "нагадай", "", 1)
-	reminder = strings.TrimSpace(reminder) // Видалення зайвих пробілів
+func handleAddReminder(grammar *Grammar, command string) map[string]interface{} {
+	// Видалення ключового слова (незалежно від регістру), найдовші варіанти перевіряються першими
+	reminder := command
+	for _, keyword := range grammar.ReminderKeywords {
+		if strings.Contains(reminder, keyword) {
+			reminder = strings.Replace(reminder, keyword, "", 1)
+			break
+		}
+	}
 
 	result := map[string]interface{}{
-		"category": reminder,
-		"type":     "нагадування",
+		"category": strings.TrimSpace(reminder), // Видалення зайвих пробілів
+		"type":     string(VoiceActionReminder),
 	}
 	return result
-
 }
 
 // Функція для показу статистики витрат та доходів
-func handleShowStatistics(command string) map[string]interface{} {
+func handleShowStatistics(grammar *Grammar, command string) map[string]interface{} {
 	var category string
-	if strings.Contains(command, "доход") {
-		category = "доходи"
+	if includes(grammar.IncomeWords, command) {
+		category = string(VoiceActionIncome)
 	}
 
-	if strings.Contains(command, "витрат") {
-		category = "витрати"
+	if includes(grammar.ExpenseWords, command) {
+		category = string(VoiceActionExpense)
 	}
 
-	// Якщо команда вимагає статистику за місяць або тиждень
-	if strings.Contains(command, "місяць") {
-		// Логіка показу статистики за місяць (псевдокод)
-		// Припустимо, що ми отримуємо статистику за поточний місяць
-
-		result := map[string]interface{}{
-			"category": category,
-			"range":    "місяць",
-			"type":     "статистика",
-		}
-		return result
-		// Тут можна додавати реальний код для отримання статистики з бази
-	} else if strings.Contains(command, "тиждень") {
-		// Логіка показу статистики за тиждень
-		fmt.Println("Показано статистику за поточний тиждень")
-		// Тут можна додавати реальний код для отримання статистики з бази
-		result := map[string]interface{}{
-			"category": category,
-			"range":    "тиждень",
-			"type":     "статистика",
-		}
-		return result
-	} else if strings.Contains(command, "день") {
-		// Логіка показу статистики за тиждень
-		fmt.Println("Показано статистику за поточний тиждень")
-		// Тут можна додавати реальний код для отримання статистики з бази
-		result := map[string]interface{}{
-			"category": category,
-			"range":    "день",
-			"type":     "статистика",
-		}
-		return result
-
-	} else {
-		// Якщо період не визначено, показуємо загальну статистику
-		fmt.Println("Показано загальну статистику витрат та доходів")
-		// Тут можна додавати реальний код для отримання загальної статистики з бази
-		result := map[string]interface{}{
-			"category": category,
-			"range":    "",
-			"type":     "статистика",
+	// Якщо період не визначено, показуємо загальну статистику.
+	// Найдовше слово перемагає: "тиждень" містить "день", "сегодня" містить "год".
+	statisticsRange := ""
+	longest := 0
+	for _, candidate := range []StatisticsRange{RangeDay, RangeWeek, RangeMonth, RangeYear} {
+		for _, word := range grammar.RangeWords[candidate] {
+			if len(word) > longest && strings.Contains(command, word) {
+				statisticsRange = string(candidate)
+				longest = len(word)
+			}
 		}
-		return result
-	}
-}
-
-func wordToNumber(word string) int {
-	numberMap := map[string]int{
-		"нуль": 0, "один": 1, "два": 2, "три": 3, "чотири": 4, "п’ять": 5,
-		"шість": 6, "сім": 7, "вісім": 8, "дев’ять": 9, "десять": 10,
-		"одинадцять": 11, "дванадцять": 12, "тринадцять": 13, "чотирнадцять": 14,
-		"п’ятнадцять": 15, "шістнадцять": 16, "сімнадцять": 17, "вісімнадцять": 18,
-		"дев’ятнадцять": 19, "двадцять": 20, "тридцять": 30, "сорок": 40,
-		"п’ятдесят": 50, "шістдесят": 60, "сімдесят": 70, "вісімдесят": 80,
-		"дев’яносто": 90, "сто": 100, "двісті": 200, "триста": 300,
-		"чотириста": 400, "п’ятсот": 500, "шістсот": 600, "сімсот": 700,
-		"вісімсот": 800, "дев’ятсот": 900, "тисяча": 1000,
 	}
 
-	// Normalize the input to lower case for easier matching
-	word = strings.ToLower(word)
-
-	if val, found := numberMap[word]; found {
-		return val
-	}
-	return 0 // If the word isn't found, return 0
-}
-
-func convertVoiceCommand(command string) int {
-	// Split command into parts
-	parts := strings.Fields(command)
-	total := 0
-
-	// Convert each part into a number and sum up
-	for _, part := range parts {
-		total += wordToNumber(part)
+	result := map[string]interface{}{
+		"category": category,
+		"range":    statisticsRange,
+		"type":     string(VoiceActionStatistics),
 	}
-
-	return total
+	return result
 }
 
-func AskAi(command string) (error, map[string]interface{}) {
-	location := "us-central1"
-	modelName := "gemini-1.5-flash-001"
-	projectID := "cool-academy-359612"
-	ctx := context.Background()
-
-	// Initialize the client with credentials
-	client, err := genai.NewClient(ctx, projectID, location, option.WithCredentialsFile(config.Config("CLOUD_JSON_PATH")))
+// AskAi interprets a text command in the given language with the intent parsers selected
+// in the configuration. The parsed output is validated, see ValidateVoiceAction; dates are
+// resolved relative to now and in its location.
+func AskAi(command string, language *Language, now time.Time) (error, VoiceAction) {
+	parser, err := NewIntentParser()
 	if err != nil {
-		return fmt.Errorf("error creating client: %w", err), nil
+		return err, nil
 	}
 
-	gemini := client.GenerativeModel(modelName)
-
-	// Define the prompt
-	prompt := genai.Text(fmt.Sprintf(
-		`Я створюю додаток ведення балансу. Ти експерт розпізнавання команд від користувача. 
-		Тобі потрібно розпізнати команду та вивести розультат в форматі JSON. Якщо якусь з інформації користувач не надав поверни відповідний ключ з пустою строкою.
-		Є кілька типів команд, які підтримує додаток: додавання витрат або 
-		доходів, створення нагадувань, статистика. Приклад відовіді яку я 
-		очікую, якщо запит на додавання витрат або додавання доходів: 
-		{ "amount": 0, "category": "не вказано", "type": "витрати" }. 
-		
-		Type повинен бути: "доходи", "витрати" або "". 
-		Amount: число заокруглене до сотих, category - вказує 
-		на що вирати чи доходи(наприклад, продукти).
-		Наступний тип команди - створення нагадувань. Приклад 
-		відповіді яку я очікую: { "category": "оплатити рахунок за електроенергію", "type": "нагадування" }, 
-		де category - текст нагадування. Type - завжди "нагадування". Наступний тип команди. - відобразити статистику. 
-		Приклад відповіді яку я очікую: { "category": "", "range": "тиждень", "type": "статистика" }. Повинна повертати range: "тиждень", "рік","місяць",”день”. Якщо не визначено тип команди чи користувач говорить дивні запити, повертай type пустим рядком.
-
-		Розпізнай наступний текст та поверни результат: %s.
-		`, command))
-
-	// Generate content
-	resp, err := gemini.GenerateContent(ctx, prompt)
+	parsed, err := parser.ParseIntent(context.Background(), command, language)
 	if err != nil {
-		return fmt.Errorf("error generating content: %w", err), nil
+		return err, nil
 	}
 
-	// Format the response to JSON
-	rb, err := json.MarshalIndent(resp, "", "  ")
+	action, err := ValidateVoiceAction(parsed, language, now)
 	if err != nil {
-		return fmt.Errorf("error formatting response to JSON: %w", err), nil
+		return err, nil
 	}
-
-	res := string(rb)
-	fmt.Println("Generated Response:", res)
-
-	// Parse the response into a struct
-	var data Data
-	if err := json.Unmarshal([]byte(res), &data); err != nil {
-		return fmt.Errorf("error unmarshalling response into Data struct: %w", err), nil
-	}
-
-	// Validate if the response has Candidates and Content
-	if len(data.Candidates) == 0 {
-		return fmt.Errorf("no candidates found in the response"), nil
-	}
-
-	content := &data.Candidates[0].Content
-	content.Parts = toStringSlice(parseJSONParts(content.Parts))
-
-	// Ensure at least one Part is available
-	if len(content.Parts) == 0 {
-		return fmt.Errorf("no content parts found in the response"), nil
-	}
-
-	// Parse the JSON from the first part
-	var parsed map[string]interface{}
-	if err := json.Unmarshal([]byte(content.Parts[0]), &parsed); err != nil {
-		return fmt.Errorf("error unmarshalling content part to JSON: %w", err), nil
-	}
-
-	// Return the parsed JSON object
-	return nil, parsed
+	return nil, action
 }
 
 func toStringSlice(parts []interface{}) []string {
@@ -0,0 +1,334 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	models "github.com/KashyretsIvanna/voice-balance/internals/model"
+)
+
+// VoiceActionType tells which kind of action a voice command asks for
+type VoiceActionType string
+
+const (
+	VoiceActionExpense    VoiceActionType = "expense"
+	VoiceActionIncome     VoiceActionType = "income"
+	VoiceActionReminder   VoiceActionType = "reminder"
+	VoiceActionStatistics VoiceActionType = "statistics"
+	VoiceActionUnknown    VoiceActionType = "unknown"
+)
+
+// StatisticsRange is the calendar period a statistics command asks about
+type StatisticsRange string
+
+const (
+	RangeDay   StatisticsRange = "day"
+	RangeWeek  StatisticsRange = "week"
+	RangeMonth StatisticsRange = "month"
+	RangeYear  StatisticsRange = "year"
+	RangeAll   StatisticsRange = "all"
+)
+
+// VoiceAction is the validated result of interpreting a voice command. It is one of
+// ExpenseAction, IncomeAction, ReminderAction, StatisticsAction or UnknownAction.
+type VoiceAction interface {
+	ActionType() VoiceActionType
+}
+
+// ExpenseAction adds an expense
+type ExpenseAction struct {
+	Type   VoiceActionType `json:"type" example:"expense"`
+	Amount models.Money    `json:"amount" swaggertype:"number" example:"250.5"`
+	// Category is empty when the command names none
+	Category string `json:"category" example:"продукти"`
+	// Currency of the amount when the command names one
+	Currency string `json:"currency,omitempty" example:"USD"`
+	// Date of the expense when the command names one
+	Date *time.Time `json:"date,omitempty"`
+}
+
+// IncomeAction adds an income
+type IncomeAction struct {
+	Type   VoiceActionType `json:"type" example:"income"`
+	Amount models.Money    `json:"amount" swaggertype:"number" example:"20000"`
+	// Category is empty when the command names none
+	Category string `json:"category" example:"зарплата"`
+	// Currency of the amount when the command names one
+	Currency string `json:"currency,omitempty" example:"USD"`
+	// Date of the income when the command names one
+	Date *time.Time `json:"date,omitempty"`
+}
+
+// ReminderAction creates a reminder
+type ReminderAction struct {
+	Type   VoiceActionType `json:"type" example:"reminder"`
+	Text   string          `json:"text" example:"оплатити рахунок за електроенергію"`
+	Amount *models.Money   `json:"amount,omitempty" swaggertype:"number"`
+	// DueDate is set when the command names the date or the time of the reminder
+	DueDate *time.Time `json:"due_date,omitempty"`
+}
+
+// StatisticsAction shows income and expense statistics. An empty CategoryType
+// means both incomes and expenses.
+type StatisticsAction struct {
+	Type         VoiceActionType `json:"type" example:"statistics"`
+	Range        StatisticsRange `json:"range" example:"month"`
+	CategoryType string          `json:"category_type,omitempty" example:"expense"`
+}
+
+// UnknownAction is returned when the command does not match any supported action
+type UnknownAction struct {
+	Type VoiceActionType `json:"type" example:"unknown"`
+}
+
+func (a ExpenseAction) ActionType() VoiceActionType    { return VoiceActionExpense }
+func (a IncomeAction) ActionType() VoiceActionType     { return VoiceActionIncome }
+func (a ReminderAction) ActionType() VoiceActionType   { return VoiceActionReminder }
+func (a StatisticsAction) ActionType() VoiceActionType { return VoiceActionStatistics }
+func (a UnknownAction) ActionType() VoiceActionType    { return VoiceActionUnknown }
+
+// FieldError describes a problem with one field of an interpreted action
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// VoiceActionValidationError lists every field of an interpreted action that is missing or invalid
+type VoiceActionValidationError struct {
+	Type   VoiceActionType `json:"type"`
+	Fields []FieldError    `json:"fields"`
+}
+
+func (e *VoiceActionValidationError) Error() string {
+	messages := make([]string, len(e.Fields))
+	for i, field := range e.Fields {
+		messages[i] = field.Field + ": " + field.Message
+	}
+	return fmt.Sprintf("invalid %s action: %s", e.Type, strings.Join(messages, "; "))
+}
+
+func (e *VoiceActionValidationError) add(field, message string) {
+	e.Fields = append(e.Fields, FieldError{Field: field, Message: message})
+}
+
+// Action type names produced by the parsers, in Ukrainian and canonical form
+var voiceActionTypes = map[string]VoiceActionType{
+	"витрати":     VoiceActionExpense,
+	"витрата":     VoiceActionExpense,
+	"expense":     VoiceActionExpense,
+	"доходи":      VoiceActionIncome,
+	"дохід":       VoiceActionIncome,
+	"income":      VoiceActionIncome,
+	"нагадування": VoiceActionReminder,
+	"reminder":    VoiceActionReminder,
+	"статистика":  VoiceActionStatistics,
+	"statistics":  VoiceActionStatistics,
+}
+
+var statisticsRanges = map[string]StatisticsRange{
+	"":        RangeAll,
+	"all":     RangeAll,
+	"день":    RangeDay,
+	"day":     RangeDay,
+	"тиждень": RangeWeek,
+	"week":    RangeWeek,
+	"місяць":  RangeMonth,
+	"month":   RangeMonth,
+	"рік":     RangeYear,
+	"year":    RangeYear,
+}
+
+// Placeholders the parsers use instead of leaving a value out
+var missingValues = map[string]bool{
+	"":            true,
+	"0":           true,
+	"не вказано":  true,
+	"не указано":  true,
+	"unspecified": true,
+	"нуль":        true,
+}
+
+// ValidateVoiceAction checks the raw output of an IntentParser against the VoiceAction
+// schema and normalizes it: amounts become models.Money, amounts with more than two decimals
+// are rejected rather than rounded, ranges become StatisticsRange values and texts are trimmed.
+// Date phrases are resolved in the language of the command relative to now.
+// Missing or invalid fields are reported with a *VoiceActionValidationError.
+func ValidateVoiceAction(raw map[string]interface{}, language *Language, now time.Time) (VoiceAction, error) {
+	actionType, ok := voiceActionTypes[strings.ToLower(textValue(raw["type"]))]
+	if !ok {
+		return UnknownAction{Type: VoiceActionUnknown}, nil
+	}
+
+	validation := &VoiceActionValidationError{Type: actionType}
+
+	switch actionType {
+	case VoiceActionExpense, VoiceActionIncome:
+		amount, err := decimalValue(raw["amount"])
+		if err != nil {
+			validation.add("amount", err.Error())
+		}
+		// Without a category the category rules of the user choose one when the action is executed
+		category := textValue(raw["category"])
+		if missingValues[strings.ToLower(category)] {
+			category = ""
+		}
+		currency, err := currencyValue(raw["currency"], language)
+		if err != nil {
+			validation.add("currency", err.Error())
+		}
+		var date *time.Time
+		parsed, err := dateValue(raw["date"], language, now, false)
+		if err != nil {
+			validation.add("date", err.Error())
+		} else if parsed != nil {
+			date = &parsed.Time
+		}
+		if len(validation.Fields) > 0 {
+			return nil, validation
+		}
+
+		if actionType == VoiceActionIncome {
+			return IncomeAction{Type: actionType, Amount: amount, Category: category, Currency: currency, Date: date}, nil
+		}
+		return ExpenseAction{Type: actionType, Amount: amount, Category: category, Currency: currency, Date: date}, nil
+
+	case VoiceActionReminder:
+		action := ReminderAction{Type: actionType, Text: textValue(raw["text"])}
+		if action.Text == "" {
+			// The parsers put the reminder text into "category"
+			action.Text = textValue(raw["category"])
+		}
+		if action.Text == "" {
+			validation.add("text", "is required")
+		}
+		if value, present := raw["amount"]; present && !missingValues[strings.ToLower(textValue(value))] {
+			amount, err := decimalValue(value)
+			if err != nil {
+				validation.add("amount", err.Error())
+			}
+			action.Amount = &amount
+		}
+		parsed, err := dateValue(raw["date"], language, now, true)
+		if err != nil {
+			validation.add("date", err.Error())
+		} else if parsed == nil && action.Text != "" {
+			// Models sometimes leave the date in the text of the reminder
+			if phrase, ok := ParseDatePhrase(language, action.Text, now, true); ok && phrase.Rest != "" {
+				parsed, action.Text = &phrase, phrase.Rest
+			}
+		}
+		if parsed != nil {
+			dueDate := parsed.Time
+			if !parsed.HasTime {
+				y, m, d := dueDate.Date()
+				dueDate = time.Date(y, m, d, defaultReminderHour, 0, 0, 0, dueDate.Location())
+			}
+			action.DueDate = &dueDate
+		}
+		if len(validation.Fields) > 0 {
+			return nil, validation
+		}
+		return action, nil
+
+	default:
+		action := StatisticsAction{Type: actionType}
+		rangeValue := strings.ToLower(textValue(raw["range"]))
+		if action.Range, ok = statisticsRanges[rangeValue]; !ok {
+			validation.add("range", fmt.Sprintf("unknown range %q", rangeValue))
+		}
+		switch voiceActionTypes[strings.ToLower(textValue(raw["category"]))] {
+		case VoiceActionExpense:
+			action.CategoryType = models.CategoryTypeExpense
+		case VoiceActionIncome:
+			action.CategoryType = models.CategoryTypeIncome
+		}
+		if len(validation.Fields) > 0 {
+			return nil, validation
+		}
+		return action, nil
+	}
+}
+
+// textValue returns a trimmed string for string and number values
+func textValue(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return strings.TrimSpace(v)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		return ""
+	}
+}
+
+// currencyValue reads an ISO 4217 code or a currency word of the language, it returns "" when there is none
+func currencyValue(value interface{}, language *Language) (string, error) {
+	text := strings.ToLower(textValue(value))
+	if missingValues[text] {
+		return "", nil
+	}
+	if code := currencyOfWord(&language.Grammar, text); code != "" {
+		return code, nil
+	}
+	code, err := NormalizeCurrency(text)
+	if err != nil {
+		return "", fmt.Errorf("%q is not a currency", text)
+	}
+	return code, nil
+}
+
+// dateValue resolves a date phrase like "вчора" or "завтра о 9", it returns nil when there is none
+func dateValue(value interface{}, language *Language, now time.Time, future bool) (*ParsedDate, error) {
+	text := textValue(value)
+	if missingValues[strings.ToLower(text)] {
+		return nil, nil
+	}
+
+	parsed, ok := ParseDatePhrase(language, text, now, future)
+	if !ok {
+		return nil, fmt.Errorf("%q is not a date", text)
+	}
+	return &parsed, nil
+}
+
+// decimalValue reads a positive amount given either as a number or as a string.
+// Amounts with more than two decimals are rejected rather than rounded.
+func decimalValue(value interface{}) (models.Money, error) {
+	var amount models.Money
+	switch v := value.(type) {
+	case nil:
+		return 0, fmt.Errorf("is required")
+	case float64:
+		parsed, err := models.MoneyFromFloat(v)
+		if errors.Is(err, models.ErrMoneyPrecision) {
+			return 0, fmt.Errorf("%v has more than two decimals", v)
+		}
+		if err != nil {
+			return 0, fmt.Errorf("%v: %w", v, err)
+		}
+		amount = parsed
+	case string:
+		text := strings.TrimSpace(v)
+		if missingValues[strings.ToLower(text)] {
+			return 0, fmt.Errorf("is required")
+		}
+		parsed, err := models.ParseMoney(text)
+		if errors.Is(err, models.ErrMoneyPrecision) {
+			return 0, fmt.Errorf("%q has more than two decimals", text)
+		}
+		if err != nil {
+			return 0, fmt.Errorf("%q is not a number", text)
+		}
+		amount = parsed
+	default:
+		return 0, fmt.Errorf("must be a number")
+	}
+
+	if amount <= 0 {
+		return 0, fmt.Errorf("must be greater than zero")
+	}
+	return amount, nil
+}
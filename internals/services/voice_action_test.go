@@ -0,0 +1,109 @@
+package services
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	models "github.com/KashyretsIvanna/voice-balance/internals/model"
+)
+
+func TestValidateVoiceActionReportsFields(t *testing.T) {
+	// A Wednesday
+	now := time.Date(2024, time.May, 15, 10, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name   string
+		raw    map[string]interface{}
+		fields []string
+		// message is contained in the message of the first field
+		message string
+	}{
+		{"missing amount", map[string]interface{}{"type": "expense", "category": "кава"}, []string{"amount"}, "is required"},
+		{"placeholder amount", map[string]interface{}{"type": "витрати", "amount": "не вказано"}, []string{"amount"}, "is required"},
+		{"negative amount", map[string]interface{}{"type": "expense", "amount": -50.0}, []string{"amount"}, "greater than zero"},
+		{"negative amount text", map[string]interface{}{"type": "income", "amount": "-50"}, []string{"amount"}, "greater than zero"},
+		{"zero amount", map[string]interface{}{"type": "income", "amount": 0.0}, []string{"amount"}, "greater than zero"},
+		{"too precise amount", map[string]interface{}{"type": "expense", "amount": 12.345}, []string{"amount"}, "more than two decimals"},
+		{"too precise amount text", map[string]interface{}{"type": "expense", "amount": "12.345"}, []string{"amount"}, "more than two decimals"},
+		{"too large amount", map[string]interface{}{"type": "expense", "amount": 1e20}, []string{"amount"}, "too large"},
+		{"amount of another type", map[string]interface{}{"type": "expense", "amount": true}, []string{"amount"}, "must be a number"},
+		{"bad date", map[string]interface{}{"type": "expense", "amount": 50.0, "date": "колись"}, []string{"date"}, "is not a date"},
+		{"bad currency", map[string]interface{}{"type": "expense", "amount": 50.0, "currency": "тугрики"}, []string{"currency"}, "is not a currency"},
+		{"every bad field", map[string]interface{}{"type": "expense", "amount": -1.0, "currency": "тугрики", "date": "колись"},
+			[]string{"amount", "currency", "date"}, "greater than zero"},
+		{"reminder without text", map[string]interface{}{"type": "reminder", "date": "колись"}, []string{"text", "date"}, "is required"},
+		{"reminder with bad amount", map[string]interface{}{"type": "reminder", "text": "оплатити", "amount": "багато"}, []string{"amount"}, "is not a number"},
+		{"unknown range", map[string]interface{}{"type": "statistics", "range": "століття"}, []string{"range"}, "unknown range"},
+	}
+	for _, test := range tests {
+		action, err := ValidateVoiceAction(test.raw, DefaultLanguage(), now)
+		var validation *VoiceActionValidationError
+		if !errors.As(err, &validation) {
+			t.Errorf("%s: %v, %v, want a *VoiceActionValidationError", test.name, action, err)
+			continue
+		}
+		if action != nil {
+			t.Errorf("%s: action %+v returned with the error", test.name, action)
+		}
+
+		var fields []string
+		for _, field := range validation.Fields {
+			fields = append(fields, field.Field)
+		}
+		if !reflect.DeepEqual(fields, test.fields) {
+			t.Errorf("%s: fields %v, want %v", test.name, fields, test.fields)
+			continue
+		}
+		if message := validation.Fields[0].Message; !strings.Contains(message, test.message) {
+			t.Errorf("%s: message %q, want it to contain %q", test.name, message, test.message)
+		}
+	}
+}
+
+func TestValidateVoiceActionUnknownType(t *testing.T) {
+	for _, actionType := range []interface{}{"танці", "", nil, 42.0} {
+		action, err := ValidateVoiceAction(map[string]interface{}{"type": actionType, "amount": -1.0}, DefaultLanguage(), time.Now())
+		if err != nil || action != (UnknownAction{Type: VoiceActionUnknown}) {
+			t.Errorf("type %v: %+v, %v, want an unknown action", actionType, action, err)
+		}
+	}
+}
+
+func TestValidateVoiceActionNormalizes(t *testing.T) {
+	now := time.Date(2024, time.May, 15, 10, 30, 0, 0, time.UTC)
+	yesterday := time.Date(2024, time.May, 14, 10, 30, 0, 0, time.UTC)
+
+	action, err := ValidateVoiceAction(map[string]interface{}{
+		"type": "Витрати", "amount": "250.5", "category": " продукти ", "currency": "usd", "date": "вчора",
+	}, DefaultLanguage(), now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expense, ok := action.(ExpenseAction)
+	if !ok {
+		t.Fatalf("action %T, want ExpenseAction", action)
+	}
+	if expense.Amount != 25050 || expense.Category != "продукти" || expense.Currency != "USD" ||
+		expense.Date == nil || !expense.Date.Equal(yesterday) {
+		t.Errorf("expense %+v, want 250.50 USD on продукти yesterday", expense)
+	}
+
+	action, err = ValidateVoiceAction(map[string]interface{}{"type": "income", "amount": 20000.0, "category": "не вказано"}, DefaultLanguage(), now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if income, ok := action.(IncomeAction); !ok || income.Amount != models.Money(2000000) || income.Category != "" {
+		t.Errorf("income %+v, want 20000 without a category", action)
+	}
+
+	action, err = ValidateVoiceAction(map[string]interface{}{"type": "статистика", "range": "Місяць", "category": "витрати"}, DefaultLanguage(), now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if statistics := action.(StatisticsAction); statistics.Range != RangeMonth || statistics.CategoryType != models.CategoryTypeExpense {
+		t.Errorf("statistics %+v, want the expenses of the month", statistics)
+	}
+}
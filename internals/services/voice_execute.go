@@ -0,0 +1,164 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/KashyretsIvanna/voice-balance/database"
+	models "github.com/KashyretsIvanna/voice-balance/internals/model"
+	repositories "github.com/KashyretsIvanna/voice-balance/internals/repositories"
+	"github.com/google/uuid"
+)
+
+// ErrIncompleteVoiceAction is returned when an interpreted voice command cannot be executed
+var ErrIncompleteVoiceAction = errors.New("voice command is incomplete")
+
+// VoiceExecutionResult holds the entity created or the report computed for a voice command
+type VoiceExecutionResult struct {
+	Type        VoiceActionType     `json:"type"`
+	Transaction *models.Transaction `json:"transaction,omitempty"`
+	Reminder    *models.Reminder    `json:"reminder,omitempty"`
+	Statistics  *StatisticsReport   `json:"statistics,omitempty"`
+	// Set when the transaction pushed a budget over its threshold
+	BudgetWarning  bool            `json:"budget_warning,omitempty"`
+	BudgetWarnings []BudgetWarning `json:"budget_warnings,omitempty"`
+}
+
+// ProcessVoiceCommand interprets a transcribed command in the given language and,
+// when execute is set, performs it
+func ProcessVoiceCommand(userID uuid.UUID, transcription string, language *Language, execute bool) (VoiceAction, *VoiceExecutionResult, error) {
+	location, err := UserLocation(userID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	err, action := AskAi(strings.ToLower(transcription), language, time.Now().In(location))
+	if err != nil {
+		return nil, nil, err
+	}
+	if !execute {
+		return action, nil, nil
+	}
+
+	result, err := ExecuteVoiceAction(userID, action, transcription)
+	return action, result, err
+}
+
+// ExecuteVoiceAction performs the action interpreted from a voice command on behalf of the user.
+// The transcription describes expenses and incomes that name no category.
+func ExecuteVoiceAction(userID uuid.UUID, action VoiceAction, transcription string) (*VoiceExecutionResult, error) {
+	result := &VoiceExecutionResult{Type: action.ActionType()}
+
+	var err error
+	switch action := action.(type) {
+	case ExpenseAction:
+		result.Transaction, result.BudgetWarnings, err = executeTransactionAction(userID, models.CategoryTypeExpense, action.Amount, action.Currency, action.Category, action.Date, transcription)
+	case IncomeAction:
+		result.Transaction, result.BudgetWarnings, err = executeTransactionAction(userID, models.CategoryTypeIncome, action.Amount, action.Currency, action.Category, action.Date, transcription)
+	case ReminderAction:
+		result.Reminder, err = executeReminderAction(userID, action)
+	case StatisticsAction:
+		result.Statistics, err = executeStatisticsAction(userID, action)
+	default:
+		err = fmt.Errorf("%w: the command was not recognized", ErrIncompleteVoiceAction)
+	}
+	if err != nil {
+		return nil, err
+	}
+	result.BudgetWarning = len(result.BudgetWarnings) > 0
+
+	return result, nil
+}
+
+// executeTransactionAction saves an expense or an income. Without a category name the transcription
+// becomes the description and the first category rule of the type it matches chooses the category.
+func executeTransactionAction(userID uuid.UUID, categoryType string, amount models.Money, currency, categoryName string, date *time.Time, transcription string) (*models.Transaction, []BudgetWarning, error) {
+	transactionDate := time.Now()
+	if date != nil {
+		transactionDate = *date
+	}
+
+	transaction := &models.Transaction{
+		Amount:      amount,
+		Currency:    currency,
+		Description: categoryName,
+		Date:        transactionDate,
+		UserID:      userID,
+	}
+
+	var category *models.Category
+	var err error
+	if categoryName != "" {
+		category, err = ResolveCategoryByName(userID, categoryName, categoryType)
+	} else {
+		category, err = categoryFromRules(transaction, categoryType, transcription)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	transaction.CategoryID = category.ID
+
+	created, err := CreateTransaction(transaction)
+	if err != nil {
+		return nil, nil, err
+	}
+	transaction.Category = *category
+
+	return transaction, created.BudgetWarnings, nil
+}
+
+// categoryFromRules describes the transaction with the transcription and returns the category of the
+// first category rule of the type it matches
+func categoryFromRules(transaction *models.Transaction, categoryType, transcription string) (*models.Category, error) {
+	description := []rune(strings.TrimSpace(transcription))
+	if len(description) > 255 {
+		description = description[:255]
+	}
+	transaction.Description = string(description)
+
+	rule, err := matchCategoryRule(transaction, categoryType)
+	if err != nil {
+		return nil, err
+	}
+	if rule == nil {
+		return nil, fmt.Errorf("%w: the command names no category and no category rule matches it", ErrIncompleteVoiceAction)
+	}
+	return repositories.FindCategoryByID(database.DB, transaction.UserID, rule.CategoryID)
+}
+
+func executeReminderAction(userID uuid.UUID, action ReminderAction) (*models.Reminder, error) {
+	reminder := &models.Reminder{
+		Title: action.Text,
+		// Reminders without a date in the command are due in a day
+		DueDate: time.Now().Add(24 * time.Hour),
+		UserID:  userID,
+	}
+	if action.DueDate != nil {
+		reminder.DueDate = *action.DueDate
+	}
+	if action.Amount != nil {
+		reminder.Amount = *action.Amount
+	}
+
+	if err := CreateReminder(reminder); err != nil {
+		return nil, err
+	}
+	return reminder, nil
+}
+
+func executeStatisticsAction(userID uuid.UUID, action StatisticsAction) (*StatisticsReport, error) {
+	settings, err := GetUserSettings(userID)
+	if err != nil {
+		return nil, err
+	}
+	location, err := settings.Location()
+	if err != nil {
+		return nil, err
+	}
+
+	start, end := StatisticsRangeBounds(action.Range, time.Now().In(location), settings.FirstDayOfWeek())
+
+	return GetStatisticsReport(userID, action.Range, start, end, action.CategoryType)
+}
@@ -0,0 +1,110 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/KashyretsIvanna/voice-balance/database/dbtest"
+	models "github.com/KashyretsIvanna/voice-balance/internals/model"
+	repositories "github.com/KashyretsIvanna/voice-balance/internals/repositories"
+)
+
+func TestExecuteVoiceCommandsOnPostgres(t *testing.T) {
+	db := dbtest.Use(t)
+	useFakeVoice(t)
+	kyiv := kyivLocation(t)
+	user := saveTestUser(t, db)
+	language := DefaultLanguage()
+
+	action, result, err := ProcessVoiceCommand(user.ID, "Витратив 250 гривень на продукти", language, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if action.ActionType() != VoiceActionExpense || result.Type != VoiceActionExpense || result.Transaction == nil {
+		t.Fatalf("action %#v with result %+v, want an expense", action, result)
+	}
+	// The named category is created
+	expense, category := storedTransaction(t, db, result.Transaction.ID), result.Transaction.Category
+	if expense.Amount != 25000 || expense.Currency != "UAH" || expense.CategoryID != category.ID || category.Name != "продукти" || category.Type != models.CategoryTypeExpense {
+		t.Errorf("expense of %v %s in %q (%s)", expense.Amount, expense.Currency, category.Name, category.Type)
+	}
+	if result.BudgetWarning || len(result.BudgetWarnings) != 0 {
+		t.Errorf("budget warnings %+v without budgets", result.BudgetWarnings)
+	}
+
+	_, result, err = ProcessVoiceCommand(user.ID, "отримав 5000 гривень за категорією зарплата", language, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Type != VoiceActionIncome || result.Transaction == nil {
+		t.Fatalf("result %+v, want an income", result)
+	}
+	income, category := storedTransaction(t, db, result.Transaction.ID), result.Transaction.Category
+	if income.Amount != 500000 || income.CategoryID != category.ID || category.Name != "зарплата" || category.Type != models.CategoryTypeIncome {
+		t.Errorf("income of %v in %q (%s)", income.Amount, category.Name, category.Type)
+	}
+
+	_, result, err = ProcessVoiceCommand(user.ID, "нагадай завтра о 9 сплатити оренду", language, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Type != VoiceActionReminder || result.Reminder == nil {
+		t.Fatalf("result %+v, want a reminder", result)
+	}
+	reminder, err := GetReminder(user.ID, result.Reminder.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Dates of commands are in the time zone of the user
+	tomorrow := time.Now().In(kyiv).AddDate(0, 0, 1)
+	due := reminder.DueDate.In(kyiv)
+	if reminder.Title != "сплатити оренду" || due.Hour() != 9 || due.Minute() != 0 || due.YearDay() != tomorrow.YearDay() {
+		t.Errorf("reminder %q due %v, want it due tomorrow at 9:00 in Kyiv", reminder.Title, due)
+	}
+
+	_, result, err = ProcessVoiceCommand(user.ID, "статистика за місяць", language, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Type != VoiceActionStatistics || result.Statistics == nil {
+		t.Fatalf("result %+v, want statistics", result)
+	}
+	if report := result.Statistics; report.Range != RangeMonth || report.Income != 500000 || report.Expense != 25000 || report.Balance != 475000 {
+		t.Errorf("statistics of %s with income %v, expense %v and balance %v", report.Range, report.Income, report.Expense, report.Balance)
+	}
+
+	// Without execute nothing is saved
+	if _, result, err := ProcessVoiceCommand(user.ID, "витратив 100 гривень на каву", language, false); err != nil || result != nil {
+		t.Errorf("result %+v and %v without execute", result, err)
+	}
+	if count := countTransactions(t, db, user); count != 2 {
+		t.Errorf("%d transactions saved, want the expense and the income", count)
+	}
+}
+
+func TestExecuteIncompleteVoiceCommandsOnPostgres(t *testing.T) {
+	db := dbtest.Use(t)
+	useFakeVoice(t)
+	user := saveTestUser(t, db)
+
+	// Commands the rules cannot recognize are not executed
+	if _, result, err := ProcessVoiceCommand(user.ID, "привіт як справи", DefaultLanguage(), true); err == nil {
+		t.Errorf("an unrecognized command gave %+v", result)
+	}
+	if _, err := ExecuteVoiceAction(user.ID, UnknownAction{Type: VoiceActionUnknown}, "привіт як справи"); !errors.Is(err, ErrIncompleteVoiceAction) {
+		t.Errorf("unknown action: %v, want ErrIncompleteVoiceAction", err)
+	}
+
+	// An expense naming no category needs a category rule matching the transcription
+	if _, _, err := ProcessVoiceCommand(user.ID, "витратив 100", DefaultLanguage(), true); !errors.Is(err, ErrIncompleteVoiceAction) {
+		t.Errorf("expense without a category: %v, want ErrIncompleteVoiceAction", err)
+	}
+
+	if count := countTransactions(t, db, user); count != 0 {
+		t.Errorf("%d transactions saved", count)
+	}
+	if reminders, err := GetReminders(user.ID, repositories.ReminderFilter{}); err != nil || len(reminders) != 0 {
+		t.Errorf("reminders %v, %v", reminders, err)
+	}
+}
@@ -0,0 +1,126 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	models "github.com/KashyretsIvanna/voice-balance/internals/model"
+	repositories "github.com/KashyretsIvanna/voice-balance/internals/repositories"
+	"github.com/google/uuid"
+)
+
+// voiceJobTimeout limits the processing of one job
+const voiceJobTimeout = 30 * time.Minute
+
+// voiceJobInterruptedAfter is how long after its last update an unfinished job, e.g. one whose server
+// restarted, is reported as failed. It is longer than voiceJobTimeout, so a running job finishes itself first.
+const voiceJobInterruptedAfter = voiceJobTimeout + 5*time.Minute
+
+// CreateVoiceJob stores a job for a long recording in the given language and processes it in the background
+func CreateVoiceJob(userID uuid.UUID, audio []byte, language *Language, execute bool) (*models.VoiceJob, error) {
+	job := &models.VoiceJob{
+		UserID:   userID,
+		Status:   models.VoiceJobPending,
+		Execute:  execute,
+		Language: language.Code,
+	}
+	if err := repositories.SaveVoiceJob(job); err != nil {
+		return nil, err
+	}
+
+	go runVoiceJob(*job, audio, voiceJobTimeout)
+	return job, nil
+}
+
+// GetVoiceJob returns a job of the user
+func GetVoiceJob(userID, jobID uuid.UUID) (*models.VoiceJob, error) {
+	job, err := repositories.FindVoiceJobByID(userID, jobID)
+	if err != nil {
+		return nil, err
+	}
+
+	unfinished := job.Status == models.VoiceJobPending || job.Status == models.VoiceJobProcessing
+	if unfinished && time.Since(job.UpdatedAt) > voiceJobInterruptedAfter {
+		job.Status = models.VoiceJobFailed
+		job.Error = "the job was interrupted"
+		finished, err := repositories.FinishVoiceJob(job)
+		if err != nil {
+			return nil, err
+		}
+		if !finished {
+			// The job finished meanwhile
+			return repositories.FindVoiceJobByID(userID, jobID)
+		}
+	}
+	return job, nil
+}
+
+// runVoiceJob processes a job and saves its outcome, a job still running after timeout fails
+func runVoiceJob(job models.VoiceJob, audio []byte, timeout time.Duration) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	job.Status = models.VoiceJobProcessing
+	if err := repositories.UpdateVoiceJob(&job); err != nil {
+		log.Printf("voice job %s: %v", job.ID, err)
+		return
+	}
+
+	err := processVoiceJob(ctx, &job, audio)
+	switch {
+	case err != nil && ctx.Err() == context.DeadlineExceeded:
+		job.Status = models.VoiceJobFailed
+		job.Error = fmt.Sprintf("the job timed out after %v", timeout)
+	case err != nil:
+		job.Status = models.VoiceJobFailed
+		job.Error = err.Error()
+	default:
+		job.Status = models.VoiceJobDone
+	}
+
+	finished, err := repositories.FinishVoiceJob(&job)
+	if err != nil {
+		log.Printf("voice job %s: %v", job.ID, err)
+	} else if !finished {
+		log.Printf("voice job %s: finished after it was reported as interrupted", job.ID)
+	}
+}
+
+func processVoiceJob(ctx context.Context, job *models.VoiceJob, audio []byte) error {
+	language, err := LookupLanguage(job.Language)
+	if err != nil {
+		return err
+	}
+
+	transcript, err := TranscribeAudioData(ctx, audio, language, true)
+	if err != nil {
+		return err
+	}
+	job.Transcript = transcript
+
+	// A job past its deadline is reported as failed, its command must not run
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	action, result, err := ProcessVoiceCommand(job.UserID, transcript, language, job.Execute)
+	if action != nil {
+		encoded, _ := json.Marshal(action)
+		job.Action = string(encoded)
+	}
+	if err != nil {
+		return err
+	}
+
+	if result != nil {
+		encoded, err := json.Marshal(result)
+		if err != nil {
+			return err
+		}
+		job.Result = string(encoded)
+	}
+	return nil
+}
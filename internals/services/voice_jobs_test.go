@@ -0,0 +1,127 @@
+package services
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/KashyretsIvanna/voice-balance/database/dbtest"
+	models "github.com/KashyretsIvanna/voice-balance/internals/model"
+	repositories "github.com/KashyretsIvanna/voice-balance/internals/repositories"
+	"gorm.io/gorm"
+)
+
+// useFakeVoice makes the uploads their own transcripts and recognizes them with the rules
+func useFakeVoice(t *testing.T) {
+	t.Setenv("STT_PROVIDER", "fake")
+	t.Setenv("STT_FAKE_TEXT", "")
+	t.Setenv("INTENT_PARSER", "rules")
+	t.Setenv("INTENT_PARSER_FALLBACK", "none")
+}
+
+func countTransactions(t *testing.T, db *gorm.DB, user *models.User) int64 {
+	t.Helper()
+	var count int64
+	if err := db.Model(&models.Transaction{}).Where("user_id = ?", user.ID).Count(&count).Error; err != nil {
+		t.Fatal(err)
+	}
+	return count
+}
+
+func TestVoiceJobOnPostgres(t *testing.T) {
+	db := dbtest.Use(t)
+	useFakeVoice(t)
+	user := saveTestUser(t, db)
+
+	job, err := CreateVoiceJob(user.ID, []byte("витратив 250 гривень на продукти"), DefaultLanguage(), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.Now().Add(10 * time.Second)
+	for job.Status == models.VoiceJobPending || job.Status == models.VoiceJobProcessing {
+		if time.Now().After(deadline) {
+			t.Fatalf("the job is still %s", job.Status)
+		}
+		time.Sleep(20 * time.Millisecond)
+		if job, err = GetVoiceJob(user.ID, job.ID); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if job.Status != models.VoiceJobDone || job.Transcript != "витратив 250 гривень на продукти" {
+		t.Fatalf("job %s with transcript %q and error %q, want it done", job.Status, job.Transcript, job.Error)
+	}
+	if !strings.Contains(job.Action, `"type":"expense"`) || job.Result != "" {
+		t.Errorf("action %s and result %q, want an expense that is not executed", job.Action, job.Result)
+	}
+	if count := countTransactions(t, db, user); count != 0 {
+		t.Errorf("%d transactions saved without execute", count)
+	}
+}
+
+func TestVoiceJobTimesOutBeforeExecutingOnPostgres(t *testing.T) {
+	db := dbtest.Use(t)
+	useFakeVoice(t)
+	user := saveTestUser(t, db)
+	job := &models.VoiceJob{UserID: user.ID, Status: models.VoiceJobPending, Execute: true, Language: DefaultLanguage().Code}
+	if err := repositories.SaveVoiceJob(job); err != nil {
+		t.Fatal(err)
+	}
+
+	runVoiceJob(*job, []byte("витратив 250 гривень на продукти"), time.Nanosecond)
+
+	stored, err := GetVoiceJob(user.ID, job.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.Status != models.VoiceJobFailed || !strings.Contains(stored.Error, "timed out") {
+		t.Errorf("job %s with error %q, want it timed out", stored.Status, stored.Error)
+	}
+	if count := countTransactions(t, db, user); count != 0 {
+		t.Errorf("a job past its deadline saved %d transactions", count)
+	}
+}
+
+func TestInterruptedVoiceJobOnPostgres(t *testing.T) {
+	db := dbtest.Use(t)
+	user := saveTestUser(t, db)
+	saveJob := func(lastUpdate time.Time) *models.VoiceJob {
+		job := &models.VoiceJob{UserID: user.ID, Status: models.VoiceJobProcessing, Language: DefaultLanguage().Code}
+		if err := repositories.SaveVoiceJob(job); err != nil {
+			t.Fatal(err)
+		}
+		if err := db.Model(job).UpdateColumn("updated_at", lastUpdate).Error; err != nil {
+			t.Fatal(err)
+		}
+		return job
+	}
+	running := saveJob(time.Now().Add(-voiceJobTimeout))
+	abandoned := saveJob(time.Now().Add(-voiceJobInterruptedAfter - time.Minute))
+
+	if job, err := GetVoiceJob(user.ID, running.ID); err != nil || job.Status != models.VoiceJobProcessing {
+		t.Errorf("a job that may still be running is %v, %v", job, err)
+	}
+
+	job, err := GetVoiceJob(user.ID, abandoned.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if job.Status != models.VoiceJobFailed || job.Error != "the job was interrupted" {
+		t.Fatalf("an abandoned job is %s with error %q, want it interrupted", job.Status, job.Error)
+	}
+
+	// A runner finishing after that does not overwrite the reported failure
+	late := *abandoned
+	late.Status = models.VoiceJobDone
+	late.Transcript = "дохід 5000"
+	finished, err := repositories.FinishVoiceJob(&late)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if finished {
+		t.Errorf("a late runner finished an interrupted job")
+	}
+	if job, err = GetVoiceJob(user.ID, abandoned.ID); err != nil || job.Status != models.VoiceJobFailed || job.Transcript != "" {
+		t.Errorf("the interrupted job became %v, %v", job, err)
+	}
+}
@@ -0,0 +1,296 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// voiceStreamIdleTimeout is how long a stream is kept without receiving audio
+const voiceStreamIdleTimeout = 5 * time.Minute
+
+// ErrVoiceStreamNotFound is returned for unknown, expired or finished streams
+var ErrVoiceStreamNotFound = errors.New("voice stream not found")
+
+// VoiceStream is a recording that is transcribed while the client uploads it in chunks
+type VoiceStream struct {
+	ID     uuid.UUID
+	UserID uuid.UUID
+
+	ctx         context.Context
+	cancel      context.CancelFunc
+	transcriber Transcriber
+	format      *AudioFormat
+	language    *Language
+
+	mu          sync.Mutex
+	stream      TranscriptionStream
+	final       []string
+	interim     string
+	err         string
+	done        chan struct{}
+	subscribers map[chan TranscriptResult]struct{}
+	lastActive  time.Time
+}
+
+// VoiceStreamState is a snapshot of the transcript of a stream
+type VoiceStreamState struct {
+	ID         uuid.UUID `json:"id"`
+	Transcript string    `json:"transcript"`
+	Interim    string    `json:"interim"`
+	Done       bool      `json:"done"`
+	Error      string    `json:"error,omitempty"`
+}
+
+type voiceStreamRegistry struct {
+	mu      sync.Mutex
+	streams map[uuid.UUID]*VoiceStream
+	once    sync.Once
+}
+
+var voiceStreams = &voiceStreamRegistry{streams: map[uuid.UUID]*VoiceStream{}}
+
+// StartVoiceStream opens a stream for the user recognizing speech in the given language.
+// When format is nil it is detected from the header of the first chunk.
+func StartVoiceStream(userID uuid.UUID, format *AudioFormat, language *Language) (*VoiceStream, error) {
+	transcriber, err := NewTranscriber()
+	if err != nil {
+		return nil, err
+	}
+	return startVoiceStream(userID, transcriber, format, language)
+}
+
+// startVoiceStream is StartVoiceStream with the given recognizer
+func startVoiceStream(userID uuid.UUID, transcriber Transcriber, format *AudioFormat, language *Language) (*VoiceStream, error) {
+	if format != nil && !recognizableCodecs[format.Codec] {
+		return nil, fmt.Errorf("%w: %s audio cannot be streamed", ErrUnsupportedAudioFormat, format.Codec)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stream := &VoiceStream{
+		ID:          uuid.New(),
+		UserID:      userID,
+		ctx:         ctx,
+		cancel:      cancel,
+		transcriber: transcriber,
+		format:      format,
+		language:    language,
+		done:        make(chan struct{}),
+		subscribers: map[chan TranscriptResult]struct{}{},
+		lastActive:  time.Now(),
+	}
+
+	voiceStreams.once.Do(func() { go voiceStreams.expireIdle() })
+	voiceStreams.mu.Lock()
+	voiceStreams.streams[stream.ID] = stream
+	voiceStreams.mu.Unlock()
+
+	return stream, nil
+}
+
+// GetVoiceStream returns an open stream of the user
+func GetVoiceStream(userID, streamID uuid.UUID) (*VoiceStream, error) {
+	voiceStreams.mu.Lock()
+	defer voiceStreams.mu.Unlock()
+
+	stream, ok := voiceStreams.streams[streamID]
+	if !ok || stream.UserID != userID {
+		return nil, ErrVoiceStreamNotFound
+	}
+	return stream, nil
+}
+
+// expireIdle closes streams that stopped receiving audio
+func (r *voiceStreamRegistry) expireIdle() {
+	for now := range time.Tick(time.Minute) {
+		r.expire(now)
+	}
+}
+
+// expire closes the streams that received no audio for voiceStreamIdleTimeout before now
+func (r *voiceStreamRegistry) expire(now time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for id, stream := range r.streams {
+		stream.mu.Lock()
+		idle := now.Sub(stream.lastActive) > voiceStreamIdleTimeout
+		stream.mu.Unlock()
+
+		if idle {
+			stream.cancel()
+			delete(r.streams, id)
+		}
+	}
+}
+
+func (r *voiceStreamRegistry) remove(id uuid.UUID) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.streams, id)
+}
+
+// Send passes the next chunk of audio to the recognizer
+func (s *VoiceStream) Send(chunk []byte) error {
+	s.mu.Lock()
+	s.lastActive = time.Now()
+	if s.stream == nil {
+		if err := s.start(chunk); err != nil {
+			s.mu.Unlock()
+			return err
+		}
+	}
+	stream := s.stream
+	s.mu.Unlock()
+
+	// The recognizer may take no more audio until its results are read,
+	// collect needs the lock to read them
+	return stream.Send(chunk)
+}
+
+// start opens the recognizer stream, the lock must be held
+func (s *VoiceStream) start(firstChunk []byte) error {
+	if _, fake := s.transcriber.(*FakeTranscriber); fake && s.format == nil {
+		// The fake transcriber takes the upload as is, it may be plain text
+		s.format = &AudioFormat{}
+	}
+
+	if s.format == nil {
+		format, err := DetectAudioFormat(firstChunk)
+		if err != nil {
+			return err
+		}
+		if !recognizableCodecs[format.Codec] {
+			return fmt.Errorf("%w: %s audio cannot be streamed", ErrUnsupportedAudioFormat, format.Codec)
+		}
+		s.format = &format
+	}
+
+	stream, err := StartTranscriptionStream(s.ctx, s.transcriber, *s.format, s.language.RecognizerCode)
+	if err != nil {
+		return err
+	}
+	s.stream = stream
+
+	go s.collect(stream)
+	return nil
+}
+
+// collect stores the results of the recognizer and fans them out to the subscribers
+func (s *VoiceStream) collect(stream TranscriptionStream) {
+	for result := range stream.Results() {
+		s.mu.Lock()
+		switch {
+		case result.Error != "":
+			s.err = result.Error
+		case result.IsFinal:
+			s.final = append(s.final, strings.TrimSpace(result.Text))
+			s.interim = ""
+		default:
+			s.interim = result.Text
+		}
+		for subscriber := range s.subscribers {
+			select {
+			case subscriber <- result:
+			default:
+				// Slow subscribers miss interim results rather than blocking recognition
+			}
+		}
+		s.mu.Unlock()
+	}
+
+	s.mu.Lock()
+	for subscriber := range s.subscribers {
+		close(subscriber)
+		delete(s.subscribers, subscriber)
+	}
+	close(s.done)
+	s.mu.Unlock()
+}
+
+// Subscribe returns a channel receiving the transcripts of the stream until it is done
+func (s *VoiceStream) Subscribe() (<-chan TranscriptResult, func()) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	subscriber := make(chan TranscriptResult, 16)
+	select {
+	case <-s.done:
+		close(subscriber)
+		return subscriber, func() {}
+	default:
+	}
+	s.subscribers[subscriber] = struct{}{}
+
+	unsubscribe := func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		if _, ok := s.subscribers[subscriber]; ok {
+			delete(s.subscribers, subscriber)
+			close(subscriber)
+		}
+	}
+	return subscriber, unsubscribe
+}
+
+// State returns the transcript recognized so far
+func (s *VoiceStream) State() VoiceStreamState {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	done := false
+	select {
+	case <-s.done:
+		done = true
+	default:
+	}
+
+	return VoiceStreamState{
+		ID:         s.ID,
+		Transcript: strings.Join(s.final, " "),
+		Interim:    s.interim,
+		Done:       done,
+		Error:      s.err,
+	}
+}
+
+// Language returns the language the stream is recognized in
+func (s *VoiceStream) Language() *Language {
+	return s.language
+}
+
+// Finish closes the audio stream, waits for the final transcript and releases the stream
+func (s *VoiceStream) Finish(ctx context.Context) (string, error) {
+	defer voiceStreams.remove(s.ID)
+	defer s.cancel()
+
+	s.mu.Lock()
+	stream := s.stream
+	s.mu.Unlock()
+	if stream == nil {
+		return "", fmt.Errorf("%w: no audio was sent", ErrIncompleteVoiceAction)
+	}
+
+	if err := stream.CloseSend(); err != nil {
+		return "", err
+	}
+
+	select {
+	case <-s.done:
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+
+	state := s.State()
+	if state.Error != "" {
+		return "", errors.New(state.Error)
+	}
+	return state.Transcript, nil
+}
@@ -0,0 +1,179 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// lockstepTranscriber recognizes every chunk while it is sent. Like a recognizer under flow
+// control, Send returns only after the interim and the final result of the chunk are read.
+type lockstepTranscriber struct{}
+
+func (lockstepTranscriber) Transcribe(ctx context.Context, req TranscriptionRequest) (string, error) {
+	return "", errors.New("lockstep transcriber only streams")
+}
+
+func (lockstepTranscriber) StartStream(ctx context.Context, format AudioFormat, languageCode string) (TranscriptionStream, error) {
+	return &lockstepStream{ctx: ctx, results: make(chan TranscriptResult)}, nil
+}
+
+type lockstepStream struct {
+	ctx     context.Context
+	results chan TranscriptResult
+}
+
+func (s *lockstepStream) Send(chunk []byte) error {
+	for _, result := range []TranscriptResult{{Text: string(chunk)}, {Text: string(chunk), IsFinal: true}} {
+		select {
+		case s.results <- result:
+		case <-s.ctx.Done():
+			return s.ctx.Err()
+		}
+	}
+	return nil
+}
+
+func (s *lockstepStream) CloseSend() error {
+	close(s.results)
+	return nil
+}
+
+func (s *lockstepStream) Results() <-chan TranscriptResult {
+	return s.results
+}
+
+func TestVoiceStreamSendsWhileResultsAreCollected(t *testing.T) {
+	stream, err := startVoiceStream(uuid.New(), lockstepTranscriber{}, &AudioFormat{Codec: CodecPCM16}, DefaultLanguage())
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The results are read until the stream is done, there is no need to unsubscribe
+	results, _ := stream.Subscribe()
+
+	sent := make(chan error)
+	go func() {
+		for _, chunk := range []string{"витрати", "сто", "гривень"} {
+			if err := stream.Send([]byte(chunk)); err != nil {
+				sent <- err
+				return
+			}
+		}
+		sent <- nil
+	}()
+	select {
+	case err := <-sent:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Send blocked while the results of the recognizer waited to be collected")
+	}
+
+	transcript, err := stream.Finish(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if transcript != "витрати сто гривень" {
+		t.Errorf("transcript %q, want %q", transcript, "витрати сто гривень")
+	}
+	if state := stream.State(); !state.Done || state.Interim != "" {
+		t.Errorf("state %+v, want done without an interim result", state)
+	}
+
+	var finals int
+	for result := range results {
+		if result.IsFinal {
+			finals++
+		}
+	}
+	if finals != 3 {
+		t.Errorf("the subscriber got %d final results, want 3", finals)
+	}
+}
+
+func TestVoiceStreamRegistry(t *testing.T) {
+	userID := uuid.New()
+	stream, err := startVoiceStream(userID, &FakeTranscriber{}, nil, DefaultLanguage())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if found, err := GetVoiceStream(userID, stream.ID); err != nil || found != stream {
+		t.Fatalf("the owner got %v, %v", found, err)
+	}
+	if _, err := GetVoiceStream(uuid.New(), stream.ID); !errors.Is(err, ErrVoiceStreamNotFound) {
+		t.Errorf("another user got the stream: %v", err)
+	}
+
+	for _, chunk := range []string{"витрати 100 ", "на каву"} {
+		if err := stream.Send([]byte(chunk)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	transcript, err := stream.Finish(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if transcript != "витрати 100 на каву" {
+		t.Errorf("transcript %q, want %q", transcript, "витрати 100 на каву")
+	}
+	if _, err := GetVoiceStream(userID, stream.ID); !errors.Is(err, ErrVoiceStreamNotFound) {
+		t.Errorf("a finished stream is still open: %v", err)
+	}
+}
+
+func TestVoiceStreamRejectsUnusableAudio(t *testing.T) {
+	if _, err := startVoiceStream(uuid.New(), &FakeTranscriber{}, &AudioFormat{Codec: CodecMP3}, DefaultLanguage()); !errors.Is(err, ErrUnsupportedAudioFormat) {
+		t.Errorf("an mp3 stream was opened: %v", err)
+	}
+
+	stream, err := startVoiceStream(uuid.New(), &FakeTranscriber{}, nil, DefaultLanguage())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := stream.Finish(context.Background()); !errors.Is(err, ErrIncompleteVoiceAction) {
+		t.Errorf("finishing a stream without audio: %v, want ErrIncompleteVoiceAction", err)
+	}
+}
+
+func TestVoiceStreamRegistryExpiresIdleStreams(t *testing.T) {
+	now := time.Now()
+	registry := &voiceStreamRegistry{streams: map[uuid.UUID]*VoiceStream{}}
+	newStream := func(lastActive time.Time) *VoiceStream {
+		ctx, cancel := context.WithCancel(context.Background())
+		stream := &VoiceStream{ID: uuid.New(), ctx: ctx, cancel: cancel, lastActive: lastActive}
+		registry.streams[stream.ID] = stream
+		return stream
+	}
+	idle := newStream(now.Add(-voiceStreamIdleTimeout - time.Second))
+	active := newStream(now.Add(-voiceStreamIdleTimeout + time.Second))
+
+	registry.expire(now)
+	if _, ok := registry.streams[idle.ID]; ok || idle.ctx.Err() == nil {
+		t.Errorf("the idle stream is kept open")
+	}
+	if _, ok := registry.streams[active.ID]; !ok || active.ctx.Err() != nil {
+		t.Errorf("the active stream was closed")
+	}
+
+	// Audio keeps a stream open
+	stream, err := startVoiceStream(uuid.New(), &FakeTranscriber{}, nil, DefaultLanguage())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stream.Finish(context.Background())
+	stream.mu.Lock()
+	stream.lastActive = now.Add(-time.Hour)
+	stream.mu.Unlock()
+	if err := stream.Send([]byte("дохід")); err != nil {
+		t.Fatal(err)
+	}
+	voiceStreams.expire(time.Now())
+	if _, err := GetVoiceStream(stream.UserID, stream.ID); err != nil {
+		t.Errorf("a stream that just received audio expired: %v", err)
+	}
+}
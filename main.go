@@ -1,7 +1,13 @@
 package main
 
 import (
+	"context"
+
+	// Time zones of users are loaded from the binary, the image has no zoneinfo
+	_ "time/tzdata"
+
 	"github.com/KashyretsIvanna/voice-balance/database"
+	"github.com/KashyretsIvanna/voice-balance/internals/services"
 	"github.com/KashyretsIvanna/voice-balance/router"
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/cors"
@@ -10,12 +16,20 @@ import (
 
 // @Param Authorization header string true "Insert your access token" default(Bearer <Add access token here>)
 func main() {
-	// Start a new fiber app
-	app := fiber.New()
+	// Start a new fiber app, long voice recordings need more than the default 4 MB body limit
+	app := fiber.New(fiber.Config{
+		BodyLimit: 32 * 1024 * 1024,
+	})
 	app.Get("/swagger/*", swagger.HandlerDefault) // Route to Swagger UI
 
 	// Connect to the Database
 	database.ConnectDB()
+
+	// Post due occurrences of recurring transactions in the background
+	services.StartRecurringScheduler(context.Background())
+	// Deliver reminders coming due
+	services.StartReminderDispatcher(context.Background())
+
 	app.Use(cors.New())
 
 	// Setup the router
@@ -6,7 +6,13 @@ import (
 
 	_ "github.com/KashyretsIvanna/voice-balance/docs"
 	authRoutes "github.com/KashyretsIvanna/voice-balance/internals/routes/auth"
+	budgetRoutes "github.com/KashyretsIvanna/voice-balance/internals/routes/budgets"
 	categoryRoutes "github.com/KashyretsIvanna/voice-balance/internals/routes/categories"
+	categoryRuleRoutes "github.com/KashyretsIvanna/voice-balance/internals/routes/category_rules"
+	exchangeRateRoutes "github.com/KashyretsIvanna/voice-balance/internals/routes/exchange_rates"
+	notificationRoutes "github.com/KashyretsIvanna/voice-balance/internals/routes/notifications"
+	recurringRoutes "github.com/KashyretsIvanna/voice-balance/internals/routes/recurring"
+	reminderRoutes "github.com/KashyretsIvanna/voice-balance/internals/routes/reminders"
 	statisticRoutes "github.com/KashyretsIvanna/voice-balance/internals/routes/statistic"
 	transactionRoutes "github.com/KashyretsIvanna/voice-balance/internals/routes/transaction"
 	userRoutes "github.com/KashyretsIvanna/voice-balance/internals/routes/user"
@@ -26,7 +32,13 @@ func SetupRoutes(app *fiber.App) {
 	transactionRoutes.SetupTransactionRoutes(api)
 	authRoutes.SetupAuthRoutes(api)
 	categoryRoutes.SetupCategoriesRoutes(api)
+	categoryRuleRoutes.SetupCategoryRuleRoutes(api)
 	userRoutes.SetupUserRoutes(api)
 	voiceRoutes.SetupVoiceRoutes(api)
+	reminderRoutes.SetupReminderRoutes(api)
+	budgetRoutes.SetupBudgetRoutes(api)
+	recurringRoutes.SetupRecurringRoutes(api)
+	notificationRoutes.SetupNotificationRoutes(api)
+	exchangeRateRoutes.SetupExchangeRateRoutes(api)
 
 }